@@ -216,13 +216,23 @@ func New(requiredUserAgent string, requiredPassword string, cs modules.Consensus
 		router.POST("/host", RequirePassword(api.hostHandlerPOST, requiredPassword))              // Change the settings of the host.
 		router.POST("/host/announce", RequirePassword(api.hostAnnounceHandler, requiredPassword)) // Announce the host to the network.
 		router.GET("/host/estimatescore", api.hostEstimateScoreGET)
+		router.GET("/host/pricing", api.hostPricingHandlerGET)
+		router.POST("/host/pricing", RequirePassword(api.hostPricingHandlerPOST, requiredPassword))
+		router.GET("/host/renters", api.hostRentersHandlerGET)
+		router.POST("/host/renters", RequirePassword(api.hostRentersHandlerPOST, requiredPassword))
+		router.GET("/host/revenue", api.hostRevenueHandlerGET)
+		router.GET("/host/winddown", api.hostWindDownHandlerGET)
+		router.POST("/host/winddown", RequirePassword(api.hostWindDownHandlerPOST, requiredPassword))
 
 		// Calls pertaining to the storage manager that the host uses.
 		router.GET("/host/storage", api.storageHandler)
 		router.POST("/host/storage/folders/add", RequirePassword(api.storageFoldersAddHandler, requiredPassword))
 		router.POST("/host/storage/folders/remove", RequirePassword(api.storageFoldersRemoveHandler, requiredPassword))
 		router.POST("/host/storage/folders/resize", RequirePassword(api.storageFoldersResizeHandler, requiredPassword))
+		router.POST("/host/storage/folders/move", RequirePassword(api.storageFoldersMoveHandler, requiredPassword))
 		router.POST("/host/storage/sectors/delete/:merkleroot", RequirePassword(api.storageSectorsDeleteHandler, requiredPassword))
+		router.GET("/host/storage/obligations", api.storageObligationsHandler)
+		router.GET("/host/storage/obligations/:id", api.storageObligationHandler)
 	}
 
 	// Miner API Calls
@@ -260,6 +270,11 @@ func New(requiredUserAgent string, requiredPassword string, cs modules.Consensus
 		router.GET("/hostdb/active", api.hostdbActiveHandler)
 		router.GET("/hostdb/all", api.hostdbAllHandler)
 		router.GET("/hostdb/hosts/:pubkey", api.hostdbHostsHandler)
+		router.GET("/hostdb/scoretest", api.hostdbScoreTestHandler)
+		router.GET("/hostdb/weights", api.hostdbWeightsHandlerGET)
+		router.POST("/hostdb/weights", RequirePassword(api.hostdbWeightsHandlerPOST, requiredPassword))
+		router.GET("/hostdb/pruning", api.hostdbPruningHandlerGET)
+		router.POST("/hostdb/pruning", RequirePassword(api.hostdbPruningHandlerPOST, requiredPassword))
 	}
 
 	// Transaction pool API Calls
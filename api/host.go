@@ -4,8 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
 
@@ -33,6 +35,7 @@ type (
 		NetworkMetrics       modules.HostNetworkMetrics       `json:"networkmetrics"`
 		ConnectabilityStatus modules.HostConnectabilityStatus `json:"connectabilitystatus"`
 		WorkingStatus        modules.HostWorkingStatus        `json:"workingstatus"`
+		ProofAlerts          []modules.HostProofAlert         `json:"proofalerts"`
 	}
 
 	// HostEstimateScoreGET contains the information that is returned from a
@@ -42,11 +45,58 @@ type (
 		ConversionRate float64        `json:"conversionrate"`
 	}
 
+	// HostPricingGET contains the policy that the host uses to adjust its
+	// prices, and the changes that the policy made most recently.
+	HostPricingGET struct {
+		Policy      modules.HostPricingPolicy     `json:"policy"`
+		Adjustments []modules.HostPriceAdjustment `json:"adjustments"`
+	}
+
+	// HostRentersGET contains the policy that determines which renters the
+	// host forms and renews contracts with. Renters are listed by their
+	// public key strings.
+	HostRentersGET struct {
+		Mode    modules.HostRenterPolicyMode `json:"mode"`
+		Renters []string                     `json:"renters"`
+	}
+
+	// HostRevenueGET contains the revenue ledger entries of the storage
+	// obligations that were resolved in the requested period, and their
+	// totals.
+	HostRevenueGET struct {
+		Entries []modules.HostRevenueEntry `json:"entries"`
+		Totals  HostRevenueTotals          `json:"totals"`
+	}
+
+	// HostRevenueTotals sums the revenue, collateral and fees of a set of
+	// revenue ledger entries.
+	HostRevenueTotals struct {
+		ContractCompensation     types.Currency `json:"contractcompensation"`
+		StorageRevenue           types.Currency `json:"storagerevenue"`
+		DownloadBandwidthRevenue types.Currency `json:"downloadbandwidthrevenue"`
+		UploadBandwidthRevenue   types.Currency `json:"uploadbandwidthrevenue"`
+		LostRevenue              types.Currency `json:"lostrevenue"`
+
+		LockedCollateral types.Currency `json:"lockedcollateral"`
+		LostCollateral   types.Currency `json:"lostcollateral"`
+		TransactionFees  types.Currency `json:"transactionfees"`
+	}
+
+	// StorageObligationsGET contains a page of the host's storage
+	// obligations. If the request was filtered or paginated,
+	// TotalObligations is the number of obligations that matched the
+	// filters, of which Obligations is one page.
+	StorageObligationsGET struct {
+		Obligations      []modules.StorageObligation `json:"obligations"`
+		TotalObligations int                         `json:"totalobligations"`
+	}
+
 	// StorageGET contains the information that is returned after a GET request
 	// to /host/storage - a bunch of information about the status of storage
 	// management on the host.
 	StorageGET struct {
-		Folders []modules.StorageFolderMetadata `json:"folders"`
+		Folders     []modules.StorageFolderMetadata `json:"folders"`
+		SectorCache modules.SectorCacheMetrics      `json:"sectorcache"`
 	}
 )
 
@@ -70,6 +120,10 @@ func (api *API) hostHandlerGET(w http.ResponseWriter, req *http.Request, _ httpr
 	nm := api.host.NetworkMetrics()
 	cs := api.host.ConnectabilityStatus()
 	ws := api.host.WorkingStatus()
+	pa := api.host.ProofAlerts()
+	if pa == nil {
+		pa = []modules.HostProofAlert{}
+	}
 	hg := HostGET{
 		ExternalSettings:     es,
 		FinancialMetrics:     fm,
@@ -77,6 +131,7 @@ func (api *API) hostHandlerGET(w http.ResponseWriter, req *http.Request, _ httpr
 		NetworkMetrics:       nm,
 		ConnectabilityStatus: cs,
 		WorkingStatus:        ws,
+		ProofAlerts:          pa,
 	}
 	WriteJSON(w, hg)
 }
@@ -194,6 +249,63 @@ func (api *API) parseHostSettings(req *http.Request) (modules.HostInternalSettin
 		settings.MinUploadBandwidthPrice = x
 	}
 
+	if req.FormValue("maxdownloadspeed") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxdownloadspeed"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxDownloadSpeed = x
+	}
+	if req.FormValue("maxuploadspeed") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxuploadspeed"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxUploadSpeed = x
+	}
+	if req.FormValue("maxrenterconnections") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxrenterconnections"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxRenterConnections = x
+	}
+	if req.FormValue("renterdownloadquota") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("renterdownloadquota"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.RenterDownloadQuota = x
+	}
+	if req.FormValue("renteruploadquota") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("renteruploadquota"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.RenterUploadQuota = x
+	}
+	if req.FormValue("renterquotaperiod") != "" {
+		var x types.BlockHeight
+		_, err := fmt.Sscan(req.FormValue("renterquotaperiod"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.RenterQuotaPeriod = x
+	}
+	if req.FormValue("sectorcachesize") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("sectorcachesize"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.SectorCacheSize = x
+	}
+
 	return settings, nil
 }
 
@@ -264,6 +376,191 @@ func (api *API) hostHandlerPOST(w http.ResponseWriter, req *http.Request, _ http
 	WriteSuccess(w)
 }
 
+// hostPricingHandlerGET handles the API call asking for the pricing policy of
+// the host and the price changes that it made most recently.
+func (api *API) hostPricingHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostPricingGET{
+		Policy:      api.host.PricingPolicy(),
+		Adjustments: api.host.PriceAdjustments(),
+	})
+}
+
+// hostPricingHandlerPOST handles the API call to change the pricing policy of
+// the host. Fields that are not specified keep their current values.
+func (api *API) hostPricingHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	policy := api.host.PricingPolicy()
+	if req.FormValue("enabled") != "" {
+		_, err := fmt.Sscan(req.FormValue("enabled"), &policy.Enabled)
+		if err != nil {
+			WriteError(w, Error{"unable to parse enabled: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	fields := []struct {
+		name  string
+		value *types.Currency
+	}{
+		{"minstorageprice", &policy.MinStoragePrice},
+		{"maxstorageprice", &policy.MaxStoragePrice},
+		{"mincollateral", &policy.MinCollateral},
+		{"maxcollateral", &policy.MaxCollateral},
+	}
+	for _, f := range fields {
+		if req.FormValue(f.name) == "" {
+			continue
+		}
+		_, err := fmt.Sscan(req.FormValue(f.name), f.value)
+		if err != nil {
+			WriteError(w, Error{"unable to parse " + f.name + ": " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := api.host.SetPricingPolicy(policy); err != nil {
+		WriteError(w, Error{"unable to set pricing policy: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostRevenueHandlerGET handles the API call asking for the revenue ledger
+// entries of the storage obligations that were resolved between the start and
+// end times, which are given as unix timestamps.
+func (api *API) hostRevenueHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var start, end time.Time
+	for _, f := range []struct {
+		name  string
+		value *time.Time
+	}{
+		{"start", &start},
+		{"end", &end},
+	} {
+		if req.FormValue(f.name) == "" {
+			continue
+		}
+		var unix int64
+		_, err := fmt.Sscan(req.FormValue(f.name), &unix)
+		if err != nil || unix < 0 {
+			WriteError(w, Error{"unable to parse " + f.name + ": expected a unix timestamp"}, http.StatusBadRequest)
+			return
+		}
+		*f.value = time.Unix(unix, 0)
+	}
+	if !end.IsZero() && end.Before(start) {
+		WriteError(w, Error{"end must not be before start"}, http.StatusBadRequest)
+		return
+	}
+
+	entries := api.host.RevenueLedger(start, end)
+	if entries == nil {
+		entries = []modules.HostRevenueEntry{}
+	}
+	var totals HostRevenueTotals
+	for _, e := range entries {
+		totals.ContractCompensation = totals.ContractCompensation.Add(e.ContractCompensation)
+		totals.StorageRevenue = totals.StorageRevenue.Add(e.StorageRevenue)
+		totals.DownloadBandwidthRevenue = totals.DownloadBandwidthRevenue.Add(e.DownloadBandwidthRevenue)
+		totals.UploadBandwidthRevenue = totals.UploadBandwidthRevenue.Add(e.UploadBandwidthRevenue)
+		totals.LostRevenue = totals.LostRevenue.Add(e.LostRevenue)
+		totals.LockedCollateral = totals.LockedCollateral.Add(e.LockedCollateral)
+		totals.LostCollateral = totals.LostCollateral.Add(e.LostCollateral)
+		totals.TransactionFees = totals.TransactionFees.Add(e.TransactionFees)
+	}
+	WriteJSON(w, HostRevenueGET{
+		Entries: entries,
+		Totals:  totals,
+	})
+}
+
+// hostWindDownHandlerGET handles the API call asking whether the host is
+// winding down, and when its last storage obligation will be resolved.
+func (api *API) hostWindDownHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, api.host.WindDownStatus())
+}
+
+// hostWindDownHandlerPOST handles the API call to start or stop winding down
+// the host.
+func (api *API) hostWindDownHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if req.FormValue("enabled") == "" {
+		WriteError(w, Error{"enabled parameter is required"}, http.StatusBadRequest)
+		return
+	}
+	enabled, err := scanBool(req.FormValue("enabled"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse enabled: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.host.SetWindDown(enabled); err != nil {
+		WriteError(w, Error{"unable to set wind-down: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostRentersHandlerGET handles the API call asking for the policy that
+// determines which renters the host forms and renews contracts with.
+func (api *API) hostRentersHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	policy := api.host.RenterPolicy()
+	renters := make([]string, 0, len(policy.Renters))
+	for _, pk := range policy.Renters {
+		renters = append(renters, pk.String())
+	}
+	WriteJSON(w, HostRentersGET{
+		Mode:    policy.Mode,
+		Renters: renters,
+	})
+}
+
+// hostRentersHandlerPOST handles the API call to change the host's renter
+// policy. The mode can be changed, and a renter can be added to or removed
+// from the list, in a single call.
+func (api *API) hostRentersHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	policy := api.host.RenterPolicy()
+	if mode := req.FormValue("mode"); mode != "" {
+		policy.Mode = modules.HostRenterPolicyMode(mode)
+	}
+	parseKey := func(s string) (types.SiaPublicKey, error) {
+		var pk types.SiaPublicKey
+		pk.LoadString(s)
+		if len(pk.Key) == 0 {
+			return types.SiaPublicKey{}, errors.New("invalid renter public key " + s)
+		}
+		return pk, nil
+	}
+	if add := req.FormValue("add"); add != "" {
+		pk, err := parseKey(add)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+		listed := false
+		for _, renter := range policy.Renters {
+			listed = listed || renter.String() == pk.String()
+		}
+		if !listed {
+			policy.Renters = append(policy.Renters, pk)
+		}
+	}
+	if remove := req.FormValue("remove"); remove != "" {
+		pk, err := parseKey(remove)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+		renters := policy.Renters[:0]
+		for _, renter := range policy.Renters {
+			if renter.String() != pk.String() {
+				renters = append(renters, renter)
+			}
+		}
+		policy.Renters = renters
+	}
+	if err := api.host.SetRenterPolicy(policy); err != nil {
+		WriteError(w, Error{"unable to set renter policy: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // hostAnnounceHandler handles the API call to get the host to announce itself
 // to the network.
 func (api *API) hostAnnounceHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -284,10 +581,76 @@ func (api *API) hostAnnounceHandler(w http.ResponseWriter, req *http.Request, _
 // the host.
 func (api *API) storageHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	WriteJSON(w, StorageGET{
-		Folders: api.host.StorageFolders(),
+		Folders:     api.host.StorageFolders(),
+		SectorCache: api.host.SectorCacheMetrics(),
 	})
 }
 
+// storageObligationsHandler returns a page of the host's storage obligations,
+// filtered and sorted according to the request.
+func (api *API) storageObligationsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	q := modules.StorageObligationQuery{
+		Status: req.FormValue("status"),
+		Renter: req.FormValue("renter"),
+		SortBy: req.FormValue("sortby"),
+	}
+	ints := []struct {
+		name  string
+		value interface{}
+	}{
+		{"minexpiration", &q.MinExpiration},
+		{"maxexpiration", &q.MaxExpiration},
+		{"offset", &q.Offset},
+		{"limit", &q.Limit},
+	}
+	for _, f := range ints {
+		if req.FormValue(f.name) == "" {
+			continue
+		}
+		_, err := fmt.Sscan(req.FormValue(f.name), f.value)
+		if err != nil {
+			WriteError(w, Error{"unable to parse " + f.name + ": " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	switch req.FormValue("order") {
+	case "", "asc":
+	case "desc":
+		q.Descending = true
+	default:
+		WriteError(w, Error{errBadSortOrder.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	obligations, total, err := api.host.QueryStorageObligations(q)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if obligations == nil {
+		obligations = []modules.StorageObligation{}
+	}
+	WriteJSON(w, StorageObligationsGET{
+		Obligations:      obligations,
+		TotalObligations: total,
+	})
+}
+
+// storageObligationHandler returns the details of a storage obligation.
+func (api *API) storageObligationHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var id types.FileContractID
+	if err := (*crypto.Hash)(&id).LoadString(ps.ByName("id")); err != nil {
+		WriteError(w, Error{"unable to parse obligation id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	details, err := api.host.StorageObligation(id)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, details)
+}
+
 // storageFoldersAddHandler adds a storage folder to the storage manager.
 func (api *API) storageFoldersAddHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	folderPath := req.FormValue("path")
@@ -334,6 +697,35 @@ func (api *API) storageFoldersResizeHandler(w http.ResponseWriter, req *http.Req
 	WriteSuccess(w)
 }
 
+// storageFoldersMoveHandler moves a storage folder in the storage manager to
+// a new path.
+func (api *API) storageFoldersMoveHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	folderPath := req.FormValue("path")
+	if folderPath == "" {
+		WriteError(w, Error{"path parameter is required"}, http.StatusBadRequest)
+		return
+	}
+	newPath := req.FormValue("newpath")
+	if newPath == "" {
+		WriteError(w, Error{"newpath parameter is required"}, http.StatusBadRequest)
+		return
+	}
+
+	storageFolders := api.host.StorageFolders()
+	folderIndex, err := folderIndex(folderPath, storageFolders)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	err = api.host.MoveStorageFolder(uint16(folderIndex), newPath)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // storageFoldersRemoveHandler removes a storage folder from the storage
 // manager.
 func (api *API) storageFoldersRemoveHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -156,6 +157,307 @@ func TestEstimateWeight(t *testing.T) {
 	}
 }
 
+// TestHostPricingHandler checks that the host's pricing policy can be viewed
+// and changed through the API, and that enabling it adjusts the host's
+// prices.
+func TestHostPricingHandler(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	var hpg HostPricingGET
+	if err = st.getAPI("/host/pricing", &hpg); err != nil {
+		t.Fatal(err)
+	}
+	if hpg.Policy.Enabled {
+		t.Fatal("pricing policy should be disabled by default")
+	}
+	if len(hpg.Adjustments) != 0 {
+		t.Fatal("expected no price adjustments, got", hpg.Adjustments)
+	}
+
+	// Set the bounds, then enable the policy. The host has no storage, so
+	// it should advertise the minimum storage price and maximum collateral.
+	bounds := url.Values{
+		"minstorageprice": {"100"},
+		"maxstorageprice": {"300"},
+		"mincollateral":   {"1000"},
+		"maxcollateral":   {"2000"},
+	}
+	if err = st.stdPostAPI("/host/pricing", bounds); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.stdPostAPI("/host/pricing", url.Values{"enabled": {"true"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.getAPI("/host/pricing", &hpg); err != nil {
+		t.Fatal(err)
+	}
+	if !hpg.Policy.Enabled || !hpg.Policy.MaxStoragePrice.Equals64(300) || !hpg.Policy.MinCollateral.Equals64(1000) {
+		t.Fatal("pricing policy was not updated correctly:", hpg.Policy)
+	}
+	if len(hpg.Adjustments) != 1 {
+		t.Fatal("expected one price adjustment, got", len(hpg.Adjustments))
+	}
+	var hg HostGET
+	if err = st.getAPI("/host", &hg); err != nil {
+		t.Fatal(err)
+	}
+	if !hg.InternalSettings.MinStoragePrice.Equals64(100) || !hg.InternalSettings.Collateral.Equals64(2000) {
+		t.Fatal("host prices were not adjusted:", hg.InternalSettings.MinStoragePrice, hg.InternalSettings.Collateral)
+	}
+
+	// Invalid policies should be rejected.
+	if err = st.stdPostAPI("/host/pricing", url.Values{"minstorageprice": {"400"}}); err == nil {
+		t.Fatal("expected minimum storage price above the maximum to be rejected")
+	}
+	if err = st.stdPostAPI("/host/pricing", url.Values{"maxcollateral": {"foo"}}); err == nil {
+		t.Fatal("expected unparseable collateral to be rejected")
+	}
+}
+
+// TestHostRentersHandler checks that the host's renter policy can be viewed
+// and changed through the API.
+func TestHostRentersHandler(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	var hrg HostRentersGET
+	if err = st.getAPI("/host/renters", &hrg); err != nil {
+		t.Fatal(err)
+	}
+	if hrg.Mode != modules.HostRenterPolicyOpen || len(hrg.Renters) != 0 {
+		t.Fatal("expected an open host with no listed renters, got", hrg)
+	}
+
+	// Switch to an allowlist and add a renter twice, then another renter.
+	renter1 := "ed25519:" + strings.Repeat("ab", 32)
+	renter2 := "ed25519:" + strings.Repeat("cd", 32)
+	if err = st.stdPostAPI("/host/renters", url.Values{"mode": {"allowlist"}, "add": {renter1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.stdPostAPI("/host/renters", url.Values{"add": {renter1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.stdPostAPI("/host/renters", url.Values{"add": {renter2}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.getAPI("/host/renters", &hrg); err != nil {
+		t.Fatal(err)
+	}
+	if hrg.Mode != modules.HostRenterPolicyAllowlist || len(hrg.Renters) != 2 || hrg.Renters[0] != renter1 || hrg.Renters[1] != renter2 {
+		t.Fatal("renter policy was not updated correctly:", hrg)
+	}
+
+	// Remove a renter.
+	if err = st.stdPostAPI("/host/renters", url.Values{"remove": {renter1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.getAPI("/host/renters", &hrg); err != nil {
+		t.Fatal(err)
+	}
+	if len(hrg.Renters) != 1 || hrg.Renters[0] != renter2 {
+		t.Fatal("renter was not removed:", hrg.Renters)
+	}
+
+	// Invalid modes and keys should be rejected.
+	if err = st.stdPostAPI("/host/renters", url.Values{"mode": {"closed"}}); err == nil {
+		t.Fatal("expected unknown mode to be rejected")
+	}
+	if err = st.stdPostAPI("/host/renters", url.Values{"add": {"foo"}}); err == nil {
+		t.Fatal("expected invalid key to be rejected")
+	}
+}
+
+// TestHostRevenueHandler checks that the revenue ledger can be queried
+// through the API, and that invalid periods are rejected.
+func TestHostRevenueHandler(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// A new host has not resolved any obligations.
+	var hrg HostRevenueGET
+	if err = st.getAPI("/host/revenue", &hrg); err != nil {
+		t.Fatal(err)
+	}
+	if hrg.Entries == nil || len(hrg.Entries) != 0 || !hrg.Totals.ContractCompensation.IsZero() {
+		t.Fatal("expected an empty ledger, got", hrg)
+	}
+	if err = st.getAPI("/host/revenue?start=0&end=2000000000", &hrg); err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid periods should be rejected.
+	if err = st.getAPI("/host/revenue?start=yesterday", &hrg); err == nil {
+		t.Fatal("expected invalid start to be rejected")
+	}
+	if err = st.getAPI("/host/revenue?start=-1", &hrg); err == nil {
+		t.Fatal("expected negative start to be rejected")
+	}
+	if err = st.getAPI("/host/revenue?start=200&end=100", &hrg); err == nil {
+		t.Fatal("expected end before start to be rejected")
+	}
+}
+
+// TestStorageObligationsHandler checks that the storage obligations of the
+// host can be queried through the API, and that invalid queries are rejected.
+func TestStorageObligationsHandler(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	var sog StorageObligationsGET
+	if err = st.getAPI("/host/storage/obligations?status=unresolved&sortby=value&order=desc&offset=0&limit=10", &sog); err != nil {
+		t.Fatal(err)
+	}
+	if sog.Obligations == nil || len(sog.Obligations) != 0 || sog.TotalObligations != 0 {
+		t.Fatal("expected no obligations, got", sog)
+	}
+	var hg HostGET
+	if err = st.getAPI("/host", &hg); err != nil {
+		t.Fatal(err)
+	}
+	if hg.ProofAlerts == nil || len(hg.ProofAlerts) != 0 {
+		t.Fatal("host without obligations should have no proof alerts:", hg.ProofAlerts)
+	}
+
+	// Invalid queries should be rejected.
+	for _, query := range []string{"status=pending", "sortby=size", "order=up", "limit=-1", "minexpiration=soon"} {
+		if err = st.getAPI("/host/storage/obligations?"+query, &sog); err == nil {
+			t.Error("expected query to be rejected:", query)
+		}
+	}
+	var sod modules.StorageObligationDetails
+	if err = st.getAPI("/host/storage/obligations/"+types.FileContractID{}.String(), &sod); err == nil {
+		t.Error("expected unknown obligation to be rejected")
+	}
+	if err = st.getAPI("/host/storage/obligations/foo", &sod); err == nil {
+		t.Error("expected invalid obligation id to be rejected")
+	}
+
+	// Form a contract with the host.
+	if err = st.announceHost(); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.acceptContracts(); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.setHostStorage(); err != nil {
+		t.Fatal(err)
+	}
+	allowanceValues := url.Values{}
+	allowanceValues.Set("funds", testFunds)
+	allowanceValues.Set("period", testPeriod)
+	if err = st.stdPostAPI("/renter", allowanceValues); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, time.Millisecond*250, func() error {
+		var rc RenterContracts
+		if err := st.getAPI("/renter/contracts", &rc); err != nil {
+			return err
+		}
+		if len(rc.Contracts) != 1 {
+			return errors.New("no contracts")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The host should report the contract as an unresolved obligation.
+	if err = st.getAPI("/host/storage/obligations?status=unresolved", &sog); err != nil {
+		t.Fatal(err)
+	}
+	if len(sog.Obligations) != 1 || sog.TotalObligations != 1 {
+		t.Fatal("host has wrong number of obligations:", sog)
+	}
+	if err = st.getAPI("/host/storage/obligations/"+sog.Obligations[0].ObligationID.String(), &sod); err != nil {
+		t.Fatal(err)
+	}
+	if sod.ObligationID != sog.Obligations[0].ObligationID || len(sod.OriginTransactionSet) == 0 || len(sod.ValidProofOutputs) != 2 {
+		t.Error("host returned wrong obligation details:", sod)
+	}
+}
+
+// TestHostWindDownHandler checks that the host can be wound down through the
+// API.
+func TestHostWindDownHandler(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	var status modules.HostWindDownStatus
+	if err = st.getAPI("/host/winddown", &status); err != nil {
+		t.Fatal(err)
+	}
+	if status.Enabled || status.OutstandingObligations != 0 {
+		t.Fatal("new host should not be winding down:", status)
+	}
+
+	if err = st.stdPostAPI("/host/winddown", url.Values{"enabled": {"true"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.getAPI("/host/winddown", &status); err != nil {
+		t.Fatal(err)
+	}
+	if !status.Enabled || !status.Complete {
+		t.Fatal("host without obligations should have finished winding down:", status)
+	}
+	var hg HostGET
+	if err = st.getAPI("/host", &hg); err != nil {
+		t.Fatal(err)
+	}
+	if hg.ExternalSettings.AcceptingContracts {
+		t.Fatal("host that is winding down should not accept contracts")
+	}
+
+	if err = st.stdPostAPI("/host/winddown", url.Values{"enabled": {"false"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.getAPI("/host/winddown", &status); err != nil {
+		t.Fatal(err)
+	}
+	if status.Enabled {
+		t.Fatal("host should no longer be winding down")
+	}
+	if err = st.stdPostAPI("/host/winddown", url.Values{"enabled": {"maybe"}}); err == nil {
+		t.Fatal("expected invalid value to be rejected")
+	}
+}
+
 // TestWorkingStatus tests that the host's WorkingStatus field is set
 // correctly.
 func TestWorkingStatus(t *testing.T) {
@@ -773,6 +1075,48 @@ func TestResizeFolderNoPath(t *testing.T) {
 	}
 }
 
+// TestMoveStorageFolder checks that a storage folder can be moved to a new path
+// through the API.
+func TestMoveStorageFolder(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	if err := st.setHostStorage(); err != nil {
+		t.Fatal(err)
+	}
+	newPath := filepath.Join(st.dir, "moved")
+	if err := os.MkdirAll(newPath, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	// The call to move should fail if no new path has been provided.
+	moveValues := url.Values{}
+	moveValues.Set("path", st.dir)
+	err = st.stdPostAPI("/host/storage/folders/move", moveValues)
+	if err == nil || err.Error() != "newpath parameter is required" {
+		t.Fatal("expected the move to fail without a new path, got", err)
+	}
+
+	moveValues.Set("newpath", newPath)
+	if err = st.stdPostAPI("/host/storage/folders/move", moveValues); err != nil {
+		t.Fatal(err)
+	}
+	var sfs StorageGET
+	if err = st.getAPI("/host/storage", &sfs); err != nil {
+		t.Fatal(err)
+	}
+	if len(sfs.Folders) != 1 || sfs.Folders[0].Path != newPath {
+		t.Fatal("storage folder was not moved:", sfs.Folders)
+	}
+}
+
 // TestRemoveEmptyStorageFolder checks that removing an empty storage folder
 // succeeds -- even if the host is left with zero storage folders.
 func TestRemoveEmptyStorageFolder(t *testing.T) {
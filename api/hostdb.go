@@ -3,6 +3,8 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"sort"
+	"time"
 
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
@@ -24,9 +26,12 @@ type (
 		Hosts []ExtendedHostDBEntry `json:"hosts"`
 	}
 
-	// HostdbAllGET lists all hosts that the renter is aware of.
+	// HostdbAllGET lists all hosts that the renter is aware of. If the
+	// request was filtered or paginated, TotalHosts is the number of hosts
+	// that matched the filters, of which Hosts is one page.
 	HostdbAllGET struct {
-		Hosts []ExtendedHostDBEntry `json:"hosts"`
+		Hosts      []ExtendedHostDBEntry `json:"hosts"`
+		TotalHosts int                   `json:"totalhosts"`
 	}
 
 	// HostdbHostsGET lists detailed statistics for a particular host, selected
@@ -35,8 +40,93 @@ type (
 		Entry          ExtendedHostDBEntry        `json:"entry"`
 		ScoreBreakdown modules.HostScoreBreakdown `json:"scorebreakdown"`
 	}
+
+	// HostdbWeightsGET contains the weights that the hostdb uses to score
+	// hosts.
+	HostdbWeightsGET struct {
+		Weights modules.HostDBWeights `json:"weights"`
+	}
+
+	// HostdbPruningGET contains the policy that the hostdb uses to prune
+	// hosts, and the hosts that it pruned most recently.
+	HostdbPruningGET struct {
+		Policy      modules.HostDBPruningPolicy `json:"policy"`
+		PrunedHosts []modules.PrunedHost        `json:"prunedhosts"`
+	}
+
+	// HostdbScoreTestEntry compares the score and rank of an active host
+	// under the current and the tested weights.
+	HostdbScoreTestEntry struct {
+		NetAddress      modules.NetAddress `json:"netaddress"`
+		PublicKeyString string             `json:"publickeystring"`
+		CurrentRank     int                `json:"currentrank"`
+		CurrentScore    types.Currency     `json:"currentscore"`
+		TestRank        int                `json:"testrank"`
+		TestScore       types.Currency     `json:"testscore"`
+	}
+
+	// HostdbScoreTestGET lists the active hosts, sorted by their rank under
+	// the tested weights.
+	HostdbScoreTestGET struct {
+		Weights modules.HostDBWeights  `json:"weights"`
+		Hosts   []HostdbScoreTestEntry `json:"hosts"`
+	}
 )
 
+// parseHostDBWeights overwrites the fields of w that are present in the
+// request.
+func parseHostDBWeights(req *http.Request, w *modules.HostDBWeights) error {
+	floats := map[string]*float64{
+		"ageexponent":                &w.AgeExponent,
+		"agemultiplier":              &w.AgeMultiplier,
+		"burnexponent":               &w.BurnExponent,
+		"burnmultiplier":             &w.BurnMultiplier,
+		"collateralexponent":         &w.CollateralExponent,
+		"collateralmultiplier":       &w.CollateralMultiplier,
+		"interactionexponent":        &w.InteractionExponent,
+		"interactionmultiplier":      &w.InteractionMultiplier,
+		"performanceexponent":        &w.PerformanceExponent,
+		"performancemultiplier":      &w.PerformanceMultiplier,
+		"priceexponent":              &w.PriceExponent,
+		"pricemultiplier":            &w.PriceMultiplier,
+		"storageremainingexponent":   &w.StorageRemainingExponent,
+		"storageremainingmultiplier": &w.StorageRemainingMultiplier,
+		"uptimeexponent":             &w.UptimeExponent,
+		"uptimemultiplier":           &w.UptimeMultiplier,
+		"versionexponent":            &w.VersionExponent,
+		"versionmultiplier":          &w.VersionMultiplier,
+		"contractpriceweight":        &w.ContractPriceWeight,
+		"downloadpriceweight":        &w.DownloadPriceWeight,
+		"storagepriceweight":         &w.StoragePriceWeight,
+		"uploadpriceweight":          &w.UploadPriceWeight,
+	}
+	for name, f := range floats {
+		if req.FormValue(name) == "" {
+			continue
+		}
+		if _, err := fmt.Sscan(req.FormValue(name), f); err != nil {
+			return fmt.Errorf("unable to parse %v: %v", name, err)
+		}
+	}
+	prices := map[string]*types.Currency{
+		"maxcontractprice":          &w.MaxContractPrice,
+		"maxdownloadbandwidthprice": &w.MaxDownloadBandwidthPrice,
+		"maxstorageprice":           &w.MaxStoragePrice,
+		"maxuploadbandwidthprice":   &w.MaxUploadBandwidthPrice,
+	}
+	for name, c := range prices {
+		if req.FormValue(name) == "" {
+			continue
+		}
+		price, ok := scanAmount(req.FormValue(name))
+		if !ok {
+			return fmt.Errorf("unable to parse %v", name)
+		}
+		*c = price
+	}
+	return nil
+}
+
 // hostdbActiveHandler handles the API call asking for the list of active
 // hosts.
 func (api *API) hostdbActiveHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -75,19 +165,47 @@ func (api *API) hostdbActiveHandler(w http.ResponseWriter, req *http.Request, _
 }
 
 // hostdbAllHandler handles the API call asking for the list of all hosts.
+// The hosts can be filtered, sorted and paginated through the query string.
 func (api *API) hostdbAllHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	// Get the set of all hosts and convert them into extended hosts.
-	hosts := api.renter.AllHosts()
-	var extendedHosts []ExtendedHostDBEntry
-	for _, host := range hosts {
+	q, err := parseHostdbQuery(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Only score the hosts if the query needs it.
+	scored := !q.minScore.IsZero() || q.sortBy == "score"
+	weights := api.renter.HostDBWeights()
+	var results []hostdbResult
+	for _, host := range api.renter.AllHosts() {
+		r := hostdbResult{
+			entry:     host,
+			publicKey: host.PublicKey.String(),
+			uptime:    hostUptimeRatio(host),
+		}
+		if scored {
+			r.score = api.renter.ScoreBreakdownWithWeights(host, weights).Score
+		}
+		if q.matches(r) {
+			results = append(results, r)
+		}
+	}
+	q.sort(results)
+	total := len(results)
+	results = q.page(results)
+
+	// Convert the results into extended hosts.
+	extendedHosts := make([]ExtendedHostDBEntry, 0, len(results))
+	for _, r := range results {
 		extendedHosts = append(extendedHosts, ExtendedHostDBEntry{
-			HostDBEntry:     host,
-			PublicKeyString: host.PublicKey.String(),
+			HostDBEntry:     r.entry,
+			PublicKeyString: r.publicKey,
 		})
 	}
 
 	WriteJSON(w, HostdbAllGET{
-		Hosts: extendedHosts,
+		Hosts:      extendedHosts,
+		TotalHosts: total,
 	})
 }
 
@@ -114,3 +232,104 @@ func (api *API) hostdbHostsHandler(w http.ResponseWriter, req *http.Request, ps
 		ScoreBreakdown: breakdown,
 	})
 }
+
+// hostdbWeightsHandlerGET handles the API call asking for the weights used to
+// score hosts.
+func (api *API) hostdbWeightsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostdbWeightsGET{
+		Weights: api.renter.HostDBWeights(),
+	})
+}
+
+// hostdbWeightsHandlerPOST handles the API call to change the weights used to
+// score hosts. Weights that are not specified keep their current values.
+func (api *API) hostdbWeightsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	weights := api.renter.HostDBWeights()
+	if err := parseHostDBWeights(req, &weights); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.renter.SetHostDBWeights(weights); err != nil {
+		WriteError(w, Error{"unable to set weights: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostdbPruningHandlerGET handles the API call asking for the pruning policy
+// of the hostdb and the hosts that it pruned most recently.
+func (api *API) hostdbPruningHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostdbPruningGET{
+		Policy:      api.renter.HostDBPruningPolicy(),
+		PrunedHosts: api.renter.PrunedHosts(),
+	})
+}
+
+// hostdbPruningHandlerPOST handles the API call to change the pruning policy
+// of the hostdb. Fields that are not specified keep their current values.
+func (api *API) hostdbPruningHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	policy := api.renter.HostDBPruningPolicy()
+	if req.FormValue("maxdowntime") != "" {
+		d, err := time.ParseDuration(req.FormValue("maxdowntime"))
+		if err != nil {
+			WriteError(w, Error{"unable to parse maxdowntime: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		policy.MaxDowntime = d
+	}
+	if req.FormValue("announcementwindow") != "" {
+		_, err := fmt.Sscan(req.FormValue("announcementwindow"), &policy.AnnouncementWindow)
+		if err != nil {
+			WriteError(w, Error{"unable to parse announcementwindow: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := api.renter.SetHostDBPruningPolicy(policy); err != nil {
+		WriteError(w, Error{"unable to set pruning policy: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostdbScoreTestHandler handles the API call asking how the ranking of the
+// active hosts would change under a different set of weights. Weights that
+// are not specified keep their current values. The weights in use are not
+// changed.
+func (api *API) hostdbScoreTestHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	current := api.renter.HostDBWeights()
+	weights := current
+	if err := parseHostDBWeights(req, &weights); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	hosts := api.renter.ActiveHosts()
+	entries := make([]HostdbScoreTestEntry, len(hosts))
+	for i, host := range hosts {
+		entries[i] = HostdbScoreTestEntry{
+			NetAddress:      host.NetAddress,
+			PublicKeyString: host.PublicKey.String(),
+			CurrentScore:    api.renter.ScoreBreakdownWithWeights(host, current).Score,
+			TestScore:       api.renter.ScoreBreakdownWithWeights(host, weights).Score,
+		}
+	}
+	// Rank the hosts under the current weights, then under the tested
+	// weights.
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].CurrentScore.Cmp(entries[j].CurrentScore) > 0
+	})
+	for i := range entries {
+		entries[i].CurrentRank = i + 1
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].TestScore.Cmp(entries[j].TestScore) > 0
+	})
+	for i := range entries {
+		entries[i].TestRank = i + 1
+	}
+
+	WriteJSON(w, HostdbScoreTestGET{
+		Weights: weights,
+		Hosts:   entries,
+	})
+}
@@ -141,6 +141,29 @@ func TestHostDBHostsAllHandler(t *testing.T) {
 	if len(ah.Hosts) != 1 {
 		t.Fatalf("expected 1 host, got %v", len(ah.Hosts))
 	}
+
+	// Filter and sort the hosts.
+	if err = st.getAPI("/hostdb/all?acceptingcontracts=true&sortby=score&order=desc", &ah); err != nil {
+		t.Fatal(err)
+	}
+	if len(ah.Hosts) != 1 || ah.TotalHosts != 1 {
+		t.Fatalf("expected 1 host, got %v of %v", len(ah.Hosts), ah.TotalHosts)
+	}
+	if err = st.getAPI("/hostdb/all?acceptingcontracts=false", &ah); err != nil {
+		t.Fatal(err)
+	}
+	if len(ah.Hosts) != 0 || ah.TotalHosts != 0 {
+		t.Fatalf("expected 0 hosts, got %v of %v", len(ah.Hosts), ah.TotalHosts)
+	}
+	if err = st.getAPI("/hostdb/all?offset=1", &ah); err != nil {
+		t.Fatal(err)
+	}
+	if len(ah.Hosts) != 0 || ah.TotalHosts != 1 {
+		t.Fatalf("expected 0 of 1 hosts, got %v of %v", len(ah.Hosts), ah.TotalHosts)
+	}
+	if err = st.getAPI("/hostdb/all?sortby=netaddress", &ah); err == nil {
+		t.Fatal("expected invalid sort field to be rejected")
+	}
 }
 
 // TestHostDBHostsHandler checks that the hosts handler is easily able to return
@@ -180,6 +203,11 @@ func TestHostDBHostsHandler(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// The host has been scanned, so its latencies should have been measured.
+	if hh.Entry.ConnectLatency == 0 || hh.Entry.SettingsLatency == 0 {
+		t.Error("host latencies were not measured during the scan")
+	}
+
 	// Check that none of the values equal zero. A value of zero indicates that
 	// the field is no longer being tracked/reported, which could break
 	// compatibility for some apps. The default needs to be '1', not zero.
@@ -195,6 +223,9 @@ func TestHostDBHostsHandler(t *testing.T) {
 	if hh.ScoreBreakdown.CollateralAdjustment == 0 {
 		t.Error("Zero value in host score breakdown")
 	}
+	if hh.ScoreBreakdown.PerformanceAdjustment == 0 {
+		t.Error("Zero value in host score breakdown")
+	}
 	if hh.ScoreBreakdown.PriceAdjustment == 0 {
 		t.Error("Zero value in host score breakdown")
 	}
@@ -236,6 +267,127 @@ func TestHostDBHostsHandler(t *testing.T) {
 	}
 }
 
+// TestHostDBWeightsHandler checks that the weights used to score hosts can be
+// viewed, changed, and tested through the API.
+func TestHostDBWeightsHandler(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+	if err = st.announceHost(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The default weights should weigh all price categories equally.
+	var hwg HostdbWeightsGET
+	if err = st.getAPI("/hostdb/weights", &hwg); err != nil {
+		t.Fatal(err)
+	}
+	if hwg.Weights.DownloadPriceWeight != 1 || hwg.Weights.StoragePriceWeight != 1 {
+		t.Fatal("unexpected default weights:", hwg.Weights)
+	}
+
+	// Test a set of weights without applying them.
+	var hst HostdbScoreTestGET
+	if err = st.getAPI("/hostdb/scoretest?downloadpriceweight=4", &hst); err != nil {
+		t.Fatal(err)
+	}
+	if hst.Weights.DownloadPriceWeight != 4 {
+		t.Fatal("scoretest did not use the test weights")
+	}
+	if len(hst.Hosts) != 1 {
+		t.Fatalf("expected 1 host, got %v", len(hst.Hosts))
+	}
+	if hst.Hosts[0].CurrentRank != 1 || hst.Hosts[0].TestRank != 1 {
+		t.Fatal("unexpected ranks for the only host:", hst.Hosts[0])
+	}
+	if err = st.getAPI("/hostdb/weights", &hwg); err != nil {
+		t.Fatal(err)
+	}
+	if hwg.Weights.DownloadPriceWeight != 1 {
+		t.Fatal("scoretest changed the weights")
+	}
+
+	// Change a weight, leaving the others unchanged.
+	if err = st.stdPostAPI("/hostdb/weights", url.Values{"downloadpriceweight": {"4"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.getAPI("/hostdb/weights", &hwg); err != nil {
+		t.Fatal(err)
+	}
+	if hwg.Weights.DownloadPriceWeight != 4 || hwg.Weights.StoragePriceWeight != 1 {
+		t.Fatal("weights were not updated correctly:", hwg.Weights)
+	}
+
+	// Invalid weights should be rejected.
+	if err = st.stdPostAPI("/hostdb/weights", url.Values{"uptimemultiplier": {"0"}}); err == nil {
+		t.Fatal("expected zero multiplier to be rejected")
+	}
+	if err = st.stdPostAPI("/hostdb/weights", url.Values{"ageexponent": {"foo"}}); err == nil {
+		t.Fatal("expected unparseable exponent to be rejected")
+	}
+}
+
+// TestHostDBPruningHandler checks that the pruning policy can be viewed and
+// changed through the API.
+func TestHostDBPruningHandler(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	var hpg HostdbPruningGET
+	if err = st.getAPI("/hostdb/pruning", &hpg); err != nil {
+		t.Fatal(err)
+	}
+	if hpg.Policy.MaxDowntime == 0 {
+		t.Fatal("pruning should be enabled by default")
+	}
+	if len(hpg.PrunedHosts) != 0 {
+		t.Fatal("expected no pruned hosts, got", hpg.PrunedHosts)
+	}
+
+	// Change the max downtime, leaving the announcement window unchanged.
+	window := hpg.Policy.AnnouncementWindow
+	if err = st.stdPostAPI("/hostdb/pruning", url.Values{"maxdowntime": {"720h"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.getAPI("/hostdb/pruning", &hpg); err != nil {
+		t.Fatal(err)
+	}
+	if hpg.Policy.MaxDowntime != 720*time.Hour || hpg.Policy.AnnouncementWindow != window {
+		t.Fatal("pruning policy was not updated correctly:", hpg.Policy)
+	}
+	if err = st.stdPostAPI("/hostdb/pruning", url.Values{"announcementwindow": {"500"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.getAPI("/hostdb/pruning", &hpg); err != nil {
+		t.Fatal(err)
+	}
+	if hpg.Policy.MaxDowntime != 720*time.Hour || hpg.Policy.AnnouncementWindow != 500 {
+		t.Fatal("pruning policy was not updated correctly:", hpg.Policy)
+	}
+
+	// Invalid policies should be rejected.
+	if err = st.stdPostAPI("/hostdb/pruning", url.Values{"maxdowntime": {"-1h"}}); err == nil {
+		t.Fatal("expected negative max downtime to be rejected")
+	}
+	if err = st.stdPostAPI("/hostdb/pruning", url.Values{"maxdowntime": {"30"}}); err == nil {
+		t.Fatal("expected max downtime without a unit to be rejected")
+	}
+	if err = st.stdPostAPI("/hostdb/pruning", url.Values{"announcementwindow": {"foo"}}); err == nil {
+		t.Fatal("expected unparseable announcement window to be rejected")
+	}
+}
+
 // assembleHostHostname is assembleServerTester but you can specify which
 // hostname the host should use.
 func assembleHostPort(key crypto.TwofishKey, hostHostname string, testdir string) (*serverTester, error) {
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	errBadSortOrder = errors.New("order must be 'asc' or 'desc'")
+	errBadUptime    = errors.New("minuptime must be between 0 and 1")
+	errBadVersion   = errors.New("minversion is not a valid version")
+)
+
+// hostdbSortFuncs are the fields that the hosts returned by /hostdb/all can be
+// sorted by, in ascending order.
+var hostdbSortFuncs = map[string]func(a, b hostdbResult) bool{
+	"collateral": func(a, b hostdbResult) bool {
+		return a.entry.Collateral.Cmp(b.entry.Collateral) < 0
+	},
+	"firstseen": func(a, b hostdbResult) bool {
+		return a.entry.FirstSeen < b.entry.FirstSeen
+	},
+	"remainingstorage": func(a, b hostdbResult) bool {
+		return a.entry.RemainingStorage < b.entry.RemainingStorage
+	},
+	"score": func(a, b hostdbResult) bool {
+		return a.score.Cmp(b.score) < 0
+	},
+	"storageprice": func(a, b hostdbResult) bool {
+		return a.entry.StoragePrice.Cmp(b.entry.StoragePrice) < 0
+	},
+	"uptime": func(a, b hostdbResult) bool {
+		return a.uptime < b.uptime
+	},
+	"version": func(a, b hostdbResult) bool {
+		return build.VersionCmp(a.entry.Version, b.entry.Version) < 0
+	},
+}
+
+type (
+	// hostdbResult is a host considered by a /hostdb/all query, along with
+	// the derived values that the query can filter and sort on. The score is
+	// only computed if the query needs it.
+	hostdbResult struct {
+		entry     modules.HostDBEntry
+		publicKey string
+		score     types.Currency
+		uptime    float64
+	}
+
+	// hostdbQuery is the set of filters, the sort order, and the page
+	// requested from /hostdb/all. Unset filters match every host.
+	hostdbQuery struct {
+		minStoragePrice     types.Currency
+		maxStoragePrice     *types.Currency
+		minCollateral       types.Currency
+		maxCollateral       *types.Currency
+		minRemainingStorage uint64
+		minVersion          string
+		minUptime           float64
+		minFirstSeen        types.BlockHeight
+		maxFirstSeen        *types.BlockHeight
+		acceptingContracts  *bool
+		minScore            types.Currency
+
+		sortBy string
+		desc   bool
+		offset int
+		limit  int
+	}
+)
+
+// hostUptimeRatio returns the fraction of the host's measured lifetime that it
+// was online. Hosts with fewer than two scans have a ratio of 0.
+func hostUptimeRatio(entry modules.HostDBEntry) float64 {
+	if len(entry.ScanHistory) < 2 {
+		return 0
+	}
+	downtime := entry.HistoricDowntime
+	uptime := entry.HistoricUptime
+	recentTime := entry.ScanHistory[0].Timestamp
+	recentSuccess := entry.ScanHistory[0].Success
+	for _, scan := range entry.ScanHistory[1:] {
+		if recentSuccess {
+			uptime += scan.Timestamp.Sub(recentTime)
+		} else {
+			downtime += scan.Timestamp.Sub(recentTime)
+		}
+		recentTime = scan.Timestamp
+		recentSuccess = scan.Success
+	}
+	if uptime+downtime == 0 {
+		return 0
+	}
+	return float64(uptime) / float64(uptime+downtime)
+}
+
+// parseHostdbQuery parses the filters, sort order and page of a /hostdb/all
+// request.
+func parseHostdbQuery(req *http.Request) (q hostdbQuery, err error) {
+	prices := map[string]*types.Currency{
+		"minstorageprice": &q.minStoragePrice,
+		"mincollateral":   &q.minCollateral,
+		"minscore":        &q.minScore,
+	}
+	for name, c := range prices {
+		if req.FormValue(name) == "" {
+			continue
+		}
+		price, ok := scanAmount(req.FormValue(name))
+		if !ok {
+			return hostdbQuery{}, fmt.Errorf("unable to parse %v", name)
+		}
+		*c = price
+	}
+	maxPrices := map[string]**types.Currency{
+		"maxstorageprice": &q.maxStoragePrice,
+		"maxcollateral":   &q.maxCollateral,
+	}
+	for name, c := range maxPrices {
+		if req.FormValue(name) == "" {
+			continue
+		}
+		price, ok := scanAmount(req.FormValue(name))
+		if !ok {
+			return hostdbQuery{}, fmt.Errorf("unable to parse %v", name)
+		}
+		*c = &price
+	}
+
+	if v := req.FormValue("minremainingstorage"); v != "" {
+		if _, err := fmt.Sscan(v, &q.minRemainingStorage); err != nil {
+			return hostdbQuery{}, fmt.Errorf("unable to parse minremainingstorage: %v", err)
+		}
+	}
+	if v := req.FormValue("minversion"); v != "" {
+		if !build.IsVersion(v) {
+			return hostdbQuery{}, errBadVersion
+		}
+		q.minVersion = v
+	}
+	if v := req.FormValue("minuptime"); v != "" {
+		if _, err := fmt.Sscan(v, &q.minUptime); err != nil {
+			return hostdbQuery{}, fmt.Errorf("unable to parse minuptime: %v", err)
+		} else if q.minUptime < 0 || q.minUptime > 1 {
+			return hostdbQuery{}, errBadUptime
+		}
+	}
+	if v := req.FormValue("minfirstseen"); v != "" {
+		if _, err := fmt.Sscan(v, &q.minFirstSeen); err != nil {
+			return hostdbQuery{}, fmt.Errorf("unable to parse minfirstseen: %v", err)
+		}
+	}
+	if v := req.FormValue("maxfirstseen"); v != "" {
+		var height types.BlockHeight
+		if _, err := fmt.Sscan(v, &height); err != nil {
+			return hostdbQuery{}, fmt.Errorf("unable to parse maxfirstseen: %v", err)
+		}
+		q.maxFirstSeen = &height
+	}
+	if v := req.FormValue("acceptingcontracts"); v != "" {
+		accepting, err := scanBool(v)
+		if err != nil {
+			return hostdbQuery{}, err
+		}
+		q.acceptingContracts = &accepting
+	}
+
+	if v := req.FormValue("sortby"); v != "" {
+		if _, ok := hostdbSortFuncs[v]; !ok {
+			return hostdbQuery{}, fmt.Errorf("cannot sort by %q", v)
+		}
+		q.sortBy = v
+	}
+	switch req.FormValue("order") {
+	case "", "asc":
+	case "desc":
+		q.desc = true
+	default:
+		return hostdbQuery{}, errBadSortOrder
+	}
+	if v := req.FormValue("offset"); v != "" {
+		if _, err := fmt.Sscan(v, &q.offset); err != nil || q.offset < 0 {
+			return hostdbQuery{}, errors.New("offset must be a non-negative integer")
+		}
+	}
+	if v := req.FormValue("limit"); v != "" {
+		if _, err := fmt.Sscan(v, &q.limit); err != nil || q.limit < 0 {
+			return hostdbQuery{}, errors.New("limit must be a non-negative integer")
+		}
+	}
+	return q, nil
+}
+
+// matches returns true if the host passes every filter of the query.
+func (q hostdbQuery) matches(r hostdbResult) bool {
+	e := r.entry
+	switch {
+	case e.StoragePrice.Cmp(q.minStoragePrice) < 0:
+		return false
+	case q.maxStoragePrice != nil && e.StoragePrice.Cmp(*q.maxStoragePrice) > 0:
+		return false
+	case e.Collateral.Cmp(q.minCollateral) < 0:
+		return false
+	case q.maxCollateral != nil && e.Collateral.Cmp(*q.maxCollateral) > 0:
+		return false
+	case e.RemainingStorage < q.minRemainingStorage:
+		return false
+	case q.minVersion != "" && build.VersionCmp(e.Version, q.minVersion) < 0:
+		return false
+	case r.uptime < q.minUptime:
+		return false
+	case e.FirstSeen < q.minFirstSeen:
+		return false
+	case q.maxFirstSeen != nil && e.FirstSeen > *q.maxFirstSeen:
+		return false
+	case q.acceptingContracts != nil && e.AcceptingContracts != *q.acceptingContracts:
+		return false
+	case r.score.Cmp(q.minScore) < 0:
+		return false
+	}
+	return true
+}
+
+// sort sorts the results by the field of the query. Hosts are first ordered
+// by public key, so that the order, and therefore each page, is consistent
+// between requests.
+func (q hostdbQuery) sort(results []hostdbResult) {
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].publicKey < results[j].publicKey
+	})
+	less, ok := hostdbSortFuncs[q.sortBy]
+	if !ok {
+		return
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if q.desc {
+			return less(results[j], results[i])
+		}
+		return less(results[i], results[j])
+	})
+}
+
+// page returns the page of results selected by the query's offset and limit.
+// A limit of 0 returns every result after the offset.
+func (q hostdbQuery) page(results []hostdbResult) []hostdbResult {
+	if q.offset >= len(results) {
+		return nil
+	}
+	results = results[q.offset:]
+	if q.limit > 0 && q.limit < len(results) {
+		results = results[:q.limit]
+	}
+	return results
+}
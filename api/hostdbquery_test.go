@@ -0,0 +1,150 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// newHostdbQuery parses a hostdbQuery from the query string s.
+func newHostdbQuery(t *testing.T, s string) hostdbQuery {
+	req, err := http.NewRequest("GET", "/hostdb/all?"+s, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := parseHostdbQuery(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+// TestParseHostdbQuery checks that invalid queries are rejected.
+func TestParseHostdbQuery(t *testing.T) {
+	bad := []url.Values{
+		{"minstorageprice": {"foo"}},
+		{"maxcollateral": {"1.5"}},
+		{"minremainingstorage": {"foo"}},
+		{"minversion": {"one"}},
+		{"minuptime": {"2"}},
+		{"maxfirstseen": {"foo"}},
+		{"acceptingcontracts": {"maybe"}},
+		{"sortby": {"netaddress"}},
+		{"order": {"up"}},
+		{"offset": {"-1"}},
+		{"limit": {"foo"}},
+	}
+	for _, vals := range bad {
+		req, err := http.NewRequest("GET", "/hostdb/all?"+vals.Encode(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := parseHostdbQuery(req); err == nil {
+			t.Error("expected query to be rejected:", vals.Encode())
+		}
+	}
+}
+
+// TestHostdbQueryMatches checks that hosts are filtered correctly.
+func TestHostdbQueryMatches(t *testing.T) {
+	var entry modules.HostDBEntry
+	entry.StoragePrice = types.NewCurrency64(100)
+	entry.Collateral = types.NewCurrency64(200)
+	entry.RemainingStorage = 1e9
+	entry.Version = "1.3.0"
+	entry.FirstSeen = 50
+	entry.AcceptingContracts = true
+	r := hostdbResult{
+		entry:  entry,
+		score:  types.NewCurrency64(1000),
+		uptime: 0.9,
+	}
+
+	tests := []struct {
+		query   string
+		matches bool
+	}{
+		{"", true},
+		{"minstorageprice=100&maxstorageprice=100", true},
+		{"minstorageprice=101", false},
+		{"maxstorageprice=99", false},
+		{"maxstorageprice=0", false},
+		{"mincollateral=200&maxcollateral=300", true},
+		{"maxcollateral=199", false},
+		{"minremainingstorage=1000000000", true},
+		{"minremainingstorage=1000000001", false},
+		{"minversion=1.2.5", true},
+		{"minversion=1.3.1", false},
+		{"minuptime=0.9", true},
+		{"minuptime=0.95", false},
+		{"minfirstseen=50&maxfirstseen=50", true},
+		{"minfirstseen=51", false},
+		{"maxfirstseen=49", false},
+		{"acceptingcontracts=true", true},
+		{"acceptingcontracts=false", false},
+		{"minscore=1000", true},
+		{"minscore=1001", false},
+	}
+	for _, test := range tests {
+		if newHostdbQuery(t, test.query).matches(r) != test.matches {
+			t.Errorf("%q: expected match to be %v", test.query, test.matches)
+		}
+	}
+}
+
+// TestHostdbQuerySortPage checks that hosts are sorted and paginated
+// correctly.
+func TestHostdbQuerySortPage(t *testing.T) {
+	results := []hostdbResult{
+		{publicKey: "c", entry: modules.HostDBEntry{FirstSeen: 2}},
+		{publicKey: "a", entry: modules.HostDBEntry{FirstSeen: 1}},
+		{publicKey: "d", entry: modules.HostDBEntry{FirstSeen: 1}},
+		{publicKey: "b", entry: modules.HostDBEntry{FirstSeen: 3}},
+	}
+	order := func(results []hostdbResult) (s string) {
+		for _, r := range results {
+			s += r.publicKey
+		}
+		return s
+	}
+	tests := []struct {
+		query string
+		order string
+	}{
+		{"", "abcd"},
+		{"sortby=firstseen", "adcb"},
+		{"sortby=firstseen&order=desc", "bcad"},
+		{"sortby=firstseen&offset=1&limit=2", "dc"},
+		{"offset=3&limit=2", "d"},
+		{"offset=4", ""},
+	}
+	for _, test := range tests {
+		q := newHostdbQuery(t, test.query)
+		rs := append([]hostdbResult(nil), results...)
+		q.sort(rs)
+		if o := order(q.page(rs)); o != test.order {
+			t.Errorf("%q: expected %q, got %q", test.query, test.order, o)
+		}
+	}
+}
+
+// TestHostUptimeRatio probes the hostUptimeRatio function.
+func TestHostUptimeRatio(t *testing.T) {
+	var entry modules.HostDBEntry
+	if hostUptimeRatio(entry) != 0 {
+		t.Fatal("expected host with no scans to have an uptime ratio of 0")
+	}
+	now := time.Now()
+	entry.ScanHistory = modules.HostDBScans{
+		{Timestamp: now, Success: true},
+		{Timestamp: now.Add(3 * time.Hour), Success: false},
+		{Timestamp: now.Add(4 * time.Hour), Success: true},
+	}
+	if r := hostUptimeRatio(entry); r != 0.75 {
+		t.Fatal("expected uptime ratio of 0.75, got", r)
+	}
+}
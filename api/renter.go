@@ -72,6 +72,7 @@ type (
 		Settings         modules.RenterSettings `json:"settings"`
 		FinancialMetrics RenterFinancialMetrics `json:"financialmetrics"`
 		CurrentPeriod    types.BlockHeight      `json:"currentperiod"`
+		IdentityKey      string                 `json:"identitykey"`
 	}
 
 	// RenterFinancialMetrics contains metrics about how much the Renter has
@@ -105,6 +106,8 @@ type (
 		LastTransaction types.Transaction `json:"lasttransaction"`
 		// Address of the host the file contract was formed with.
 		NetAddress modules.NetAddress `json:"netaddress"`
+		// Attempts to resolve revision mismatches with the host.
+		Reconciliations []modules.ContractReconciliation `json:"reconciliations"`
 		// Remaining funds left for the renter to spend on uploads & downloads.
 		RenterFunds types.Currency `json:"renterfunds"`
 		// Size of the file contract, which is typically equal to the number of
@@ -188,10 +191,12 @@ func (api *API) renterHandlerGET(w http.ResponseWriter, req *http.Request, _ htt
 		}
 	}
 
+	identityKey := api.renter.IdentityKey()
 	WriteJSON(w, RenterGET{
 		Settings:         settings,
 		FinancialMetrics: fm,
 		CurrentPeriod:    periodStart,
+		IdentityKey:      identityKey.String(),
 	})
 }
 
@@ -244,13 +249,24 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		renewWindow = period / 2
 	}
 
+	// Scan the max price increase. (optional parameter)
+	var maxPriceIncrease float64
+	if req.FormValue("maxpriceincrease") != "" {
+		_, err = fmt.Sscan(req.FormValue("maxpriceincrease"), &maxPriceIncrease)
+		if err != nil {
+			WriteError(w, Error{"unable to parse maxpriceincrease: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Set the settings in the renter.
 	err = api.renter.SetSettings(modules.RenterSettings{
 		Allowance: modules.Allowance{
-			Funds:       funds,
-			Hosts:       hosts,
-			Period:      period,
-			RenewWindow: renewWindow,
+			Funds:            funds,
+			Hosts:            hosts,
+			Period:           period,
+			RenewWindow:      renewWindow,
+			MaxPriceIncrease: maxPriceIncrease,
 		},
 	})
 	if err != nil {
@@ -272,6 +288,7 @@ func (api *API) renterContractsHandler(w http.ResponseWriter, _ *http.Request, _
 			ID:               c.ID,
 			LastTransaction:  c.LastRevisionTxn,
 			NetAddress:       c.NetAddress,
+			Reconciliations:  c.Reconciliations,
 			RenterFunds:      c.RenterFunds(),
 			Size:             c.LastRevision.NewFileSize,
 			StartHeight:      c.StartHeight,
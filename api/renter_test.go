@@ -737,6 +737,10 @@ func TestRenterHandlerGetAndPost(t *testing.T) {
 	if got := get.Settings.Allowance.RenewWindow; got != expectedRenewWindow {
 		t.Fatalf("expected renew window to be %v; got %v", expectedRenewWindow, got)
 	}
+	// Check that the default max price increase is used.
+	if got := get.Settings.Allowance.MaxPriceIncrease; got != 0 {
+		t.Fatalf("expected max price increase to be 0; got %v", got)
+	}
 
 	// Try an empty funds string.
 	allowanceValues = url.Values{}
@@ -773,6 +777,31 @@ func TestRenterHandlerGetAndPost(t *testing.T) {
 	if err == nil || err.Error() != contractor.ErrAllowanceZeroWindow.Error() {
 		t.Errorf("expected error to be %v, got %v", contractor.ErrAllowanceZeroWindow, err)
 	}
+
+	// Try an invalid max price increase.
+	allowanceValues.Set("period", testPeriod)
+	allowanceValues.Set("maxpriceincrease", "x")
+	err = st.stdPostAPI("/renter", allowanceValues)
+	if err == nil || !strings.HasPrefix(err.Error(), "unable to parse maxpriceincrease: ") {
+		t.Errorf("expected error to begin with 'unable to parse maxpriceincrease: '; got %v", err)
+	}
+	// Try a max price increase that would reject hosts whose prices did not
+	// change.
+	allowanceValues.Set("maxpriceincrease", "0.5")
+	if err = st.stdPostAPI("/renter", allowanceValues); err == nil {
+		t.Error("expected max price increase below 1 to be rejected")
+	}
+	// Set a valid max price increase.
+	allowanceValues.Set("maxpriceincrease", "2.5")
+	if err = st.stdPostAPI("/renter", allowanceValues); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.getAPI("/renter", &get); err != nil {
+		t.Fatal(err)
+	}
+	if got := get.Settings.Allowance.MaxPriceIncrease; got != 2.5 {
+		t.Fatalf("expected max price increase to be 2.5; got %v", got)
+	}
 }
 
 // TestRenterLoadNonexistent checks that attempting to upload or download a
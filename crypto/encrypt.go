@@ -5,9 +5,11 @@ package crypto
 
 import (
 	"crypto/cipher"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"io"
+	"math"
 
 	"github.com/NebulousLabs/fastrand"
 
@@ -15,7 +17,8 @@ import (
 )
 
 const (
-	TwofishOverhead = 28 // number of bytes added by EncryptBytes
+	TwofishNonceSize = 12 // number of bytes of nonce prepended by EncryptBytes
+	TwofishOverhead  = 28 // number of bytes added by EncryptBytes
 )
 
 var (
@@ -72,6 +75,35 @@ func (key TwofishKey) DecryptBytes(ct Ciphertext) ([]byte, error) {
 	return aead.Open(nil, ct[:aead.NonceSize()], ct[aead.NonceSize():], nil)
 }
 
+// DecryptRange decrypts part of the ciphertext created by EncryptBytes. ct
+// must contain the nonce, followed by the encrypted bytes that begin at
+// offset within the plaintext. The authentication tag covers the entire
+// ciphertext, so it is not checked; the caller must verify the ciphertext by
+// other means, such as a Merkle proof.
+func (key TwofishKey) DecryptRange(ct Ciphertext, offset uint64) ([]byte, error) {
+	if len(ct) < TwofishNonceSize {
+		return nil, ErrInsufficientLen
+	}
+	block := key.NewCipher()
+	blockIndex := offset / uint64(block.BlockSize())
+	if blockIndex > math.MaxUint32-2 {
+		return nil, errors.New("offset is beyond the end of the ciphertext")
+	}
+
+	// GCM encrypts the plaintext in counter mode, using the nonce followed by
+	// a 32-bit block counter that starts at 2.
+	iv := make([]byte, block.BlockSize())
+	copy(iv, ct[:TwofishNonceSize])
+	binary.BigEndian.PutUint32(iv[TwofishNonceSize:], uint32(blockIndex+2))
+	stream := cipher.NewCTR(block, iv)
+	skip := make([]byte, offset%uint64(block.BlockSize()))
+	stream.XORKeyStream(skip, skip)
+
+	plaintext := make([]byte, len(ct)-TwofishNonceSize)
+	stream.XORKeyStream(plaintext, ct[TwofishNonceSize:])
+	return plaintext, nil
+}
+
 // NewWriter returns a writer that encrypts or decrypts its input stream.
 func (key TwofishKey) NewWriter(w io.Writer) io.Writer {
 	// OK to use a zero IV if the key is unique for each ciphertext.
@@ -62,6 +62,39 @@ func TestTwofishEncryption(t *testing.T) {
 	}
 }
 
+// TestTwofishDecryptRange checks that DecryptRange decrypts arbitrary ranges
+// of a ciphertext created by EncryptBytes.
+func TestTwofishDecryptRange(t *testing.T) {
+	key := GenerateTwofishKey()
+	plaintext := fastrand.Bytes(600)
+	ciphertext := key.EncryptBytes(plaintext)
+	nonce := ciphertext[:TwofishNonceSize]
+	body := ciphertext[TwofishNonceSize : len(ciphertext)-(TwofishOverhead-TwofishNonceSize)]
+
+	ranges := []struct{ offset, length uint64 }{
+		{0, 600},
+		{0, 1},
+		{15, 2},
+		{16, 16},
+		{100, 250},
+		{599, 1},
+	}
+	for _, r := range ranges {
+		ct := append(append([]byte(nil), nonce...), body[r.offset:r.offset+r.length]...)
+		decrypted, err := key.DecryptRange(ct, r.offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decrypted, plaintext[r.offset:r.offset+r.length]) {
+			t.Errorf("range %v-%v was not decrypted correctly", r.offset, r.offset+r.length)
+		}
+	}
+
+	if _, err := key.DecryptRange(nonce[:10], 0); err != ErrInsufficientLen {
+		t.Error("Expecting ErrInsufficientLen:", err)
+	}
+}
+
 // TestReaderWriter probes the NewReader and NewWriter methods of the key type.
 func TestReaderWriter(t *testing.T) {
 	// Get a key for encryption.
@@ -0,0 +1,40 @@
+package crypto
+
+import (
+	"github.com/NebulousLabs/fastrand"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+const (
+	// X25519KeySize defines the size of X25519 public and secret keys in
+	// bytes.
+	X25519KeySize = 32
+)
+
+type (
+	// X25519PublicKey is the public half of an ephemeral key used for a key
+	// exchange.
+	X25519PublicKey [X25519KeySize]byte
+
+	// X25519SecretKey is the secret half of an ephemeral key used for a key
+	// exchange.
+	X25519SecretKey [X25519KeySize]byte
+)
+
+// GenerateX25519KeyPair creates an ephemeral public-secret keypair that can be
+// used to perform a Diffie-Hellman key exchange.
+func GenerateX25519KeyPair() (sk X25519SecretKey, pk X25519PublicKey) {
+	fastrand.Read(sk[:])
+	curve25519.ScalarBaseMult((*[X25519KeySize]byte)(&pk), (*[X25519KeySize]byte)(&sk))
+	return
+}
+
+// DeriveSharedSecret computes the secret shared between the owner of sk and
+// the owner of the secret key corresponding to pk. The secret is hashed
+// before being returned, so that it can be used directly as a symmetric key.
+func DeriveSharedSecret(sk X25519SecretKey, pk X25519PublicKey) Hash {
+	var secret [X25519KeySize]byte
+	curve25519.ScalarMult(&secret, (*[X25519KeySize]byte)(&sk), (*[X25519KeySize]byte)(&pk))
+	return HashBytes(secret[:])
+}
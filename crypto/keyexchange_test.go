@@ -0,0 +1,25 @@
+package crypto
+
+import (
+	"testing"
+)
+
+// TestDeriveSharedSecret checks that both parties to a key exchange derive
+// the same secret, and that other parties do not.
+func TestDeriveSharedSecret(t *testing.T) {
+	sk1, pk1 := GenerateX25519KeyPair()
+	sk2, pk2 := GenerateX25519KeyPair()
+	if pk1 == pk2 {
+		t.Fatal("generated identical keys")
+	}
+	secret1 := DeriveSharedSecret(sk1, pk2)
+	secret2 := DeriveSharedSecret(sk2, pk1)
+	if secret1 != secret2 {
+		t.Fatal("parties derived different secrets")
+	}
+
+	sk3, _ := GenerateX25519KeyPair()
+	if DeriveSharedSecret(sk3, pk2) == secret1 {
+		t.Fatal("third party derived the shared secret")
+	}
+}
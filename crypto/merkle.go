@@ -148,3 +148,79 @@ func VerifySegment(base []byte, hashSet []Hash, numSegments, proofIndex uint64,
 	}
 	return merkletree.VerifyProof(NewHash(), root[:], proofSet, proofIndex, numSegments)
 }
+
+// leftSubtreeSize returns the number of leaves in the left subtree of a
+// Merkle tree with n leaves, which is the largest power of two smaller than
+// n.
+func leftSubtreeSize(n uint64) uint64 {
+	size := uint64(1)
+	for size*2 < n {
+		size *= 2
+	}
+	return size
+}
+
+// MerkleRangeProof builds a Merkle proof that the segments in the range
+// [start, end) are a part of the Merkle root formed by 'b'. The proof
+// consists of the roots of the subtrees that cover the segments outside of
+// the range, ordered from left to right.
+func MerkleRangeProof(b []byte, start, end uint64) []Hash {
+	var proof []Hash
+	var buildProof func(i, j uint64)
+	buildProof = func(i, j uint64) {
+		if i >= start && j <= end {
+			// The subtree is within the range, and is not part of the proof.
+			return
+		} else if j <= start || i >= end {
+			// The subtree is outside of the range, and its root is part of
+			// the proof.
+			proof = append(proof, MerkleRoot(b[i*SegmentSize:j*SegmentSize]))
+			return
+		}
+		mid := i + leftSubtreeSize(j-i)
+		buildProof(i, mid)
+		buildProof(mid, j)
+	}
+	buildProof(0, CalculateLeaves(uint64(len(b))))
+	return proof
+}
+
+// VerifyMerkleRangeProof verifies that the segments in the range [start, end)
+// are a part of a Merkle root with 'numSegments' leaves, given a proof
+// created by MerkleRangeProof.
+func VerifyMerkleRangeProof(segments []byte, proof []Hash, numSegments, start, end uint64, root Hash) bool {
+	if start >= end || end > numSegments || uint64(len(segments)) != (end-start)*SegmentSize {
+		return false
+	}
+	var verifyProof func(i, j uint64) (Hash, bool)
+	verifyProof = func(i, j uint64) (Hash, bool) {
+		if i >= start && j <= end {
+			return MerkleRoot(segments[(i-start)*SegmentSize : (j-start)*SegmentSize]), true
+		} else if j <= start || i >= end {
+			if len(proof) == 0 {
+				return Hash{}, false
+			}
+			h := proof[0]
+			proof = proof[1:]
+			return h, true
+		}
+		mid := i + leftSubtreeSize(j-i)
+		left, ok := verifyProof(i, mid)
+		if !ok {
+			return Hash{}, false
+		}
+		right, ok := verifyProof(mid, j)
+		if !ok {
+			return Hash{}, false
+		}
+		h := NewHash()
+		h.Write([]byte{1})
+		h.Write(left[:])
+		h.Write(right[:])
+		var sum Hash
+		copy(sum[:], h.Sum(nil))
+		return sum, true
+	}
+	computed, ok := verifyProof(0, numSegments)
+	return ok && len(proof) == 0 && computed == root
+}
@@ -75,6 +75,50 @@ func TestNonMultipleLeafSizeStorageProof(t *testing.T) {
 	}
 }
 
+// TestMerkleRangeProof builds range proofs and checks that they verify
+// correctly.
+func TestMerkleRangeProof(t *testing.T) {
+	// Generate proof data. An odd number of segments is used to exercise
+	// unbalanced trees.
+	numSegments := uint64(13)
+	data := fastrand.Bytes(int(numSegments * SegmentSize))
+	rootHash := MerkleRoot(data)
+
+	// Create and verify proofs for all ranges.
+	for start := uint64(0); start < numSegments; start++ {
+		for end := start + 1; end <= numSegments; end++ {
+			segments := data[start*SegmentSize : end*SegmentSize]
+			proof := MerkleRangeProof(data, start, end)
+			if !VerifyMerkleRangeProof(segments, proof, numSegments, start, end, rootHash) {
+				t.Fatalf("proof for range [%v, %v) did not pass verification", start, end)
+			}
+		}
+	}
+
+	// A proof for the entire tree is empty.
+	if proof := MerkleRangeProof(data, 0, numSegments); len(proof) != 0 {
+		t.Error("expected empty proof, got", len(proof), "hashes")
+	}
+
+	// Try incorrect proofs.
+	segments := data[3*SegmentSize : 6*SegmentSize]
+	proof := MerkleRangeProof(data, 3, 6)
+	if VerifyMerkleRangeProof(segments, proof, numSegments, 4, 7, rootHash) {
+		t.Error("verified a proof for the wrong range")
+	}
+	if VerifyMerkleRangeProof(segments, proof[1:], numSegments, 3, 6, rootHash) {
+		t.Error("verified a truncated proof")
+	}
+	if VerifyMerkleRangeProof(segments, append(proof, Hash{}), numSegments, 3, 6, rootHash) {
+		t.Error("verified a proof with extra hashes")
+	}
+	badSegments := append([]byte(nil), segments...)
+	badSegments[0]++
+	if VerifyMerkleRangeProof(badSegments, proof, numSegments, 3, 6, rootHash) {
+		t.Error("verified a proof for modified data")
+	}
+}
+
 // TestCachedTree tests the cached tree functions of the package.
 func TestCachedTree(t *testing.T) {
 	if testing.Short() {
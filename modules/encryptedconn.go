@@ -0,0 +1,197 @@
+package modules
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/types"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// An encrypted connection is opened by the renter by sending the
+// RPCEncryptedConn specifier, followed by an ephemeral X25519 public key. The
+// host responds with its own ephemeral public key, and a signature of both
+// ephemeral keys made with the host's announced key. Each party derives the
+// keys for both directions of the connection from the shared secret. After
+// the handshake, every message is sent as a frame containing the length of
+// the ciphertext, followed by the ciphertext, which is sealed using
+// ChaCha20-Poly1305 with a nonce equal to the number of frames previously
+// sent in that direction. The RPC that is carried by the connection starts
+// with the first frame.
+
+const (
+	// encryptedFrameSize is the maximum amount of plaintext that is sent in
+	// a single frame of an encrypted connection.
+	encryptedFrameSize = 1 << 16
+)
+
+var (
+	// ErrBadHandshakeSignature is returned if the host's signature of the
+	// key exchange does not match the host's public key.
+	ErrBadHandshakeSignature = errors.New("host signature of key exchange is invalid")
+
+	// errLargeFrame is returned if the peer sends a frame that is larger
+	// than the maximum frame size.
+	errLargeFrame = errors.New("encrypted frame exceeds maximum size")
+
+	// errUnsupportedHostKey is returned if the renter attempts to open an
+	// encrypted connection to a host whose public key is not an ed25519 key.
+	errUnsupportedHostKey = errors.New("host public key uses an unsupported signature algorithm")
+)
+
+// encryptedConn wraps a net.Conn, encrypting and authenticating everything
+// that is sent over it.
+type encryptedConn struct {
+	net.Conn
+
+	readAEAD  cipher.AEAD
+	readBuf   []byte // decrypted data that has not been read yet
+	readNonce uint64
+	readMu    sync.Mutex
+
+	writeAEAD  cipher.AEAD
+	writeNonce uint64
+	writeMu    sync.Mutex
+}
+
+// frameNonce returns the nonce used for the frame with index n.
+func frameNonce(n uint64) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	binary.LittleEndian.PutUint64(nonce, n)
+	return nonce
+}
+
+// Read reads decrypted data from the connection.
+func (c *encryptedConn) Read(b []byte) (int, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	if len(c.readBuf) == 0 {
+		var prefix [8]byte
+		if _, err := io.ReadFull(c.Conn, prefix[:]); err != nil {
+			return 0, err
+		}
+		size := binary.LittleEndian.Uint64(prefix[:])
+		if size > encryptedFrameSize+uint64(c.readAEAD.Overhead()) {
+			return 0, errLargeFrame
+		}
+		ciphertext := make([]byte, size)
+		if _, err := io.ReadFull(c.Conn, ciphertext); err != nil {
+			return 0, err
+		}
+		plaintext, err := c.readAEAD.Open(ciphertext[:0], frameNonce(c.readNonce), ciphertext, nil)
+		if err != nil {
+			return 0, err
+		}
+		c.readNonce++
+		c.readBuf = plaintext
+	}
+	n := copy(b, c.readBuf)
+	c.readBuf = c.readBuf[n:]
+	return n, nil
+}
+
+// Write encrypts data and writes it to the connection.
+func (c *encryptedConn) Write(b []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	written := 0
+	for len(b) > 0 {
+		chunk := b
+		if len(chunk) > encryptedFrameSize {
+			chunk = chunk[:encryptedFrameSize]
+		}
+		frame := make([]byte, 8, 8+len(chunk)+c.writeAEAD.Overhead())
+		frame = c.writeAEAD.Seal(frame, frameNonce(c.writeNonce), chunk, nil)
+		binary.LittleEndian.PutUint64(frame, uint64(len(frame)-8))
+		if _, err := c.Conn.Write(frame); err != nil {
+			return written, err
+		}
+		c.writeNonce++
+		written += len(chunk)
+		b = b[len(chunk):]
+	}
+	return written, nil
+}
+
+// handshakeHash returns the hash of the ephemeral keys that the host signs
+// during the handshake.
+func handshakeHash(renterKey, hostKey crypto.X25519PublicKey) crypto.Hash {
+	return crypto.HashAll(RPCEncryptedConn, renterKey, hostKey)
+}
+
+// newEncryptedConn returns an encryptedConn using keys derived from the
+// shared secret.
+func newEncryptedConn(conn net.Conn, secret crypto.Hash, isHost bool) net.Conn {
+	renterKey := crypto.HashAll(secret, "renter")
+	hostKey := crypto.HashAll(secret, "host")
+	if isHost {
+		renterKey, hostKey = hostKey, renterKey
+	}
+	// NOTE: New only returns an error if the key has the wrong size.
+	writeAEAD, _ := chacha20poly1305.New(renterKey[:])
+	readAEAD, _ := chacha20poly1305.New(hostKey[:])
+	return &encryptedConn{
+		Conn:      conn,
+		readAEAD:  readAEAD,
+		writeAEAD: writeAEAD,
+	}
+}
+
+// EncryptRenterConn performs the renter's half of the handshake that opens an
+// encrypted connection to a host, including sending the RPCEncryptedConn
+// specifier. The handshake fails if the host cannot prove that it owns
+// hostKey. The returned connection is used in place of conn.
+func EncryptRenterConn(conn net.Conn, hostKey types.SiaPublicKey) (net.Conn, error) {
+	if hostKey.Algorithm != types.SignatureEd25519 || len(hostKey.Key) != crypto.PublicKeySize {
+		return nil, errUnsupportedHostKey
+	}
+	var pk crypto.PublicKey
+	copy(pk[:], hostKey.Key)
+
+	sk, renterKey := crypto.GenerateX25519KeyPair()
+	if err := encoding.WriteObject(conn, RPCEncryptedConn); err != nil {
+		return nil, errors.New("couldn't initiate RPC: " + err.Error())
+	}
+	if err := encoding.WriteObject(conn, renterKey); err != nil {
+		return nil, errors.New("couldn't send ephemeral key: " + err.Error())
+	}
+	var hostEphemeralKey crypto.X25519PublicKey
+	var sig crypto.Signature
+	if err := encoding.ReadObject(conn, &hostEphemeralKey, crypto.X25519KeySize); err != nil {
+		return nil, errors.New("couldn't read host's ephemeral key: " + err.Error())
+	}
+	if err := encoding.ReadObject(conn, &sig, crypto.SignatureSize); err != nil {
+		return nil, errors.New("couldn't read host's signature: " + err.Error())
+	}
+	if crypto.VerifyHash(handshakeHash(renterKey, hostEphemeralKey), pk, sig) != nil {
+		return nil, ErrBadHandshakeSignature
+	}
+	return newEncryptedConn(conn, crypto.DeriveSharedSecret(sk, hostEphemeralKey), false), nil
+}
+
+// EncryptHostConn performs the host's half of the handshake that opens an
+// encrypted connection, after the RPCEncryptedConn specifier has been read
+// from conn. The host proves its identity by signing the handshake with sk.
+// The returned connection is used in place of conn.
+func EncryptHostConn(conn net.Conn, sk crypto.SecretKey) (net.Conn, error) {
+	var renterKey crypto.X25519PublicKey
+	if err := encoding.ReadObject(conn, &renterKey, crypto.X25519KeySize); err != nil {
+		return nil, errors.New("couldn't read renter's ephemeral key: " + err.Error())
+	}
+	ephemeralSK, hostKey := crypto.GenerateX25519KeyPair()
+	sig := crypto.SignHash(handshakeHash(renterKey, hostKey), sk)
+	if err := encoding.WriteObject(conn, hostKey); err != nil {
+		return nil, errors.New("couldn't send ephemeral key: " + err.Error())
+	}
+	if err := encoding.WriteObject(conn, sig); err != nil {
+		return nil, errors.New("couldn't send signature: " + err.Error())
+	}
+	return newEncryptedConn(conn, crypto.DeriveSharedSecret(ephemeralSK, renterKey), true), nil
+}
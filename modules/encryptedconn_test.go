@@ -0,0 +1,126 @@
+package modules
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
+)
+
+// encryptedPipe performs the handshake over a net.Pipe, returning the
+// renter's and the host's ends of the encrypted connection.
+func encryptedPipe(hostKey types.SiaPublicKey, sk crypto.SecretKey) (renterConn, hostConn net.Conn, renterErr, hostErr error) {
+	renterEnd, hostEnd := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var id types.Specifier
+		if hostErr = encoding.ReadObject(hostEnd, &id, 16); hostErr != nil {
+			return
+		} else if id != RPCEncryptedConn {
+			hostErr = io.ErrUnexpectedEOF
+			return
+		}
+		hostConn, hostErr = EncryptHostConn(hostEnd, sk)
+	}()
+	renterConn, renterErr = EncryptRenterConn(renterEnd, hostKey)
+	if renterErr != nil {
+		renterEnd.Close()
+	}
+	<-done
+	return
+}
+
+// TestEncryptedConn checks that data sent over an encrypted connection
+// arrives intact in both directions.
+func TestEncryptedConn(t *testing.T) {
+	sk, pk := crypto.GenerateKeyPair()
+	hostKey := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+	renterConn, hostConn, renterErr, hostErr := encryptedPipe(hostKey, sk)
+	if renterErr != nil || hostErr != nil {
+		t.Fatal(renterErr, hostErr)
+	}
+	defer renterConn.Close()
+	defer hostConn.Close()
+
+	// Send an object that spans several frames from the renter to the host,
+	// and a small object back.
+	data := fastrand.Bytes(3*encryptedFrameSize + 10)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- encoding.WriteObject(renterConn, data)
+	}()
+	var received []byte
+	if err := encoding.ReadObject(hostConn, &received, uint64(len(data))+8); err != nil {
+		t.Fatal(err)
+	} else if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, received) {
+		t.Fatal("host received wrong data")
+	}
+
+	go func() {
+		errChan <- WriteNegotiationAcceptance(hostConn)
+	}()
+	if err := ReadNegotiationAcceptance(renterConn); err != nil {
+		t.Fatal(err)
+	} else if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestEncryptedConnWrongHost checks that the handshake fails if the host
+// cannot prove that it owns the public key that the renter expects.
+func TestEncryptedConnWrongHost(t *testing.T) {
+	sk, _ := crypto.GenerateKeyPair()
+	_, pk := crypto.GenerateKeyPair()
+	hostKey := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+	_, hostConn, renterErr, _ := encryptedPipe(hostKey, sk)
+	if renterErr != ErrBadHandshakeSignature {
+		t.Fatal("expected ErrBadHandshakeSignature, got", renterErr)
+	}
+	if hostConn != nil {
+		hostConn.Close()
+	}
+}
+
+// TestEncryptedConnTampering checks that modified frames are rejected.
+func TestEncryptedConnTampering(t *testing.T) {
+	sk, pk := crypto.GenerateKeyPair()
+	hostKey := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+	renterConn, hostConn, renterErr, hostErr := encryptedPipe(hostKey, sk)
+	if renterErr != nil || hostErr != nil {
+		t.Fatal(renterErr, hostErr)
+	}
+	defer renterConn.Close()
+	defer hostConn.Close()
+
+	// Write a frame directly to the underlying connection, flipping a bit of
+	// the ciphertext.
+	go func() {
+		rc := renterConn.(*encryptedConn)
+		frame := rc.writeAEAD.Seal(make([]byte, 8), frameNonce(rc.writeNonce), []byte("settings"), nil)
+		frame[len(frame)-1] ^= 1
+		frame[0] = byte(len(frame) - 8)
+		rc.Conn.Write(frame)
+	}()
+	buf := make([]byte, 16)
+	if _, err := hostConn.Read(buf); err == nil {
+		t.Fatal("tampered frame was accepted")
+	}
+}
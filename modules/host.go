@@ -1,6 +1,9 @@
 package modules
 
 import (
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -44,6 +47,18 @@ var (
 	// ConnectabilityStatus() if the host is not connectable at its configured
 	// netaddress.
 	HostConnectabilityStatusNotConnectable = HostConnectabilityStatus("not connectable")
+
+	// HostRenterPolicyOpen is the renter policy mode in which the host
+	// accepts contracts from all renters.
+	HostRenterPolicyOpen = HostRenterPolicyMode("open")
+
+	// HostRenterPolicyAllowlist is the renter policy mode in which the host
+	// only accepts contracts from the listed renters.
+	HostRenterPolicyAllowlist = HostRenterPolicyMode("allowlist")
+
+	// HostRenterPolicyBlocklist is the renter policy mode in which the host
+	// accepts contracts from all renters except the listed renters.
+	HostRenterPolicyBlocklist = HostRenterPolicyMode("blocklist")
 )
 
 type (
@@ -94,16 +109,140 @@ type (
 		MinDownloadBandwidthPrice types.Currency `json:"mindownloadbandwidthprice"`
 		MinStoragePrice           types.Currency `json:"minstorageprice"`
 		MinUploadBandwidthPrice   types.Currency `json:"minuploadbandwidthprice"`
+
+		// Bandwidth limits. The speeds are in bytes per second and apply to
+		// all renters combined. The quotas are in bytes per quota period and,
+		// like the connection limit, apply to each renter separately. A value
+		// of zero means that there is no limit.
+		MaxDownloadSpeed     uint64            `json:"maxdownloadspeed"`
+		MaxUploadSpeed       uint64            `json:"maxuploadspeed"`
+		MaxRenterConnections uint64            `json:"maxrenterconnections"`
+		RenterDownloadQuota  uint64            `json:"renterdownloadquota"`
+		RenterUploadQuota    uint64            `json:"renteruploadquota"`
+		RenterQuotaPeriod    types.BlockHeight `json:"renterquotaperiod"`
+
+		// SectorCacheSize is the number of bytes of recently read sectors
+		// that the host keeps in memory. A value of zero disables the cache.
+		SectorCacheSize uint64 `json:"sectorcachesize"`
+	}
+
+	// HostRenterPolicy determines which renters the host forms and renews
+	// contracts with. Renters are identified by the public key in the unlock
+	// conditions of their contracts. In HostRenterPolicyOpen mode, the list of
+	// renters is ignored and all renters are accepted.
+	HostRenterPolicy struct {
+		Mode    HostRenterPolicyMode `json:"mode"`
+		Renters []types.SiaPublicKey `json:"renters"`
+	}
+
+	// HostPricingPolicy lets the host adjust its advertised storage price and
+	// collateral automatically, within bounds set by the operator. When the
+	// policy is enabled, the storage price rises from MinStoragePrice to
+	// MaxStoragePrice as the host's storage fills up, and the collateral falls
+	// from MaxCollateral to MinCollateral as the collateral budget is used up.
+	// All values are per byte per block, like the MinStoragePrice and
+	// Collateral fields of HostInternalSettings, which the policy overwrites.
+	HostPricingPolicy struct {
+		Enabled bool `json:"enabled"`
+
+		MinStoragePrice types.Currency `json:"minstorageprice"`
+		MaxStoragePrice types.Currency `json:"maxstorageprice"`
+
+		MinCollateral types.Currency `json:"mincollateral"`
+		MaxCollateral types.Currency `json:"maxcollateral"`
+	}
+
+	// A HostPriceAdjustment records an automatic change to the host's storage
+	// price or collateral made by the host's pricing policy, along with the
+	// fraction of the host's storage and collateral budget that was in use
+	// at the time.
+	HostPriceAdjustment struct {
+		BlockHeight types.BlockHeight `json:"blockheight"`
+		Timestamp   time.Time         `json:"timestamp"`
+
+		StorageUtilization    float64 `json:"storageutilization"`
+		CollateralUtilization float64 `json:"collateralutilization"`
+
+		OldStoragePrice types.Currency `json:"oldstorageprice"`
+		NewStoragePrice types.Currency `json:"newstorageprice"`
+		OldCollateral   types.Currency `json:"oldcollateral"`
+		NewCollateral   types.Currency `json:"newcollateral"`
+	}
+
+	// A HostRevenueEntry records the financial outcome of a storage
+	// obligation once it has been resolved. Revenue is only earned by
+	// obligations that succeed; obligations that fail lose their revenue and
+	// their risked collateral, and rejected obligations neither earn nor lose
+	// anything. Entries are ordered by the time at which the obligation was
+	// resolved.
+	HostRevenueEntry struct {
+		ContractID types.FileContractID `json:"contractid"`
+		Status     string               `json:"status"`
+
+		NegotiationHeight types.BlockHeight `json:"negotiationheight"`
+		ExpirationHeight  types.BlockHeight `json:"expirationheight"`
+		ResolutionHeight  types.BlockHeight `json:"resolutionheight"`
+		Timestamp         time.Time         `json:"timestamp"`
+
+		ContractCompensation     types.Currency `json:"contractcompensation"`
+		StorageRevenue           types.Currency `json:"storagerevenue"`
+		DownloadBandwidthRevenue types.Currency `json:"downloadbandwidthrevenue"`
+		UploadBandwidthRevenue   types.Currency `json:"uploadbandwidthrevenue"`
+		LostRevenue              types.Currency `json:"lostrevenue"`
+
+		LockedCollateral types.Currency `json:"lockedcollateral"`
+		LostCollateral   types.Currency `json:"lostcollateral"`
+		TransactionFees  types.Currency `json:"transactionfees"`
+	}
+
+	// HostWindDownStatus reports the progress of a host that is winding down.
+	// A host that is winding down refuses new contracts and renewals, but
+	// keeps serving its existing contracts until they end. CompletionHeight
+	// is the proof deadline of the last outstanding storage obligation, after
+	// which the host can be shut down without losing revenue or collateral.
+	// The pending values are the totals of the outstanding obligations.
+	HostWindDownStatus struct {
+		Enabled     bool              `json:"enabled"`
+		StartHeight types.BlockHeight `json:"startheight"`
+		BlockHeight types.BlockHeight `json:"blockheight"`
+
+		OutstandingObligations uint64            `json:"outstandingobligations"`
+		CompletionHeight       types.BlockHeight `json:"completionheight"`
+		Complete               bool              `json:"complete"`
+
+		PendingContractCompensation     types.Currency `json:"pendingcontractcompensation"`
+		PendingStorageRevenue           types.Currency `json:"pendingstoragerevenue"`
+		PendingDownloadBandwidthRevenue types.Currency `json:"pendingdownloadbandwidthrevenue"`
+		PendingUploadBandwidthRevenue   types.Currency `json:"pendinguploadbandwidthrevenue"`
+		LockedCollateral                types.Currency `json:"lockedcollateral"`
+		RiskedCollateral                types.Currency `json:"riskedcollateral"`
+	}
+
+	// HostProofAlert reports a storage obligation whose storage proof is at
+	// risk of not being confirmed by the proof deadline, in which case the
+	// host loses the collateral that it risked on the obligation.
+	HostProofAlert struct {
+		ObligationID     types.FileContractID `json:"obligationid"`
+		ProofState       string               `json:"proofstate"`
+		Reason           string               `json:"reason"`
+		ExpirationHeight types.BlockHeight    `json:"expirationheight"`
+		ProofDeadline    types.BlockHeight    `json:"proofdeadline"`
+		BlocksRemaining  types.BlockHeight    `json:"blocksremaining"`
+		ProofSubmissions uint64               `json:"proofsubmissions"`
+		RiskedCollateral types.Currency       `json:"riskedcollateral"`
+		Value            types.Currency       `json:"value"`
 	}
 
 	// HostNetworkMetrics reports the quantity of each type of RPC call that
 	// has been made to the host.
 	HostNetworkMetrics struct {
 		DownloadCalls     uint64 `json:"downloadcalls"`
+		EncryptedCalls    uint64 `json:"encryptedcalls"`
 		ErrorCalls        uint64 `json:"errorcalls"`
 		FormContractCalls uint64 `json:"formcontractcalls"`
 		RenewCalls        uint64 `json:"renewcalls"`
 		ReviseCalls       uint64 `json:"revisecalls"`
+		SessionCalls      uint64 `json:"sessioncalls"`
 		SettingsCalls     uint64 `json:"settingscalls"`
 		UnrecognizedCalls uint64 `json:"unrecognizedcalls"`
 	}
@@ -111,6 +250,13 @@ type (
 	// StorageObligation contains information about a storage obligation that
 	// the host has accepted.
 	StorageObligation struct {
+		ObligationID     types.FileContractID `json:"obligationid"`
+		Renter           string               `json:"renter"`
+		DataSize         uint64               `json:"datasize"`
+		ExpirationHeight types.BlockHeight    `json:"expirationheight"`
+		ProofDeadline    types.BlockHeight    `json:"proofdeadline"`
+		Value            types.Currency       `json:"value"`
+
 		NegotiationHeight types.BlockHeight `json:"negotiationheight"`
 
 		OriginConfirmed     bool   `json:"originconfirmed"`
@@ -119,6 +265,54 @@ type (
 		ProofConstructed    bool   `json:"proofconstructed"`
 		ProofConfirmed      bool   `json:"proofconfirmed"`
 		ObligationStatus    uint64 `json:"obligationstatus"`
+
+		ProofState       string `json:"proofstate"`
+		ProofSubmissions uint64 `json:"proofsubmissions"`
+	}
+
+	// StorageObligationDetails contains everything the host knows about a
+	// storage obligation, including the payouts of the latest revision and
+	// the transactions that the host has submitted for it. The sector roots
+	// of resolved obligations are discarded, so their sector count is zero.
+	StorageObligationDetails struct {
+		StorageObligation
+
+		SectorCount    uint64      `json:"sectorcount"`
+		RevisionNumber uint64      `json:"revisionnumber"`
+		FileMerkleRoot crypto.Hash `json:"filemerkleroot"`
+
+		ContractCost             types.Currency `json:"contractcost"`
+		LockedCollateral         types.Currency `json:"lockedcollateral"`
+		PotentialStorageRevenue  types.Currency `json:"potentialstoragerevenue"`
+		PotentialDownloadRevenue types.Currency `json:"potentialdownloadrevenue"`
+		PotentialUploadRevenue   types.Currency `json:"potentialuploadrevenue"`
+		RiskedCollateral         types.Currency `json:"riskedcollateral"`
+		TransactionFeesAdded     types.Currency `json:"transactionfeesadded"`
+
+		ValidProofOutputs  []types.SiacoinOutput `json:"validproofoutputs"`
+		MissedProofOutputs []types.SiacoinOutput `json:"missedproofoutputs"`
+
+		OriginTransactionSet   []types.Transaction `json:"origintransactionset"`
+		RevisionTransactionSet []types.Transaction `json:"revisiontransactionset"`
+	}
+
+	// A StorageObligationQuery selects a page of the host's storage
+	// obligations. Obligations can be filtered by status, which is one of
+	// "unresolved", "rejected", "succeeded" or "failed", by expiration height,
+	// and by the public key of the renter. A MaxExpiration of zero means that
+	// there is no upper bound. Obligations are sorted by id unless SortBy is
+	// "expiration" or "value". A Limit of zero returns every obligation after
+	// the Offset.
+	StorageObligationQuery struct {
+		Status        string
+		MinExpiration types.BlockHeight
+		MaxExpiration types.BlockHeight
+		Renter        string
+
+		SortBy     string
+		Descending bool
+		Offset     int
+		Limit      int
 	}
 
 	// HostWorkingStatus reports the working state of a host. Can be one of
@@ -129,6 +323,10 @@ type (
 	// one of "checking", "connectable", or "not connectable"
 	HostConnectabilityStatus string
 
+	// HostRenterPolicyMode determines how the host uses its list of renters.
+	// Can be one of "open", "allowlist", or "blocklist".
+	HostRenterPolicyMode string
+
 	// A Host can take storage from disk and offer it to the network, managing
 	// things such as announcements, settings, and implementing all of the RPCs
 	// of the host protocol.
@@ -154,16 +352,54 @@ type (
 		// have been made to the host.
 		NetworkMetrics() HostNetworkMetrics
 
+		// PriceAdjustments returns the most recent automatic changes made
+		// to the host's prices by its pricing policy.
+		PriceAdjustments() []HostPriceAdjustment
+
+		// PricingPolicy returns the policy used by the host to adjust its
+		// prices automatically.
+		PricingPolicy() HostPricingPolicy
+
 		// PublicKey returns the public key of the host.
 		PublicKey() types.SiaPublicKey
 
+		// RevenueLedger returns the ledger entries of the storage
+		// obligations that were resolved in the interval [start, end). A zero
+		// end time means that there is no upper bound.
+		RevenueLedger(start, end time.Time) []HostRevenueEntry
+
 		// SetInternalSettings sets the hosting parameters of the host.
 		SetInternalSettings(HostInternalSettings) error
 
+		// SetPricingPolicy sets the policy used by the host to adjust its
+		// prices automatically. The policy is applied immediately.
+		SetPricingPolicy(HostPricingPolicy) error
+
+		// RenterPolicy returns the policy that determines which renters the
+		// host forms and renews contracts with.
+		RenterPolicy() HostRenterPolicy
+
+		// SetRenterPolicy sets the policy that determines which renters the
+		// host forms and renews contracts with. Existing contracts are not
+		// affected.
+		SetRenterPolicy(HostRenterPolicy) error
+
+		// SetWindDown starts or stops winding down the host. While winding
+		// down, the host refuses new contracts and renewals.
+		SetWindDown(bool) error
+
 		// StorageObligations returns the set of storage obligations held by
 		// the host.
 		StorageObligations() []StorageObligation
 
+		// QueryStorageObligations returns the page of storage obligations
+		// selected by the query, along with the number of obligations that
+		// matched the query's filters.
+		QueryStorageObligations(StorageObligationQuery) ([]StorageObligation, int, error)
+
+		// StorageObligation returns the details of a storage obligation.
+		StorageObligation(types.FileContractID) (StorageObligationDetails, error)
+
 		// ConnectabilityStatus returns the connectability status of the host, that
 		// is, if it can connect to itself on the configured NetAddress.
 		ConnectabilityStatus() HostConnectabilityStatus
@@ -172,6 +408,14 @@ type (
 		// settings calls are increasing.
 		WorkingStatus() HostWorkingStatus
 
+		// WindDownStatus reports whether the host is winding down, and when
+		// its last storage obligation will be resolved.
+		WindDownStatus() HostWindDownStatus
+
+		// ProofAlerts returns the storage obligations whose storage proofs
+		// are at risk of not being confirmed by their deadlines.
+		ProofAlerts() []HostProofAlert
+
 		// The storage manager provides an interface for adding and removing
 		// storage folders and data sectors to the host.
 		StorageManager
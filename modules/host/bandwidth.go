@@ -0,0 +1,221 @@
+package host
+
+// bandwidth.go limits the bandwidth that renters can use. Every connection is
+// throttled by global upload and download rate limits, so that hosting does
+// not saturate the uplink of the machine. In addition, each renter, identified
+// by the public key in its contract, may be limited in the number of bytes
+// that it uploads and downloads per quota period and in the number of
+// connections that it holds open at once.
+//
+// Renter usage is not persistent, so quotas are reset when the host restarts.
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errRenterConnectionLimit is returned if a renter opens more concurrent
+	// connections than the host allows.
+	errRenterConnectionLimit = ErrorCommunication("renter has too many open connections to the host")
+
+	// errRenterDownloadQuota is returned if a download would take the renter
+	// over its download quota for the current period.
+	errRenterDownloadQuota = ErrorCommunication("renter has exhausted its download quota for this period")
+
+	// errRenterUploadQuota is returned if an upload would take the renter over
+	// its upload quota for the current period.
+	errRenterUploadQuota = ErrorCommunication("renter has exhausted its upload quota for this period")
+)
+
+type (
+	// bandwidthLimiter limits the rate at which bytes pass through the
+	// connections that share it.
+	bandwidthLimiter struct {
+		// rate is the limit in bytes per second. A rate of zero means that
+		// there is no limit.
+		rate uint64
+		// next is the time at which the next bytes may pass.
+		next time.Time
+		mu   sync.Mutex
+	}
+
+	// limitedConn is a net.Conn whose reads and writes are throttled by the
+	// host's bandwidth limiters. Reads are renter uploads and writes are
+	// renter downloads.
+	limitedConn struct {
+		net.Conn
+		read  *bandwidthLimiter
+		write *bandwidthLimiter
+		stop  <-chan struct{}
+	}
+
+	// renterUsage tracks the bandwidth used by a renter in the current quota
+	// period, and the number of connections that the renter has open.
+	renterUsage struct {
+		connections uint64
+		downloaded  uint64
+		periodStart types.BlockHeight
+		uploaded    uint64
+	}
+)
+
+// setRate sets the limit of the bandwidthLimiter in bytes per second. A rate of
+// zero removes the limit.
+func (bl *bandwidthLimiter) setRate(rate uint64) {
+	bl.mu.Lock()
+	bl.rate = rate
+	bl.mu.Unlock()
+}
+
+// wait blocks until n bytes may pass through the limiter, or until stop is
+// closed.
+func (bl *bandwidthLimiter) wait(n int, stop <-chan struct{}) {
+	bl.mu.Lock()
+	if bl.rate == 0 {
+		bl.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	if bl.next.Before(now) {
+		bl.next = now
+	}
+	delay := bl.next.Sub(now)
+	bl.next = bl.next.Add(time.Duration(uint64(n) * uint64(time.Second) / bl.rate))
+	bl.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-stop:
+		}
+	}
+}
+
+// Read reads from the underlying connection, waiting for the upload limiter
+// afterwards.
+func (lc *limitedConn) Read(b []byte) (int, error) {
+	if len(b) > bandwidthLimitChunkSize {
+		b = b[:bandwidthLimitChunkSize]
+	}
+	n, err := lc.Conn.Read(b)
+	lc.read.wait(n, lc.stop)
+	return n, err
+}
+
+// Write writes to the underlying connection in chunks, waiting for the
+// download limiter before each chunk.
+func (lc *limitedConn) Write(b []byte) (int, error) {
+	var written int
+	for len(b) > 0 {
+		chunk := b
+		if len(chunk) > bandwidthLimitChunkSize {
+			chunk = chunk[:bandwidthLimitChunkSize]
+		}
+		lc.write.wait(len(chunk), lc.stop)
+		n, err := lc.Conn.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		b = b[n:]
+	}
+	return written, nil
+}
+
+// limitConn wraps a connection so that it is throttled by the host's global
+// bandwidth limits.
+func (h *Host) limitConn(conn net.Conn) net.Conn {
+	return &limitedConn{
+		Conn:  conn,
+		read:  &h.uploadLimiter,
+		write: &h.downloadLimiter,
+		stop:  h.tg.StopChan(),
+	}
+}
+
+// updateBandwidthLimits applies the bandwidth limits in the host's settings to
+// the host's limiters.
+func (h *Host) updateBandwidthLimits() {
+	h.downloadLimiter.setRate(h.settings.MaxDownloadSpeed)
+	h.uploadLimiter.setRate(h.settings.MaxUploadSpeed)
+}
+
+// renterKey returns the key that identifies the renter of a storage
+// obligation, which is the renter's public key in the contract.
+func renterKey(so storageObligation) string {
+	revision := so.RevisionTransactionSet[len(so.RevisionTransactionSet)-1].FileContractRevisions[0]
+	return revision.UnlockConditions.PublicKeys[0].String()
+}
+
+// usage returns the usage record of a renter, starting a new quota period if
+// the current one has ended.
+func (h *Host) usage(key string) *renterUsage {
+	ru, exists := h.renterUsage[key]
+	if !exists {
+		ru = &renterUsage{periodStart: h.blockHeight}
+		h.renterUsage[key] = ru
+	}
+	if h.blockHeight >= ru.periodStart+h.settings.RenterQuotaPeriod {
+		ru.downloaded = 0
+		ru.uploaded = 0
+		ru.periodStart = h.blockHeight
+	}
+	return ru
+}
+
+// managedAddRenterConn registers an open connection from the renter of the
+// storage obligation, returning an error if the renter already has the
+// maximum number of connections open. Every successful call must be matched
+// by a call to managedRemoveRenterConn.
+func (h *Host) managedAddRenterConn(so storageObligation) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ru := h.usage(renterKey(so))
+	if h.settings.MaxRenterConnections != 0 && ru.connections >= h.settings.MaxRenterConnections {
+		return errRenterConnectionLimit
+	}
+	ru.connections++
+	return nil
+}
+
+// managedRemoveRenterConn unregisters a connection from the renter of the
+// storage obligation.
+func (h *Host) managedRemoveRenterConn(so storageObligation) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ru, exists := h.renterUsage[renterKey(so)]
+	if exists && ru.connections > 0 {
+		ru.connections--
+	}
+}
+
+// managedSpendRenterQuota charges the given number of downloaded and uploaded
+// bytes to the quota of the renter of the storage obligation. If either quota
+// would be exceeded, nothing is charged and an error is returned.
+func (h *Host) managedSpendRenterQuota(so storageObligation, downloaded, uploaded uint64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ru := h.usage(renterKey(so))
+	if h.settings.RenterDownloadQuota != 0 && ru.downloaded+downloaded > h.settings.RenterDownloadQuota {
+		return errRenterDownloadQuota
+	} else if h.settings.RenterUploadQuota != 0 && ru.uploaded+uploaded > h.settings.RenterUploadQuota {
+		return errRenterUploadQuota
+	}
+	ru.downloaded += downloaded
+	ru.uploaded += uploaded
+	return nil
+}
+
+// pruneRenterUsage removes the usage records of renters that have no open
+// connections and whose quota period has ended.
+func (h *Host) pruneRenterUsage() {
+	for key, ru := range h.renterUsage {
+		if ru.connections == 0 && h.blockHeight >= ru.periodStart+h.settings.RenterQuotaPeriod {
+			delete(h.renterUsage, key)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package host
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// quotaTestObligation returns a storage obligation whose renter is identified
+// by the given key.
+func quotaTestObligation(key byte) storageObligation {
+	return storageObligation{
+		RevisionTransactionSet: []types.Transaction{{
+			FileContractRevisions: []types.FileContractRevision{{
+				UnlockConditions: types.UnlockConditions{
+					PublicKeys: []types.SiaPublicKey{
+						{Algorithm: types.SignatureEd25519, Key: []byte{key}},
+						{Algorithm: types.SignatureEd25519, Key: []byte{0}},
+					},
+				},
+			}},
+		}},
+	}
+}
+
+// TestBandwidthLimiter checks that the bandwidth limiter delays bytes
+// according to its rate.
+func TestBandwidthLimiter(t *testing.T) {
+	var bl bandwidthLimiter
+	stop := make(chan struct{})
+
+	// Without a rate, there should be no delay.
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		bl.wait(1e6, stop)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("unlimited limiter delayed bytes")
+	}
+
+	// At 400 KB/s, 4 waits of 100 KB should take at least 750ms, as the first
+	// wait is not delayed.
+	bl.setRate(400e3)
+	start = time.Now()
+	for i := 0; i < 4; i++ {
+		bl.wait(100e3, stop)
+	}
+	if elapsed := time.Since(start); elapsed < 700*time.Millisecond {
+		t.Fatal("limiter did not delay bytes enough:", elapsed)
+	}
+
+	// Closing the stop channel should interrupt the wait.
+	bl.setRate(1)
+	bl.wait(1e3, stop)
+	close(stop)
+	start = time.Now()
+	bl.wait(1e3, stop)
+	if time.Since(start) > time.Second {
+		t.Fatal("wait was not interrupted by stop")
+	}
+}
+
+// TestLimitedConn checks that data passes through a limitedConn intact.
+func TestLimitedConn(t *testing.T) {
+	read := &bandwidthLimiter{rate: 10e6}
+	write := &bandwidthLimiter{rate: 10e6}
+	c1, c2 := net.Pipe()
+	lc := &limitedConn{Conn: c1, read: read, write: write, stop: make(chan struct{})}
+	defer lc.Close()
+	defer c2.Close()
+
+	data := make([]byte, 5*bandwidthLimitChunkSize+3)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	errChan := make(chan error, 1)
+	go func() {
+		_, err := lc.Write(data)
+		errChan <- err
+	}()
+	received := make([]byte, len(data))
+	for n := 0; n < len(received); {
+		m, err := c2.Read(received[n:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		n += m
+	}
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	for i := range data {
+		if data[i] != received[i] {
+			t.Fatal("data was corrupted at byte", i)
+		}
+	}
+}
+
+// TestRenterQuotas checks that the host enforces the per-renter bandwidth
+// quotas and connection limit, and resets the quotas each period.
+func TestRenterQuotas(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+	h := ht.host
+
+	settings := h.InternalSettings()
+	settings.RenterDownloadQuota = 100
+	settings.RenterUploadQuota = 50
+	settings.RenterQuotaPeriod = 0
+	if err := h.SetInternalSettings(settings); err == nil {
+		t.Fatal("expected quotas without a period to be rejected")
+	}
+	settings.RenterQuotaPeriod = 10
+	settings.MaxRenterConnections = 1
+	if err := h.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	// Quotas are per renter.
+	renter1, renter2 := quotaTestObligation(1), quotaTestObligation(2)
+	if err := h.managedSpendRenterQuota(renter1, 60, 50); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.managedSpendRenterQuota(renter1, 60, 0); err != errRenterDownloadQuota {
+		t.Fatal("expected errRenterDownloadQuota, got", err)
+	}
+	if err := h.managedSpendRenterQuota(renter1, 0, 1); err != errRenterUploadQuota {
+		t.Fatal("expected errRenterUploadQuota, got", err)
+	}
+	if err := h.managedSpendRenterQuota(renter1, 40, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.managedSpendRenterQuota(renter2, 100, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// The quota should reset once the period has ended.
+	h.mu.Lock()
+	h.blockHeight += 10
+	h.mu.Unlock()
+	if err := h.managedSpendRenterQuota(renter1, 100, 50); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only one connection is allowed per renter.
+	if err := h.managedAddRenterConn(renter1); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.managedAddRenterConn(renter1); err != errRenterConnectionLimit {
+		t.Fatal("expected errRenterConnectionLimit, got", err)
+	}
+	if err := h.managedAddRenterConn(renter2); err != nil {
+		t.Fatal(err)
+	}
+	h.managedRemoveRenterConn(renter1)
+	if err := h.managedAddRenterConn(renter1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Renters with open connections are not pruned.
+	h.mu.Lock()
+	h.blockHeight += 10
+	h.pruneRenterUsage()
+	numRenters := len(h.renterUsage)
+	h.mu.Unlock()
+	if numRenters != 2 {
+		t.Fatal("expected 2 renters to be tracked, got", numRenters)
+	}
+	h.managedRemoveRenterConn(renter1)
+	h.managedRemoveRenterConn(renter2)
+	h.mu.Lock()
+	h.pruneRenterUsage()
+	numRenters = len(h.renterUsage)
+	h.mu.Unlock()
+	if numRenters != 0 {
+		t.Fatal("expected no renters to be tracked, got", numRenters)
+	}
+}
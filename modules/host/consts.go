@@ -9,6 +9,11 @@ import (
 )
 
 const (
+	// bandwidthLimitChunkSize is the largest number of bytes that a
+	// connection reads or writes at once when bandwidth limits are in place.
+	// Smaller chunks make the limits smoother.
+	bandwidthLimitChunkSize = 1 << 14 // 16 KiB
+
 	// defaultMaxDuration defines the maximum number of blocks into the future
 	// that the host will accept for the duration of an incoming file contract
 	// obligation. 6 months is chosen because hosts are expected to be
@@ -26,11 +31,27 @@ const (
 	// connection.
 	iteratedConnectionTime = 1200 * time.Second
 
+	// maxPriceAdjustments is the number of automatic price changes that the
+	// host remembers. Older changes are discarded.
+	maxPriceAdjustments = 100
+
+	// maxProofFeeMultiplier is the largest factor by which the host will
+	// multiply the recommended fee when resubmitting a storage proof.
+	maxProofFeeMultiplier = 8
+
+	// defaultRenterQuotaPeriod is the default number of blocks over which the
+	// bandwidth used by a renter is counted towards its quotas.
+	defaultRenterQuotaPeriod = 144 // 1 day.
+
 	// resubmissionTimeout defines the number of blocks that a host will wait
 	// before attempting to resubmit a transaction to the blockchain.
 	// Typically, this transaction will contain either a file contract, a file
 	// contract revision, or a storage proof.
 	resubmissionTimeout = 3
+
+	// sessionIdleTime is the amount of time that the host will wait for the
+	// next operation in a session before closing the connection.
+	sessionIdleTime = 5 * time.Minute
 )
 
 var (
@@ -83,6 +104,11 @@ var (
 	// with a number like 65 MiB.
 	defaultMaxReviseBatchSize = 17 * (1 << 20)
 
+	// defaultSectorCacheSize defines the number of bytes of recently read
+	// sectors that the host keeps in memory. 16 sectors is enough to serve a
+	// few popular files without using a large amount of memory.
+	defaultSectorCacheSize = 16 * modules.SectorSize
+
 	// defaultMaxCollateral defines the maximum amount of collateral that the
 	// host is comfortable putting into a single file contract. 10e3 is a
 	// relatively small file contract, but millions of siacoins could be locked
@@ -204,6 +230,15 @@ var (
 		Testing:  time.Second * 3,
 	}).(time.Duration)
 
+	// proofAlertBuffer is the number of blocks before the proof deadline at
+	// which an unconfirmed storage proof is reported as at risk. Inside the
+	// buffer, storage proofs are submitted with the highest fee.
+	proofAlertBuffer = build.Select(build.Var{
+		Dev:      types.BlockHeight(12), // About 2 minutes.
+		Standard: types.BlockHeight(36), // 6 hours.
+		Testing:  types.BlockHeight(2),
+	}).(types.BlockHeight)
+
 	// revisionSubmissionBuffer describes the number of blocks ahead of time
 	// that the host will submit a file contract revision. The host will not
 	// accept any more revisions once inside the submission buffer.
@@ -220,6 +255,16 @@ var (
 		Standard: time.Millisecond * 50,
 		Testing:  time.Millisecond,
 	}).(time.Duration)
+
+	// supportedRPCs are the optional RPCs that the host advertises in its
+	// external settings. Renters do not call these RPCs on hosts that do not
+	// advertise them.
+	supportedRPCs = []types.Specifier{
+		modules.RPCAudit,
+		modules.RPCDownloadRange,
+		modules.RPCEncryptedConn,
+		modules.RPCSession,
+	}
 )
 
 // All of the following variables define the names of buckets used by the host
@@ -238,6 +283,13 @@ var (
 	// bucketStorageObligations contains a set of serialized
 	// 'storageObligations' sorted by their file contract id.
 	bucketStorageObligations = []byte("BucketStorageObligations")
+
+	// bucketRevenueLedger contains a serialized 'modules.HostRevenueEntry' for
+	// every storage obligation that has been resolved. The key is the time
+	// of resolution in nanoseconds, stored as a big endian uint64, followed
+	// by the file contract id, so that bolt keeps the entries in the order
+	// that they were resolved.
+	bucketRevenueLedger = []byte("BucketRevenueLedger")
 )
 
 // init runs a series of sanity checks to verify that the constants have sane
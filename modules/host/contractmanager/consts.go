@@ -124,4 +124,22 @@ var (
 		Standard: time.Second * 60 * 5,
 		Testing:  time.Second * 8,
 	}).(time.Duration)
+
+	// scrubInterval specifies the amount of time that the contract manager
+	// waits between checking all of its sectors for corruption.
+	scrubInterval = build.Select(build.Var{
+		Dev:      time.Minute * 10,
+		Standard: time.Hour * 24,
+		Testing:  time.Second * 5,
+	}).(time.Duration)
+
+	// scrubSectorDelay specifies the amount of time that the contract manager
+	// waits between sectors while checking them for corruption, limiting the
+	// disk bandwidth used by scrubbing to about 16 MiB/s on the production
+	// network.
+	scrubSectorDelay = build.Select(build.Var{
+		Dev:      time.Millisecond * 50,
+		Standard: time.Millisecond * 250,
+		Testing:  time.Millisecond,
+	}).(time.Duration)
 )
@@ -92,6 +92,10 @@ type ContractManager struct {
 	// or modified.
 	lockedSectors map[sectorID]*sectorLock
 
+	// sectorCache keeps recently read sectors in memory. It has its own
+	// mutex, and is disabled until the host sets its size.
+	sectorCache *sectorCache
+
 	// Utilities.
 	dependencies
 	log        *persist.Logger
@@ -113,6 +117,7 @@ func newContractManager(dependencies dependencies, persistDir string) (*Contract
 		sectorLocations: make(map[sectorID]sectorLocation),
 
 		lockedSectors: make(map[sectorID]*sectorLock),
+		sectorCache:   newSectorCache(),
 
 		dependencies: dependencies,
 		persistDir:   persistDir,
@@ -213,6 +218,10 @@ func newContractManager(dependencies dependencies, persistDir string) (*Contract
 	// and adds them if they are discovered.
 	go cm.threadedFolderRecheck()
 
+	// Spin up the thread that periodically checks the stored sectors for
+	// corruption.
+	go cm.threadedScrubSectors()
+
 	// Simulate an error to make sure the cleanup code is triggered correctly.
 	if cm.dependencies.disrupt("erroredStartup") {
 		err = errors.New("startup disrupted")
@@ -0,0 +1,394 @@
+package contractmanager
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
+)
+
+var (
+	// errNoContractManager is returned if a directory is checked that does
+	// not contain the settings of a contract manager.
+	errNoContractManager = errors.New("directory does not contain a contract manager settings file")
+)
+
+type (
+	// A FsckReport describes the consistency of the sector bookkeeping in a
+	// contract manager directory.
+	//
+	// Sectors are tracked by the usage bits of their storage folder, which
+	// are saved in the settings file, and by the sector metadata file inside
+	// of the storage folder. Every location whose usage bit is set should
+	// have sector metadata, and every location with sector metadata should
+	// have its usage bit set.
+	FsckReport struct {
+		StorageFolders     int      `json:"storagefolders"`
+		UnavailableFolders []string `json:"unavailablefolders"`
+		PhysicalSectors    uint64   `json:"physicalsectors"`
+		VirtualSectors     uint64   `json:"virtualsectors"`
+		UncommittedChanges int      `json:"uncommittedchanges"`
+
+		// PendingUpdates are sector locations whose metadata on disk differs
+		// from the last uncommitted update to them in the WAL. They are
+		// expected after an unclean shutdown, and are resolved by recovering
+		// the WAL, so they do not mean that the directory is corrupt.
+		PendingUpdates uint64 `json:"pendingupdates"`
+
+		// TruncatedSectors are sectors whose usage bit is set, but which lie
+		// beyond the end of their storage folder's files.
+		TruncatedSectors uint64 `json:"truncatedsectors"`
+
+		// EmptyUsageBits are usage bits that are set for a location that has
+		// no sector metadata.
+		EmptyUsageBits uint64 `json:"emptyusagebits"`
+
+		// OrphanedSectors are locations with sector metadata whose usage bit
+		// is not set, and which have no pending update in the WAL. The
+		// contract manager does not know about the sectors stored there.
+		// Contract managers that predate the check left the metadata of
+		// removed sectors in place, so their removed sectors are reported as
+		// orphaned until the directory is repaired.
+		OrphanedSectors uint64 `json:"orphanedsectors"`
+
+		// DuplicateSectors are additional locations of sectors that are
+		// stored in more than one location.
+		DuplicateSectors uint64 `json:"duplicatesectors"`
+
+		// CountMismatches are sectors stored in more than one location whose
+		// virtual sector count differs between the locations. Repairs do not
+		// correct virtual sector counts.
+		CountMismatches uint64 `json:"countmismatches"`
+	}
+
+	// recoveryDependencies are the dependencies of a contract manager that
+	// is only loaded to recover its WAL. The scrubber and the storage folder
+	// recheck are not started, so that recovery does not read every sector
+	// or touch the storage folders beyond replaying the WAL.
+	recoveryDependencies struct {
+		productionDependencies
+	}
+
+	// fsckLocation is a location of a sector that was found while checking
+	// a contract manager directory.
+	fsckLocation struct {
+		folder uint16
+		index  uint32
+		count  uint16
+	}
+
+	// fsckLocationKey identifies a location in the storage folders of a
+	// contract manager directory.
+	fsckLocationKey struct {
+		folder uint16
+		index  uint32
+	}
+)
+
+// Corrupt returns true if any inconsistencies were found in the contract
+// manager directory. Pending updates in the WAL are not inconsistencies.
+func (r FsckReport) Corrupt() bool {
+	return r.TruncatedSectors > 0 || r.EmptyUsageBits > 0 || r.OrphanedSectors > 0 || r.DuplicateSectors > 0 || r.CountMismatches > 0
+}
+
+// readWALChanges reads the changes in the WAL of a contract manager directory
+// without applying them. If there is no WAL, the previous shutdown was clean
+// and no changes are returned.
+func readWALChanges(persistDir string) ([]stateChange, error) {
+	f, err := os.Open(filepath.Join(persistDir, walFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	decoder := json.NewDecoder(f)
+	err = readWALMetadata(decoder)
+	if err != nil {
+		return nil, err
+	}
+	var scs []stateChange
+	for {
+		var sc stateChange
+		err = decoder.Decode(&sc)
+		if err == io.EOF {
+			return scs, nil
+		} else if err != nil {
+			return nil, build.ExtendErr("unable to read WAL", err)
+		}
+		scs = append(scs, sc)
+	}
+}
+
+// checkStorage checks the consistency of the contract manager directory. If
+// repair is set and inconsistencies are found, the settings file is rewritten
+// without the usage bits of the inconsistent sector locations, and the virtual
+// sector count in the metadata of orphaned sectors is cleared. The sector data
+// is never modified.
+func checkStorage(persistDir string, repair bool) (report FsckReport, err error) {
+	settingsPath := filepath.Join(persistDir, settingsFile)
+	var ss savedSettings
+	err = persist.LoadJSON(settingsMetadata, &ss, settingsPath)
+	if os.IsNotExist(err) {
+		return report, errNoContractManager
+	} else if err != nil {
+		return report, build.ExtendErr("unable to load contract manager settings", err)
+	}
+	scs, err := readWALChanges(persistDir)
+	if err != nil {
+		return report, err
+	}
+	report.UncommittedChanges = len(scs)
+
+	// Only the last update in the WAL to each location matters.
+	updates := make(map[fsckLocationKey]sectorUpdate)
+	for _, sc := range scs {
+		for _, su := range sc.SectorUpdates {
+			updates[fsckLocationKey{su.Folder, su.Index}] = su
+		}
+	}
+
+	// Check the sectors of each storage folder, in order of index.
+	sort.Slice(ss.StorageFolders, func(i, j int) bool {
+		return ss.StorageFolders[i].Index < ss.StorageFolders[j].Index
+	})
+	folders := make(map[uint16]*storageFolder)
+	locations := make(map[sectorID][]fsckLocation)
+	var orphans []fsckLocationKey
+	for _, ssf := range ss.StorageFolders {
+		report.StorageFolders++
+		numSectors := uint64(len(ssf.Usage)) * storageFolderGranularity
+		metadataBytes, dataSectors, err := readStorageFolderFiles(ssf.Path, numSectors)
+		if err != nil {
+			report.UnavailableFolders = append(report.UnavailableFolders, ssf.Path)
+			continue
+		}
+		metadataSectors := uint64(len(metadataBytes)) / sectorMetadataDiskSize
+
+		// The usage of the storage folder is cleared through a storage folder
+		// object, which shares the usage of the saved settings.
+		sf := &storageFolder{
+			index: ssf.Index,
+			path:  ssf.Path,
+			usage: ssf.Usage,
+		}
+		folders[sf.index] = sf
+		for sectorIndex := uint32(0); uint64(sectorIndex) < numSectors; sectorIndex++ {
+			used := sf.usage[sectorIndex/storageFolderGranularity]&(1<<(sectorIndex%storageFolderGranularity)) != 0
+			var id sectorID
+			var count uint16
+			if uint64(sectorIndex) < metadataSectors {
+				id, count = sectorMetadata(metadataBytes, sectorIndex)
+			}
+
+			// A location with an uncommitted update in the WAL is checked
+			// against the update instead. The update is applied when the WAL
+			// is recovered.
+			if su, pending := updates[fsckLocationKey{sf.index, sectorIndex}]; pending {
+				if !used {
+					count = 0
+				}
+				if su.Count != count || (count > 0 && su.ID != id) {
+					report.PendingUpdates++
+					continue
+				}
+			}
+
+			switch {
+			case !used && count > 0:
+				report.OrphanedSectors++
+				orphans = append(orphans, fsckLocationKey{sf.index, sectorIndex})
+				continue
+			case !used:
+				continue
+			case uint64(sectorIndex) >= metadataSectors || uint64(sectorIndex) >= dataSectors:
+				report.TruncatedSectors++
+				sf.clearUsage(sectorIndex)
+				continue
+			case count == 0:
+				report.EmptyUsageBits++
+				sf.clearUsage(sectorIndex)
+				continue
+			}
+			report.PhysicalSectors++
+			report.VirtualSectors += uint64(count)
+			locations[id] = append(locations[id], fsckLocation{
+				folder: sf.index,
+				index:  sectorIndex,
+				count:  count,
+			})
+		}
+	}
+
+	// A sector that is stored in multiple locations is only loaded from one
+	// of them, and the others leak storage. The location with the highest
+	// virtual sector count is kept.
+	for _, sls := range locations {
+		if len(sls) < 2 {
+			continue
+		}
+		sort.Slice(sls, func(i, j int) bool {
+			if sls[i].count != sls[j].count {
+				return sls[i].count > sls[j].count
+			}
+			if sls[i].folder != sls[j].folder {
+				return sls[i].folder < sls[j].folder
+			}
+			return sls[i].index < sls[j].index
+		})
+		if sls[0].count != sls[len(sls)-1].count {
+			report.CountMismatches++
+		}
+		for _, sl := range sls[1:] {
+			report.DuplicateSectors++
+			report.PhysicalSectors--
+			report.VirtualSectors -= uint64(sl.count)
+			folders[sl.folder].clearUsage(sl.index)
+		}
+	}
+
+	if !repair || !report.Corrupt() {
+		return report, nil
+	}
+
+	// Keep a copy of the original settings before rewriting them.
+	original, err := ioutil.ReadFile(settingsPath)
+	if err != nil {
+		return report, err
+	}
+	err = ioutil.WriteFile(settingsPath+"_corrupt", original, 0600)
+	if err != nil {
+		return report, err
+	}
+	err = persist.SaveJSON(settingsMetadata, ss, settingsPath)
+	if err != nil {
+		return report, err
+	}
+	return report, clearOrphanedSectors(folders, orphans)
+}
+
+// clearOrphanedSectors clears the virtual sector count in the metadata of the
+// orphaned sectors, so that their locations are no longer reported.
+func clearOrphanedSectors(folders map[uint16]*storageFolder, orphans []fsckLocationKey) error {
+	for _, sf := range folders {
+		f, err := os.OpenFile(filepath.Join(sf.path, metadataFile), os.O_RDWR, 0700)
+		if err != nil {
+			return err
+		}
+		for _, o := range orphans {
+			if o.folder != sf.index {
+				continue
+			}
+			if err := writeSectorMetadata(f, o.index, sectorID{}, 0); err != nil {
+				f.Close()
+				return err
+			}
+		}
+		if err := build.ComposeErrors(f.Sync(), f.Close()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readStorageFolderFiles reads the sector metadata of a storage folder,
+// returning the metadata along with the number of sectors that fit in the
+// sector file. Files that are shorter than numSectors are not an error.
+func readStorageFolderFiles(path string, numSectors uint64) ([]byte, uint64, error) {
+	f, err := os.Open(filepath.Join(path, metadataFile))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	metadataBytes := make([]byte, numSectors*sectorMetadataDiskSize)
+	n, err := f.ReadAt(metadataBytes, 0)
+	if err != nil && err != io.EOF {
+		return nil, 0, err
+	}
+
+	dataInfo, err := os.Stat(filepath.Join(path, sectorFile))
+	if err != nil {
+		return nil, 0, err
+	}
+	return metadataBytes[:n], uint64(dataInfo.Size()) / modules.SectorSize, nil
+}
+
+// sectorMetadata decodes the id and virtual sector count of the sector at the
+// given index of a storage folder's metadata.
+func sectorMetadata(metadataBytes []byte, sectorIndex uint32) (id sectorID, count uint16) {
+	readHead := sectorMetadataDiskSize * sectorIndex
+	copy(id[:], metadataBytes[readHead:readHead+12])
+	count = binary.LittleEndian.Uint16(metadataBytes[readHead+12 : readHead+14])
+	return id, count
+}
+
+// disrupt prevents the background threads of the contract manager, other than
+// the WAL sync loop, from running.
+func (recoveryDependencies) disrupt(s string) bool {
+	return s == "noScrub" || s == "noRecheck"
+}
+
+// Fsck checks the consistency of the contract manager directory at persistDir
+// without modifying it. Uncommitted changes in the WAL are not applied, but
+// are compared to the sector metadata on disk, and the locations where they
+// differ are reported as pending updates. Fsck is meant to be used while the
+// contract manager is not running.
+func Fsck(persistDir string) (FsckReport, error) {
+	return checkStorage(persistDir, false)
+}
+
+// RepairFsck repairs the contract manager directory at persistDir. The WAL is
+// recovered first, the same way as when the contract manager starts after an
+// unclean shutdown, which resolves any differences between the WAL and the
+// sector metadata. Then, the usage bits of truncated sectors, of locations
+// without sector metadata and of duplicate sector locations are cleared, and
+// the settings are rewritten. The original settings are kept in a copy whose
+// name is suffixed with "_corrupt". Finally, the virtual sector counts in the
+// metadata of orphaned sectors are cleared. RepairFsck must not be called
+// while the contract manager is running.
+//
+// RepairFsck does not correct virtual sector counts: of a sector stored in
+// several locations with different counts, the location with the highest
+// count is kept, and its count is left as it is. Orphaned sectors are not
+// added back to the contract manager. Sector data that does not match its
+// root and unavailable storage folders are not repaired either.
+func RepairFsck(persistDir string) (FsckReport, error) {
+	// Loading a contract manager from a directory without settings would
+	// create a new contract manager.
+	_, err := os.Stat(filepath.Join(persistDir, settingsFile))
+	if os.IsNotExist(err) {
+		return FsckReport{}, errNoContractManager
+	} else if err != nil {
+		return FsckReport{}, err
+	}
+	scs, err := readWALChanges(persistDir)
+	if err != nil {
+		return FsckReport{}, err
+	}
+
+	// Recover the WAL by loading the contract manager and shutting it down
+	// cleanly.
+	if len(scs) > 0 {
+		cm, err := newContractManager(new(recoveryDependencies), persistDir)
+		if err != nil {
+			return FsckReport{}, build.ExtendErr("unable to recover the WAL", err)
+		}
+		err = cm.Close()
+		if err != nil {
+			return FsckReport{}, build.ExtendErr("unable to recover the WAL", err)
+		}
+	}
+
+	report, err := checkStorage(persistDir, true)
+	report.UncommittedChanges = len(scs)
+	return report, err
+}
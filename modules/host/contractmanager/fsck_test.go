@@ -0,0 +1,286 @@
+package contractmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
+)
+
+// TestFsck checks that Fsck finds usage bits without sector metadata and
+// that RepairFsck clears them.
+func TestFsck(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmDir := cmt.cm.persistDir
+
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		root, data := randSector()
+		err = cmt.cm.AddSector(root, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = cmt.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The storage folders of a cleanly closed contract manager should be
+	// consistent.
+	report, err := Fsck(cmDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Corrupt() || report.StorageFolders != 1 || report.PhysicalSectors != 3 || report.VirtualSectors != 3 {
+		t.Fatal("unexpected report for a consistent contract manager:", report)
+	}
+
+	// Set a usage bit for a location that has no sector metadata.
+	settingsPath := filepath.Join(cmDir, settingsFile)
+	var ss savedSettings
+	err = persist.LoadJSON(settingsMetadata, &ss, settingsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage := ss.StorageFolders[0].Usage
+	var emptyIndex uint32
+	for emptyIndex = 0; usage[0]&(1<<emptyIndex) != 0; emptyIndex++ {
+	}
+	usage[0] |= 1 << emptyIndex
+	err = persist.SaveJSON(settingsMetadata, ss, settingsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = Fsck(cmDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Corrupt() || report.EmptyUsageBits != 1 || report.PhysicalSectors != 3 {
+		t.Fatal("fsck did not find the empty usage bit:", report)
+	}
+
+	// Repair the contract manager, which should keep a copy of the original
+	// settings.
+	report, err = RepairFsck(cmDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.EmptyUsageBits != 1 {
+		t.Fatal("repair did not find the empty usage bit:", report)
+	}
+	_, err = os.Stat(settingsPath + "_corrupt")
+	if err != nil {
+		t.Fatal("original settings were not kept:", err)
+	}
+	report, err = Fsck(cmDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Corrupt() || report.PhysicalSectors != 3 {
+		t.Fatal("contract manager is still inconsistent after repair:", report)
+	}
+
+	// The repaired contract manager should load with all of its sectors.
+	cm, err := New(cmDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cm.Close()
+	sfs := cm.StorageFolders()
+	if len(sfs) != 1 || sfs[0].Capacity-sfs[0].CapacityRemaining != modules.SectorSize*3 {
+		t.Fatal("repaired contract manager has the wrong number of sectors:", sfs)
+	}
+}
+
+// TestRepairFsckWAL checks that RepairFsck recovers the WAL of a contract
+// manager that was shut down uncleanly.
+func TestRepairFsckWAL(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	d := new(dependencyNoSettingsSave)
+	cmt, err := newMockedContractManagerTester(d, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmDir := cmt.cm.persistDir
+
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a sector that only makes it into the WAL, and shut down uncleanly.
+	root, data := randSector()
+	err = cmt.cm.AddSector(root, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.mu.Lock()
+	d.triggered = true
+	d.mu.Unlock()
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := Fsck(cmDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.UncommittedChanges == 0 || report.PendingUpdates == 0 {
+		t.Fatal("fsck did not find the uncommitted changes:", report)
+	}
+	if report.Corrupt() {
+		t.Fatal("pending updates were reported as corruption:", report)
+	}
+
+	// Repairing should commit the WAL and remove it.
+	_, err = RepairFsck(cmDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.Stat(filepath.Join(cmDir, walFile))
+	if !os.IsNotExist(err) {
+		t.Fatal("WAL was not removed by the repair:", err)
+	}
+	report, err = Fsck(cmDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Corrupt() || report.UncommittedChanges != 0 || report.PhysicalSectors != 1 {
+		t.Fatal("unexpected report after recovering the WAL:", report)
+	}
+}
+
+// TestFsckOrphanedSectors checks that Fsck finds sector metadata without a
+// usage bit, that removed sectors are not reported as orphaned, and that
+// RepairFsck clears the metadata of orphaned sectors.
+func TestFsckOrphanedSectors(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmDir := cmt.cm.persistDir
+
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var roots []crypto.Hash
+	for i := 0; i < 3; i++ {
+		root, data := randSector()
+		err = cmt.cm.AddSector(root, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+	err = cmt.cm.RemoveSector(roots[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The removed sector should not be reported as orphaned.
+	report, err := Fsck(cmDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Corrupt() || report.PhysicalSectors != 2 {
+		t.Fatal("unexpected report after removing a sector:", report)
+	}
+
+	// Clear the usage bit of a sector, leaving its metadata in place.
+	settingsPath := filepath.Join(cmDir, settingsFile)
+	var ss savedSettings
+	err = persist.LoadJSON(settingsMetadata, &ss, settingsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage := ss.StorageFolders[0].Usage
+	var usedIndex uint32
+	for usedIndex = 0; usage[0]&(1<<usedIndex) == 0; usedIndex++ {
+	}
+	usage[0] &^= 1 << usedIndex
+	err = persist.SaveJSON(settingsMetadata, ss, settingsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = Fsck(cmDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Corrupt() || report.OrphanedSectors != 1 || report.PhysicalSectors != 1 {
+		t.Fatal("fsck did not find the orphaned sector:", report)
+	}
+
+	// Repairing should clear the metadata of the orphaned sector.
+	report, err = RepairFsck(cmDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OrphanedSectors != 1 {
+		t.Fatal("repair did not find the orphaned sector:", report)
+	}
+	report, err = Fsck(cmDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Corrupt() || report.PhysicalSectors != 1 {
+		t.Fatal("contract manager is still inconsistent after repair:", report)
+	}
+}
+
+// TestFsckNoContractManager checks that Fsck and RepairFsck refuse to operate
+// on a directory without a contract manager.
+func TestFsckNoContractManager(t *testing.T) {
+	dir := build.TempDir(modules.ContractManagerDir, t.Name())
+	_, err := Fsck(dir)
+	if err != errNoContractManager {
+		t.Fatal("expected errNoContractManager, got", err)
+	}
+	_, err = RepairFsck(dir)
+	if err != errNoContractManager {
+		t.Fatal("expected errNoContractManager, got", err)
+	}
+}
@@ -0,0 +1,106 @@
+package contractmanager
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
+)
+
+// threadedScrubSectors periodically re-reads every sector stored by the
+// contract manager and checks that its data still matches its Merkle root.
+// Disks can silently corrupt data over time, and without scrubbing, the
+// corruption is only found once a renter or a storage proof needs the sector.
+// The scrubber pauses between sectors so that it does not compete with renters
+// for disk bandwidth.
+func (cm *ContractManager) threadedScrubSectors() {
+	// Don't spawn the loop if 'noScrub' disruption is set.
+	if cm.dependencies.disrupt("noScrub") {
+		return
+	}
+
+	for {
+		select {
+		case <-cm.tg.StopChan():
+			return
+		case <-time.After(scrubInterval):
+		}
+		cm.managedScrubSectors()
+	}
+}
+
+// managedScrubSectors checks every sector once, returning the number of
+// corrupt sectors that were found. Sectors are checked in the order that they
+// are stored on disk.
+func (cm *ContractManager) managedScrubSectors() (corrupt uint64) {
+	err := cm.tg.Add()
+	if err != nil {
+		return 0
+	}
+	defer cm.tg.Done()
+
+	cm.wal.mu.Lock()
+	ids := make([]sectorID, 0, len(cm.sectorLocations))
+	for id := range cm.sectorLocations {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := cm.sectorLocations[ids[i]], cm.sectorLocations[ids[j]]
+		if a.storageFolder != b.storageFolder {
+			return a.storageFolder < b.storageFolder
+		}
+		return a.index < b.index
+	})
+	cm.wal.mu.Unlock()
+
+	for _, id := range ids {
+		select {
+		case <-cm.tg.StopChan():
+			return corrupt
+		case <-time.After(scrubSectorDelay):
+		}
+		if cm.managedScrubSector(id) {
+			corrupt++
+		}
+	}
+	if corrupt > 0 {
+		cm.log.Printf("WARN: scrubbing found %v corrupt sectors\n", corrupt)
+	}
+	return corrupt
+}
+
+// managedScrubSector reads a sector from disk and checks that its Merkle root
+// matches its id, returning true if the sector is corrupt. Reads and corrupt
+// sectors are counted towards the health of the sector's storage folder.
+func (cm *ContractManager) managedScrubSector(id sectorID) bool {
+	cm.wal.managedLockSector(id)
+	defer cm.wal.managedUnlockSector(id)
+
+	// Fetch the sector metadata. The sector may have been removed since the
+	// scrub started.
+	cm.wal.mu.Lock()
+	sl, exists1 := cm.sectorLocations[id]
+	sf, exists2 := cm.storageFolders[sl.storageFolder]
+	cm.wal.mu.Unlock()
+	if !exists1 || !exists2 || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+		return false
+	}
+
+	sf.fileMu.RLock()
+	sectorData, err := readSector(sf.sectorFile, sl.index)
+	sf.fileMu.RUnlock()
+	if err != nil {
+		atomic.AddUint64(&sf.atomicFailedReads, 1)
+		cm.log.Printf("Unable to scrub sector %v in storage folder %v: %v\n", sl.index, sf.path, err)
+		return false
+	}
+	if cm.managedSectorID(crypto.MerkleRoot(sectorData)) != id {
+		atomic.AddUint64(&sf.atomicFailedReads, 1)
+		atomic.AddUint64(&sf.atomicCorruptSectors, 1)
+		cm.log.Printf("WARN: sector %v in storage folder %v is corrupt\n", sl.index, sf.path)
+		return true
+	}
+	atomic.AddUint64(&sf.atomicSuccessfulReads, 1)
+	return false
+}
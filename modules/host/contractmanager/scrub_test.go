@@ -0,0 +1,92 @@
+package contractmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/fastrand"
+)
+
+// dependencyNoScrub is a mocked dependency that prevents the background
+// scrubber from running, so that tests can scrub on demand.
+type dependencyNoScrub struct {
+	productionDependencies
+}
+
+// disrupt prevents the scrub loop from running in the contract manager.
+func (dependencyNoScrub) disrupt(s string) bool {
+	return s == "noScrub"
+}
+
+// TestScrubSectors checks that the scrubber finds corrupt sectors and reports
+// them in the health of their storage folder.
+func TestScrubSectors(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newMockedContractManagerTester(&dependencyNoScrub{}, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		root, data := randSector()
+		err = cmt.cm.AddSector(root, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A scrub of healthy sectors should find nothing.
+	if corrupt := cmt.cm.managedScrubSectors(); corrupt != 0 {
+		t.Fatal("expected no corrupt sectors, got", corrupt)
+	}
+	sfs := cmt.cm.StorageFolders()
+	if sfs[0].CorruptSectors != 0 || sfs[0].FailedReads != 0 || sfs[0].SuccessfulReads != 3 {
+		t.Fatal("storage folder health is incorrect after a clean scrub:", sfs[0])
+	}
+
+	// Corrupt one of the sectors on disk.
+	cmt.cm.wal.mu.Lock()
+	var sl sectorLocation
+	for _, sl = range cmt.cm.sectorLocations {
+		break
+	}
+	sf := cmt.cm.storageFolders[sl.storageFolder]
+	cmt.cm.wal.mu.Unlock()
+	err = writeSector(sf.sectorFile, sl.index, fastrand.Bytes(int(modules.SectorSize)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if corrupt := cmt.cm.managedScrubSectors(); corrupt != 1 {
+		t.Fatal("expected one corrupt sector, got", corrupt)
+	}
+	sfs = cmt.cm.StorageFolders()
+	if sfs[0].CorruptSectors != 1 || sfs[0].FailedReads != 1 || sfs[0].SuccessfulReads != 5 {
+		t.Fatal("storage folder health is incorrect after finding a corrupt sector:", sfs[0])
+	}
+
+	// Resetting the health of the folder should clear the corrupt sectors.
+	err = cmt.cm.ResetStorageFolderHealth(sfs[0].Index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sfs = cmt.cm.StorageFolders()
+	if sfs[0].CorruptSectors != 0 || sfs[0].FailedReads != 0 {
+		t.Fatal("storage folder health was not reset:", sfs[0])
+	}
+}
@@ -33,6 +33,11 @@ var (
 
 	// ErrSectorNotFound is returned when a lookup for a sector fails.
 	ErrSectorNotFound = errors.New("could not find the desired sector")
+
+	// ErrSectorUnavailable is returned when a sector is stored in a storage
+	// folder that is currently unavailable. Unlike ErrSectorNotFound, it
+	// does not mean that the sector has been lost.
+	ErrSectorUnavailable = errors.New("the desired sector is in an unavailable storage folder")
 )
 
 // sectorLocation indicates the location of a sector on disk.
@@ -148,17 +153,24 @@ func (cm *ContractManager) ReadSector(root crypto.Hash) ([]byte, error) {
 		return nil, ErrSectorNotFound
 	}
 	if atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
-		// TODO: Pick a new error instead.
-		return nil, ErrSectorNotFound
+		return nil, ErrSectorUnavailable
+	}
+
+	// Popular sectors can be served from memory.
+	if sectorData, cached := cm.sectorCache.get(id); cached {
+		return sectorData, nil
 	}
 
 	// Read the sector.
+	sf.fileMu.RLock()
 	sectorData, err := readSector(sf.sectorFile, sl.index)
+	sf.fileMu.RUnlock()
 	if err != nil {
 		atomic.AddUint64(&sf.atomicFailedReads, 1)
 		return nil, build.ExtendErr("unable to fetch sector", err)
 	}
 	atomic.AddUint64(&sf.atomicSuccessfulReads, 1)
+	cm.sectorCache.add(id, sl.storageFolder, sectorData)
 	return sectorData, nil
 }
 
@@ -0,0 +1,159 @@
+package contractmanager
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+type (
+	// sectorCache keeps the data of recently read sectors in memory, so that
+	// popular sectors do not need to be read from disk for every download.
+	// When the cache is full, the least recently used sectors are evicted.
+	//
+	// A sector's data never changes, because the sector id is derived from
+	// the sector's Merkle root. The cache therefore only needs to be
+	// invalidated when a sector is removed or moved, and ReadSector checks
+	// that a sector exists before consulting the cache.
+	sectorCache struct {
+		// entries maps the id of each cached sector to its element in lru,
+		// which is ordered from most recently used to least recently used.
+		entries map[sectorID]*list.Element
+		lru     *list.List
+
+		maxSize uint64
+		size    uint64
+
+		hits   uint64
+		misses uint64
+
+		mu sync.Mutex
+	}
+
+	// cachedSector is a sector in the sector cache, along with the storage
+	// folder that it was read from.
+	cachedSector struct {
+		id     sectorID
+		folder uint16
+		data   []byte
+	}
+)
+
+// newSectorCache returns an empty sector cache. The cache is disabled until
+// it is given a size.
+func newSectorCache() *sectorCache {
+	return &sectorCache{
+		entries: make(map[sectorID]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// evict removes the least recently used sectors from the cache until the
+// cache fits within its maximum size.
+func (sc *sectorCache) evict() {
+	for sc.size > sc.maxSize {
+		sc.removeElement(sc.lru.Back())
+	}
+}
+
+// removeElement removes an element from the cache.
+func (sc *sectorCache) removeElement(e *list.Element) {
+	cs := sc.lru.Remove(e).(*cachedSector)
+	delete(sc.entries, cs.id)
+	sc.size -= uint64(len(cs.data))
+}
+
+// add places a copy of the data of a sector in the cache, evicting other
+// sectors if necessary.
+func (sc *sectorCache) add(id sectorID, folder uint16, data []byte) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if uint64(len(data)) > sc.maxSize {
+		return
+	}
+	if e, exists := sc.entries[id]; exists {
+		sc.removeElement(e)
+	}
+	cs := &cachedSector{
+		id:     id,
+		folder: folder,
+		data:   append([]byte(nil), data...),
+	}
+	sc.entries[id] = sc.lru.PushFront(cs)
+	sc.size += uint64(len(cs.data))
+	sc.evict()
+}
+
+// get returns a copy of the data of a sector if the sector is in the cache,
+// marking the sector as recently used.
+func (sc *sectorCache) get(id sectorID) ([]byte, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.maxSize == 0 {
+		return nil, false
+	}
+	e, exists := sc.entries[id]
+	if !exists {
+		sc.misses++
+		return nil, false
+	}
+	sc.hits++
+	sc.lru.MoveToFront(e)
+	return append([]byte(nil), e.Value.(*cachedSector).data...), true
+}
+
+// remove removes a sector from the cache.
+func (sc *sectorCache) remove(id sectorID) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if e, exists := sc.entries[id]; exists {
+		sc.removeElement(e)
+	}
+}
+
+// removeFolder removes all sectors that were read from a storage folder from
+// the cache.
+func (sc *sectorCache) removeFolder(folder uint16) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	var next *list.Element
+	for e := sc.lru.Front(); e != nil; e = next {
+		next = e.Next()
+		if e.Value.(*cachedSector).folder == folder {
+			sc.removeElement(e)
+		}
+	}
+}
+
+// SectorCacheMetrics returns the size and the hit and miss counts of the
+// sector cache.
+func (cm *ContractManager) SectorCacheMetrics() modules.SectorCacheMetrics {
+	sc := cm.sectorCache
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	return modules.SectorCacheMetrics{
+		Capacity: sc.maxSize,
+		Size:     sc.size,
+		Sectors:  uint64(len(sc.entries)),
+		Hits:     sc.hits,
+		Misses:   sc.misses,
+	}
+}
+
+// SetSectorCacheSize sets the maximum number of bytes of sector data that are
+// kept in memory, evicting sectors if the cache shrinks. A size of zero
+// disables the cache.
+func (cm *ContractManager) SetSectorCacheSize(size uint64) {
+	sc := cm.sectorCache
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	sc.maxSize = size
+	sc.evict()
+}
@@ -0,0 +1,138 @@
+package contractmanager
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// TestSectorCacheEviction checks that the sector cache evicts the least
+// recently used sectors when it is full.
+func TestSectorCacheEviction(t *testing.T) {
+	sc := newSectorCache()
+	data := make([]byte, 10)
+
+	// A disabled cache should not hold or count anything.
+	sc.add(sectorID{1}, 0, data)
+	if _, cached := sc.get(sectorID{1}); cached || sc.misses != 0 {
+		t.Fatal("disabled cache served or counted a read")
+	}
+
+	sc.maxSize = 30
+	sc.add(sectorID{1}, 0, data)
+	sc.add(sectorID{2}, 0, data)
+	sc.add(sectorID{3}, 1, data)
+	if _, cached := sc.get(sectorID{1}); !cached {
+		t.Fatal("sector 1 should be cached")
+	}
+
+	// Sector 2 is now the least recently used sector.
+	sc.add(sectorID{4}, 1, data)
+	if _, cached := sc.get(sectorID{2}); cached {
+		t.Fatal("least recently used sector was not evicted")
+	}
+	if sc.size != 30 || len(sc.entries) != 3 {
+		t.Fatal("cache has the wrong size:", sc.size, len(sc.entries))
+	}
+
+	// Removing a folder should remove only the sectors from that folder.
+	sc.removeFolder(1)
+	if _, cached := sc.get(sectorID{3}); cached {
+		t.Fatal("sector from removed folder is still cached")
+	}
+	if _, cached := sc.get(sectorID{1}); !cached {
+		t.Fatal("sector from another folder was removed")
+	}
+	if sc.hits != 2 || sc.misses != 2 {
+		t.Fatal("wrong hit and miss counts:", sc.hits, sc.misses)
+	}
+
+	// Shrinking the cache should evict sectors.
+	cm := &ContractManager{sectorCache: sc}
+	cm.SetSectorCacheSize(10)
+	if m := cm.SectorCacheMetrics(); m.Sectors != 1 || m.Size != 10 || m.Capacity != 10 {
+		t.Fatal("cache was not shrunk:", m)
+	}
+}
+
+// TestSectorCacheReadSector checks that ReadSector serves sectors from the
+// cache, and that removed sectors are not served.
+func TestSectorCacheReadSector(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newMockedContractManagerTester(&dependencyNoScrub{}, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, data := randSector()
+	err = cmt.cm.AddSector(root, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm.SetSectorCacheSize(modules.SectorSize * 4)
+
+	// The first read should miss, and the second should hit.
+	for i := 0; i < 2; i++ {
+		readData, err := cmt.cm.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(readData, data) {
+			t.Fatal("read sector does not match the added sector")
+		}
+	}
+	m := cmt.cm.SectorCacheMetrics()
+	if m.Hits != 1 || m.Misses != 1 || m.Sectors != 1 {
+		t.Fatal("unexpected cache metrics:", m)
+	}
+	sfs := cmt.cm.StorageFolders()
+	if sfs[0].SuccessfulReads != 1 {
+		t.Fatal("cache hit was read from disk:", sfs[0].SuccessfulReads)
+	}
+
+	// A sector in an unavailable storage folder should be reported as
+	// unavailable rather than missing, even if it is cached.
+	setUnavailable := func(unavailable uint64) {
+		cmt.cm.wal.mu.Lock()
+		for _, sf := range cmt.cm.storageFolders {
+			atomic.StoreUint64(&sf.atomicUnavailable, unavailable)
+		}
+		cmt.cm.wal.mu.Unlock()
+	}
+	setUnavailable(1)
+	_, err = cmt.cm.ReadSector(root)
+	if err != ErrSectorUnavailable {
+		t.Fatal("expected ErrSectorUnavailable, got", err)
+	}
+	setUnavailable(0)
+
+	// Removing the sector should remove it from the cache.
+	err = cmt.cm.RemoveSector(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := cmt.cm.SectorCacheMetrics(); m.Sectors != 0 || m.Size != 0 {
+		t.Fatal("removed sector is still cached:", m)
+	}
+	_, err = cmt.cm.ReadSector(root)
+	if err != ErrSectorNotFound {
+		t.Fatal("expected ErrSectorNotFound, got", err)
+	}
+}
@@ -20,8 +20,11 @@ func (wal *writeAheadLog) commitUpdateSector(su sectorUpdate) {
 		return
 	}
 
-	// If the sector is being cleaned from disk, unset the usage flag.
+	// If the sector is being cleaned from disk, clear its count in the
+	// metadata and unset the usage flag. The usage flag is unset even if the
+	// metadata write fails.
 	if su.Count == 0 {
+		wal.writeSectorMetadata(sf, su) // Error is logged by writeSectorMetadata.
 		sf.clearUsage(su.Index)
 		return
 	}
@@ -215,6 +218,8 @@ func (wal *writeAheadLog) managedAddVirtualSector(id sectorID, location sectorLo
 
 // managedDeleteSector will delete a sector (physical) from the contract manager.
 func (wal *writeAheadLog) managedDeleteSector(id sectorID) error {
+	wal.cm.sectorCache.remove(id)
+
 	// Write the sector delete to the WAL.
 	var location sectorLocation
 	var syncChan chan struct{}
@@ -258,6 +263,16 @@ func (wal *writeAheadLog) managedDeleteSector(id sectorID) error {
 	}
 	<-syncChan
 
+	// Clear the count in the metadata, so that the location is not mistaken
+	// for an orphaned sector. The location cannot be reused until its usage is
+	// cleared.
+	wal.writeSectorMetadata(sf, sectorUpdate{
+		Count:  0,
+		ID:     id,
+		Folder: location.storageFolder,
+		Index:  location.index,
+	}) // Error is logged by writeSectorMetadata.
+
 	// Only update the usage after the sector delete has been committed to disk
 	// fully.
 	wal.mu.Lock()
@@ -270,6 +285,10 @@ func (wal *writeAheadLog) managedDeleteSector(id sectorID) error {
 // managedRemoveSector will remove a sector (virtual or physical) from the
 // contract manager.
 func (wal *writeAheadLog) managedRemoveSector(id sectorID) error {
+	// The sector is locked, so it cannot be added back to the cache before
+	// the removal has completed.
+	wal.cm.sectorCache.remove(id)
+
 	// Inform the WAL of the removed sector.
 	var location sectorLocation
 	var su sectorUpdate
@@ -342,8 +361,10 @@ func (wal *writeAheadLog) managedRemoveSector(id sectorID) error {
 	// Only update the usage after the sector removal has been committed to
 	// disk entirely. The usage is not updated until after the commit has
 	// completed to prevent the actual sector data from being overwritten in
-	// the event of unclean shutdown.
+	// the event of unclean shutdown. The count in the metadata is cleared
+	// first, so that the location is not mistaken for an orphaned sector.
 	if location.count == 0 {
+		wal.writeSectorMetadata(sf, su) // Error is logged by writeSectorMetadata.
 		wal.mu.Lock()
 		sf.clearUsage(location.index)
 		delete(sf.availableSectors, id)
@@ -355,7 +376,9 @@ func (wal *writeAheadLog) managedRemoveSector(id sectorID) error {
 // writeSectorMetadata will take a sector update and write the related metadata
 // to disk.
 func (wal *writeAheadLog) writeSectorMetadata(sf *storageFolder, su sectorUpdate) error {
+	sf.fileMu.RLock()
 	err := writeSectorMetadata(sf.metadataFile, su.Index, su.ID, su.Count)
+	sf.fileMu.RUnlock()
 	if err != nil {
 		wal.cm.log.Printf("ERROR: unable to write sector metadata to folder %v when adding sector: %v\n", su.Folder, err)
 		atomic.AddUint64(&sf.atomicFailedWrites, 1)
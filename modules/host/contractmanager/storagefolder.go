@@ -93,6 +93,10 @@ type storageFolder struct {
 	atomicSuccessfulReads  uint64
 	atomicSuccessfulWrites uint64
 
+	// The number of sectors that the scrubber found to be corrupt during this
+	// boot cycle.
+	atomicCorruptSectors uint64
+
 	// Atomic bool indicating whether or not the storage folder is available. If
 	// the storage folder is not available, it will still be loaded but return
 	// an error if it is queried.
@@ -118,6 +122,11 @@ type storageFolder struct {
 	// or resized.
 	mu sync.TryRWMutex
 
+	// fileMu needs to be RLocked to use the file handles of the storage folder
+	// without holding the WAL lock. fileMu is Locked while the file handles
+	// are swapped as the storage folder is moved to a new path.
+	fileMu sync.TryRWMutex
+
 	// An open file handle is kept so that writes can easily be made to the
 	// storage folder without needing to grab a new file handle. This also
 	// makes it easy to do delayed-syncing.
@@ -347,6 +356,7 @@ func (cm *ContractManager) ResetStorageFolderHealth(index uint16) error {
 	atomic.StoreUint64(&sf.atomicFailedWrites, 0)
 	atomic.StoreUint64(&sf.atomicSuccessfulReads, 0)
 	atomic.StoreUint64(&sf.atomicSuccessfulWrites, 0)
+	atomic.StoreUint64(&sf.atomicCorruptSectors, 0)
 	return nil
 }
 
@@ -411,6 +421,7 @@ func (cm *ContractManager) StorageFolders() []modules.StorageFolderMetadata {
 			FailedWrites:     atomic.LoadUint64(&sf.atomicFailedWrites),
 			SuccessfulReads:  atomic.LoadUint64(&sf.atomicSuccessfulReads),
 			SuccessfulWrites: atomic.LoadUint64(&sf.atomicSuccessfulWrites),
+			CorruptSectors:   atomic.LoadUint64(&sf.atomicCorruptSectors),
 
 			Capacity:          modules.SectorSize * 64 * uint64(len(sf.usage)),
 			CapacityRemaining: ((64 * uint64(len(sf.usage))) - sf.sectors) * modules.SectorSize,
@@ -21,6 +21,9 @@ func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
 	wal.managedLockSector(id)
 	defer wal.managedUnlockSector(id)
 
+	// The sector is moving to a different storage folder.
+	wal.cm.sectorCache.remove(id)
+
 	// Find the sector to be moved.
 	wal.mu.Lock()
 	oldLocation, exists1 := wal.cm.sectorLocations[id]
@@ -0,0 +1,354 @@
+package contractmanager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+var (
+	// errMoveDestinationInUse is returned if a storage folder is moved to a
+	// path that already contains the files of a storage folder.
+	errMoveDestinationInUse = errors.New("destination path already contains a storage folder")
+
+	// errMoveInterrupted is returned if a storage folder move is interrupted
+	// by the contract manager shutting down.
+	errMoveInterrupted = errors.New("storage folder move was interrupted by shutdown")
+
+	// errMoveSamePath is returned if a storage folder is moved to the path
+	// that it is already at.
+	errMoveSamePath = errors.New("storage folder is already at that path")
+)
+
+type (
+	// storageFolderMove indicates a storage folder that is being moved, or
+	// has been moved, from one path to another.
+	storageFolderMove struct {
+		Index   uint16
+		OldPath string
+		NewPath string
+	}
+)
+
+// findUnfinishedStorageFolderMoves will scroll through a set of state changes
+// and figure out which of the unfinished storage folder moves are still
+// unfinished.
+func findUnfinishedStorageFolderMoves(scs []stateChange) []storageFolderMove {
+	usfmMap := make(map[uint16]storageFolderMove)
+	for _, sc := range scs {
+		for _, sfm := range sc.UnfinishedStorageFolderMoves {
+			usfmMap[sfm.Index] = sfm
+		}
+		for _, sfm := range sc.StorageFolderMoves {
+			delete(usfmMap, sfm.Index)
+		}
+		for _, index := range sc.ErroredStorageFolderMoves {
+			delete(usfmMap, index)
+		}
+		for _, sfr := range sc.StorageFolderRemovals {
+			delete(usfmMap, sfr.Index)
+		}
+	}
+
+	// Return the active unfinished storage folder moves as a slice.
+	var sfms []storageFolderMove
+	for _, sfm := range usfmMap {
+		sfms = append(sfms, sfm)
+	}
+	return sfms
+}
+
+// cleanupUnfinishedStorageFolderMoves will purge any partial copies of storage
+// folders that were being moved during the previous run. The storage folders
+// themselves remain at their old paths.
+func (wal *writeAheadLog) cleanupUnfinishedStorageFolderMoves(scs []stateChange) {
+	usfms := findUnfinishedStorageFolderMoves(scs)
+	for _, usfm := range usfms {
+		// Remove any leftover files.
+		wal.removeStorageFolderFiles(usfm.NewPath)
+
+		// Append an error call to the changeset, indicating that the storage
+		// folder move was not completed successfully.
+		wal.appendChange(stateChange{
+			ErroredStorageFolderMoves: []uint16{usfm.Index},
+		})
+	}
+}
+
+// removeStorageFolderFiles removes the metadata and sector files of a storage
+// folder at the given path, ignoring files that do not exist.
+func (wal *writeAheadLog) removeStorageFolderFiles(path string) {
+	for _, name := range []string{metadataFile, sectorFile} {
+		err := wal.cm.dependencies.removeFile(filepath.Join(path, name))
+		if err != nil && !os.IsNotExist(err) {
+			wal.cm.log.Printf("Error: unable to remove %v from %v: %v\n", name, path, err)
+		}
+	}
+}
+
+// commitStorageFolderMove finalizes a storage folder move. If the storage
+// folder is still at its old path, which can only happen during WAL recovery,
+// the files at the new path are opened. The files at the old path are then
+// deleted. The operation is idempotent.
+func (wal *writeAheadLog) commitStorageFolderMove(sfm storageFolderMove) {
+	sf, exists := wal.cm.storageFolders[sfm.Index]
+	if !exists {
+		// The storage folder has since been removed.
+		return
+	}
+
+	if sf.path == sfm.OldPath {
+		metadata, err1 := wal.cm.dependencies.openFile(filepath.Join(sfm.NewPath, metadataFile), os.O_RDWR, 0700)
+		sectors, err2 := wal.cm.dependencies.openFile(filepath.Join(sfm.NewPath, sectorFile), os.O_RDWR, 0700)
+		if err1 != nil || err2 != nil {
+			// The moved files cannot be opened, so the files at the old path
+			// are kept.
+			if err1 == nil {
+				metadata.Close()
+			}
+			if err2 == nil {
+				sectors.Close()
+			}
+			wal.cm.log.Printf("ERROR: unable to open storage folder %v after it was moved from %v: %v\n", sfm.NewPath, sfm.OldPath, build.ComposeErrors(err1, err2))
+			return
+		}
+
+		if atomic.LoadUint64(&sf.atomicUnavailable) == 0 {
+			err := build.ComposeErrors(sf.metadataFile.Close(), sf.sectorFile.Close())
+			if err != nil {
+				wal.cm.log.Printf("Error: unable to close the files of storage folder %v as it is moved: %v\n", sf.path, err)
+			}
+		}
+		sf.metadataFile = metadata
+		sf.sectorFile = sectors
+		sf.path = sfm.NewPath
+		atomic.StoreUint64(&sf.atomicUnavailable, 0)
+	}
+
+	// Delete the files at the old path.
+	wal.removeStorageFolderFiles(sfm.OldPath)
+}
+
+// managedMoveStorageFolder copies the files of a storage folder to a new path,
+// and then switches the storage folder over to the new files.
+//
+// The move is crash-safe. The WAL is told that a move is in progress before
+// any files are created at the new path, so that a partial copy can be removed
+// upon recovery. The switch to the new path is committed through the WAL
+// before the files at the old path are deleted.
+//
+// Sectors can be read from the storage folder throughout the move, but no new
+// sectors are written to the storage folder until the move has completed.
+func (wal *writeAheadLog) managedMoveStorageFolder(sf *storageFolder, newPath string) (err error) {
+	// Lock the storage folder for the duration of the function. This keeps
+	// new sectors out of the storage folder, and blocks any resize or removal.
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	oldPath := sf.path
+	if filepath.Clean(newPath) == filepath.Clean(oldPath) {
+		return errMoveSamePath
+	}
+	newMetadataName := filepath.Join(newPath, metadataFile)
+	newSectorName := filepath.Join(newPath, sectorFile)
+	sfm := storageFolderMove{
+		Index:   sf.index,
+		OldPath: oldPath,
+		NewPath: newPath,
+	}
+
+	// Create the files at the new path and tell the WAL that the move is in
+	// progress.
+	var newMetadata, newSectors file
+	var sectors []uint32
+	var numSectors uint64
+	var syncChan chan struct{}
+	err = func() error {
+		wal.mu.Lock()
+		defer wal.mu.Unlock()
+
+		for _, csf := range wal.cm.storageFolders {
+			if csf.path == newPath {
+				return ErrRepeatFolder
+			}
+		}
+		for _, name := range []string{newMetadataName, newSectorName} {
+			if _, err := os.Stat(name); !os.IsNotExist(err) {
+				return errMoveDestinationInUse
+			}
+		}
+
+		var err error
+		newMetadata, err = wal.cm.dependencies.createFile(newMetadataName)
+		if err != nil {
+			return build.ExtendErr("could not create storage folder file", err)
+		}
+		newSectors, err = wal.cm.dependencies.createFile(newSectorName)
+		if err != nil {
+			err = build.ComposeErrors(err, newMetadata.Close())
+			err = build.ComposeErrors(err, wal.cm.dependencies.removeFile(newMetadataName))
+			return build.ExtendErr("could not create storage folder file", err)
+		}
+
+		// Only the sectors that are in use need to be copied.
+		sectors = usageSectors(sf.usage)
+		numSectors = uint64(len(sf.usage)) * storageFolderGranularity
+		atomic.StoreUint64(&sf.atomicProgressNumerator, 0)
+		atomic.StoreUint64(&sf.atomicProgressDenominator, uint64(len(sectors))*modules.SectorSize)
+
+		wal.appendChange(stateChange{
+			UnfinishedStorageFolderMoves: []storageFolderMove{sfm},
+		})
+		syncChan = wal.syncChan
+		return nil
+	}()
+	if err != nil {
+		return err
+	}
+	// Block until the commitment to the unfinished move is complete.
+	<-syncChan
+
+	// If there's an error in the rest of the function, the partial copy needs
+	// to be removed, and the WAL needs to be told that the move has failed.
+	defer func() {
+		if err != nil {
+			wal.mu.Lock()
+			defer wal.mu.Unlock()
+
+			err = build.ComposeErrors(err, newMetadata.Close())
+			err = build.ComposeErrors(err, newSectors.Close())
+			wal.removeStorageFolderFiles(newPath)
+			wal.appendChange(stateChange{
+				ErroredStorageFolderMoves: []uint16{sf.index},
+			})
+			atomic.StoreUint64(&sf.atomicProgressNumerator, 0)
+			atomic.StoreUint64(&sf.atomicProgressDenominator, 0)
+		}
+	}()
+
+	// Allocate the files at the new path and copy the sectors.
+	err = newMetadata.Truncate(int64(numSectors * sectorMetadataDiskSize))
+	if err != nil {
+		return build.ExtendErr("could not allocate sector metadata file", err)
+	}
+	err = newSectors.Truncate(int64(numSectors * modules.SectorSize))
+	if err != nil {
+		return build.ExtendErr("could not allocate sector data file", err)
+	}
+	for _, sectorIndex := range sectors {
+		select {
+		case <-wal.cm.tg.StopChan():
+			return errMoveInterrupted
+		default:
+		}
+
+		sectorData, err := readSector(sf.sectorFile, sectorIndex)
+		if err != nil {
+			atomic.AddUint64(&sf.atomicFailedReads, 1)
+			return build.ExtendErr("unable to read sector during storage folder move", err)
+		}
+		atomic.AddUint64(&sf.atomicSuccessfulReads, 1)
+		err = writeSector(newSectors, sectorIndex, sectorData)
+		if err != nil {
+			return build.ExtendErr("unable to write sector during storage folder move", err)
+		}
+		atomic.AddUint64(&sf.atomicProgressNumerator, modules.SectorSize)
+	}
+	err = newSectors.Sync()
+	if err != nil {
+		return build.ExtendErr("could not synchronize moved sector data file", err)
+	}
+
+	// Simulate power failure at this point for some testing scenarios.
+	if wal.cm.dependencies.disrupt("storageFolderMoveFinish") {
+		return build.ComposeErrors(newMetadata.Close(), newSectors.Close())
+	}
+
+	// Copy the metadata and switch the storage folder over to the new files.
+	// The metadata is copied last, while holding the file lock, because the
+	// counts of virtual sectors can change throughout the move.
+	wal.mu.Lock()
+	sf.fileMu.Lock()
+	err = func() error {
+		metadata, err := readFullMetadata(sf.metadataFile, int(numSectors))
+		if err != nil {
+			atomic.AddUint64(&sf.atomicFailedReads, 1)
+			return err
+		}
+		_, err = newMetadata.WriteAt(metadata, 0)
+		if err != nil {
+			return build.ExtendErr("unable to write sector metadata during storage folder move", err)
+		}
+		return newMetadata.Sync()
+	}()
+	if err != nil {
+		sf.fileMu.Unlock()
+		wal.mu.Unlock()
+		return err
+	}
+	oldMetadata, oldSectors := sf.metadataFile, sf.sectorFile
+	sf.metadataFile = newMetadata
+	sf.sectorFile = newSectors
+	sf.path = newPath
+	sf.fileMu.Unlock()
+	closeErr := build.ComposeErrors(oldMetadata.Close(), oldSectors.Close())
+	if closeErr != nil {
+		wal.cm.log.Printf("Error: unable to close the files of storage folder %v as it is moved: %v\n", oldPath, closeErr)
+	}
+	wal.appendChange(stateChange{
+		StorageFolderMoves: []storageFolderMove{sfm},
+	})
+	syncChan = wal.syncChan
+	wal.mu.Unlock()
+
+	// Wait until the move has been committed. The files at the old path are
+	// deleted as part of the commit.
+	<-syncChan
+	atomic.StoreUint64(&sf.atomicProgressNumerator, 0)
+	atomic.StoreUint64(&sf.atomicProgressDenominator, 0)
+	return nil
+}
+
+// MoveStorageFolder moves a storage folder to a new path, for example on a new
+// disk. The sectors in the storage folder can still be read while the folder
+// is being moved.
+func (cm *ContractManager) MoveStorageFolder(index uint16, newPath string) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+
+	// Check that the new path is an absolute path to an existing folder.
+	if !filepath.IsAbs(newPath) {
+		return errRelativePath
+	}
+	pathInfo, err := os.Stat(newPath)
+	if err != nil {
+		return err
+	}
+	if !pathInfo.Mode().IsDir() {
+		return errStorageFolderNotFolder
+	}
+
+	cm.wal.mu.Lock()
+	sf, exists := cm.storageFolders[index]
+	cm.wal.mu.Unlock()
+	if !exists || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+		return errStorageFolderNotFound
+	}
+
+	err = cm.wal.managedMoveStorageFolder(sf, newPath)
+	// Sectors that were read while the folder was being moved were read from
+	// the old files.
+	cm.sectorCache.removeFolder(index)
+	if err != nil {
+		cm.log.Println("Call to MoveStorageFolder has failed:", err)
+		return err
+	}
+	return nil
+}
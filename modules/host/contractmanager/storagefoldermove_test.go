@@ -0,0 +1,223 @@
+package contractmanager
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// TestMoveStorageFolder moves a storage folder to a new path and checks that
+// its sectors are still available, including after a restart.
+func TestMoveStorageFolder(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	oldDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	newDir := filepath.Join(cmt.persistDir, "storageFolderTwo")
+	for _, dir := range []string{oldDir, newDir} {
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = cmt.cm.AddStorageFolder(oldDir, modules.SectorSize*storageFolderGranularity*2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := make([]crypto.Hash, 3)
+	datas := make([][]byte, 3)
+	for i := range roots {
+		roots[i], datas[i] = randSector()
+		err = cmt.cm.AddSector(roots[i], datas[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Add a virtual sector as well.
+	err = cmt.cm.AddSector(roots[0], datas[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := cmt.cm.StorageFolders()[0].Index
+
+	// Invalid moves should be rejected.
+	if err := cmt.cm.MoveStorageFolder(index, "relative/path"); err != errRelativePath {
+		t.Fatal("expected errRelativePath, got", err)
+	}
+	if err := cmt.cm.MoveStorageFolder(index, oldDir); err != errMoveSamePath {
+		t.Fatal("expected errMoveSamePath, got", err)
+	}
+	if err := cmt.cm.MoveStorageFolder(index+1, newDir); err != errStorageFolderNotFound {
+		t.Fatal("expected errStorageFolderNotFound, got", err)
+	}
+
+	err = cmt.cm.MoveStorageFolder(index, newDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sfs := cmt.cm.StorageFolders()
+	if len(sfs) != 1 || sfs[0].Path != newDir || sfs[0].Index != index {
+		t.Fatal("storage folder was not moved:", sfs)
+	}
+	files, err := ioutil.ReadDir(oldDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Fatal("files were left behind at the old path:", len(files))
+	}
+
+	// checkSectors verifies that every sector can be read, and that the
+	// virtual sector is still tracked.
+	checkSectors := func() {
+		for i, root := range roots {
+			data, err := cmt.cm.ReadSector(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, datas[i]) {
+				t.Fatal("sector data does not match after the move")
+			}
+		}
+		cmt.cm.wal.mu.Lock()
+		sl := cmt.cm.sectorLocations[cmt.cm.managedSectorID(roots[0])]
+		cmt.cm.wal.mu.Unlock()
+		if sl.count != 2 {
+			t.Fatal("virtual sector count was lost in the move:", sl.count)
+		}
+	}
+	checkSectors()
+
+	// New sectors should be written to the new path.
+	root, data := randSector()
+	err = cmt.cm.AddSector(root, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots = append(roots, root)
+	datas = append(datas, data)
+	checkSectors()
+
+	// Restart the contract manager and check that the move persisted.
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = New(filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sfs = cmt.cm.StorageFolders()
+	if len(sfs) != 1 || sfs[0].Path != newDir {
+		t.Fatal("storage folder move did not persist:", sfs)
+	}
+	checkSectors()
+}
+
+// dependencySFMoveNoFinish is a mocked dependency that prevents a storage
+// folder move from completing, and prevents the WAL from being cleaned up.
+type dependencySFMoveNoFinish struct {
+	productionDependencies
+}
+
+// disrupt stops the storage folder move after the sectors are copied, and
+// keeps the WAL file around at shutdown.
+func (dependencySFMoveNoFinish) disrupt(s string) bool {
+	return s == "storageFolderMoveFinish" || s == "cleanWALFile"
+}
+
+// TestMoveStorageFolderUnfinished interrupts a storage folder move and checks
+// that the storage folder remains at its old path after a restart, with the
+// partial copy removed.
+func TestMoveStorageFolderUnfinished(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newMockedContractManagerTester(&dependencySFMoveNoFinish{}, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	oldDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	newDir := filepath.Join(cmt.persistDir, "storageFolderTwo")
+	for _, dir := range []string{oldDir, newDir} {
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = cmt.cm.AddStorageFolder(oldDir, modules.SectorSize*storageFolderGranularity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, data := randSector()
+	err = cmt.cm.AddSector(root, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := cmt.cm.StorageFolders()[0].Index
+
+	// The move will copy the files, but will not switch over to them.
+	err = cmt.cm.MoveStorageFolder(index, newDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := ioutil.ReadDir(newDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatal("expected a partial copy at the new path, found", len(files), "files")
+	}
+
+	// Restart the contract manager with normal dependencies.
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = New(filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sfs := cmt.cm.StorageFolders()
+	if len(sfs) != 1 || sfs[0].Path != oldDir {
+		t.Fatal("storage folder should have remained at its old path:", sfs)
+	}
+	files, err = ioutil.ReadDir(newDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Fatal("the partial copy was not removed:", len(files))
+	}
+	sectorData, err := cmt.cm.ReadSector(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sectorData, data) {
+		t.Fatal("sector data was corrupted by the interrupted move")
+	}
+
+	// A second attempt at the move should succeed.
+	err = cmt.cm.MoveStorageFolder(index, newDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sfs := cmt.cm.StorageFolders(); sfs[0].Path != newDir {
+		t.Fatal("storage folder was not moved:", sfs)
+	}
+}
@@ -91,5 +91,6 @@ func (cm *ContractManager) RemoveStorageFolder(index uint16, force bool) error {
 	syncChan = cm.wal.syncChan
 	cm.wal.mu.Unlock()
 	<-syncChan
+	cm.sectorCache.removeFolder(index)
 	return nil
 }
@@ -56,6 +56,15 @@ type (
 		UnfinishedStorageFolderAdditions  []savedStorageFolder
 		UnfinishedStorageFolderExtensions []unfinishedStorageFolderExtension
 
+		// Storage folder moves follow the same pattern as storage folder
+		// additions. An 'UnfinishedStorageFolderMove' is appended before any
+		// files are created at the new path, and a 'StorageFolderMove' is
+		// appended once the storage folder has switched to the new files. An
+		// errored move indicates that the partial copy has been removed.
+		ErroredStorageFolderMoves    []uint16
+		StorageFolderMoves           []storageFolderMove
+		UnfinishedStorageFolderMoves []storageFolderMove
+
 		// Updates to the sector metadata. Careful ordering of events ensures
 		// that a sector update will not make it into the synced WAL unless the
 		// sector data is already on-disk and synced.
@@ -180,6 +189,11 @@ func (wal *writeAheadLog) commitChange(sc stateChange) {
 			wal.commitStorageFolderReduction(sfr)
 		}
 	}
+	for _, sfm := range sc.StorageFolderMoves {
+		for i := uint64(0); i < wal.cm.dependencies.atLeastOne(); i++ {
+			wal.commitStorageFolderMove(sfm)
+		}
+	}
 	for _, sfr := range sc.StorageFolderRemovals {
 		for i := uint64(0); i < wal.cm.dependencies.atLeastOne(); i++ {
 			wal.commitStorageFolderRemoval(sfr)
@@ -248,6 +262,7 @@ func (wal *writeAheadLog) recoverWAL(walFile file) error {
 	// completed.
 	wal.cleanupUnfinishedStorageFolderAdditions(scs)
 	wal.cleanupUnfinishedStorageFolderExtensions(scs)
+	wal.cleanupUnfinishedStorageFolderMoves(scs)
 	return nil
 }
 
@@ -119,6 +119,9 @@ func (wal *writeAheadLog) syncResources() {
 		for _, sfr := range sc.StorageFolderReductions {
 			wal.commitStorageFolderReduction(sfr)
 		}
+		for _, sfm := range sc.StorageFolderMoves {
+			wal.commitStorageFolderMove(sfm)
+		}
 		for _, sfr := range sc.StorageFolderRemovals {
 			wal.commitStorageFolderRemoval(sfr)
 		}
@@ -149,6 +152,7 @@ func (wal *writeAheadLog) commit() {
 	// Extract any unfinished long-running jobs from the list of WAL items.
 	unfinishedAdditions := findUnfinishedStorageFolderAdditions(wal.uncommittedChanges)
 	unfinishedExtensions := findUnfinishedStorageFolderExtensions(wal.uncommittedChanges)
+	unfinishedMoves := findUnfinishedStorageFolderMoves(wal.uncommittedChanges)
 
 	// Clear the set of uncommitted changes.
 	wal.uncommittedChanges = nil
@@ -205,6 +209,7 @@ func (wal *writeAheadLog) commit() {
 		wal.appendChange(stateChange{
 			UnfinishedStorageFolderAdditions:  unfinishedAdditions,
 			UnfinishedStorageFolderExtensions: unfinishedExtensions,
+			UnfinishedStorageFolderMoves:      unfinishedMoves,
 		})
 	}()
 	wg.Wait()
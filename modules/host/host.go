@@ -69,6 +69,7 @@ import (
 	"net"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
@@ -116,12 +117,16 @@ var (
 type Host struct {
 	// RPC Metrics - atomic variables need to be placed at the top to preserve
 	// compatibility with 32bit systems. These values are not persistent.
+	atomicAuditCalls          uint64
 	atomicDownloadCalls       uint64
+	atomicEncryptedCalls      uint64
 	atomicErroredCalls        uint64
 	atomicFormContractCalls   uint64
 	atomicRenewCalls          uint64
 	atomicReviseCalls         uint64
 	atomicRecentRevisionCalls uint64
+	atomicSectorRootsCalls    uint64
+	atomicSessionCalls        uint64
 	atomicSettingsCalls       uint64
 	atomicUnrecognizedCalls   uint64
 
@@ -158,9 +163,25 @@ type Host struct {
 	financialMetrics     modules.HostFinancialMetrics
 	settings             modules.HostInternalSettings
 	revisionNumber       uint64
+	pricingPolicy        modules.HostPricingPolicy
+	priceAdjustments     []modules.HostPriceAdjustment
+	renterPolicy         modules.HostRenterPolicy
+	windDown             bool
+	windDownHeight       types.BlockHeight
 	workingStatus        modules.HostWorkingStatus
 	connectabilityStatus modules.HostConnectabilityStatus
 
+	// Bandwidth limits. The limiters are shared by all connections, and the
+	// usage of each renter is tracked to enforce the per-renter limits.
+	// These values are not persistent.
+	downloadLimiter bandwidthLimiter
+	uploadLimiter   bandwidthLimiter
+	renterUsage     map[string]*renterUsage
+
+	// The time of the most recent audit of each contract, used to rate limit
+	// audits. This value is not persistent.
+	lastAudits map[types.FileContractID]time.Time
+
 	// A map of storage obligations that are currently being modified. Locks on
 	// storage obligations can be long-running, and each storage obligation can
 	// be locked separately.
@@ -224,6 +245,8 @@ func newHost(dependencies dependencies, cs modules.ConsensusSet, tpool modules.T
 		dependencies: dependencies,
 
 		lockedStorageObligations: make(map[types.FileContractID]*siasync.TryMutex),
+		renterUsage:              make(map[string]*renterUsage),
+		lastAudits:               make(map[types.FileContractID]time.Time),
 
 		persistDir: persistDir,
 	}
@@ -381,6 +404,11 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 		}
 	}
 
+	// Quotas are counted per period, so a period is needed to enforce them.
+	if (settings.RenterDownloadQuota != 0 || settings.RenterUploadQuota != 0) && settings.RenterQuotaPeriod == 0 {
+		return errors.New("internal settings not updated, renter quotas require a renter quota period")
+	}
+
 	// Check if the net address for the host has changed. If it has, and it's
 	// not equal to the auto address, then the host is going to need to make
 	// another blockchain announcement.
@@ -388,8 +416,16 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 		h.announced = false
 	}
 
+	// The pricing policy overrides the storage price and the collateral, so
+	// values set by the operator will not last while it is enabled.
+	if h.pricingPolicy.Enabled && (!settings.MinStoragePrice.Equals(h.settings.MinStoragePrice) || !settings.Collateral.Equals(h.settings.Collateral)) {
+		h.log.Println("WARN: the storage price and collateral are managed by the pricing policy, and will be overridden at the next block")
+	}
+
 	h.settings = settings
 	h.revisionNumber++
+	h.updateBandwidthLimits()
+	h.StorageManager.SetSectorCacheSize(settings.SectorCacheSize)
 
 	err = h.saveSync()
 	if err != nil {
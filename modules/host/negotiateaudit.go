@@ -0,0 +1,122 @@
+package host
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/host/contractmanager"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errAuditOutOfBounds is returned if the renter requests a segment that
+	// is not within a sector.
+	errAuditOutOfBounds = ErrorCommunication("audit requested a segment outside of the sector")
+
+	// errAuditReadFailed is returned if the host cannot read the audited
+	// sector for a reason other than not storing it, such as an unavailable
+	// storage folder.
+	errAuditReadFailed = errors.New("host could not read the audited sector")
+
+	// errAuditRateLimited is returned if the renter audits a contract again
+	// within modules.MinAuditInterval.
+	errAuditRateLimited = ErrorCommunication(modules.ErrAuditRateLimited.Error())
+
+	// errAuditUnknownSector is returned if the renter audits a sector that is
+	// not covered by the contract.
+	errAuditUnknownSector = ErrorCommunication(modules.ErrAuditUnknownSector.Error())
+)
+
+// managedAllowAudit reports whether the contract with the given ID may be
+// audited, and if so records the time of the audit. Records of audits that
+// no longer limit anything are discarded.
+func (h *Host) managedAllowAudit(id types.FileContractID) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	if last, exists := h.lastAudits[id]; exists && now.Sub(last) < modules.MinAuditInterval {
+		return false
+	}
+	for fcid, last := range h.lastAudits {
+		if now.Sub(last) >= modules.MinAuditInterval {
+			delete(h.lastAudits, fcid)
+		}
+	}
+	h.lastAudits[id] = now
+	return true
+}
+
+// managedRPCAudit proves to the renter that the host is storing a sector of
+// one of the renter's contracts. After the recent revision protocol, the
+// renter requests a segment of a sector, and the host responds with the
+// segment and a Merkle proof that the segment belongs to the sector.
+func (h *Host) managedRPCAudit(conn net.Conn) error {
+	// Perform the recent revision protocol to get the file contract being
+	// audited. The storage obligation is returned under lock.
+	fcid, so, release, err := h.managedRPCRecentRevision(conn)
+	if err != nil {
+		return extendErr("failed RPCRecentRevision during RPCAudit: ", err)
+	}
+	defer release()
+
+	// Reading the sector can take a while on a busy host; allow as much time
+	// as a download.
+	conn.SetDeadline(time.Now().Add(modules.NegotiateDownloadTime))
+	var req modules.AuditRequest
+	err = encoding.ReadObject(conn, &req, modules.NegotiateMaxAuditRequestSize)
+	if err != nil {
+		return extendErr("failed to read audit request: ", ErrorConnection(err.Error()))
+	}
+
+	// Check that the request can be answered.
+	err = func() error {
+		if !h.managedAllowAudit(fcid) {
+			return errAuditRateLimited
+		}
+		if req.SegmentIndex >= modules.SectorSize/crypto.SegmentSize {
+			return errAuditOutOfBounds
+		}
+		for _, root := range so.SectorRoots {
+			if root == req.MerkleRoot {
+				return nil
+			}
+		}
+		return errAuditUnknownSector
+	}()
+	if err != nil {
+		modules.WriteNegotiationRejection(conn, err) // Error is ignored so that the error type can be preserved in extendErr.
+		return extendErr("rejected audit request: ", err)
+	}
+	// Only a sector that the host does not have is reported to the renter as
+	// missing. Other read errors, such as a storage folder that is
+	// unavailable, may be temporary, and are not an admission that the data
+	// is lost.
+	sector, err := h.ReadSector(req.MerkleRoot)
+	if err == contractmanager.ErrSectorNotFound {
+		modules.WriteNegotiationRejection(conn, modules.ErrAuditMissingSector) // Error is ignored so that the error type can be preserved in extendErr.
+		return extendErr("audited sector is missing: ", ErrorInternal(err.Error()))
+	} else if err != nil {
+		modules.WriteNegotiationRejection(conn, errAuditReadFailed) // Error is ignored so that the error type can be preserved in extendErr.
+		return extendErr("failed to read audited sector: ", ErrorInternal(err.Error()))
+	}
+	base, hashSet := crypto.MerkleProof(sector, req.SegmentIndex)
+
+	// Send the segment and the proof.
+	err = modules.WriteNegotiationAcceptance(conn)
+	if err != nil {
+		return extendErr("failed to accept audit request: ", ErrorConnection(err.Error()))
+	}
+	err = encoding.WriteObject(conn, base)
+	if err != nil {
+		return extendErr("failed to write audited segment: ", ErrorConnection(err.Error()))
+	}
+	err = encoding.WriteObject(conn, hashSet)
+	if err != nil {
+		return extendErr("failed to write audit proof: ", ErrorConnection(err.Error()))
+	}
+	return nil
+}
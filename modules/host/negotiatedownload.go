@@ -5,6 +5,7 @@ import (
 	"net"
 	"time"
 
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
@@ -19,11 +20,17 @@ var (
 	// errRequestOutOfBounds is returned when a download request is made which
 	// asks for elements of a sector which do not exist.
 	errRequestOutOfBounds = ErrorCommunication("download request has invalid sector bounds")
+
+	// errRequestUnaligned is returned when a range download request is made
+	// which does not start and end on a segment boundary, meaning that no
+	// Merkle proof can be provided for it.
+	errRequestUnaligned = ErrorCommunication("range download request is not aligned to segment boundaries")
 )
 
 // managedDownloadIteration is responsible for managing a single iteration of
-// the download loop for RPCDownload.
-func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) error {
+// the download loop for RPCDownload. If rangeProofs is set, each range of data
+// is followed by a Merkle proof that the range is a part of its sector.
+func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation, rangeProofs bool) error {
 	// Exchange settings with the renter.
 	err := h.managedRPCSettings(conn)
 	if err != nil {
@@ -65,6 +72,7 @@ func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) er
 	// for the renter.
 	existingRevision := so.RevisionTransactionSet[len(so.RevisionTransactionSet)-1].FileContractRevisions[0]
 	var payload [][]byte
+	var proofs [][]crypto.Hash
 	err = func() error {
 		// Check that the length of each file is in-bounds, and that the total
 		// size being requested is acceptable.
@@ -73,6 +81,9 @@ func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) er
 			if request.Length > modules.SectorSize || request.Offset+request.Length > modules.SectorSize {
 				return extendErr("download iteration request failed: ", errRequestOutOfBounds)
 			}
+			if rangeProofs && (request.Length == 0 || request.Offset%crypto.SegmentSize != 0 || request.Length%crypto.SegmentSize != 0) {
+				return extendErr("download iteration request failed: ", errRequestUnaligned)
+			}
 			totalSize += request.Length
 		}
 		if totalSize > settings.MaxDownloadBatchSize {
@@ -87,6 +98,13 @@ func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) er
 			return extendErr("payment verification failed: ", err)
 		}
 
+		// Only downloads that have been paid for count towards the renter's
+		// quota.
+		err = h.managedSpendRenterQuota(*so, totalSize, 0)
+		if err != nil {
+			return extendErr("download iteration refused: ", err)
+		}
+
 		// Load the sectors and build the data payload.
 		for _, request := range requests {
 			sectorData, err := h.ReadSector(request.MerkleRoot)
@@ -94,6 +112,11 @@ func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) er
 				return extendErr("failed to load sector: ", ErrorInternal(err.Error()))
 			}
 			payload = append(payload, sectorData[request.Offset:request.Offset+request.Length])
+			if rangeProofs {
+				start := request.Offset / crypto.SegmentSize
+				end := (request.Offset + request.Length) / crypto.SegmentSize
+				proofs = append(proofs, crypto.MerkleRangeProof(sectorData, start, end))
+			}
 		}
 		return nil
 	}()
@@ -142,6 +165,12 @@ func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) er
 	if err != nil {
 		return extendErr("failed to write payload: ", ErrorConnection(err.Error()))
 	}
+	if rangeProofs {
+		err = encoding.WriteObject(conn, proofs)
+		if err != nil {
+			return extendErr("failed to write range proofs: ", ErrorConnection(err.Error()))
+		}
+	}
 	return nil
 }
 
@@ -230,27 +259,26 @@ func verifyPaymentRevision(existingRevision, paymentRevision types.FileContractR
 }
 
 // managedRPCDownload is responsible for handling an RPC request from the
-// renter to download data.
-func (h *Host) managedRPCDownload(conn net.Conn) error {
+// renter to download data. If rangeProofs is set, the data is accompanied by
+// Merkle range proofs, as requested by RPCDownloadRange.
+func (h *Host) managedRPCDownload(conn net.Conn, rangeProofs bool) error {
 	// Get the start time to limit the length of the whole connection.
 	startTime := time.Now()
 	// Perform the file contract revision exchange, giving the renter the most
 	// recent file contract revision and getting the storage obligation that
 	// will be used to pay for the data.
-	_, so, err := h.managedRPCRecentRevision(conn)
+	_, so, release, err := h.managedRPCRecentRevision(conn)
 	if err != nil {
 		return extendErr("failed RPCRecentRevision during RPCDownload: ", err)
 	}
 	// The storage obligation is returned with a lock on it. Defer a call to
 	// unlock the storage obligation.
-	defer func() {
-		h.managedUnlockStorageObligation(so.id())
-	}()
+	defer release()
 
 	// Perform a loop that will allow downloads to happen until the maximum
 	// time for a single connection has been reached.
 	for time.Now().Before(startTime.Add(iteratedConnectionTime)) {
-		err := h.managedDownloadIteration(conn, &so)
+		err := h.managedDownloadIteration(conn, &so, rangeProofs)
 		if err == modules.ErrStopResponse {
 			// The renter has indicated that it has finished downloading the
 			// data, therefore there is no error. Return nil.
@@ -75,8 +75,9 @@ func (h *Host) managedRPCFormContract(conn net.Conn) error {
 	// understand that the connection is going to be closed.
 	h.mu.RLock()
 	settings := h.settings
+	windDown := h.windDown
 	h.mu.RUnlock()
-	if !settings.AcceptingContracts {
+	if !settings.AcceptingContracts || windDown {
 		h.log.Debugln("Turning down contract because the host is not accepting contracts.")
 		return nil
 	}
@@ -115,6 +116,13 @@ func (h *Host) managedRPCFormContract(conn net.Conn) error {
 		modules.WriteNegotiationRejection(conn, err) // Error ignored to preserve type in extendErr
 		return extendErr("contract verification failed: ", err)
 	}
+	// The renter's public key is now known to be the one in the unlock
+	// conditions of the contract. Check that the host accepts the renter.
+	err = h.managedCheckRenterPolicy(types.Ed25519PublicKey(renterPK))
+	if err != nil {
+		modules.WriteNegotiationRejection(conn, err) // Error ignored to preserve type in extendErr
+		return extendErr("renter refused: ", err)
+	}
 	// The host adds collateral to the transaction.
 	txnBuilder, newParents, newInputs, newOutputs, err := h.managedAddCollateral(settings, txnSet)
 	if err != nil {
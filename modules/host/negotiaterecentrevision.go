@@ -90,8 +90,11 @@ func (h *Host) managedVerifyChallengeResponse(fcid types.FileContractID, challen
 // revision, including signatures, to the renter, for the file contract with
 // the id given by the renter.
 //
-// The storage obligation is returned under a storage obligation lock.
-func (h *Host) managedRPCRecentRevision(conn net.Conn) (types.FileContractID, storageObligation, error) {
+// The storage obligation is returned under a storage obligation lock, and
+// counts as an open connection from the renter. Both are held until the
+// returned release function is called, which every caller must do once it
+// is finished with the storage obligation.
+func (h *Host) managedRPCRecentRevision(conn net.Conn) (types.FileContractID, storageObligation, func(), error) {
 	// Set the negotiation deadline.
 	conn.SetDeadline(time.Now().Add(modules.NegotiateRecentRevisionTime))
 
@@ -99,7 +102,7 @@ func (h *Host) managedRPCRecentRevision(conn net.Conn) (types.FileContractID, st
 	var fcid types.FileContractID
 	err := encoding.ReadObject(conn, &fcid, uint64(len(fcid)))
 	if err != nil {
-		return types.FileContractID{}, storageObligation{}, extendErr("could not read file contract id: ", ErrorConnection(err.Error()))
+		return types.FileContractID{}, storageObligation{}, nil, extendErr("could not read file contract id: ", ErrorConnection(err.Error()))
 	}
 
 	// Send a challenge to the renter to verify that the renter has write
@@ -108,21 +111,21 @@ func (h *Host) managedRPCRecentRevision(conn net.Conn) (types.FileContractID, st
 	fastrand.Read(challenge[16:])
 	err = encoding.WriteObject(conn, challenge)
 	if err != nil {
-		return types.FileContractID{}, storageObligation{}, extendErr("cound not write challenge: ", ErrorConnection(err.Error()))
+		return types.FileContractID{}, storageObligation{}, nil, extendErr("cound not write challenge: ", ErrorConnection(err.Error()))
 	}
 
 	// Read the signed response from the renter.
 	var challengeResponse crypto.Signature
 	err = encoding.ReadObject(conn, &challengeResponse, uint64(len(challengeResponse)))
 	if err != nil {
-		return types.FileContractID{}, storageObligation{}, extendErr("could not read challenge response: ", ErrorConnection(err.Error()))
+		return types.FileContractID{}, storageObligation{}, nil, extendErr("could not read challenge response: ", ErrorConnection(err.Error()))
 	}
 	// Verify the response. In the process, fetch the related storage
 	// obligation, file contract revision, and transaction signatures.
 	so, recentRevision, revisionSigs, err := h.managedVerifyChallengeResponse(fcid, challenge, challengeResponse)
 	if err != nil {
 		modules.WriteNegotiationRejection(conn, err) // Error not reported to preserve error type in extendErr.
-		return types.FileContractID{}, storageObligation{}, extendErr("challenge failed: ", err)
+		return types.FileContractID{}, storageObligation{}, nil, extendErr("challenge failed: ", err)
 	}
 	// Defer a call to unlock the storage obligation in the event of an error.
 	defer func() {
@@ -131,22 +134,38 @@ func (h *Host) managedRPCRecentRevision(conn net.Conn) (types.FileContractID, st
 		}
 	}()
 
+	// Refuse the renter if it already has too many connections open.
+	err = h.managedAddRenterConn(so)
+	if err != nil {
+		modules.WriteNegotiationRejection(conn, err) // Error not reported to preserve error type in extendErr.
+		return types.FileContractID{}, storageObligation{}, nil, extendErr("renter refused: ", err)
+	}
+	defer func() {
+		if err != nil {
+			h.managedRemoveRenterConn(so)
+		}
+	}()
+
 	// Send the file contract revision and the corresponding signatures to the
 	// renter.
 	err = modules.WriteNegotiationAcceptance(conn)
 	if err != nil {
 		err = extendErr("failed to write challenge acceptance: ", ErrorConnection(err.Error()))
-		return types.FileContractID{}, storageObligation{}, err
+		return types.FileContractID{}, storageObligation{}, nil, err
 	}
 	err = encoding.WriteObject(conn, recentRevision)
 	if err != nil {
 		err = extendErr("failed to write recent revision: ", ErrorConnection(err.Error()))
-		return types.FileContractID{}, storageObligation{}, err
+		return types.FileContractID{}, storageObligation{}, nil, err
 	}
 	err = encoding.WriteObject(conn, revisionSigs)
 	if err != nil {
 		err = extendErr("failed to write recent revision signatures: ", ErrorConnection(err.Error()))
-		return types.FileContractID{}, storageObligation{}, err
+		return types.FileContractID{}, storageObligation{}, nil, err
+	}
+	release := func() {
+		h.managedRemoveRenterConn(so)
+		h.managedUnlockStorageObligation(fcid)
 	}
-	return fcid, so, nil
+	return fcid, so, release, nil
 }
@@ -77,15 +77,13 @@ func (h *Host) managedAddRenewCollateral(so storageObligation, settings modules.
 func (h *Host) managedRPCRenewContract(conn net.Conn) error {
 	// Perform the recent revision protocol to get the file contract being
 	// revised.
-	_, so, err := h.managedRPCRecentRevision(conn)
+	_, so, release, err := h.managedRPCRecentRevision(conn)
 	if err != nil {
 		return extendErr("RPCRecentRevision failed: ", err)
 	}
 	// The storage obligation is received with a lock. Defer a call to unlock
 	// the storage obligation.
-	defer func() {
-		h.managedUnlockStorageObligation(so.id())
-	}()
+	defer release()
 
 	// Perform the host settings exchange with the renter.
 	err = h.managedRPCSettings(conn)
@@ -120,14 +118,28 @@ func (h *Host) managedRPCRenewContract(conn net.Conn) error {
 
 	h.mu.RLock()
 	settings := h.externalSettings()
+	windDown := h.windDown
 	h.mu.RUnlock()
 
+	// A host that is winding down does not extend its existing contracts.
+	if windDown {
+		modules.WriteNegotiationRejection(conn, errWindingDown) // Error ignored to preserve type in extendErr
+		return extendErr("renewal refused: ", errWindingDown)
+	}
+
 	// Verify that the transaction coming over the wire is a proper renewal.
 	err = h.managedVerifyRenewedContract(so, txnSet, renterPK)
 	if err != nil {
 		modules.WriteNegotiationRejection(conn, err) // Error is ignored to preserve type for extendErr
 		return extendErr("verification of renewal failed: ", err)
 	}
+	// Check that the host still accepts the renter whose key is in the
+	// unlock conditions of the renewed contract.
+	err = h.managedCheckRenterPolicy(types.Ed25519PublicKey(renterPK))
+	if err != nil {
+		modules.WriteNegotiationRejection(conn, err) // Error is ignored to preserve type for extendErr
+		return extendErr("renter refused: ", err)
+	}
 	txnBuilder, newParents, newInputs, newOutputs, err := h.managedAddRenewCollateral(so, settings, txnSet)
 	if err != nil {
 		modules.WriteNegotiationRejection(conn, err) // Error is ignored to preserve type for extendErr
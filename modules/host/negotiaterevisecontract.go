@@ -65,6 +65,7 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 	var sectorsRemoved []crypto.Hash
 	var sectorsGained []crypto.Hash
 	var gainedSectorData [][]byte
+	var uploadedBytes uint64
 	err = func() error {
 		for _, modification := range modifications {
 			// Check that the index points to an existing sector root. If the type
@@ -80,6 +81,7 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 			if uint64(len(modification.Data)) > modules.SectorSize {
 				return errLargeSector
 			}
+			uploadedBytes += uint64(len(modification.Data))
 
 			switch modification.Type {
 			case modules.ActionDelete:
@@ -136,7 +138,11 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 			}
 		}
 		newRevenue := storageRevenue.Add(bandwidthRevenue)
-		return extendErr("unable to verify updated contract: ", verifyRevision(*so, revision, blockHeight, newRevenue, newCollateral))
+		err := verifyRevision(*so, revision, blockHeight, newRevenue, newCollateral)
+		if err != nil {
+			return extendErr("unable to verify updated contract: ", err)
+		}
+		return extendErr("revision refused: ", h.managedSpendRenterQuota(*so, 0, uploadedBytes))
 	}()
 	if err != nil {
 		modules.WriteNegotiationRejection(conn, err) // Error is ignored so that the error type can be preserved in extendErr.
@@ -200,15 +206,13 @@ func (h *Host) managedRPCReviseContract(conn net.Conn) error {
 	// Perform the file contract revision exchange, giving the renter the most
 	// recent file contract revision and getting the storage obligation that
 	// will be used to pay for the data.
-	_, so, err := h.managedRPCRecentRevision(conn)
+	_, so, release, err := h.managedRPCRecentRevision(conn)
 	if err != nil {
 		return extendErr("RPCRecentRevision failed: ", err)
 	}
 	// The storage obligation is received with a lock on it. Defer a call to
 	// unlock the storage obligation.
-	defer func() {
-		h.managedUnlockStorageObligation(so.id())
-	}()
+	defer release()
 
 	// Begin the revision loop. The host will process revisions until a
 	// timeout is reached, or until the renter sends a StopResponse.
@@ -0,0 +1,31 @@
+package host
+
+import (
+	"net"
+	"time"
+
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// managedRPCSectorRoots sends the most recent file contract revision,
+// including signatures, to the renter, followed by the Merkle roots of every
+// sector in the storage obligation. Renters use the roots to recover from a
+// desynchronized contract.
+func (h *Host) managedRPCSectorRoots(conn net.Conn) error {
+	// Perform the recent revision protocol to get the file contract being
+	// queried. The storage obligation is returned under lock.
+	_, so, release, err := h.managedRPCRecentRevision(conn)
+	if err != nil {
+		return extendErr("failed RPCRecentRevision during RPCSectorRoots: ", err)
+	}
+	defer release()
+
+	// The set of roots can be large; allow as much time as a download.
+	conn.SetDeadline(time.Now().Add(modules.NegotiateDownloadTime))
+	err = encoding.WriteObject(conn, so.SectorRoots)
+	if err != nil {
+		return extendErr("failed to write sector roots: ", ErrorConnection(err.Error()))
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package host
+
+import (
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errUnknownSessionOp is returned if the renter requests an operation
+	// that cannot be performed as part of a session.
+	errUnknownSessionOp = ErrorCommunication("renter requested an unknown session operation")
+)
+
+// managedSessionOp reads the specifier of the next operation in a session
+// and performs the operation. io.EOF is returned if the renter has closed the
+// session.
+func (h *Host) managedSessionOp(conn net.Conn, so *storageObligation, expired bool) error {
+	var op types.Specifier
+	err := encoding.ReadObject(conn, &op, uint64(len(op)))
+	if err == io.EOF {
+		return err
+	} else if err != nil {
+		return extendErr("could not read session operation: ", ErrorConnection(err.Error()))
+	}
+
+	// Once the session has been open for too long, the storage obligation is
+	// released and the renter has to open a new session.
+	if expired {
+		return modules.WriteNegotiationStop(conn)
+	}
+	switch op {
+	case modules.RPCSettings, modules.RPCReviseContract, modules.RPCDownload, modules.RPCDownloadRange:
+	default:
+		modules.WriteNegotiationRejection(conn, errUnknownSessionOp) // Error is ignored so that the error type can be preserved in extendErr.
+		return extendErr("session operation rejected: ", errUnknownSessionOp)
+	}
+	err = modules.WriteNegotiationAcceptance(conn)
+	if err != nil {
+		return extendErr("could not accept session operation: ", ErrorConnection(err.Error()))
+	}
+
+	switch op {
+	case modules.RPCSettings:
+		atomic.AddUint64(&h.atomicSettingsCalls, 1)
+		err = extendErr("RPCSettings failed: ", h.managedRPCSettings(conn))
+	case modules.RPCReviseContract:
+		atomic.AddUint64(&h.atomicReviseCalls, 1)
+		err = h.managedRevisionIteration(conn, so, false)
+	case modules.RPCDownload:
+		atomic.AddUint64(&h.atomicDownloadCalls, 1)
+		err = h.managedDownloadIteration(conn, so, false)
+	case modules.RPCDownloadRange:
+		atomic.AddUint64(&h.atomicDownloadCalls, 1)
+		err = h.managedDownloadIteration(conn, so, true)
+	}
+	// A stop response means that the renter rejected the host's settings and
+	// abandoned the operation. The session remains open.
+	if err == modules.ErrStopResponse {
+		return nil
+	}
+	return err
+}
+
+// managedRPCSession handles a session with the renter. The renter is
+// authenticated once using the recent revision protocol, after which any
+// number of settings, revision and download operations may be performed on
+// the contract without reopening the connection.
+func (h *Host) managedRPCSession(conn net.Conn) error {
+	startTime := time.Now()
+
+	// Acknowledge the session, so that the renter knows that the host
+	// supports sessions.
+	err := modules.WriteNegotiationAcceptance(conn)
+	if err != nil {
+		return extendErr("could not accept session: ", ErrorConnection(err.Error()))
+	}
+
+	// Perform the file contract revision exchange, authenticating the renter
+	// and getting the storage obligation that the session operates on.
+	_, so, release, err := h.managedRPCRecentRevision(conn)
+	if err != nil {
+		return extendErr("RPCRecentRevision failed: ", err)
+	}
+	// The storage obligation is received with a lock on it. Defer a call to
+	// unlock the storage obligation.
+	defer release()
+
+	// Process operations until the renter closes the session. The session is
+	// limited to the same length of time as the iterated RPCs.
+	for {
+		expired := time.Since(startTime) > iteratedConnectionTime
+		conn.SetDeadline(time.Now().Add(sessionIdleTime))
+		err := h.managedSessionOp(conn, &so, expired)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return extendErr("session operation failed: ", err)
+		} else if expired {
+			return nil
+		}
+	}
+}
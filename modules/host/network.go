@@ -261,6 +261,9 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 		conn.Close()
 	}()
 
+	// Throttle the connection to the host's bandwidth limits.
+	conn = h.limitConn(conn)
+
 	// Set an initial duration that is generous, but finite. RPCs can extend
 	// this if desired.
 	err = conn.SetDeadline(time.Now().Add(5 * time.Minute))
@@ -277,10 +280,38 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 		return
 	}
 
+	// If the renter requested an encrypted connection, perform the handshake
+	// and read the specifier of the RPC from the encrypted connection.
+	// Renters that do not support encryption call the RPC directly.
+	if id == modules.RPCEncryptedConn {
+		atomic.AddUint64(&h.atomicEncryptedCalls, 1)
+		h.mu.RLock()
+		secretKey := h.secretKey
+		h.mu.RUnlock()
+		encryptedConn, err := modules.EncryptHostConn(conn, secretKey)
+		if err != nil {
+			atomic.AddUint64(&h.atomicErroredCalls, 1)
+			h.log.Debugf("WARN: incoming conn %v failed to encrypt: %v", conn.RemoteAddr(), err)
+			return
+		}
+		conn = encryptedConn
+		if err := encoding.ReadObject(conn, &id, 16); err != nil {
+			atomic.AddUint64(&h.atomicUnrecognizedCalls, 1)
+			h.log.Debugf("WARN: incoming encrypted conn %v was malformed: %v", conn.RemoteAddr(), err)
+			return
+		}
+	}
+
 	switch id {
+	case modules.RPCAudit:
+		atomic.AddUint64(&h.atomicAuditCalls, 1)
+		err = extendErr("incoming RPCAudit failed: ", h.managedRPCAudit(conn))
 	case modules.RPCDownload:
 		atomic.AddUint64(&h.atomicDownloadCalls, 1)
-		err = extendErr("incoming RPCDownload failed: ", h.managedRPCDownload(conn))
+		err = extendErr("incoming RPCDownload failed: ", h.managedRPCDownload(conn, false))
+	case modules.RPCDownloadRange:
+		atomic.AddUint64(&h.atomicDownloadCalls, 1)
+		err = extendErr("incoming RPCDownloadRange failed: ", h.managedRPCDownload(conn, true))
 	case modules.RPCRenewContract:
 		atomic.AddUint64(&h.atomicRenewCalls, 1)
 		err = extendErr("incoming RPCRenewContract failed: ", h.managedRPCRenewContract(conn))
@@ -292,14 +323,20 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 		err = extendErr("incoming RPCReviseContract failed: ", h.managedRPCReviseContract(conn))
 	case modules.RPCRecentRevision:
 		atomic.AddUint64(&h.atomicRecentRevisionCalls, 1)
-		var so storageObligation
-		_, so, err = h.managedRPCRecentRevision(conn)
+		var release func()
+		_, _, release, err = h.managedRPCRecentRevision(conn)
 		err = extendErr("incoming RPCRecentRevision failed: ", err)
-		if err != nil {
+		if err == nil {
 			// The unlock can be called immediately, as no action is taken with
 			// the storage obligation that gets returned.
-			h.managedUnlockStorageObligation(so.id())
+			release()
 		}
+	case modules.RPCSectorRoots:
+		atomic.AddUint64(&h.atomicSectorRootsCalls, 1)
+		err = extendErr("incoming RPCSectorRoots failed: ", h.managedRPCSectorRoots(conn))
+	case modules.RPCSession:
+		atomic.AddUint64(&h.atomicSessionCalls, 1)
+		err = extendErr("incoming RPCSession failed: ", h.managedRPCSession(conn))
 	case modules.RPCSettings:
 		atomic.AddUint64(&h.atomicSettingsCalls, 1)
 		err = extendErr("incoming RPCSettings failed: ", h.managedRPCSettings(conn))
@@ -357,10 +394,12 @@ func (h *Host) NetworkMetrics() modules.HostNetworkMetrics {
 	defer h.mu.RUnlock()
 	return modules.HostNetworkMetrics{
 		DownloadCalls:     atomic.LoadUint64(&h.atomicDownloadCalls),
+		EncryptedCalls:    atomic.LoadUint64(&h.atomicEncryptedCalls),
 		ErrorCalls:        atomic.LoadUint64(&h.atomicErroredCalls),
 		FormContractCalls: atomic.LoadUint64(&h.atomicFormContractCalls),
 		RenewCalls:        atomic.LoadUint64(&h.atomicRenewCalls),
 		ReviseCalls:       atomic.LoadUint64(&h.atomicReviseCalls),
+		SessionCalls:      atomic.LoadUint64(&h.atomicSessionCalls),
 		SettingsCalls:     atomic.LoadUint64(&h.atomicSettingsCalls),
 		UnrecognizedCalls: atomic.LoadUint64(&h.atomicUnrecognizedCalls),
 	}
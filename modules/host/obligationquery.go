@@ -0,0 +1,189 @@
+package host
+
+// obligationquery.go lets the host's storage obligations be browsed a page at
+// a time. Large hosts hold many thousands of obligations, so the obligations
+// are filtered and summarized as they are read from the database, and only
+// the requested page is returned.
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+var (
+	// errBadObligationPage is returned if a storage obligation query has a
+	// negative offset or limit.
+	errBadObligationPage = errors.New("offset and limit must not be negative")
+
+	// errUnknownObligationSort is returned if a storage obligation query
+	// asks for obligations to be sorted by an unsupported field.
+	errUnknownObligationSort = errors.New("storage obligations can only be sorted by expiration or value")
+
+	// errUnknownObligationStatus is returned if a storage obligation query
+	// filters by an unknown status.
+	errUnknownObligationStatus = errors.New("storage obligation status must be unresolved, rejected, succeeded or failed")
+)
+
+// obligationSortFuncs are the fields that storage obligations can be sorted
+// by, in ascending order.
+var obligationSortFuncs = map[string]func(a, b modules.StorageObligation) bool{
+	"expiration": func(a, b modules.StorageObligation) bool {
+		return a.ExpirationHeight < b.ExpirationHeight
+	},
+	"value": func(a, b modules.StorageObligation) bool {
+		return a.Value.Cmp(b.Value) < 0
+	},
+}
+
+// renter returns the public key string of the renter of the storage
+// obligation, or the empty string if the obligation has no revision.
+func (so storageObligation) renter() string {
+	if len(so.RevisionTransactionSet) == 0 {
+		return ""
+	}
+	return renterKey(so)
+}
+
+// summary returns the metadata of the storage obligation that is reported by
+// the host at the given height.
+func (so storageObligation) summary(blockHeight types.BlockHeight) modules.StorageObligation {
+	return modules.StorageObligation{
+		ObligationID:     so.id(),
+		Renter:           so.renter(),
+		DataSize:         so.fileSize(),
+		ExpirationHeight: so.expiration(),
+		ProofDeadline:    so.proofDeadline(),
+		Value:            so.value(),
+
+		NegotiationHeight: so.NegotiationHeight,
+
+		OriginConfirmed:     so.OriginConfirmed,
+		RevisionConstructed: so.RevisionConstructed,
+		RevisionConfirmed:   so.RevisionConfirmed,
+		ProofConstructed:    so.ProofConstructed,
+		ProofConfirmed:      so.ProofConfirmed,
+		ObligationStatus:    uint64(so.ObligationStatus),
+
+		ProofState:       so.proofState(blockHeight),
+		ProofSubmissions: so.ProofSubmissions,
+	}
+}
+
+// QueryStorageObligations returns the page of storage obligations selected by
+// the query, along with the number of obligations that matched the query's
+// filters.
+func (h *Host) QueryStorageObligations(q modules.StorageObligationQuery) ([]modules.StorageObligation, int, error) {
+	switch q.Status {
+	case "", obligationUnresolved.String(), obligationRejected.String(), obligationSucceeded.String(), obligationFailed.String():
+	default:
+		return nil, 0, errUnknownObligationStatus
+	}
+	less, sorted := obligationSortFuncs[q.SortBy]
+	if q.SortBy != "" && !sorted {
+		return nil, 0, errUnknownObligationSort
+	}
+	if q.Offset < 0 || q.Limit < 0 {
+		return nil, 0, errBadObligationPage
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	// Obligations are read in order of their id, which keeps the pages
+	// consistent between requests when the obligations are not sorted.
+	var matches []modules.StorageObligation
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			err := json.Unmarshal(soBytes, &so)
+			if err != nil {
+				return build.ExtendErr("unable to unmarshal storage obligation:", err)
+			}
+			switch {
+			case q.Status != "" && so.ObligationStatus.String() != q.Status:
+				return nil
+			case so.expiration() < q.MinExpiration:
+				return nil
+			case q.MaxExpiration != 0 && so.expiration() > q.MaxExpiration:
+				return nil
+			case q.Renter != "" && so.renter() != q.Renter:
+				return nil
+			}
+			matches = append(matches, so.summary(h.blockHeight))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, 0, build.ExtendErr("database failed to provide storage obligations:", err)
+	}
+
+	if sorted {
+		sort.SliceStable(matches, func(i, j int) bool {
+			if q.Descending {
+				return less(matches[j], matches[i])
+			}
+			return less(matches[i], matches[j])
+		})
+	}
+	total := len(matches)
+	if q.Offset >= len(matches) {
+		return nil, total, nil
+	}
+	matches = matches[q.Offset:]
+	if q.Limit > 0 && q.Limit < len(matches) {
+		matches = matches[:q.Limit]
+	}
+	return matches, total, nil
+}
+
+// StorageObligation returns the details of a storage obligation.
+func (h *Host) StorageObligation(id types.FileContractID) (modules.StorageObligationDetails, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var so storageObligation
+	err := h.db.View(func(tx *bolt.Tx) error {
+		var err error
+		so, err = getStorageObligation(tx, id)
+		return err
+	})
+	if err != nil {
+		return modules.StorageObligationDetails{}, err
+	}
+
+	var revisionNumber uint64
+	if len(so.RevisionTransactionSet) > 0 {
+		revisionNumber = so.RevisionTransactionSet[len(so.RevisionTransactionSet)-1].FileContractRevisions[0].NewRevisionNumber
+	} else {
+		revisionNumber = so.OriginTransactionSet[len(so.OriginTransactionSet)-1].FileContracts[0].RevisionNumber
+	}
+	valid, missed := so.payouts()
+	return modules.StorageObligationDetails{
+		StorageObligation: so.summary(h.blockHeight),
+
+		SectorCount:    uint64(len(so.SectorRoots)),
+		RevisionNumber: revisionNumber,
+		FileMerkleRoot: so.merkleRoot(),
+
+		ContractCost:             so.ContractCost,
+		LockedCollateral:         so.LockedCollateral,
+		PotentialStorageRevenue:  so.PotentialStorageRevenue,
+		PotentialDownloadRevenue: so.PotentialDownloadRevenue,
+		PotentialUploadRevenue:   so.PotentialUploadRevenue,
+		RiskedCollateral:         so.RiskedCollateral,
+		TransactionFeesAdded:     so.TransactionFeesAdded,
+
+		ValidProofOutputs:  valid,
+		MissedProofOutputs: missed,
+
+		OriginTransactionSet:   so.OriginTransactionSet,
+		RevisionTransactionSet: so.RevisionTransactionSet,
+	}, nil
+}
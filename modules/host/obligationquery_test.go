@@ -0,0 +1,130 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// TestQueryStorageObligations checks that the host's storage obligations can
+// be filtered, sorted and paginated.
+func TestQueryStorageObligations(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+	h := ht.host
+
+	// Add obligations from two renters, expiring at different heights and
+	// with different values.
+	obligations := []struct {
+		renter      byte
+		expiration  types.BlockHeight
+		value       uint64
+		status      storageObligationStatus
+		sectorCount int
+	}{
+		{1, 100, 30, obligationUnresolved, 2},
+		{1, 200, 10, obligationSucceeded, 0},
+		{2, 300, 20, obligationUnresolved, 1},
+		{2, 400, 40, obligationFailed, 0},
+	}
+	ids := make(map[types.BlockHeight]types.FileContractID)
+	err = h.db.Update(func(tx *bolt.Tx) error {
+		for _, o := range obligations {
+			so := quotaTestObligation(o.renter)
+			so.OriginTransactionSet = []types.Transaction{{
+				FileContracts: []types.FileContract{{WindowStart: o.expiration}},
+			}}
+			revision := &so.RevisionTransactionSet[0].FileContractRevisions[0]
+			revision.NewWindowStart = o.expiration
+			revision.NewWindowEnd = o.expiration + 10
+			revision.NewRevisionNumber = 5
+			so.ContractCost = types.NewCurrency64(o.value)
+			so.ObligationStatus = o.status
+			so.SectorRoots = make([]crypto.Hash, o.sectorCount)
+			ids[o.expiration] = so.id()
+			if err := putStorageObligation(tx, so); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// expirations returns the expiration heights of the obligations.
+	expirations := func(sos []modules.StorageObligation) (heights []types.BlockHeight) {
+		for _, so := range sos {
+			heights = append(heights, so.ExpirationHeight)
+		}
+		return heights
+	}
+	renter2 := quotaTestObligation(2).renter()
+	tests := []struct {
+		query    modules.StorageObligationQuery
+		expected []types.BlockHeight
+		total    int
+	}{
+		{modules.StorageObligationQuery{SortBy: "expiration"}, []types.BlockHeight{100, 200, 300, 400}, 4},
+		{modules.StorageObligationQuery{SortBy: "expiration", Descending: true, Limit: 2}, []types.BlockHeight{400, 300}, 4},
+		{modules.StorageObligationQuery{SortBy: "value"}, []types.BlockHeight{200, 300, 100, 400}, 4},
+		{modules.StorageObligationQuery{SortBy: "value", Offset: 1, Limit: 2}, []types.BlockHeight{300, 100}, 4},
+		{modules.StorageObligationQuery{Status: "unresolved", SortBy: "expiration"}, []types.BlockHeight{100, 300}, 2},
+		{modules.StorageObligationQuery{Status: "failed"}, []types.BlockHeight{400}, 1},
+		{modules.StorageObligationQuery{MinExpiration: 150, MaxExpiration: 350, SortBy: "expiration"}, []types.BlockHeight{200, 300}, 2},
+		{modules.StorageObligationQuery{Renter: renter2, SortBy: "value", Descending: true}, []types.BlockHeight{400, 300}, 2},
+		{modules.StorageObligationQuery{SortBy: "expiration", Offset: 10}, nil, 4},
+	}
+	for i, test := range tests {
+		sos, total, err := h.QueryStorageObligations(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		heights := expirations(sos)
+		if total != test.total || len(heights) != len(test.expected) {
+			t.Errorf("query %v: got %v of %v obligations, expected %v of %v", i, heights, total, test.expected, test.total)
+			continue
+		}
+		for j := range heights {
+			if heights[j] != test.expected[j] {
+				t.Errorf("query %v: got %v, expected %v", i, heights, test.expected)
+				break
+			}
+		}
+	}
+
+	// Invalid queries should be rejected.
+	if _, _, err := h.QueryStorageObligations(modules.StorageObligationQuery{Status: "pending"}); err != errUnknownObligationStatus {
+		t.Error("expected errUnknownObligationStatus, got", err)
+	}
+	if _, _, err := h.QueryStorageObligations(modules.StorageObligationQuery{SortBy: "size"}); err != errUnknownObligationSort {
+		t.Error("expected errUnknownObligationSort, got", err)
+	}
+	if _, _, err := h.QueryStorageObligations(modules.StorageObligationQuery{Limit: -1}); err != errBadObligationPage {
+		t.Error("expected errBadObligationPage, got", err)
+	}
+
+	// Check the details of an obligation.
+	details, err := h.StorageObligation(ids[100])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.ObligationID != ids[100] || details.SectorCount != 2 || details.RevisionNumber != 5 ||
+		details.ProofDeadline != 110 || details.Renter != quotaTestObligation(1).renter() || details.ContractCost.Cmp64(30) != 0 {
+		t.Error("storage obligation details are incorrect:", details)
+	}
+	if _, err := h.StorageObligation(types.FileContractID{}); err != errNoStorageObligation {
+		t.Error("expected errNoStorageObligation, got", err)
+	}
+}
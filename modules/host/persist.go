@@ -29,6 +29,17 @@ type persistence struct {
 	SecretKey        crypto.SecretKey             `json:"secretkey"`
 	Settings         modules.HostInternalSettings `json:"settings"`
 	UnlockHash       types.UnlockHash             `json:"unlockhash"`
+
+	// Pricing.
+	PricingPolicy    modules.HostPricingPolicy     `json:"pricingpolicy"`
+	PriceAdjustments []modules.HostPriceAdjustment `json:"priceadjustments"`
+
+	// Renters.
+	RenterPolicy modules.HostRenterPolicy `json:"renterpolicy"`
+
+	// Wind-down.
+	WindDown       bool              `json:"winddown"`
+	WindDownHeight types.BlockHeight `json:"winddownheight"`
 }
 
 // persistData returns the data in the Host that will be saved to disk.
@@ -47,6 +58,17 @@ func (h *Host) persistData() persistence {
 		SecretKey:        h.secretKey,
 		Settings:         h.settings,
 		UnlockHash:       h.unlockHash,
+
+		// Pricing.
+		PricingPolicy:    h.pricingPolicy,
+		PriceAdjustments: h.priceAdjustments,
+
+		// Renters.
+		RenterPolicy: h.renterPolicy,
+
+		// Wind-down.
+		WindDown:       h.windDown,
+		WindDownHeight: h.windDownHeight,
 	}
 }
 
@@ -68,6 +90,14 @@ func (h *Host) establishDefaults() error {
 		MinContractPrice:          defaultContractPrice,
 		MinDownloadBandwidthPrice: defaultDownloadBandwidthPrice,
 		MinUploadBandwidthPrice:   defaultUploadBandwidthPrice,
+
+		RenterQuotaPeriod: defaultRenterQuotaPeriod,
+
+		SectorCacheSize: defaultSectorCacheSize,
+	}
+	h.StorageManager.SetSectorCacheSize(h.settings.SectorCacheSize)
+	h.renterPolicy = modules.HostRenterPolicy{
+		Mode: modules.HostRenterPolicyOpen,
 	}
 
 	// Generate signing key, for revising contracts.
@@ -106,7 +136,29 @@ func (h *Host) loadPersistObject(p *persistence) {
 		h.log.Printf("WARN: NetAddress '%v' loaded from persist is invalid: %v", p.Settings.NetAddress, err)
 		h.settings.NetAddress = ""
 	}
+	if h.settings.RenterQuotaPeriod == 0 {
+		// Hosts created before renter quotas were introduced have no quota
+		// period.
+		h.settings.RenterQuotaPeriod = defaultRenterQuotaPeriod
+	}
+	h.updateBandwidthLimits()
+	h.StorageManager.SetSectorCacheSize(h.settings.SectorCacheSize)
 	h.unlockHash = p.UnlockHash
+
+	// Copy over pricing.
+	h.pricingPolicy = p.PricingPolicy
+	h.priceAdjustments = p.PriceAdjustments
+
+	// Copy over the renter policy. Hosts created before the policy was
+	// introduced accept all renters.
+	h.renterPolicy = p.RenterPolicy
+	if h.renterPolicy.Mode == "" {
+		h.renterPolicy.Mode = modules.HostRenterPolicyOpen
+	}
+
+	// Copy over the wind-down state.
+	h.windDown = p.WindDown
+	h.windDownHeight = p.WindDownHeight
 }
 
 // initDB will check that the database has been initialized and if not, will
@@ -130,6 +182,7 @@ func (h *Host) initDB() (err error) {
 		buckets := [][]byte{
 			bucketActionItems,
 			bucketStorageObligations,
+			bucketRevenueLedger,
 		}
 		for _, bucket := range buckets {
 			_, err := tx.CreateBucketIfNotExists(bucket)
@@ -155,6 +208,12 @@ func (h *Host) load() error {
 	// the most recent version, but older versions need to be updated to the
 	// more recent structures.
 	p := new(persistence)
+	// COMPATv1.3.0
+	// Persist files written before the sector cache was introduced have no
+	// cache size. The default is set before decoding so that it only applies
+	// when the field is absent, and a cache disabled by the operator stays
+	// disabled.
+	p.Settings.SectorCacheSize = defaultSectorCacheSize
 	err = h.dependencies.loadFile(persistMetadata, p, filepath.Join(h.persistDir, settingsFile))
 	if err == nil {
 		// Copy in the persistence.
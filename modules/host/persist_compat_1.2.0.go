@@ -389,6 +389,7 @@ func (h *Host) upgradeFromV112ToV120() error {
 	}
 	// Try loading the persist again.
 	p := new(persistence)
+	p.Settings.SectorCacheSize = defaultSectorCacheSize
 	err = h.dependencies.loadFile(v112PersistMetadata, p, filepath.Join(h.persistDir, settingsFile))
 	if err != nil {
 		return build.ExtendErr("upgrade appears complete, but having difficulties reloading host after upgrade", err)
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
 )
 
 // TestHostContractCountPersistence checks that the host persists its contract
@@ -85,3 +86,62 @@ func TestHostAddressPersistence(t *testing.T) {
 		t.Error("User-set address does not seem to be persisting.")
 	}
 }
+
+// TestHostSectorCacheSizePersistence checks that a disabled sector cache stays
+// disabled after a reboot, and that persist files without a sector cache size
+// load with the default size.
+func TestHostSectorCacheSizePersistence(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+	hostDir := filepath.Join(ht.persistDir, modules.HostDir)
+
+	// Disable the sector cache and reboot the host.
+	settings := ht.host.InternalSettings()
+	settings.SectorCacheSize = 0
+	err = ht.host.SetInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ht.host.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host, err = New(ht.cs, ht.tpool, ht.wallet, "localhost:0", hostDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := ht.host.InternalSettings().SectorCacheSize; size != 0 {
+		t.Fatal("disabled sector cache was re-enabled after a reboot:", size)
+	}
+
+	// Remove the sector cache size from the persist file, as in persist files
+	// written by older hosts, and reboot the host.
+	err = ht.host.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	settingsPath := filepath.Join(hostDir, settingsFile)
+	var p map[string]interface{}
+	err = persist.LoadJSON(persistMetadata, &p, settingsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	delete(p["settings"].(map[string]interface{}), "sectorcachesize")
+	err = persist.SaveJSON(persistMetadata, p, settingsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host, err = New(ht.cs, ht.tpool, ht.wallet, "localhost:0", hostDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := ht.host.InternalSettings().SectorCacheSize; size != defaultSectorCacheSize {
+		t.Fatal("sector cache size of an old persist file was not set to the default:", size)
+	}
+}
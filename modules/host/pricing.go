@@ -0,0 +1,156 @@
+package host
+
+// pricing.go implements the host's pricing policy. When the policy is enabled,
+// the host adjusts its storage price and collateral each block according to
+// how much of its storage and collateral budget is in use. The storage price
+// rises as the host fills up, so that the remaining space is sold at a
+// premium, and the collateral falls as the collateral budget is used up, so
+// that the host can keep accepting contracts. Every automatic change is
+// recorded so that the operator can see why the prices moved.
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errPricingCollateralBounds is returned if the policy's minimum
+	// collateral is larger than its maximum collateral.
+	errPricingCollateralBounds = errors.New("minimum collateral must not exceed maximum collateral")
+
+	// errPricingStorageBounds is returned if the policy's minimum storage
+	// price is larger than its maximum storage price.
+	errPricingStorageBounds = errors.New("minimum storage price must not exceed maximum storage price")
+
+	// errPricingZeroStoragePrice is returned if an enabled policy has a
+	// maximum storage price of zero, which would make the host store data
+	// for free.
+	errPricingZeroStoragePrice = errors.New("maximum storage price must be greater than zero")
+)
+
+// pricingSteps is the number of steps that the pricing policy divides the
+// range between the minimum and the maximum of each price into. Quantizing
+// the prices keeps the host from publishing a new price every time a sector
+// is added or removed.
+const pricingSteps = 100
+
+// interpolatePrice returns the price that lies the given number of steps
+// (out of pricingSteps) from min towards max.
+func interpolatePrice(min, max types.Currency, steps uint64) types.Currency {
+	return min.Add(max.Sub(min).Mul64(steps).Div64(pricingSteps))
+}
+
+// utilizationSteps converts the fraction used/total into a number of steps
+// out of pricingSteps, returning the fraction as a float for reporting. The
+// fraction is clamped to [0, 1].
+func utilizationSteps(used, total *big.Int) (uint64, float64) {
+	if total.Sign() <= 0 || used.Sign() <= 0 {
+		return 0, 0
+	}
+	if used.Cmp(total) >= 0 {
+		return pricingSteps, 1
+	}
+	steps := new(big.Int).Mul(used, big.NewInt(pricingSteps))
+	steps.Div(steps, total)
+	fraction, _ := new(big.Rat).SetFrac(used, total).Float64()
+	return steps.Uint64(), fraction
+}
+
+// applyPricingPolicy recomputes the host's storage price and collateral
+// according to the pricing policy. If either value changes, the settings
+// revision number is incremented and the change is recorded. The caller is
+// responsible for saving the host.
+func (h *Host) applyPricingPolicy() {
+	policy := h.pricingPolicy
+	if !policy.Enabled {
+		return
+	}
+
+	// Storage price follows the fraction of the host's storage that is in
+	// use.
+	total, remaining := h.capacity()
+	var usedStorage uint64
+	if remaining < total {
+		usedStorage = total - remaining
+	}
+	storageSteps, storageUtilization := utilizationSteps(new(big.Int).SetUint64(usedStorage), new(big.Int).SetUint64(total))
+	storagePrice := interpolatePrice(policy.MinStoragePrice, policy.MaxStoragePrice, storageSteps)
+
+	// Collateral follows the fraction of the collateral budget that is
+	// locked in contracts, falling as the budget is used up.
+	lockedCollateral := h.financialMetrics.LockedStorageCollateral
+	collateralSteps, collateralUtilization := utilizationSteps(lockedCollateral.Big(), h.settings.CollateralBudget.Big())
+	collateral := interpolatePrice(policy.MinCollateral, policy.MaxCollateral, pricingSteps-collateralSteps)
+
+	if storagePrice.Equals(h.settings.MinStoragePrice) && collateral.Equals(h.settings.Collateral) {
+		return
+	}
+	h.priceAdjustments = append(h.priceAdjustments, modules.HostPriceAdjustment{
+		BlockHeight: h.blockHeight,
+		Timestamp:   time.Now(),
+
+		StorageUtilization:    storageUtilization,
+		CollateralUtilization: collateralUtilization,
+
+		OldStoragePrice: h.settings.MinStoragePrice,
+		NewStoragePrice: storagePrice,
+		OldCollateral:   h.settings.Collateral,
+		NewCollateral:   collateral,
+	})
+	if len(h.priceAdjustments) > maxPriceAdjustments {
+		h.priceAdjustments = h.priceAdjustments[len(h.priceAdjustments)-maxPriceAdjustments:]
+	}
+	h.log.Printf("INFO: pricing policy changed the storage price from %v to %v and the collateral from %v to %v", h.settings.MinStoragePrice, storagePrice, h.settings.Collateral, collateral)
+	h.settings.MinStoragePrice = storagePrice
+	h.settings.Collateral = collateral
+	h.revisionNumber++
+}
+
+// PricingPolicy returns the policy used by the host to adjust its prices.
+func (h *Host) PricingPolicy() modules.HostPricingPolicy {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.pricingPolicy
+}
+
+// PriceAdjustments returns the most recent automatic changes made to the
+// host's prices by its pricing policy, oldest first.
+func (h *Host) PriceAdjustments() []modules.HostPriceAdjustment {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	adjustments := make([]modules.HostPriceAdjustment, len(h.priceAdjustments))
+	copy(adjustments, h.priceAdjustments)
+	return adjustments
+}
+
+// SetPricingPolicy sets the policy used by the host to adjust its prices. If
+// the policy is enabled, the host's prices are updated immediately.
+func (h *Host) SetPricingPolicy(policy modules.HostPricingPolicy) error {
+	if policy.Enabled && policy.MaxStoragePrice.IsZero() {
+		return errPricingZeroStoragePrice
+	} else if policy.MinStoragePrice.Cmp(policy.MaxStoragePrice) > 0 {
+		return errPricingStorageBounds
+	} else if policy.MinCollateral.Cmp(policy.MaxCollateral) > 0 {
+		return errPricingCollateralBounds
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	err := h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	h.pricingPolicy = policy
+	h.applyPricingPolicy()
+	err = h.saveSync()
+	if err != nil {
+		return errors.New("pricing policy updated, but failed saving to disk: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package host
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
+)
+
+// TestPricingPolicy checks that the host adjusts its prices within the bounds
+// of its pricing policy as its storage and collateral budget are used, and
+// that the policy and its changes persist.
+func TestPricingPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// An enabled policy must not let the host store data for free.
+	policy := modules.HostPricingPolicy{Enabled: true}
+	if err := ht.host.SetPricingPolicy(policy); err != errPricingZeroStoragePrice {
+		t.Fatal("expected errPricingZeroStoragePrice, got", err)
+	}
+
+	// The bounds must be ordered.
+	policy = modules.HostPricingPolicy{
+		Enabled:         true,
+		MinStoragePrice: types.NewCurrency64(300),
+		MaxStoragePrice: types.NewCurrency64(100),
+	}
+	if err := ht.host.SetPricingPolicy(policy); err != errPricingStorageBounds {
+		t.Fatal("expected errPricingStorageBounds, got", err)
+	}
+	policy.MinStoragePrice, policy.MaxStoragePrice = policy.MaxStoragePrice, policy.MinStoragePrice
+	policy.MinCollateral = types.NewCurrency64(2000)
+	policy.MaxCollateral = types.NewCurrency64(1000)
+	if err := ht.host.SetPricingPolicy(policy); err != errPricingCollateralBounds {
+		t.Fatal("expected errPricingCollateralBounds, got", err)
+	}
+	policy.MinCollateral, policy.MaxCollateral = policy.MaxCollateral, policy.MinCollateral
+
+	// The host is empty, so enabling the policy should set the minimum
+	// storage price and the maximum collateral.
+	if err := ht.host.SetPricingPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	settings := ht.host.InternalSettings()
+	if !settings.MinStoragePrice.Equals(policy.MinStoragePrice) {
+		t.Fatal("wrong storage price:", settings.MinStoragePrice)
+	} else if !settings.Collateral.Equals(policy.MaxCollateral) {
+		t.Fatal("wrong collateral:", settings.Collateral)
+	}
+	if len(ht.host.PriceAdjustments()) != 1 {
+		t.Fatal("expected one price adjustment, got", len(ht.host.PriceAdjustments()))
+	}
+
+	// Fill a quarter of the host's storage and mine a block. The storage
+	// price should rise by a quarter of the range.
+	total, _ := ht.host.capacity()
+	for i := uint64(0); i < total/modules.SectorSize/4; i++ {
+		sectorData := fastrand.Bytes(int(modules.SectorSize))
+		if err := ht.host.AddSector(crypto.MerkleRoot(sectorData), sectorData); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := ht.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if price := ht.host.InternalSettings().MinStoragePrice; !price.Equals64(150) {
+		t.Fatal("expected storage price of 150, got", price)
+	}
+	adjustments := ht.host.PriceAdjustments()
+	if len(adjustments) != 2 {
+		t.Fatal("expected two price adjustments, got", len(adjustments))
+	} else if a := adjustments[1]; a.StorageUtilization != 0.25 || !a.OldStoragePrice.Equals64(100) || !a.NewStoragePrice.Equals64(150) {
+		t.Fatalf("wrong price adjustment: %+v", a)
+	}
+
+	// Lock half of the collateral budget. The collateral should fall by half
+	// of the range.
+	ht.host.mu.Lock()
+	ht.host.financialMetrics.LockedStorageCollateral = ht.host.settings.CollateralBudget.Div64(2)
+	ht.host.mu.Unlock()
+	if _, err := ht.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if collateral := ht.host.InternalSettings().Collateral; !collateral.Equals64(1500) {
+		t.Fatal("expected collateral of 1500, got", collateral)
+	}
+
+	// Mining a block without any change in utilization should not adjust
+	// the prices.
+	if _, err := ht.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ht.host.PriceAdjustments()) != 3 {
+		t.Fatal("expected three price adjustments, got", len(ht.host.PriceAdjustments()))
+	}
+
+	// Reload the host and check that the policy and the adjustments were
+	// kept.
+	if err := ht.host.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ht.host, err = New(ht.cs, ht.tpool, ht.wallet, "localhost:0", filepath.Join(ht.persistDir, modules.HostDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := ht.host.PricingPolicy(); !p.Enabled || !p.MinStoragePrice.Equals(policy.MinStoragePrice) || !p.MaxCollateral.Equals(policy.MaxCollateral) {
+		t.Fatal("pricing policy was not persisted")
+	} else if len(ht.host.PriceAdjustments()) != 3 {
+		t.Fatal("price adjustments were not persisted")
+	}
+}
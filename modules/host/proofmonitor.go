@@ -0,0 +1,220 @@
+package host
+
+// proofmonitor.go submits storage proofs and keeps track of them until they
+// are confirmed. A storage proof that is dropped from the transaction pool,
+// for example because its fee was too low, is resubmitted with a higher fee,
+// and the fee is raised further as the proof deadline nears. Obligations
+// whose proofs are not confirmed in time lose their collateral, so any
+// obligation at risk is reported through the log and through ProofAlerts.
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+var (
+	// errProofFeeTooHigh is returned if the fee required to submit a storage
+	// proof is higher than the value of the storage obligation.
+	errProofFeeTooHigh = errors.New("storage proof fee exceeds the value of the obligation")
+)
+
+// proofState returns the state of the storage proof of the obligation. The
+// state is "waiting" before the proof window opens, "pending" once the window
+// is open but before a proof was submitted, "submitted" if the proof was
+// submitted but not seen in the transaction pool at the last check, "intpool"
+// if it was, and "confirmed" once the proof is on the blockchain.
+func (so storageObligation) proofState(blockHeight types.BlockHeight) string {
+	switch {
+	case so.ProofConfirmed:
+		return "confirmed"
+	case so.ProofSubmissions > 0 && so.ProofSeenInTpool:
+		return "intpool"
+	case so.ProofSubmissions > 0:
+		return "submitted"
+	case blockHeight >= so.expiration():
+		return "pending"
+	default:
+		return "waiting"
+	}
+}
+
+// proofRisk returns the reason that the storage proof of the obligation is at
+// risk of not being confirmed by the deadline, or the empty string if it is
+// not at risk.
+func (so storageObligation) proofRisk(blockHeight types.BlockHeight) string {
+	if so.ObligationStatus != obligationUnresolved || so.ProofConfirmed || blockHeight < so.expiration() {
+		return ""
+	}
+	switch {
+	case blockHeight > so.proofDeadline():
+		return "storage proof deadline has passed"
+	case so.ProofError != "":
+		return "storage proof could not be submitted: " + so.ProofError
+	case so.ProofSubmissions > 0 && !so.ProofSeenInTpool:
+		return "storage proof was dropped from the transaction pool"
+	case so.ProofSubmissions == 0 && blockHeight > so.expiration()+2*resubmissionTimeout:
+		return "storage proof has not been submitted"
+	case blockHeight >= so.proofDeadline() || so.proofDeadline()-blockHeight <= proofAlertBuffer:
+		return "storage proof deadline is approaching"
+	}
+	return ""
+}
+
+// proofFeeMultiplier returns the factor by which the recommended fee is
+// multiplied when submitting the storage proof of the obligation. The fee
+// doubles with every resubmission, and is at its maximum once the deadline is
+// near.
+func (so storageObligation) proofFeeMultiplier(blockHeight types.BlockHeight) uint64 {
+	if blockHeight >= so.proofDeadline() || so.proofDeadline()-blockHeight <= proofAlertBuffer {
+		return maxProofFeeMultiplier
+	}
+	multiplier := uint64(1)
+	for i := uint64(0); i < so.ProofSubmissions && multiplier < maxProofFeeMultiplier; i++ {
+		multiplier *= 2
+	}
+	return multiplier
+}
+
+// managedSubmitStorageProof builds the storage proof of the obligation and
+// submits it to the transaction pool. If the proof that was submitted last is
+// still in the transaction pool, it is rebroadcast instead, as the pool will
+// not accept a second proof for the same contract. The updated obligation is
+// returned, and must be saved by the caller.
+func (h *Host) managedSubmitStorageProof(so storageObligation, blockHeight types.BlockHeight) (storageObligation, error) {
+	if so.ProofSubmissions > 0 {
+		txn, parents, exists := h.tpool.Transaction(so.ProofTransactionID)
+		so.ProofSeenInTpool = exists
+		if exists {
+			h.tpool.Broadcast(append(parents, txn))
+			return so, nil
+		}
+		h.log.Printf("Storage proof for obligation %v is no longer in the transaction pool, resubmitting\n", so.id())
+	}
+
+	// Get the index of the segment, and the index of the sector containing
+	// the segment.
+	segmentIndex, err := h.cs.StorageProofSegment(so.id())
+	if err != nil {
+		return so, build.ExtendErr("could not fetch the storage proof segment:", err)
+	}
+	sectorIndex := segmentIndex / (modules.SectorSize / crypto.SegmentSize)
+	// Pull the corresponding sector into memory.
+	sectorRoot := so.SectorRoots[sectorIndex]
+	sectorBytes, err := h.ReadSector(sectorRoot)
+	if err != nil {
+		return so, build.ExtendErr("could not read the storage proof sector:", err)
+	}
+
+	// Build the storage proof for just the sector.
+	sectorSegment := segmentIndex % (modules.SectorSize / crypto.SegmentSize)
+	base, cachedHashSet := crypto.MerkleProof(sectorBytes, sectorSegment)
+
+	// Using the sector, build a cached root.
+	log2SectorSize := uint64(0)
+	for 1<<log2SectorSize < (modules.SectorSize / crypto.SegmentSize) {
+		log2SectorSize++
+	}
+	ct := crypto.NewCachedTree(log2SectorSize)
+	ct.SetIndex(segmentIndex)
+	for _, root := range so.SectorRoots {
+		ct.Push(root)
+	}
+	hashSet := ct.Prove(base, cachedHashSet)
+	sp := types.StorageProof{
+		ParentID: so.id(),
+		HashSet:  hashSet,
+	}
+	copy(sp.Segment[:], base)
+
+	// Create and build the transaction with the storage proof. The fee is
+	// raised with every resubmission, but is never raised above the value
+	// of the obligation.
+	_, feeRecommendation := h.tpool.FeeEstimation()
+	txnSize := uint64(len(encoding.Marshal(sp)) + 300)
+	requiredFee := feeRecommendation.Mul64(txnSize)
+	if so.value().Cmp(requiredFee) < 0 {
+		// There's no sense submitting the storage proof if the fee is more
+		// than the anticipated revenue.
+		return so, errProofFeeTooHigh
+	}
+	if bumpedFee := requiredFee.Mul64(so.proofFeeMultiplier(blockHeight)); so.value().Cmp(bumpedFee) >= 0 {
+		requiredFee = bumpedFee
+	}
+	builder := h.wallet.StartTransaction()
+	err = builder.FundSiacoins(requiredFee)
+	if err != nil {
+		return so, build.ExtendErr("could not fund the storage proof transaction fee:", err)
+	}
+	builder.AddMinerFee(requiredFee)
+	builder.AddStorageProof(sp)
+	storageProofSet, err := builder.Sign(true)
+	if err != nil {
+		builder.Drop()
+		return so, build.ExtendErr("could not sign the storage proof transaction:", err)
+	}
+	err = h.tpool.AcceptTransactionSet(storageProofSet)
+	if err != nil {
+		builder.Drop()
+		return so, build.ExtendErr("could not submit the storage proof transaction to the transaction pool:", err)
+	}
+	so.TransactionFeesAdded = so.TransactionFeesAdded.Add(requiredFee)
+	so.ProofConstructed = true
+	so.ProofSeenInTpool = true
+	so.ProofSubmissions++
+	so.ProofSubmittedHeight = blockHeight
+	so.ProofTransactionID = storageProofSet[len(storageProofSet)-1].ID()
+	return so, nil
+}
+
+// ProofAlerts returns the storage obligations whose storage proofs are at risk
+// of not being confirmed by their deadlines, ordered by deadline.
+func (h *Host) ProofAlerts() (alerts []modules.HostProofAlert) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			err := json.Unmarshal(soBytes, &so)
+			if err != nil {
+				return build.ExtendErr("unable to unmarshal storage obligation:", err)
+			}
+			risk := so.proofRisk(h.blockHeight)
+			if risk == "" {
+				return nil
+			}
+			var remaining types.BlockHeight
+			if so.proofDeadline() > h.blockHeight {
+				remaining = so.proofDeadline() - h.blockHeight
+			}
+			alerts = append(alerts, modules.HostProofAlert{
+				ObligationID:     so.id(),
+				ProofState:       so.proofState(h.blockHeight),
+				Reason:           risk,
+				ExpirationHeight: so.expiration(),
+				ProofDeadline:    so.proofDeadline(),
+				BlocksRemaining:  remaining,
+				ProofSubmissions: so.ProofSubmissions,
+				RiskedCollateral: so.RiskedCollateral,
+				Value:            so.value(),
+			})
+			return nil
+		})
+	})
+	if err != nil {
+		h.log.Println(build.ExtendErr("database failed to provide storage obligations:", err))
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].ProofDeadline < alerts[j].ProofDeadline
+	})
+	return alerts
+}
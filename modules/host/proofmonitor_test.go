@@ -0,0 +1,144 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// proofTestObligation returns an unresolved storage obligation whose proof
+// window opens at height 100 and closes at height 120.
+func proofTestObligation(key byte) storageObligation {
+	so := quotaTestObligation(key)
+	so.OriginTransactionSet = []types.Transaction{{
+		FileContracts: []types.FileContract{{FileSize: uint64(key)}},
+	}}
+	revision := &so.RevisionTransactionSet[0].FileContractRevisions[0]
+	revision.NewWindowStart = 100
+	revision.NewWindowEnd = 120
+	so.RiskedCollateral = types.NewCurrency64(50)
+	return so
+}
+
+// TestProofRisk checks that the state of storage proofs is reported
+// correctly, and that proofs at risk of missing their deadlines are detected.
+func TestProofRisk(t *testing.T) {
+	tests := []struct {
+		height      types.BlockHeight
+		submissions uint64
+		inTpool     bool
+		confirmed   bool
+		proofErr    string
+		status      storageObligationStatus
+		state       string
+		risk        string
+	}{
+		{90, 0, false, false, "", obligationUnresolved, "waiting", ""},
+		{100, 0, false, false, "", obligationUnresolved, "pending", ""},
+		{100 + 2*resubmissionTimeout + 1, 0, false, false, "", obligationUnresolved, "pending", "storage proof has not been submitted"},
+		{104, 1, true, false, "", obligationUnresolved, "intpool", ""},
+		{104, 1, false, false, "", obligationUnresolved, "submitted", "storage proof was dropped from the transaction pool"},
+		{104, 0, false, false, "insufficient balance", obligationUnresolved, "pending", "storage proof could not be submitted: insufficient balance"},
+		{120 - proofAlertBuffer, 1, true, false, "", obligationUnresolved, "intpool", "storage proof deadline is approaching"},
+		{121, 1, true, false, "", obligationUnresolved, "intpool", "storage proof deadline has passed"},
+		{119, 1, false, true, "", obligationUnresolved, "confirmed", ""},
+		{121, 0, false, false, "", obligationFailed, "pending", ""},
+	}
+	for i, test := range tests {
+		so := proofTestObligation(1)
+		so.ProofSubmissions = test.submissions
+		so.ProofSeenInTpool = test.inTpool
+		so.ProofConfirmed = test.confirmed
+		so.ProofError = test.proofErr
+		so.ObligationStatus = test.status
+		if state := so.proofState(test.height); state != test.state {
+			t.Errorf("test %v: proof state is %q, expected %q", i, state, test.state)
+		}
+		if risk := so.proofRisk(test.height); risk != test.risk {
+			t.Errorf("test %v: proof risk is %q, expected %q", i, risk, test.risk)
+		}
+	}
+
+	// The fee should double with every submission, up to the maximum, and
+	// should be at the maximum close to the deadline.
+	so := proofTestObligation(1)
+	for submissions, expected := range []uint64{1, 2, 4, 8, 8} {
+		so.ProofSubmissions = uint64(submissions)
+		if m := so.proofFeeMultiplier(100); m != expected {
+			t.Errorf("fee multiplier after %v submissions is %v, expected %v", submissions, m, expected)
+		}
+	}
+	so.ProofSubmissions = 0
+	if m := so.proofFeeMultiplier(120 - proofAlertBuffer); m != maxProofFeeMultiplier {
+		t.Error("fee multiplier should be at its maximum close to the deadline, got", m)
+	}
+	if m := so.proofFeeMultiplier(121); m != maxProofFeeMultiplier {
+		t.Error("fee multiplier should be at its maximum past the deadline, got", m)
+	}
+}
+
+// TestProofAlerts checks that the host reports the storage obligations whose
+// proofs are at risk, ordered by deadline.
+func TestProofAlerts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+	h := ht.host
+
+	if alerts := h.ProofAlerts(); len(alerts) != 0 {
+		t.Fatal("new host should have no proof alerts:", alerts)
+	}
+
+	// Add an obligation whose proof is in the transaction pool, one whose
+	// proof was dropped, and one with a later deadline whose proof could not
+	// be submitted.
+	healthy := proofTestObligation(1)
+	healthy.ProofSubmissions = 1
+	healthy.ProofSeenInTpool = true
+	dropped := proofTestObligation(2)
+	dropped.ProofSubmissions = 1
+	late := proofTestObligation(3)
+	late.RevisionTransactionSet[0].FileContractRevisions[0].NewWindowEnd = 130
+	late.ProofError = "insufficient balance"
+	err = h.db.Update(func(tx *bolt.Tx) error {
+		for _, so := range []storageObligation{late, healthy, dropped} {
+			if err := putStorageObligation(tx, so); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.mu.Lock()
+	h.blockHeight = 105
+	h.mu.Unlock()
+	alerts := h.ProofAlerts()
+	if len(alerts) != 2 {
+		t.Fatalf("expected 2 proof alerts, got %v", alerts)
+	}
+	if alerts[0].ObligationID != dropped.id() || alerts[0].ProofState != "submitted" ||
+		alerts[0].BlocksRemaining != 15 || alerts[0].RiskedCollateral.Cmp64(50) != 0 {
+		t.Error("alert for the dropped proof is incorrect:", alerts[0])
+	}
+	if alerts[1].ObligationID != late.id() || alerts[1].ProofState != "pending" || alerts[1].BlocksRemaining != 25 {
+		t.Error("alert for the unsubmitted proof is incorrect:", alerts[1])
+	}
+
+	// The summaries of the obligations should report the proof state.
+	for _, so := range h.StorageObligations() {
+		if so.ObligationID == healthy.id() && (so.ProofState != "intpool" || so.ProofSubmissions != 1) {
+			t.Error("storage obligation summary has the wrong proof state:", so)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package host
+
+// renterpolicy.go implements the policy that determines which renters the
+// host forms and renews contracts with. Renters are identified by the public
+// key that appears in the unlock conditions of their contracts.
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errRenterNotAllowed is returned if a renter tries to form or renew a
+	// contract with a host whose renter policy does not admit it.
+	errRenterNotAllowed = ErrorCommunication("host does not accept contracts from this renter")
+
+	// errUnknownRenterPolicyMode is returned if the renter policy has a mode
+	// other than open, allowlist or blocklist.
+	errUnknownRenterPolicyMode = errors.New("renter policy mode must be open, allowlist or blocklist")
+)
+
+// renterListed returns whether the public key appears in the list of keys.
+func renterListed(renters []types.SiaPublicKey, pk types.SiaPublicKey) bool {
+	for _, renter := range renters {
+		if renter.Algorithm == pk.Algorithm && bytes.Equal(renter.Key, pk.Key) {
+			return true
+		}
+	}
+	return false
+}
+
+// managedCheckRenterPolicy returns an error if the host's renter policy does
+// not admit the renter with the given public key.
+func (h *Host) managedCheckRenterPolicy(pk types.SiaPublicKey) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	switch h.renterPolicy.Mode {
+	case modules.HostRenterPolicyAllowlist:
+		if !renterListed(h.renterPolicy.Renters, pk) {
+			return errRenterNotAllowed
+		}
+	case modules.HostRenterPolicyBlocklist:
+		if renterListed(h.renterPolicy.Renters, pk) {
+			return errRenterNotAllowed
+		}
+	}
+	return nil
+}
+
+// RenterPolicy returns the policy that determines which renters the host
+// forms and renews contracts with.
+func (h *Host) RenterPolicy() modules.HostRenterPolicy {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	policy := h.renterPolicy
+	policy.Renters = append([]types.SiaPublicKey(nil), h.renterPolicy.Renters...)
+	return policy
+}
+
+// SetRenterPolicy sets the policy that determines which renters the host forms
+// and renews contracts with.
+func (h *Host) SetRenterPolicy(policy modules.HostRenterPolicy) error {
+	switch policy.Mode {
+	case modules.HostRenterPolicyOpen, modules.HostRenterPolicyAllowlist, modules.HostRenterPolicyBlocklist:
+	default:
+		return errUnknownRenterPolicyMode
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	err := h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	h.renterPolicy = policy
+	err = h.saveSync()
+	if err != nil {
+		return errors.New("renter policy updated, but failed saving to disk: " + err.Error())
+	}
+	return nil
+}
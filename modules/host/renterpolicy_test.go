@@ -0,0 +1,82 @@
+package host
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestRenterPolicy checks that the host admits renters according to its
+// renter policy, and that the policy persists.
+func TestRenterPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	listed := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1, 2, 3}}
+	unlisted := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{4, 5, 6}}
+
+	// The host should be open by default.
+	if mode := ht.host.RenterPolicy().Mode; mode != modules.HostRenterPolicyOpen {
+		t.Fatal("expected the host to be open by default, got", mode)
+	}
+	if err := ht.host.managedCheckRenterPolicy(unlisted); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unknown modes should be rejected.
+	if err := ht.host.SetRenterPolicy(modules.HostRenterPolicy{Mode: "closed"}); err != errUnknownRenterPolicyMode {
+		t.Fatal("expected errUnknownRenterPolicyMode, got", err)
+	}
+
+	tests := []struct {
+		mode            modules.HostRenterPolicyMode
+		listedAllowed   bool
+		unlistedAllowed bool
+	}{
+		{modules.HostRenterPolicyOpen, true, true},
+		{modules.HostRenterPolicyAllowlist, true, false},
+		{modules.HostRenterPolicyBlocklist, false, true},
+	}
+	for _, test := range tests {
+		policy := modules.HostRenterPolicy{
+			Mode:    test.mode,
+			Renters: []types.SiaPublicKey{listed},
+		}
+		if err := ht.host.SetRenterPolicy(policy); err != nil {
+			t.Fatal(err)
+		}
+		if err := ht.host.managedCheckRenterPolicy(listed); (err == nil) != test.listedAllowed {
+			t.Errorf("%v: listed renter allowed: %v, expected %v", test.mode, err == nil, test.listedAllowed)
+		}
+		if err := ht.host.managedCheckRenterPolicy(unlisted); (err == nil) != test.unlistedAllowed {
+			t.Errorf("%v: unlisted renter allowed: %v, expected %v", test.mode, err == nil, test.unlistedAllowed)
+		}
+	}
+
+	// Reload the host and check that the allowlist was kept.
+	if err := ht.host.SetRenterPolicy(modules.HostRenterPolicy{Mode: modules.HostRenterPolicyAllowlist, Renters: []types.SiaPublicKey{listed}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.host.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ht.host, err = New(ht.cs, ht.tpool, ht.wallet, "localhost:0", filepath.Join(ht.persistDir, modules.HostDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.host.managedCheckRenterPolicy(unlisted); err != errRenterNotAllowed {
+		t.Fatal("expected errRenterNotAllowed after reload, got", err)
+	}
+	if err := ht.host.managedCheckRenterPolicy(listed); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,110 @@
+package host
+
+// revenue.go keeps a ledger of the revenue earned and lost by the host. When a
+// storage obligation is resolved, an entry is added to the ledger recording
+// the compensation, revenue, collateral and fees of the obligation, so that
+// the host's earnings can be accounted for over any period of time.
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// String returns the name of the storage obligation status as it appears in
+// the revenue ledger.
+func (sos storageObligationStatus) String() string {
+	switch sos {
+	case obligationRejected:
+		return "rejected"
+	case obligationSucceeded:
+		return "succeeded"
+	case obligationFailed:
+		return "failed"
+	default:
+		return "unresolved"
+	}
+}
+
+// revenueEntry returns the revenue ledger entry of a storage obligation that
+// was resolved with the given status.
+func revenueEntry(so storageObligation, sos storageObligationStatus, height types.BlockHeight, timestamp time.Time) modules.HostRevenueEntry {
+	entry := modules.HostRevenueEntry{
+		ContractID: so.id(),
+		Status:     sos.String(),
+
+		NegotiationHeight: so.NegotiationHeight,
+		ExpirationHeight:  so.expiration(),
+		ResolutionHeight:  height,
+		Timestamp:         timestamp,
+
+		LockedCollateral: so.LockedCollateral,
+	}
+	switch sos {
+	case obligationSucceeded:
+		entry.ContractCompensation = so.ContractCost
+		entry.StorageRevenue = so.PotentialStorageRevenue
+		entry.DownloadBandwidthRevenue = so.PotentialDownloadRevenue
+		entry.UploadBandwidthRevenue = so.PotentialUploadRevenue
+		entry.TransactionFees = so.TransactionFeesAdded
+	case obligationFailed:
+		entry.LostRevenue = so.ContractCost.Add(so.PotentialStorageRevenue).Add(so.PotentialDownloadRevenue).Add(so.PotentialUploadRevenue)
+		entry.LostCollateral = so.RiskedCollateral
+		entry.TransactionFees = so.TransactionFeesAdded
+	}
+	return entry
+}
+
+// revenueKey returns the key of a revenue ledger entry.
+func revenueKey(timestamp time.Time, id types.FileContractID) []byte {
+	key := make([]byte, 8, 8+len(id))
+	binary.BigEndian.PutUint64(key, uint64(timestamp.UnixNano()))
+	return append(key, id[:]...)
+}
+
+// putRevenueEntry adds an entry to the revenue ledger.
+func putRevenueEntry(tx *bolt.Tx, entry modules.HostRevenueEntry) error {
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucketRevenueLedger).Put(revenueKey(entry.Timestamp, entry.ContractID), entryBytes)
+}
+
+// RevenueLedger returns the ledger entries of the storage obligations that
+// were resolved in the interval [start, end). A zero end time means that there
+// is no upper bound.
+func (h *Host) RevenueLedger(start, end time.Time) (entries []modules.HostRevenueEntry) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var startKey []byte
+	if start.After(time.Unix(0, 0)) {
+		startKey = revenueKey(start, types.FileContractID{})
+	}
+	err := h.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketRevenueLedger).Cursor()
+		for key, entryBytes := c.Seek(startKey); key != nil; key, entryBytes = c.Next() {
+			if !end.IsZero() && binary.BigEndian.Uint64(key[:8]) >= uint64(end.UnixNano()) {
+				break
+			}
+			var entry modules.HostRevenueEntry
+			err := json.Unmarshal(entryBytes, &entry)
+			if err != nil {
+				return build.ExtendErr("unable to unmarshal revenue entry:", err)
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		h.log.Println(build.ExtendErr("database failed to provide revenue ledger:", err))
+	}
+	return entries
+}
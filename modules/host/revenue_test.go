@@ -0,0 +1,126 @@
+package host
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// revenueTestObligation returns a storage obligation with the given window
+// start and revenue, whose potential revenue and risk have been added to the
+// host's financial metrics.
+func (h *Host) revenueTestObligation(windowStart types.BlockHeight, revenue uint64) storageObligation {
+	so := storageObligation{
+		OriginTransactionSet: []types.Transaction{{
+			FileContracts: []types.FileContract{{WindowStart: windowStart}},
+		}},
+		ContractCost:             types.NewCurrency64(revenue),
+		LockedCollateral:         types.NewCurrency64(2 * revenue),
+		PotentialStorageRevenue:  types.NewCurrency64(3 * revenue),
+		PotentialDownloadRevenue: types.NewCurrency64(4 * revenue),
+		PotentialUploadRevenue:   types.NewCurrency64(5 * revenue),
+		RiskedCollateral:         types.NewCurrency64(6 * revenue),
+		TransactionFeesAdded:     types.NewCurrency64(7 * revenue),
+	}
+	fm := &h.financialMetrics
+	fm.ContractCount++
+	fm.PotentialContractCompensation = fm.PotentialContractCompensation.Add(so.ContractCost)
+	fm.LockedStorageCollateral = fm.LockedStorageCollateral.Add(so.LockedCollateral)
+	fm.PotentialStorageRevenue = fm.PotentialStorageRevenue.Add(so.PotentialStorageRevenue)
+	fm.PotentialDownloadBandwidthRevenue = fm.PotentialDownloadBandwidthRevenue.Add(so.PotentialDownloadRevenue)
+	fm.PotentialUploadBandwidthRevenue = fm.PotentialUploadBandwidthRevenue.Add(so.PotentialUploadRevenue)
+	fm.RiskedStorageCollateral = fm.RiskedStorageCollateral.Add(so.RiskedCollateral)
+	fm.TransactionFeeExpenses = fm.TransactionFeeExpenses.Add(so.TransactionFeesAdded)
+	return so
+}
+
+// TestRevenueLedger checks that resolving storage obligations adds entries to
+// the revenue ledger, and that the ledger can be queried by time.
+func TestRevenueLedger(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+	h := ht.host
+
+	// Resolve one obligation of each status, with a pause in between so that
+	// they can be told apart by time.
+	statuses := []storageObligationStatus{obligationSucceeded, obligationFailed, obligationRejected}
+	var times []time.Time
+	for i, sos := range statuses {
+		times = append(times, time.Now())
+		time.Sleep(10 * time.Millisecond)
+		h.mu.Lock()
+		so := h.revenueTestObligation(types.BlockHeight(100+i), 10)
+		err := h.removeStorageObligation(so, sos)
+		h.mu.Unlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries := h.RevenueLedger(time.Time{}, time.Time{})
+	if len(entries) != len(statuses) {
+		t.Fatalf("expected %v entries, got %v", len(statuses), len(entries))
+	}
+	for i, e := range entries {
+		if e.Status != statuses[i].String() {
+			t.Errorf("entry %v has status %v, expected %v", i, e.Status, statuses[i])
+		}
+		if e.ExpirationHeight != types.BlockHeight(100+i) {
+			t.Errorf("entry %v has expiration height %v", i, e.ExpirationHeight)
+		}
+		if e.LockedCollateral.Cmp64(20) != 0 {
+			t.Errorf("entry %v has locked collateral %v", i, e.LockedCollateral)
+		}
+	}
+
+	// Only the succeeded obligation earns revenue, and only the failed
+	// obligation loses revenue and collateral.
+	succeeded, failed, rejected := entries[0], entries[1], entries[2]
+	if succeeded.ContractCompensation.Cmp64(10) != 0 || succeeded.StorageRevenue.Cmp64(30) != 0 ||
+		succeeded.DownloadBandwidthRevenue.Cmp64(40) != 0 || succeeded.UploadBandwidthRevenue.Cmp64(50) != 0 ||
+		succeeded.TransactionFees.Cmp64(70) != 0 || !succeeded.LostCollateral.IsZero() {
+		t.Error("succeeded entry is incorrect:", succeeded)
+	}
+	if !failed.ContractCompensation.IsZero() || !failed.StorageRevenue.IsZero() ||
+		failed.LostRevenue.Cmp64(130) != 0 || failed.LostCollateral.Cmp64(60) != 0 ||
+		failed.TransactionFees.Cmp64(70) != 0 {
+		t.Error("failed entry is incorrect:", failed)
+	}
+	if !rejected.ContractCompensation.IsZero() || !rejected.LostRevenue.IsZero() ||
+		!rejected.LostCollateral.IsZero() || !rejected.TransactionFees.IsZero() {
+		t.Error("rejected entry is incorrect:", rejected)
+	}
+
+	// The ledger should be filtered by resolution time.
+	if entries := h.RevenueLedger(times[1], time.Time{}); len(entries) != 2 || entries[0].Status != "failed" {
+		t.Error("start time was not respected:", entries)
+	}
+	if entries := h.RevenueLedger(times[0], times[1]); len(entries) != 1 || entries[0].Status != "succeeded" {
+		t.Error("end time was not respected:", entries)
+	}
+	if entries := h.RevenueLedger(time.Now(), time.Time{}); len(entries) != 0 {
+		t.Error("expected no entries after now, got", entries)
+	}
+
+	// The ledger should persist.
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ht.host, err = New(ht.cs, ht.tpool, ht.wallet, "localhost:0", filepath.Join(ht.persistDir, modules.HostDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries := ht.host.RevenueLedger(time.Time{}, time.Time{}); len(entries) != len(statuses) {
+		t.Fatalf("expected %v entries after reload, got %v", len(statuses), len(entries))
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
@@ -145,6 +146,16 @@ type storageObligation struct {
 	ProofConstructed    bool
 	ProofConfirmed      bool
 	ObligationStatus    storageObligationStatus
+
+	// Variables tracking the submission of the storage proof. The host
+	// resubmits the proof with a higher fee if the last proof transaction
+	// was dropped from the transaction pool, and reports the obligation as
+	// at risk if the proof has not been confirmed as the deadline nears.
+	ProofError           string
+	ProofSeenInTpool     bool
+	ProofSubmissions     uint64
+	ProofSubmittedHeight types.BlockHeight
+	ProofTransactionID   types.TransactionID
 }
 
 // getStorageObligation fetches a storage obligation from the database tx.
@@ -591,8 +602,13 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 	h.financialMetrics.ContractCount--
 	so.ObligationStatus = sos
 	so.SectorRoots = nil
+	entry := revenueEntry(so, sos, h.blockHeight, time.Now())
 	return h.db.Update(func(tx *bolt.Tx) error {
-		return putStorageObligation(tx, so)
+		err := putStorageObligation(tx, so)
+		if err != nil {
+			return err
+		}
+		return putRevenueEntry(tx, entry)
 	})
 }
 
@@ -756,75 +772,36 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 			return
 		}
 
-		// Get the index of the segment, and the index of the sector containing
-		// the segment.
-		segmentIndex, err := h.cs.StorageProofSegment(so.id())
-		if err != nil {
-			h.log.Debugln("Host got an error when fetching a storage proof segment:", err)
-			return
-		}
-		sectorIndex := segmentIndex / (modules.SectorSize / crypto.SegmentSize)
-		// Pull the corresponding sector into memory.
-		sectorRoot := so.SectorRoots[sectorIndex]
-		sectorBytes, err := h.ReadSector(sectorRoot)
-		if err != nil {
-			h.log.Debugln(err)
-			return
-		}
-
-		// Build the storage proof for just the sector.
-		sectorSegment := segmentIndex % (modules.SectorSize / crypto.SegmentSize)
-		base, cachedHashSet := crypto.MerkleProof(sectorBytes, sectorSegment)
-
-		// Using the sector, build a cached root.
-		log2SectorSize := uint64(0)
-		for 1<<log2SectorSize < (modules.SectorSize / crypto.SegmentSize) {
-			log2SectorSize++
-		}
-		ct := crypto.NewCachedTree(log2SectorSize)
-		ct.SetIndex(segmentIndex)
-		for _, root := range so.SectorRoots {
-			ct.Push(root)
+		// Queue another action item to check whether the storage proof got
+		// confirmed. The proof is checked every few blocks until the deadline,
+		// so that a proof that was dropped can be resubmitted in time.
+		nextCheck := blockHeight + resubmissionTimeout
+		if nextCheck > so.proofDeadline() {
+			nextCheck = so.proofDeadline()
 		}
-		hashSet := ct.Prove(base, cachedHashSet)
-		sp := types.StorageProof{
-			ParentID: so.id(),
-			HashSet:  hashSet,
-		}
-		copy(sp.Segment[:], base)
-
-		// Create and build the transaction with the storage proof.
-		builder := h.wallet.StartTransaction()
-		_, feeRecommendation := h.tpool.FeeEstimation()
-		if so.value().Cmp(feeRecommendation) < 0 {
-			// There's no sense submitting the storage proof if the fee is more
-			// than the anticipated revenue.
-			h.log.Debugln("Host not submitting storage proof due to a value that does not sufficiently exceed the fee cost")
-			return
+		if nextCheck <= blockHeight {
+			nextCheck = blockHeight + 1
 		}
-		txnSize := uint64(len(encoding.Marshal(sp)) + 300)
-		requiredFee := feeRecommendation.Mul64(txnSize)
-		err = builder.FundSiacoins(requiredFee)
+		h.mu.Lock()
+		err = h.queueActionItem(nextCheck, so.id())
+		h.mu.Unlock()
 		if err != nil {
-			h.log.Println("Host error when funding a storage proof transaction fee:", err)
-			return
+			h.log.Println("Error queuing action item:", err)
 		}
-		builder.AddMinerFee(requiredFee)
-		builder.AddStorageProof(sp)
-		storageProofSet, err := builder.Sign(true)
+
+		so, err = h.managedSubmitStorageProof(so, blockHeight)
 		if err != nil {
-			h.log.Println("Host error when signing the storage proof transaction:", err)
-			return
+			h.log.Println("Host unable to submit storage proof:", err)
+			so.ProofError = err.Error()
+		} else {
+			so.ProofError = ""
 		}
-		err = h.tpool.AcceptTransactionSet(storageProofSet)
-		if err != nil {
-			h.log.Println("Host unable to submit storage proof transaction to transaction pool:", err)
-			return
+		if risk := so.proofRisk(blockHeight); risk != "" {
+			h.log.Printf("WARN: storage proof for obligation %v is at risk, deadline is block %v: %v\n", so.id(), so.proofDeadline(), risk)
 		}
-		so.TransactionFeesAdded = so.TransactionFeesAdded.Add(requiredFee)
-
-		// Queue another action item to check whether the storage proof
-		// got confirmed.
+	} else if so.ProofConfirmed && blockHeight < so.proofDeadline() {
+		// Queue an action item to resolve the obligation once the proof
+		// window has closed.
 		h.mu.Lock()
 		err = h.queueActionItem(so.proofDeadline(), so.id())
 		h.mu.Unlock()
@@ -869,17 +846,7 @@ func (h *Host) StorageObligations() (sos []modules.StorageObligation) {
 			if err != nil {
 				return build.ExtendErr("unable to unmarshal storage obligation:", err)
 			}
-			mso := modules.StorageObligation{
-				NegotiationHeight: so.NegotiationHeight,
-
-				OriginConfirmed:     so.OriginConfirmed,
-				RevisionConstructed: so.RevisionConstructed,
-				RevisionConfirmed:   so.RevisionConfirmed,
-				ProofConstructed:    so.ProofConstructed,
-				ProofConfirmed:      so.ProofConfirmed,
-				ObligationStatus:    uint64(so.ObligationStatus),
-			}
-			sos = append(sos, mso)
+			sos = append(sos, so.summary(h.blockHeight))
 			return nil
 		})
 		if err != nil {
@@ -304,6 +304,11 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 	// change.
 	h.recentChange = cc.ID
 
+	// Adjust the host's prices to the new block, and forget the renters
+	// whose quota periods have ended.
+	h.applyPricingPolicy()
+	h.pruneRenterUsage()
+
 	// Save the host.
 	err = h.saveSync()
 	if err != nil {
@@ -0,0 +1,92 @@
+package host
+
+// winddown.go lets the host be retired gracefully. A host that is winding down
+// refuses new contracts and renewals, but keeps serving downloads, revisions
+// and storage proofs for its existing contracts. Once the last storage
+// obligation has been resolved, the host can be shut down without losing any
+// revenue or collateral.
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+var (
+	// errWindingDown is returned if a renter tries to renew a contract with a
+	// host that is winding down.
+	errWindingDown = ErrorCommunication("host is winding down and does not accept new contracts or renewals")
+)
+
+// WindDownStatus reports whether the host is winding down, and when its last
+// storage obligation will be resolved.
+func (h *Host) WindDownStatus() modules.HostWindDownStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	status := modules.HostWindDownStatus{
+		Enabled:     h.windDown,
+		StartHeight: h.windDownHeight,
+		BlockHeight: h.blockHeight,
+	}
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			err := json.Unmarshal(soBytes, &so)
+			if err != nil {
+				return build.ExtendErr("unable to unmarshal storage obligation:", err)
+			}
+			if so.ObligationStatus != obligationUnresolved {
+				return nil
+			}
+			status.OutstandingObligations++
+			if deadline := so.proofDeadline(); deadline > status.CompletionHeight {
+				status.CompletionHeight = deadline
+			}
+			status.PendingContractCompensation = status.PendingContractCompensation.Add(so.ContractCost)
+			status.PendingStorageRevenue = status.PendingStorageRevenue.Add(so.PotentialStorageRevenue)
+			status.PendingDownloadBandwidthRevenue = status.PendingDownloadBandwidthRevenue.Add(so.PotentialDownloadRevenue)
+			status.PendingUploadBandwidthRevenue = status.PendingUploadBandwidthRevenue.Add(so.PotentialUploadRevenue)
+			status.LockedCollateral = status.LockedCollateral.Add(so.LockedCollateral)
+			status.RiskedCollateral = status.RiskedCollateral.Add(so.RiskedCollateral)
+			return nil
+		})
+	})
+	if err != nil {
+		h.log.Println(build.ExtendErr("database failed to provide storage obligations:", err))
+	}
+	status.Complete = h.windDown && status.OutstandingObligations == 0
+	return status
+}
+
+// SetWindDown starts or stops winding down the host. While winding down, the
+// host refuses new contracts and renewals.
+func (h *Host) SetWindDown(windDown bool) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	err := h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	if windDown && !h.windDown {
+		h.windDownHeight = h.blockHeight
+		h.log.Println("Winding down the host at height", h.blockHeight)
+	} else if !windDown && h.windDown {
+		h.windDownHeight = 0
+		h.log.Println("No longer winding down the host")
+	}
+	h.windDown = windDown
+	// The change in the host's settings needs to be picked up by renters.
+	h.revisionNumber++
+	err = h.saveSync()
+	if err != nil {
+		return errors.New("wind-down updated, but failed saving to disk: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package host
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// TestWindDown checks that winding down the host stops it from accepting
+// contracts, that the wind-down status reports the outstanding obligations,
+// and that the wind-down persists.
+func TestWindDown(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+	h := ht.host
+
+	// Add an outstanding obligation and a resolved obligation.
+	err = h.db.Update(func(tx *bolt.Tx) error {
+		for i, status := range []storageObligationStatus{obligationUnresolved, obligationSucceeded} {
+			so := quotaTestObligation(1)
+			so.OriginTransactionSet = []types.Transaction{{
+				FileContracts: []types.FileContract{{WindowStart: types.BlockHeight(100 + i)}},
+			}}
+			so.RevisionTransactionSet[0].FileContractRevisions[0].NewWindowEnd = types.BlockHeight(200 + i)
+			so.ContractCost = types.NewCurrency64(10)
+			so.RiskedCollateral = types.NewCurrency64(20)
+			so.ObligationStatus = status
+			if err := putStorageObligation(tx, so); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.mu.Lock()
+	h.settings.AcceptingContracts = true
+	h.mu.Unlock()
+	status := h.WindDownStatus()
+	if status.Enabled || status.Complete || status.OutstandingObligations != 1 || status.CompletionHeight != 200 {
+		t.Fatal("wind-down status is incorrect:", status)
+	}
+	if status.PendingContractCompensation.Cmp64(10) != 0 || status.RiskedCollateral.Cmp64(20) != 0 {
+		t.Fatal("pending revenue is incorrect:", status)
+	}
+
+	// Wind down the host.
+	if err := h.SetWindDown(true); err != nil {
+		t.Fatal(err)
+	}
+	if h.ExternalSettings().AcceptingContracts {
+		t.Fatal("host that is winding down should not accept contracts")
+	}
+	if status := h.WindDownStatus(); !status.Enabled || status.StartHeight != h.blockHeight {
+		t.Fatal("wind-down status is incorrect:", status)
+	}
+
+	// Reload the host and check that it is still winding down.
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ht.host, err = New(ht.cs, ht.tpool, ht.wallet, "localhost:0", filepath.Join(ht.persistDir, modules.HostDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ht.host.WindDownStatus().Enabled || ht.host.ExternalSettings().AcceptingContracts {
+		t.Fatal("wind-down was not persisted")
+	}
+
+	// Stopping the wind-down should make the host accept contracts again.
+	if err := ht.host.SetWindDown(false); err != nil {
+		t.Fatal(err)
+	}
+	if !ht.host.ExternalSettings().AcceptingContracts {
+		t.Fatal("host should accept contracts after the wind-down is stopped")
+	}
+}
@@ -60,6 +60,10 @@ const (
 	// should be successful even if both parties are on Tor.
 	NegotiateSettingsTime = 120 * time.Second
 
+	// NegotiateMaxAuditRequestSize defines the maximum size that an audit
+	// request can be.
+	NegotiateMaxAuditRequestSize = 1e3
+
 	// NegotiateMaxDownloadActionRequestSize defines the maximum size that a
 	// download request can be. Note, this is not a max size for the data that
 	// can be requested, but instead is a max size for the definition of the
@@ -124,6 +128,18 @@ var (
 	// announcement is not a type of signature that is recognized.
 	ErrAnnUnrecognizedSignature = errors.New("the signature provided in the host announcement is not recognized")
 
+	// ErrAuditMissingSector is sent by the host when it rejects an audit of
+	// a sector that is covered by the contract but that it is not storing.
+	ErrAuditMissingSector = errors.New("host is not storing the audited sector")
+
+	// ErrAuditRateLimited is sent by the host when it rejects an audit
+	// because the contract was audited too recently.
+	ErrAuditRateLimited = errors.New("contract was audited too recently")
+
+	// ErrAuditUnknownSector is sent by the host when it rejects an audit of a
+	// sector that is not covered by the contract.
+	ErrAuditUnknownSector = errors.New("audit requested a sector that is not in the contract")
+
 	// ErrRevisionCoveredFields is returned if there is a covered fields object
 	// in a transaction signature which has the 'WholeTransaction' field set to
 	// true, meaning that miner fees cannot be added to the transaction without
@@ -138,14 +154,44 @@ var (
 	// it reads the StopResponse string.
 	ErrStopResponse = errors.New("sender wishes to stop communicating")
 
+	// MinAuditInterval is the shortest time that hosts allow between two
+	// audits of the same contract. Audits are free for the renter, so without
+	// a limit a renter could make the host read sectors from disk
+	// continuously. Renters use it to tell whether a host that refuses an
+	// audit as too frequent is telling the truth.
+	MinAuditInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute * 10,
+		Testing:  time.Second,
+	}).(time.Duration)
+
 	// PrefixHostAnnouncement is used to indicate that a transaction's
 	// Arbitrary Data field contains a host announcement. The encoded
 	// announcement will follow this prefix.
 	PrefixHostAnnouncement = types.Specifier{'H', 'o', 's', 't', 'A', 'n', 'n', 'o', 'u', 'n', 'c', 'e', 'm', 'e', 'n', 't'}
 
+	// RPCAudit is the specifier for auditing a host. The renter requests a
+	// single segment of a sector covered by one of its contracts, and the
+	// host responds with the segment and a Merkle proof that the segment
+	// belongs to the sector.
+	RPCAudit = types.Specifier{'A', 'u', 'd', 'i', 't', 1}
+
 	// RPCDownload is the specifier for downloading a file from a host.
 	RPCDownload = types.Specifier{'D', 'o', 'w', 'n', 'l', 'o', 'a', 'd', 2}
 
+	// RPCDownloadRange is the specifier for downloading ranges of sectors
+	// from a host. It is identical to RPCDownload, except that the requested
+	// ranges must be aligned to segment boundaries, and the host follows the
+	// data with a Merkle range proof for each range.
+	RPCDownloadRange = types.Specifier{'D', 'o', 'w', 'n', 'l', 'o', 'a', 'd', 'R', 'a', 'n', 'g', 'e', 1}
+
+	// RPCEncryptedConn is the specifier for opening an encrypted connection
+	// with a host. The handshake that follows is described in
+	// encryptedconn.go. Once the connection is encrypted, the renter sends
+	// the specifier of the RPC that it wishes to call over the encrypted
+	// connection.
+	RPCEncryptedConn = types.Specifier{'E', 'n', 'c', 'r', 'y', 'p', 't', 'e', 'd', 'C', 'o', 'n', 'n', 1}
+
 	// RPCFormContract is the specifier for forming a contract with a host.
 	RPCFormContract = types.Specifier{'F', 'o', 'r', 'm', 'C', 'o', 'n', 't', 'r', 'a', 'c', 't', 2}
 
@@ -160,6 +206,18 @@ var (
 	// contract revision for a given file contract.
 	RPCRecentRevision = types.Specifier{'R', 'e', 'c', 'e', 'n', 't', 'R', 'e', 'v', 'i', 's', 'i', 'o', 'n', 2}
 
+	// RPCSectorRoots is the specifier for getting the most recent file
+	// contract revision for a given file contract, along with the Merkle
+	// roots of every sector covered by the contract.
+	RPCSectorRoots = types.Specifier{'S', 'e', 'c', 't', 'o', 'r', 'R', 'o', 'o', 't', 's', 2}
+
+	// RPCSession is the specifier for opening a session with a host. A
+	// session authenticates the renter once, and then carries any number of
+	// settings, revision and download operations over the same connection.
+	// Each operation is requested by sending the specifier of the
+	// corresponding standalone RPC.
+	RPCSession = types.Specifier{'S', 'e', 's', 's', 'i', 'o', 'n', 1}
+
 	// RPCSettings is the specifier for requesting settings from the host.
 	RPCSettings = types.Specifier{'S', 'e', 't', 't', 'i', 'n', 'g', 's', 2}
 
@@ -176,6 +234,15 @@ var (
 )
 
 type (
+	// An AuditRequest asks the host to prove that it is storing a sector. The
+	// MerkleRoot identifies the sector, which must be covered by the contract
+	// that the audit was opened for, and the SegmentIndex indicates which
+	// segment of the sector the host should prove.
+	AuditRequest struct {
+		MerkleRoot   crypto.Hash
+		SegmentIndex uint64
+	}
+
 	// A DownloadAction is a description of a download that the renter would
 	// like to make. The MerkleRoot indicates the root of the sector, the
 	// offset indicates what portion of the sector is being downloaded, and the
@@ -256,6 +323,12 @@ type (
 		// which is the most recent.
 		RevisionNumber uint64 `json:"revisionnumber"`
 		Version        string `json:"version"`
+
+		// SupportedRPCs lists the optional RPCs that the host accepts, such
+		// as RPCEncryptedConn and RPCSession. Renters only call these RPCs on
+		// hosts that advertise them, rather than inferring support from the
+		// host's version. Hosts that predate the field advertise nothing.
+		SupportedRPCs []types.Specifier `json:"supportedrpcs"`
 	}
 
 	// A RevisionAction is a description of an edit to be performed on a file
@@ -278,6 +351,90 @@ type (
 	}
 )
 
+// SupportsRPC returns true if the host advertises support for the RPC with
+// the given specifier.
+func (hes HostExternalSettings) SupportsRPC(rpc types.Specifier) bool {
+	for _, s := range hes.SupportedRPCs {
+		if s == rpc {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalSia implements the encoding.SiaMarshaler interface.
+func (hes HostExternalSettings) MarshalSia(w io.Writer) error {
+	return encoding.NewEncoder(w).EncodeAll(
+		hes.AcceptingContracts,
+		hes.MaxDownloadBatchSize,
+		hes.MaxDuration,
+		hes.MaxReviseBatchSize,
+		hes.NetAddress,
+		hes.RemainingStorage,
+		hes.SectorSize,
+		hes.TotalStorage,
+		hes.UnlockHash,
+		hes.WindowSize,
+		hes.Collateral,
+		hes.MaxCollateral,
+		hes.ContractPrice,
+		hes.DownloadBandwidthPrice,
+		hes.StoragePrice,
+		hes.UploadBandwidthPrice,
+		hes.RevisionNumber,
+		hes.Version,
+		hes.SupportedRPCs,
+	)
+}
+
+// UnmarshalSia implements the encoding.SiaUnmarshaler interface.
+func (hes *HostExternalSettings) UnmarshalSia(r io.Reader) error {
+	dec := encoding.NewDecoder(r)
+	err := dec.DecodeAll(
+		&hes.AcceptingContracts,
+		&hes.MaxDownloadBatchSize,
+		&hes.MaxDuration,
+		&hes.MaxReviseBatchSize,
+		&hes.NetAddress,
+		&hes.RemainingStorage,
+		&hes.SectorSize,
+		&hes.TotalStorage,
+		&hes.UnlockHash,
+		&hes.WindowSize,
+		&hes.Collateral,
+		&hes.MaxCollateral,
+		&hes.ContractPrice,
+		&hes.DownloadBandwidthPrice,
+		&hes.StoragePrice,
+		&hes.UploadBandwidthPrice,
+		&hes.RevisionNumber,
+		&hes.Version,
+	)
+	if err != nil {
+		return err
+	}
+
+	// COMPATv1.3.0 - the settings of older hosts end after the version.
+	var prefix [8]byte
+	if _, err := io.ReadFull(r, prefix[:]); err == io.EOF {
+		hes.SupportedRPCs = nil
+		return nil
+	} else if err != nil {
+		return err
+	}
+	numRPCs := encoding.DecUint64(prefix[:])
+	if numRPCs > NegotiateMaxHostExternalSettingsLen/types.SpecifierLen {
+		return errors.New("host advertises too many supported RPCs")
+	}
+	hes.SupportedRPCs = make([]types.Specifier, numRPCs)
+	for i := range hes.SupportedRPCs {
+		if err := dec.Decode(&hes.SupportedRPCs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ReadNegotiationAcceptance reads an accept/reject response from r (usually a
 // net.Conn). If the response is not AcceptResponse, ReadNegotiationAcceptance
 // returns the response as an error. If the response is StopResponse,
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -125,3 +126,44 @@ func TestNegotiationResponses(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestHostExternalSettingsEncoding checks that the supported RPCs survive an
+// encoding round trip, and that the settings of hosts that predate them
+// still decode.
+func TestHostExternalSettingsEncoding(t *testing.T) {
+	t.Parallel()
+
+	hes := HostExternalSettings{
+		NetAddress:    "f.o:1234",
+		Version:       "1.3.0",
+		SupportedRPCs: []types.Specifier{RPCEncryptedConn, RPCSession},
+	}
+	var dec HostExternalSettings
+	if err := encoding.Unmarshal(encoding.Marshal(hes), &dec); err != nil {
+		t.Fatal(err)
+	}
+	if dec.NetAddress != hes.NetAddress || dec.Version != hes.Version {
+		t.Error("decoded settings do not match:", dec)
+	}
+	if !dec.SupportsRPC(RPCEncryptedConn) || !dec.SupportsRPC(RPCSession) || dec.SupportsRPC(RPCDownloadRange) {
+		t.Error("decoded settings have the wrong supported RPCs:", dec.SupportedRPCs)
+	}
+
+	// Older hosts send the settings without the supported RPCs, i.e. without
+	// the trailing length prefix of an empty list.
+	hes.SupportedRPCs = nil
+	b := encoding.Marshal(hes)
+	legacy := b[:len(b)-8]
+	dec = HostExternalSettings{}
+	if err := encoding.Unmarshal(legacy, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if dec.Version != hes.Version || len(dec.SupportedRPCs) != 0 {
+		t.Error("decoded legacy settings do not match:", dec)
+	}
+
+	// A truncated list of supported RPCs should be rejected.
+	if err := encoding.Unmarshal(b[:len(b)-4], &dec); err == nil {
+		t.Error("expected truncated settings to be rejected")
+	}
+}
@@ -45,6 +45,12 @@ type Allowance struct {
 	Hosts       uint64            `json:"hosts"`
 	Period      types.BlockHeight `json:"period"`
 	RenewWindow types.BlockHeight `json:"renewwindow"`
+
+	// MaxPriceIncrease is the ratio by which a host's prices may rise over
+	// the prices it advertised when a contract was formed with it. Hosts
+	// whose prices rise further are no longer used for uploads, downloads or
+	// renewals. If zero, a default ratio is used.
+	MaxPriceIncrease float64 `json:"maxpriceincrease"`
 }
 
 // DownloadInfo provides information about a file that has been requested for
@@ -88,7 +94,7 @@ type FileInfo struct {
 type HostDBEntry struct {
 	HostExternalSettings
 
-	// FirstSeen is the last block height at which this host was announced.
+	// FirstSeen is the block height at which this host was first announced.
 	FirstSeen types.BlockHeight `json:"firstseen"`
 
 	// Measurements that have been taken on the host. The most recent
@@ -105,11 +111,50 @@ type HostDBEntry struct {
 
 	LastHistoricUpdate types.BlockHeight
 
+	// LastAnnouncement is the most recent block height at which the host
+	// announced itself. OfflineSince is the time of the first failed scan
+	// since the host was last seen online, and is zero if the most recent
+	// scan succeeded.
+	LastAnnouncement types.BlockHeight `json:"lastannouncement"`
+	OfflineSince     time.Time         `json:"offlinesince"`
+
+	// Performance measurements of the host, kept as moving averages. The
+	// latencies are measured during scans. The throughputs, in bytes per
+	// second, are observed while transferring data under a contract with the
+	// host, and are zero for hosts that the renter has not transferred data
+	// with.
+	ConnectLatency     time.Duration `json:"connectlatency"`
+	SettingsLatency    time.Duration `json:"settingslatency"`
+	DownloadThroughput float64       `json:"downloadthroughput"`
+	UploadThroughput   float64       `json:"uploadthroughput"`
+
+	// PriceHistory holds the prices advertised by the host, oldest first. A
+	// snapshot is only added when the prices change, and only the most
+	// recent snapshots are kept.
+	PriceHistory []HostPriceSnapshot `json:"pricehistory"`
+
+	// Dishonest is set if the host presented a contract revision that
+	// contradicts the renter's records, or failed to prove that it is storing
+	// the renter's data. Dishonest hosts are given the lowest possible score.
+	Dishonest bool `json:"dishonest"`
+
 	// The public key of the host, stored separately to minimize risk of certain
 	// MitM based vulnerabilities.
 	PublicKey types.SiaPublicKey `json:"publickey"`
 }
 
+// HostPriceSnapshot records the prices advertised by a host, as first seen in
+// a scan at the given block height.
+type HostPriceSnapshot struct {
+	BlockHeight types.BlockHeight `json:"blockheight"`
+	Timestamp   time.Time         `json:"timestamp"`
+
+	ContractPrice          types.Currency `json:"contractprice"`
+	DownloadBandwidthPrice types.Currency `json:"downloadbandwidthprice"`
+	StoragePrice           types.Currency `json:"storageprice"`
+	UploadBandwidthPrice   types.Currency `json:"uploadbandwidthprice"`
+}
+
 // HostDBScan represents a single scan event.
 type HostDBScan struct {
 	Timestamp time.Time `json:"timestamp"`
@@ -130,13 +175,71 @@ type HostScoreBreakdown struct {
 	AgeAdjustment              float64 `json:"ageadjustment"`
 	BurnAdjustment             float64 `json:"burnadjustment"`
 	CollateralAdjustment       float64 `json:"collateraladjustment"`
+	DishonestyAdjustment       float64 `json:"dishonestyadjustment"`
 	InteractionAdjustment      float64 `json:"interactionadjustment"`
+	PerformanceAdjustment      float64 `json:"performanceadjustment"`
 	PriceAdjustment            float64 `json:"pricesmultiplier"`
 	StorageRemainingAdjustment float64 `json:"storageremainingadjustment"`
 	UptimeAdjustment           float64 `json:"uptimeadjustment"`
 	VersionAdjustment          float64 `json:"versionadjustment"`
 }
 
+// HostDBWeights configures how the hostdb scores hosts. Each adjustment of the
+// host's score is raised to the power of its exponent, then multiplied by its
+// multiplier; an exponent and multiplier of 1 leave the adjustment unchanged.
+// The price weights set the relative importance of each price category when
+// computing the price adjustment. Hosts charging more than a non-zero max
+// price are given the lowest possible score.
+type HostDBWeights struct {
+	AgeExponent                float64 `json:"ageexponent"`
+	AgeMultiplier              float64 `json:"agemultiplier"`
+	BurnExponent               float64 `json:"burnexponent"`
+	BurnMultiplier             float64 `json:"burnmultiplier"`
+	CollateralExponent         float64 `json:"collateralexponent"`
+	CollateralMultiplier       float64 `json:"collateralmultiplier"`
+	InteractionExponent        float64 `json:"interactionexponent"`
+	InteractionMultiplier      float64 `json:"interactionmultiplier"`
+	PerformanceExponent        float64 `json:"performanceexponent"`
+	PerformanceMultiplier      float64 `json:"performancemultiplier"`
+	PriceExponent              float64 `json:"priceexponent"`
+	PriceMultiplier            float64 `json:"pricemultiplier"`
+	StorageRemainingExponent   float64 `json:"storageremainingexponent"`
+	StorageRemainingMultiplier float64 `json:"storageremainingmultiplier"`
+	UptimeExponent             float64 `json:"uptimeexponent"`
+	UptimeMultiplier           float64 `json:"uptimemultiplier"`
+	VersionExponent            float64 `json:"versionexponent"`
+	VersionMultiplier          float64 `json:"versionmultiplier"`
+
+	ContractPriceWeight float64 `json:"contractpriceweight"`
+	DownloadPriceWeight float64 `json:"downloadpriceweight"`
+	StoragePriceWeight  float64 `json:"storagepriceweight"`
+	UploadPriceWeight   float64 `json:"uploadpriceweight"`
+
+	MaxContractPrice          types.Currency `json:"maxcontractprice"`
+	MaxDownloadBandwidthPrice types.Currency `json:"maxdownloadbandwidthprice"`
+	MaxStoragePrice           types.Currency `json:"maxstorageprice"`
+	MaxUploadBandwidthPrice   types.Currency `json:"maxuploadbandwidthprice"`
+}
+
+// HostDBPruningPolicy determines when hosts are removed from the hostdb. A
+// host is pruned once it has been offline for longer than MaxDowntime, unless
+// the renter has a contract with it or it announced itself within the last
+// AnnouncementWindow blocks. A MaxDowntime of zero disables pruning.
+type HostDBPruningPolicy struct {
+	MaxDowntime        time.Duration     `json:"maxdowntime"`
+	AnnouncementWindow types.BlockHeight `json:"announcementwindow"`
+}
+
+// A PrunedHost is a host that was removed from the hostdb by the pruning
+// policy.
+type PrunedHost struct {
+	PublicKey    types.SiaPublicKey `json:"publickey"`
+	NetAddress   NetAddress         `json:"netaddress"`
+	OfflineSince time.Time          `json:"offlinesince"`
+	BlockHeight  types.BlockHeight  `json:"blockheight"`
+	Timestamp    time.Time          `json:"timestamp"`
+}
+
 // RenterPriceEstimation contains a bunch of files estimating the costs of
 // various operations on the network.
 type RenterPriceEstimation struct {
@@ -226,11 +329,40 @@ type RenterContract struct {
 	TxnFee      types.Currency `json:"txnfee"`
 	SiafundFee  types.Currency `json:"siafundfee"`
 
+	// FormationPrices are the prices that the host advertised when the
+	// contract was formed. Price increases over the course of the contract
+	// are measured against them.
+	FormationPrices HostPriceSnapshot `json:"formationprices"`
+
 	// GoodForUpload indicates whether the contract should be used to upload new
 	// data or not, and GoodForRenew indicates whether or not the contract
 	// should be renewed.
 	GoodForRenew  bool
 	GoodForUpload bool
+
+	// Reconciliations records each attempt to resolve a revision mismatch
+	// between the renter and the host.
+	Reconciliations []ContractReconciliation `json:"reconciliations"`
+}
+
+// Outcomes of a contract reconciliation.
+const (
+	// ReconciliationAdopted indicates that the host's revision was adopted.
+	ReconciliationAdopted = "adopted"
+
+	// ReconciliationDishonest indicates that the host's revision contradicted
+	// the renter's records, and the host was flagged as dishonest.
+	ReconciliationDishonest = "dishonest"
+)
+
+// A ContractReconciliation records an attempt to resolve a revision mismatch
+// between the renter and the host of a contract.
+type ContractReconciliation struct {
+	Height         types.BlockHeight `json:"height"`
+	RenterRevision uint64            `json:"renterrevision"`
+	HostRevision   uint64            `json:"hostrevision"`
+	Outcome        string            `json:"outcome"`
+	Reason         string            `json:"reason"`
 }
 
 // EndHeight returns the height at which the host is no longer obligated to
@@ -269,6 +401,10 @@ type Renter interface {
 	// began.
 	CurrentPeriod() types.BlockHeight
 
+	// IdentityKey returns the public key that the renter uses in all of its
+	// contracts. Hosts admit or refuse the renter by this key.
+	IdentityKey() types.SiaPublicKey
+
 	// DeleteFile deletes a file entry from the renter.
 	DeleteFile(path string) error
 
@@ -285,6 +421,13 @@ type Renter interface {
 	// Host provides the DB entry and score breakdown for the requested host.
 	Host(pk types.SiaPublicKey) (HostDBEntry, bool)
 
+	// HostDBPruningPolicy returns the policy used by the hostdb to remove
+	// hosts that have been offline for too long.
+	HostDBPruningPolicy() HostDBPruningPolicy
+
+	// HostDBWeights returns the weights used by the hostdb to score hosts.
+	HostDBWeights() HostDBWeights
+
 	// LoadSharedFiles loads a '.sia' file into the renter. A .sia file may
 	// contain multiple files. The paths of the added files are returned.
 	LoadSharedFiles(source string) ([]string, error)
@@ -308,6 +451,22 @@ type Renter interface {
 	// hostdb's weighting algorithm.
 	ScoreBreakdown(entry HostDBEntry) HostScoreBreakdown
 
+	// ScoreBreakdownWithWeights will return the score for a host db entry
+	// using the provided weights instead of the hostdb's. The conversion rate
+	// is not computed.
+	ScoreBreakdownWithWeights(entry HostDBEntry, weights HostDBWeights) HostScoreBreakdown
+
+	// PrunedHosts returns the hosts most recently removed from the hostdb by
+	// its pruning policy.
+	PrunedHosts() []PrunedHost
+
+	// SetHostDBPruningPolicy sets the policy used by the hostdb to remove
+	// hosts that have been offline for too long.
+	SetHostDBPruningPolicy(HostDBPruningPolicy) error
+
+	// SetHostDBWeights sets the weights used by the hostdb to score hosts.
+	SetHostDBWeights(HostDBWeights) error
+
 	// Settings returns the Renter's current settings.
 	Settings() RenterSettings
 
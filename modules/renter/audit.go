@@ -0,0 +1,84 @@
+package renter
+
+import (
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules/renter/contractor"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// managedAuditContracts audits the host of each contract that covers data.
+// If a host fails its audit, every file with pieces stored on that host is
+// sent to the repair loop.
+func (r *Renter) managedAuditContracts() {
+	for _, contract := range r.hostContractor.Contracts() {
+		if len(contract.MerkleRoots) == 0 {
+			continue
+		}
+		err := r.hostContractor.AuditContract(contract.ID)
+		if err == contractor.ErrAuditFailed {
+			r.log.Printf("WARN: host %v failed an audit of contract %v; repairing affected files", contract.NetAddress, contract.ID)
+			if !r.managedQueueContractRepairs(contract.ID) {
+				return
+			}
+		} else if err != nil {
+			r.log.Debugln("Audit of contract", contract.ID, "was inconclusive:", err)
+		}
+	}
+}
+
+// managedQueueContractRepairs sends every tracked file with pieces stored in
+// the contract with the given ID to the repair loop. False is returned if the
+// renter is shutting down.
+func (r *Renter) managedQueueContractRepairs(id types.FileContractID) bool {
+	lockID := r.mu.RLock()
+	fileContracts := make(map[*file][]types.FileContractID)
+	for _, file := range r.files {
+		if _, ok := r.tracking[file.name]; !ok {
+			continue
+		}
+		file.mu.RLock()
+		for fcid := range file.contracts {
+			fileContracts[file] = append(fileContracts[file], fcid)
+		}
+		file.mu.RUnlock()
+	}
+	r.mu.RUnlock(lockID)
+
+	// Contracts are resolved outside of the renter's lock, as in the repair
+	// loop, because the file may refer to contracts that have been renewed.
+	var files []*file
+	for file, fcids := range fileContracts {
+		for _, fcid := range fcids {
+			if r.hostContractor.ResolveID(fcid) == id {
+				files = append(files, file)
+				break
+			}
+		}
+	}
+
+	for _, file := range files {
+		select {
+		case r.newRepairs <- file:
+		case <-r.tg.StopChan():
+			return false
+		}
+	}
+	return true
+}
+
+// threadedAuditLoop periodically audits the renter's hosts.
+func (r *Renter) threadedAuditLoop() {
+	for {
+		select {
+		case <-time.After(auditInterval):
+		case <-r.tg.StopChan():
+			return
+		}
+		if r.tg.Add() != nil {
+			return
+		}
+		r.managedAuditContracts()
+		r.tg.Done()
+	}
+}
@@ -35,6 +35,15 @@ var (
 		Testing:  10 * time.Second,
 	}).(time.Duration)
 
+	// auditInterval defines how often the renter audits each of its hosts,
+	// asking it to prove that it is still storing a random piece of the
+	// renter's data.
+	auditInterval = build.Select(build.Var{
+		Dev:      5 * time.Minute,
+		Standard: 6 * time.Hour,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
 	// maxChunkCacheSize determines the maximum number of chunks that will be
 	// cached in memory.
 	maxChunkCacheSize = build.Select(build.Var{
@@ -43,6 +52,15 @@ var (
 		Testing:  60,
 	}).(int)
 
+	// maxUploadBatchPieces is the maximum number of pieces that the repair
+	// loop will give to a single worker at once. The worker uploads the
+	// pieces together, using as few revisions as the host allows.
+	maxUploadBatchPieces = build.Select(build.Var{
+		Dev:      4,
+		Standard: 4,
+		Testing:  4,
+	}).(int)
+
 	// chunkDownloadTimeout defines the maximum amount of time to wait for a
 	// chunk download to finish before returning in the download-to-upload repair
 	// loop
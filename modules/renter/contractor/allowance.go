@@ -53,6 +53,8 @@ func (c *Contractor) SetAllowance(a modules.Allowance) error {
 		return ErrAllowanceZeroWindow
 	} else if a.RenewWindow >= a.Period {
 		return errAllowanceWindowSize
+	} else if a.MaxPriceIncrease != 0 && a.MaxPriceIncrease < 1 {
+		return errAllowanceMaxPriceIncrease
 	} else if !c.cs.Synced() {
 		return errAllowanceNotSynced
 	}
@@ -0,0 +1,156 @@
+package contractor
+
+// audit.go checks that hosts are still storing the renter's data. A host is
+// audited by asking it to prove that it is storing a random segment of a
+// random sector of a contract. A host that fails an audit is flagged as
+// dishonest, so that its contracts are no longer used and the renter repairs
+// the affected files. A host that authenticates the contract but then leaves
+// maxIncompleteAudits audits in a row incomplete is treated the same way.
+
+import (
+	"errors"
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/renter/proto"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/fastrand"
+)
+
+var (
+	// ErrAuditFailed is returned by AuditContract if the host was unable to
+	// prove that it is storing the contract's data.
+	ErrAuditFailed = errors.New("host failed a storage audit")
+
+	errAuditEmptyContract = errors.New("contract does not cover any sectors")
+	errAuditRevising      = errors.New("contract is being revised")
+	errAuditUnsupported   = errors.New("host does not support audits")
+)
+
+// auditHistory tracks the recent audits of a contract.
+type auditHistory struct {
+	// lastAudit is the time at which the host last answered an audit of the
+	// contract after the recent revision protocol.
+	lastAudit time.Time
+
+	// incomplete is the number of audits in a row that the host left
+	// incomplete.
+	incomplete int
+}
+
+// managedRecordAudit records an audit of the contract with the given ID that
+// the host answered after the recent revision protocol, and returns the
+// number of audits in a row that the host has left incomplete.
+func (c *Contractor) managedRecordAudit(id types.FileContractID, completed bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h := c.audits[id]
+	h.lastAudit = time.Now()
+	if completed {
+		h.incomplete = 0
+	} else {
+		h.incomplete++
+	}
+	c.audits[id] = h
+	return h.incomplete
+}
+
+// managedAuditedRecently returns true if the host of the contract with the
+// given ID answered an audit of it within modules.MinAuditInterval, in which
+// case the host is allowed to refuse another audit.
+func (c *Contractor) managedAuditedRecently(id types.FileContractID) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	h, exists := c.audits[id]
+	return exists && time.Since(h.lastAudit) < modules.MinAuditInterval
+}
+
+// AuditContract asks the host of the contract with the given ID to prove
+// that it is storing a random segment of a random sector of the contract. The
+// outcome is recorded in the host's interaction metrics. If the host fails
+// the audit, or leaves maxIncompleteAudits audits in a row incomplete after
+// the recent revision protocol, it is flagged as dishonest, the contract is
+// marked as no longer useful, and ErrAuditFailed is returned. Any other error
+// means that the audit was inconclusive. A host that cannot be reached or
+// does not complete the audit is recorded as having a failed interaction,
+// unless it declined because the contract was audited too recently.
+func (c *Contractor) AuditContract(id types.FileContractID) error {
+	id = c.ResolveID(id)
+	c.mu.RLock()
+	contract, exists := c.contracts[id]
+	busy := c.revising[id] || c.renewing[id]
+	c.mu.RUnlock()
+	if !exists {
+		return errNoContract
+	} else if len(contract.MerkleRoots) == 0 {
+		return errAuditEmptyContract
+	} else if busy {
+		// The host keeps the contract locked while it is being revised, so
+		// the audit would not be answered.
+		return errAuditRevising
+	}
+	host, haveHost := c.hdb.Host(contract.HostPublicKey)
+	if !haveHost {
+		return errors.New("no record of that host")
+	} else if !host.SupportsRPC(modules.RPCAudit) {
+		return errAuditUnsupported
+	}
+	contract.NetAddress = host.NetAddress
+
+	root := contract.MerkleRoots[fastrand.Intn(len(contract.MerkleRoots))]
+	segmentIndex := uint64(fastrand.Intn(int(modules.SectorSize / crypto.SegmentSize)))
+	err := proto.AuditSector(host, contract, root, segmentIndex, c.tg.StopChan())
+	select {
+	case <-c.tg.StopChan():
+		// An audit interrupted by shutdown says nothing about the host.
+		return err
+	default:
+	}
+	if err == modules.ErrAuditRateLimited && c.managedAuditedRecently(id) {
+		// The host is not at fault for refusing to be audited so often.
+		return err
+	}
+	switch {
+	case err == nil:
+		c.managedRecordAudit(id, true)
+		c.hdb.IncrementSuccessfulInteractions(host.PublicKey)
+		return nil
+	case err == modules.ErrAuditRateLimited, proto.IsAuditIncomplete(err):
+		// The host authenticated the contract, but did not complete the
+		// audit. Once is not proof that the data is lost, but a host that
+		// never completes an audit would otherwise never fail one.
+		c.hdb.IncrementFailedInteractions(host.PublicKey)
+		incomplete := c.managedRecordAudit(id, false)
+		if incomplete < maxIncompleteAudits {
+			c.log.Printf("host %v did not complete an audit of contract %v: %v", host.NetAddress, id, err)
+			return err
+		}
+		c.log.Printf("host %v did not complete %v audits in a row of contract %v: %v", host.NetAddress, incomplete, id, err)
+	case !proto.IsAuditFailure(err):
+		// The host did not prove that it is storing the data, but a host
+		// that cannot be reached is not proof that the data is lost either.
+		c.log.Printf("audit of contract %v with host %v was inconclusive: %v", id, host.NetAddress, err)
+		c.hdb.IncrementFailedInteractions(host.PublicKey)
+		return err
+	default:
+		c.managedRecordAudit(id, true)
+		c.log.Printf("host %v failed an audit of contract %v: %v", host.NetAddress, id, err)
+		c.hdb.IncrementFailedInteractions(host.PublicKey)
+	}
+
+	c.hdb.FlagDishonestHost(host.PublicKey)
+	c.mu.Lock()
+	contract, exists = c.contracts[id]
+	if exists {
+		contract.GoodForUpload = false
+		contract.GoodForRenew = false
+		c.contracts[id] = contract
+		if saveErr := c.saveSync(); saveErr != nil {
+			c.log.Println("Failed to save the contractor after a failed audit:", saveErr)
+		}
+	}
+	c.mu.Unlock()
+	return ErrAuditFailed
+}
@@ -0,0 +1,50 @@
+package contractor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestRecordAudit tests that the contractor counts the audits of a contract
+// that the host leaves incomplete in a row, and remembers when the host last
+// answered an audit.
+func TestRecordAudit(t *testing.T) {
+	c := &Contractor{
+		audits: make(map[types.FileContractID]auditHistory),
+	}
+	id := types.FileContractID{1}
+	if c.managedAuditedRecently(id) {
+		t.Fatal("contract that was never audited was audited recently")
+	}
+
+	for i := 1; i <= maxIncompleteAudits; i++ {
+		if n := c.managedRecordAudit(id, false); n != i {
+			t.Fatalf("expected %v incomplete audits, got %v", i, n)
+		}
+	}
+	if !c.managedAuditedRecently(id) {
+		t.Fatal("incomplete audit was not recorded as recent")
+	}
+
+	// a completed audit resets the count
+	if n := c.managedRecordAudit(id, true); n != 0 {
+		t.Fatal("completed audit did not reset the count:", n)
+	}
+	if n := c.managedRecordAudit(id, false); n != 1 {
+		t.Fatal("expected 1 incomplete audit, got", n)
+	}
+	if n := c.managedRecordAudit(types.FileContractID{2}, false); n != 1 {
+		t.Fatal("audits of another contract were counted:", n)
+	}
+
+	// an audit older than the host's rate limit is not recent
+	h := c.audits[id]
+	h.lastAudit = time.Now().Add(-modules.MinAuditInterval)
+	c.audits[id] = h
+	if c.managedAuditedRecently(id) {
+		t.Fatal("old audit was reported as recent")
+	}
+}
@@ -15,6 +15,25 @@ const (
 	estimatedFileContractTransactionSize = 1200
 )
 
+// Constants related to sizing renewed contracts.
+const (
+	// minRenewSectorsDivisor limits how small a renewal can be when it is
+	// sized from contract usage. A renewed contract is always funded for at
+	// least 1/minRenewSectorsDivisor of the sectors that it would have
+	// received from uniform allowance sizing.
+	minRenewSectorsDivisor = 4
+)
+
+// Constants related to auditing hosts.
+const (
+	// maxIncompleteAudits is the number of audits of a contract in a row
+	// that the host may leave incomplete after the recent revision protocol
+	// before it is treated as having failed an audit. A single incomplete
+	// audit may be caused by a temporary problem, but a host that has lost
+	// data could otherwise avoid audits forever by never completing them.
+	maxIncompleteAudits = 3
+)
+
 // Constants related to contract formation parameters.
 var (
 	// To alleviate potential block propagation issues, the contractor sleeps
@@ -64,6 +83,20 @@ var (
 	maxStoragePrice  = types.SiacoinPrecision.Mul64(30e3).Div(modules.BlockBytesPerMonthTerabyte) // 30k SC / TB / Month
 	maxUploadPrice   = maxStoragePrice.Mul64(4320)
 
+	// defaultMaxPriceIncrease is the ratio by which a host's prices may rise
+	// over the course of a contract if the allowance does not specify one.
+	defaultMaxPriceIncrease = float64(3)
+
+	// minContractFundRenewalThreshold defines the ratio of remaining funds to
+	// total contract cost below which the contractor will refresh a contract,
+	// renewing it before it reaches the renew window.
+	minContractFundRenewalThreshold = float64(0.03) // 3%
+
+	// renewUsageHeadroom is the factor by which the usage measured over a
+	// contract's lifetime is scaled up when sizing its renewal, leaving room
+	// for the usage to grow during the next period.
+	renewUsageHeadroom = float64(1.5)
+
 	// scoreLeeway defines the factor by which a host can miss the goal score
 	// for a set of hosts. To determine the goal score, a new set of hosts is
 	// queried from the hostdb and the lowest scoring among them is selected.
@@ -71,3 +104,24 @@ var (
 	// host is allowed to have before being marked as !GoodForUpload.
 	scoreLeeway = types.NewCurrency64(25)
 )
+
+// Constants related to the contractor's journal.
+var (
+	// journalCompactionUpdates is the number of update sets that can be
+	// appended to the journal before it is compacted with a checkpoint.
+	journalCompactionUpdates = build.Select(build.Var{
+		Dev:      1000,
+		Standard: 10000,
+		Testing:  50,
+	}).(int)
+
+	// journalCompactionMinSize is the minimum number of bytes of update sets
+	// that must be appended to the journal before it is compacted because of
+	// its size. Beyond this minimum, the journal is compacted once the update
+	// sets are larger than the initial object.
+	journalCompactionMinSize = build.Select(build.Var{
+		Dev:      int64(1 << 20),  // 1 MiB
+		Standard: int64(16 << 20), // 16 MiB
+		Testing:  int64(16 << 10), // 16 KiB
+	}).(int64)
+)
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"sync"
 
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/persist"
 	siasync "github.com/NebulousLabs/Sia/sync"
@@ -63,6 +64,20 @@ type Contractor struct {
 	contracts       map[types.FileContractID]modules.RenterContract
 	oldContracts    map[types.FileContractID]modules.RenterContract
 	renewedIDs      map[types.FileContractID]types.FileContractID
+
+	// audits holds the recent audit history of each contract, which is used
+	// to decide whether a host that does not complete an audit is hiding
+	// that it has lost the contract's data.
+	audits map[types.FileContractID]auditHistory
+
+	// priceAlerts holds the hosts that have been reported for raising their
+	// prices too far, so that each host is only reported once.
+	priceAlerts map[string]struct{}
+
+	// identityKey is used in the unlock conditions of every contract that
+	// the contractor forms or renews, so that hosts can recognize the
+	// renter across contracts.
+	identityKey crypto.SecretKey
 }
 
 // Allowance returns the current allowance.
@@ -72,6 +87,14 @@ func (c *Contractor) Allowance() modules.Allowance {
 	return c.allowance
 }
 
+// IdentityKey returns the public key that the contractor uses in all of its
+// contracts. Hosts admit or refuse the renter by this key.
+func (c *Contractor) IdentityKey() types.SiaPublicKey {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return types.Ed25519PublicKey(c.identityKey.PublicKey())
+}
+
 // Contract returns the latest contract formed with the specified host.
 func (c *Contractor) Contract(hostAddr modules.NetAddress) (modules.RenterContract, bool) {
 	c.mu.RLock()
@@ -181,7 +204,7 @@ func New(cs consensusSet, wallet walletShim, tpool transactionPool, hdb hostDB,
 	}
 
 	// Create Contractor using production dependencies.
-	return newContractor(cs, &walletBridge{w: wallet}, tpool, hdb, newPersist(persistDir), logger)
+	return newContractor(cs, &walletBridge{w: wallet}, tpool, hdb, newPersist(persistDir, logger), logger)
 }
 
 // newContractor creates a Contractor using the provided dependencies.
@@ -195,11 +218,13 @@ func newContractor(cs consensusSet, w wallet, tp transactionPool, hdb hostDB, p
 		tpool:   tp,
 		wallet:  w,
 
+		audits:          make(map[types.FileContractID]auditHistory),
 		cachedRevisions: make(map[types.FileContractID]cachedRevision),
 		contracts:       make(map[types.FileContractID]modules.RenterContract),
 		downloaders:     make(map[types.FileContractID]*hostDownloader),
 		editors:         make(map[types.FileContractID]*hostEditor),
 		oldContracts:    make(map[types.FileContractID]modules.RenterContract),
+		priceAlerts:     make(map[string]struct{}),
 		renewedIDs:      make(map[types.FileContractID]types.FileContractID),
 		renewing:        make(map[types.FileContractID]bool),
 		revising:        make(map[types.FileContractID]bool),
@@ -217,6 +242,11 @@ func newContractor(cs consensusSet, w wallet, tp transactionPool, hdb hostDB, p
 	if err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
+	// Renters created before the identity key was introduced, and new
+	// renters, need a key. It is saved below.
+	if c.identityKey == (crypto.SecretKey{}) {
+		c.identityKey, _ = crypto.GenerateKeyPair()
+	}
 	// Close the persist (provided as a dependency) upon shutdown.
 	c.tg.AfterStop(func() {
 		if err := c.persist.Close(); err != nil {
@@ -31,12 +31,16 @@ func (newStub) AcceptTransactionSet([]types.Transaction) error      { return nil
 func (newStub) FeeEstimation() (a types.Currency, b types.Currency) { return }
 
 // hdb stubs
-func (newStub) AllHosts() []modules.HostDBEntry                                 { return nil }
-func (newStub) ActiveHosts() []modules.HostDBEntry                              { return nil }
-func (newStub) Host(types.SiaPublicKey) (settings modules.HostDBEntry, ok bool) { return }
-func (newStub) IncrementSuccessfulInteractions(key types.SiaPublicKey)          { return }
-func (newStub) IncrementFailedInteractions(key types.SiaPublicKey)              { return }
-func (newStub) RandomHosts(int, []types.SiaPublicKey) []modules.HostDBEntry     { return nil }
+func (newStub) AllHosts() []modules.HostDBEntry                                    { return nil }
+func (newStub) ActiveHosts() []modules.HostDBEntry                                 { return nil }
+func (newStub) FlagDishonestHost(key types.SiaPublicKey)                           { return }
+func (newStub) Host(types.SiaPublicKey) (settings modules.HostDBEntry, ok bool)    { return }
+func (newStub) IncrementSuccessfulInteractions(key types.SiaPublicKey)             { return }
+func (newStub) IncrementFailedInteractions(key types.SiaPublicKey)                 { return }
+func (newStub) RandomHosts(int, []types.SiaPublicKey) []modules.HostDBEntry        { return nil }
+func (newStub) RecordDownloadThroughput(types.SiaPublicKey, uint64, time.Duration) {}
+func (newStub) RecordUploadThroughput(types.SiaPublicKey, uint64, time.Duration)   {}
+func (newStub) SetContractedHosts([]types.SiaPublicKey)                            {}
 func (newStub) ScoreBreakdown(modules.HostDBEntry) modules.HostScoreBreakdown {
 	return modules.HostScoreBreakdown{}
 }
@@ -187,13 +191,17 @@ func TestAllowance(t *testing.T) {
 // its methods.
 type stubHostDB struct{}
 
-func (stubHostDB) AllHosts() (hs []modules.HostDBEntry)                             { return }
-func (stubHostDB) ActiveHosts() (hs []modules.HostDBEntry)                          { return }
-func (stubHostDB) Host(types.SiaPublicKey) (h modules.HostDBEntry, ok bool)         { return }
-func (stubHostDB) IncrementSuccessfulInteractions(key types.SiaPublicKey)           { return }
-func (stubHostDB) IncrementFailedInteractions(key types.SiaPublicKey)               { return }
-func (stubHostDB) PublicKey() (spk types.SiaPublicKey)                              { return }
-func (stubHostDB) RandomHosts(int, []types.SiaPublicKey) (hs []modules.HostDBEntry) { return }
+func (stubHostDB) AllHosts() (hs []modules.HostDBEntry)                               { return }
+func (stubHostDB) ActiveHosts() (hs []modules.HostDBEntry)                            { return }
+func (stubHostDB) FlagDishonestHost(key types.SiaPublicKey)                           { return }
+func (stubHostDB) Host(types.SiaPublicKey) (h modules.HostDBEntry, ok bool)           { return }
+func (stubHostDB) IncrementSuccessfulInteractions(key types.SiaPublicKey)             { return }
+func (stubHostDB) IncrementFailedInteractions(key types.SiaPublicKey)                 { return }
+func (stubHostDB) PublicKey() (spk types.SiaPublicKey)                                { return }
+func (stubHostDB) RandomHosts(int, []types.SiaPublicKey) (hs []modules.HostDBEntry)   { return }
+func (stubHostDB) RecordDownloadThroughput(types.SiaPublicKey, uint64, time.Duration) {}
+func (stubHostDB) RecordUploadThroughput(types.SiaPublicKey, uint64, time.Duration)   {}
+func (stubHostDB) SetContractedHosts([]types.SiaPublicKey)                            {}
 func (stubHostDB) ScoreBreakdown(modules.HostDBEntry) modules.HostScoreBreakdown {
 	return modules.HostScoreBreakdown{}
 }
@@ -69,6 +69,182 @@ func maxSectors(a modules.Allowance, hdb hostDB, tp transactionPool) (uint64, er
 	return numSectors, nil
 }
 
+// A fileContractRenewal describes a contract that has been selected for
+// renewal, along with the parameters of the renewed contract.
+type fileContractRenewal struct {
+	id         types.FileContractID
+	endHeight  types.BlockHeight
+	numSectors uint64
+	refresh    bool
+}
+
+// needsRefresh returns true if the contract has spent so much of its funds
+// that it should be renewed before reaching the renew window.
+func needsRefresh(contract modules.RenterContract) bool {
+	if len(contract.LastRevision.NewValidProofOutputs) < 2 || contract.TotalCost.IsZero() {
+		return false
+	}
+	threshold := contract.TotalCost.MulFloat(minContractFundRenewalThreshold)
+	return contract.RenterFunds().Cmp(threshold) < 0
+}
+
+// renewSectors estimates the number of sectors that a renewal of the contract
+// should be funded for, based on how the contract was used since it was
+// formed. The estimate covers storing the contract's current data until the
+// new end height, plus the storage, upload and download spending of the
+// contract projected over the same duration. Spending is converted to sectors
+// at the host's storage price. The result is clamped to [min, max]. If no
+// usage can be measured yet, defaultSectors is returned.
+func renewSectors(contract modules.RenterContract, host modules.HostDBEntry, blockHeight, endHeight types.BlockHeight, defaultSectors, min, max uint64) uint64 {
+	if blockHeight <= contract.StartHeight || endHeight <= blockHeight || host.StoragePrice.IsZero() {
+		return defaultSectors
+	}
+	elapsed := uint64(blockHeight - contract.StartHeight)
+	duration := uint64(endHeight - blockHeight)
+
+	// Cost of keeping the data that is already stored with the host.
+	storedCost := host.StoragePrice.Mul64(contract.LastRevision.NewFileSize).Mul64(duration)
+	// Spending of the contract so far, projected over the new duration.
+	spent := contract.StorageSpending.Add(contract.UploadSpending).Add(contract.DownloadSpending)
+	projectedCost := spent.Mul64(duration).Div64(elapsed).MulFloat(renewUsageHeadroom)
+
+	costPerSector := host.StoragePrice.Mul64(modules.SectorSize).Mul64(duration)
+	numSectors, err := storedCost.Add(projectedCost).Div(costPerSector).Uint64()
+	if err != nil || numSectors > max {
+		return max
+	} else if numSectors < min {
+		return min
+	}
+	return numSectors
+}
+
+// managedSizeRenewals sets the number of sectors that each renewal in the set
+// will be funded for. Renewals are sized from measured contract usage, but
+// together they never receive more than the uniform share of the allowance
+// that the contracts would have been given otherwise, so that busy contracts
+// are funded from the slack of quiet ones.
+func (c *Contractor) managedSizeRenewals(renewSet []fileContractRenewal, uniformSectors, maxSectors uint64) {
+	c.mu.RLock()
+	blockHeight := c.blockHeight
+	contracts := make([]modules.RenterContract, len(renewSet))
+	for i, renewal := range renewSet {
+		contracts[i] = c.contracts[renewal.id]
+	}
+	c.mu.RUnlock()
+
+	minSectors := uniformSectors / minRenewSectorsDivisor
+	if minSectors == 0 {
+		minSectors = 1
+	}
+	var total uint64
+	for i := range renewSet {
+		renewSet[i].numSectors = uniformSectors
+		host, ok := c.hdb.Host(contracts[i].HostPublicKey)
+		if ok {
+			renewSet[i].numSectors = renewSectors(contracts[i], host, blockHeight, renewSet[i].endHeight, uniformSectors, minSectors, maxSectors)
+		}
+		total += renewSet[i].numSectors
+	}
+
+	// Scale the renewals down if they exceed the budget.
+	budget := uniformSectors * uint64(len(renewSet))
+	if total <= budget {
+		return
+	}
+	ratio := float64(budget) / float64(total)
+	for i := range renewSet {
+		renewSet[i].numSectors = uint64(float64(renewSet[i].numSectors) * ratio)
+		if renewSet[i].numSectors < minSectors {
+			renewSet[i].numSectors = minSectors
+		}
+	}
+}
+
+// renewalCost estimates what renewing a contract with the host for numSectors
+// sectors over duration blocks costs the renter. It follows the funding of
+// the contract in proto.Renew: the storage allocation and the contract price,
+// the siafund fee on the payout, and the transaction fee.
+func renewalCost(host modules.HostDBEntry, numSectors uint64, duration types.BlockHeight, txnFee types.Currency) types.Currency {
+	storage := host.StoragePrice.Mul64(numSectors * modules.SectorSize).Mul64(uint64(duration))
+	collateral := host.Collateral.Mul64(numSectors * modules.SectorSize).Mul64(uint64(duration))
+	payout := storage.Add(collateral).Add(host.ContractPrice).Mul64(10406).Div64(10000)
+	return payout.Sub(collateral).Add(txnFee)
+}
+
+// periodSpending returns the total cost of the contracts formed in the
+// current period, including contracts that have since been renewed.
+func (c *Contractor) periodSpending() types.Currency {
+	var spending types.Currency
+	for _, contract := range c.contracts {
+		if contract.StartHeight >= c.currentPeriod {
+			spending = spending.Add(contract.TotalCost)
+		}
+	}
+	for _, contract := range c.oldContracts {
+		if contract.StartHeight >= c.currentPeriod {
+			spending = spending.Add(contract.TotalCost)
+		}
+	}
+	return spending
+}
+
+// managedLimitRefreshes limits the refreshes in renewSet to what is left of
+// the allowance in the current period. Refreshes are paid for in order; a
+// refresh that does not fit is shrunk to the number of sectors that the
+// remaining funds can pay for, or dropped if they cannot pay for any. Renewals
+// that are not refreshes are left as they are.
+func (c *Contractor) managedLimitRefreshes(renewSet []fileContractRenewal) []fileContractRenewal {
+	c.mu.RLock()
+	blockHeight := c.blockHeight
+	var remaining types.Currency
+	if spending := c.periodSpending(); spending.Cmp(c.allowance.Funds) < 0 {
+		remaining = c.allowance.Funds.Sub(spending)
+	}
+	contracts := make([]modules.RenterContract, len(renewSet))
+	for i, renewal := range renewSet {
+		contracts[i] = c.contracts[renewal.id]
+	}
+	c.mu.RUnlock()
+	_, maxFee := c.tpool.FeeEstimation()
+	txnFee := maxFee.Mul64(estimatedFileContractTransactionSize)
+
+	var limited []fileContractRenewal
+	for i, renewal := range renewSet {
+		if !renewal.refresh {
+			limited = append(limited, renewal)
+			continue
+		}
+		host, ok := c.hdb.Host(contracts[i].HostPublicKey)
+		if !ok || renewal.endHeight <= blockHeight {
+			continue
+		}
+		duration := renewal.endHeight - blockHeight
+		cost := renewalCost(host, renewal.numSectors, duration, txnFee)
+		if cost.Cmp(remaining) > 0 {
+			// Costs are linear in the number of sectors, up to rounding.
+			fixed := renewalCost(host, 0, duration, txnFee)
+			perSector := renewalCost(host, 1, duration, txnFee).Sub(fixed)
+			var numSectors uint64
+			if fixed.Cmp(remaining) < 0 && !perSector.IsZero() {
+				numSectors, _ = remaining.Sub(fixed).Div(perSector).Uint64()
+			}
+			for numSectors > 0 && renewalCost(host, numSectors, duration, txnFee).Cmp(remaining) > 0 {
+				numSectors--
+			}
+			if numSectors == 0 {
+				c.log.Printf("WARN: not refreshing contract %v, the allowance has been spent for this period", renewal.id)
+				continue
+			}
+			c.log.Printf("WARN: refreshing contract %v for %v instead of %v sectors to stay within the allowance", renewal.id, numSectors, renewal.numSectors)
+			renewal.numSectors = numSectors
+			cost = renewalCost(host, numSectors, duration, txnFee)
+		}
+		remaining = remaining.Sub(cost)
+		limited = append(limited, renewal)
+	}
+	return limited
+}
+
 // contractEndHeight returns the height at which the Contractor's contracts
 // end. If there are no contracts, it returns zero.
 //
@@ -136,12 +312,36 @@ func (c *Contractor) managedMarkContractsUtility() {
 			contracts[i].GoodForRenew = false
 			continue
 		}
+		// Contract has no utility if the host has been flagged as dishonest.
+		if host.Dishonest {
+			contracts[i].GoodForUpload = false
+			contracts[i].GoodForRenew = false
+			continue
+		}
 		// Contract has no utility if the score is poor.
 		if c.hdb.ScoreBreakdown(host).Score.Cmp(minScore) < 0 {
 			contracts[i].GoodForUpload = false
 			contracts[i].GoodForRenew = false
 			continue
 		}
+		// Contract has no utility if the host has raised its prices too far
+		// since the contract was formed.
+		c.mu.Lock()
+		priceErr := checkPriceIncrease(host, contracts[i], c.maxPriceIncrease())
+		key := host.PublicKey.String()
+		_, alerted := c.priceAlerts[key]
+		if priceErr != nil && !alerted {
+			c.priceAlerts[key] = struct{}{}
+			c.log.Printf("WARN: no longer using host %v for contract %v: %v", host.NetAddress, contracts[i].ID, priceErr)
+		} else if priceErr == nil && alerted {
+			delete(c.priceAlerts, key)
+		}
+		c.mu.Unlock()
+		if priceErr != nil {
+			contracts[i].GoodForUpload = false
+			contracts[i].GoodForRenew = false
+			continue
+		}
 		// Contract has no utility if the host is offline.
 		c.mu.Lock()
 		offline := c.isOffline(contracts[i].ID)
@@ -223,6 +423,7 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, numSectors uin
 		StartHeight:   c.blockHeight,
 		EndHeight:     endHeight,
 		RefundAddress: uc.UnlockHash(),
+		SecretKey:     c.identityKey,
 	}
 	c.mu.RUnlock()
 
@@ -272,6 +473,7 @@ func (c *Contractor) managedRenew(contract modules.RenterContract, numSectors ui
 		StartHeight:   c.blockHeight,
 		EndHeight:     newEndHeight,
 		RefundAddress: uc.UnlockHash(),
+		SecretKey:     c.identityKey,
 	}
 	c.mu.RUnlock()
 
@@ -279,20 +481,31 @@ func (c *Contractor) managedRenew(contract modules.RenterContract, numSectors ui
 	txnBuilder := c.wallet.StartTransaction()
 	newContract, err := proto.Renew(contract, params, txnBuilder, c.tpool, c.hdb, c.tg.StopChan())
 	if proto.IsRevisionMismatch(err) {
-		// return unused outputs to wallet
-		txnBuilder.Drop()
 		// try again with the cached revision
 		c.mu.RLock()
 		cached, ok := c.cachedRevisions[contract.ID]
 		c.mu.RUnlock()
-		if !ok {
-			// nothing we can do; return original error
-			c.log.Printf("wanted to recover contract %v with host %v, but no revision was cached", contract.ID, contract.NetAddress)
+		if ok {
+			c.log.Printf("host %v has different revision for %v; retrying with cached revision", contract.NetAddress, contract.ID)
+			contract.LastRevision = cached.Revision
+			// return unused outputs to wallet and start a new transaction
+			txnBuilder.Drop()
+			txnBuilder = c.wallet.StartTransaction()
+			newContract, err = proto.Renew(contract, params, txnBuilder, c.tpool, c.hdb, c.tg.StopChan())
+		}
+	}
+	if proto.IsRevisionMismatch(err) {
+		// return unused outputs to wallet
+		txnBuilder.Drop()
+		// the cached revision did not help; reconcile with the host
+		c.log.Printf("host %v has different revision for %v; reconciling", contract.NetAddress, contract.ID)
+		reconciled, rerr := c.managedReconcileContract(host, contract.ID)
+		if rerr != nil {
+			c.log.Printf("failed to reconcile contract %v with host %v: %v", contract.ID, contract.NetAddress, rerr)
 			return modules.RenterContract{}, err
 		}
-		c.log.Printf("host %v has different revision for %v; retrying with cached revision", contract.NetAddress, contract.ID)
-		contract.LastRevision = cached.Revision
-		// need to start a new transaction
+		contract = reconciled
+		contract.NetAddress = host.NetAddress
 		txnBuilder = c.wallet.StartTransaction()
 		newContract, err = proto.Renew(contract, params, txnBuilder, c.tpool, c.hdb, c.tg.StopChan())
 	}
@@ -340,12 +553,26 @@ func (c *Contractor) threadedContractMaintenance() {
 	// hostdb.
 	c.managedMarkContractsUtility()
 
-	// Renew any contracts that need to be renewed.
+	// Renew any contracts that need to be renewed. Contracts which are running
+	// out of money before reaching the renew window are refreshed, meaning
+	// that they are renewed early with the same end height.
 	c.mu.RLock()
-	var renewSet []types.FileContractID
+	var renewSet []fileContractRenewal
 	for _, contract := range c.contracts {
-		if contract.GoodForRenew && c.blockHeight+c.allowance.RenewWindow >= contract.EndHeight() {
-			renewSet = append(renewSet, contract.ID)
+		if !contract.GoodForRenew {
+			continue
+		}
+		if c.blockHeight+c.allowance.RenewWindow >= contract.EndHeight() {
+			renewSet = append(renewSet, fileContractRenewal{
+				id:        contract.ID,
+				endHeight: c.blockHeight + c.allowance.Period,
+			})
+		} else if needsRefresh(contract) {
+			renewSet = append(renewSet, fileContractRenewal{
+				id:        contract.ID,
+				endHeight: contract.EndHeight(),
+				refresh:   true,
+			})
 		}
 	}
 	c.mu.RUnlock()
@@ -353,12 +580,7 @@ func (c *Contractor) threadedContractMaintenance() {
 		c.log.Printf("renewing %v contracts", len(renewSet))
 	}
 
-	// TODO: Need some loop somewhere that renews contracts which haven't gone
-	// through the full period yet, but are out of money (yet the allowance
-	// still has room to refill some contracts)
-
-	// Figure out the end height and target sector count for the contracts being
-	// renewed.
+	// Figure out the target sector count for the contracts being renewed.
 	//
 	// TODO: EndHeight should be global, and it should be picked based on the
 	// current period start, not based on the current height plus the allowance
@@ -378,8 +600,14 @@ func (c *Contractor) threadedContractMaintenance() {
 		return
 	}
 
+	// Size each renewal according to how the contract was used, and keep
+	// the refreshes within the allowance.
+	c.managedSizeRenewals(renewSet, numSectors, max)
+	renewSet = c.managedLimitRefreshes(renewSet)
+
 	// Loop through the contracts and renew them one-by-one.
-	for _, id := range renewSet {
+	for _, renewal := range renewSet {
+		id := renewal.id
 		// Renew one contract.
 		func() {
 			// Mark the contract as being renewed, and defer logic to unmark it
@@ -415,12 +643,16 @@ func (c *Contractor) threadedContractMaintenance() {
 			}
 
 			// Create the new contract.
-			newContract, err := c.managedRenew(oldContract, numSectors, endHeight)
+			newContract, err := c.managedRenew(oldContract, renewal.numSectors, renewal.endHeight)
 			if err != nil {
 				c.log.Printf("WARN: failed to renew contract %v with %v: %v\n", id, oldContract.NetAddress, err)
 				return
 			}
-			c.log.Printf("Renewed contract %v with %v\n", id, oldContract.NetAddress)
+			if renewal.refresh {
+				c.log.Printf("Refreshed contract %v with %v for %v sectors\n", id, oldContract.NetAddress, renewal.numSectors)
+			} else {
+				c.log.Printf("Renewed contract %v with %v for %v sectors\n", id, oldContract.NetAddress, renewal.numSectors)
+			}
 			// Update the utility values for the new contract, and for the old
 			// contract.
 			newContract.GoodForUpload = true
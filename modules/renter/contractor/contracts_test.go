@@ -0,0 +1,126 @@
+package contractor
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestRenewSectors tests that renewals are sized according to the usage of
+// the contract being renewed.
+func TestRenewSectors(t *testing.T) {
+	host := modules.HostDBEntry{}
+	host.StoragePrice = types.NewCurrency64(1)
+	sectorCost := host.StoragePrice.Mul64(modules.SectorSize)
+
+	// A contract without measurable usage gets the default size.
+	contract := modules.RenterContract{StartHeight: 100}
+	if n := renewSectors(contract, host, 100, 200, 50, 10, 1000); n != 50 {
+		t.Fatal("expected default sector count for unused contract, got", n)
+	}
+
+	// A contract that was barely used gets the minimum size.
+	if n := renewSectors(contract, host, 200, 300, 50, 10, 1000); n != 10 {
+		t.Fatal("expected minimum sector count for idle contract, got", n)
+	}
+
+	// A contract holding 20 sectors, which spent the equivalent of 40
+	// sector-periods over its lifetime, should be sized to hold its current
+	// data plus the projected spending, scaled by the headroom.
+	contract.LastRevision.NewFileSize = 20 * modules.SectorSize
+	contract.UploadSpending = sectorCost.Mul64(100).Mul64(20)
+	contract.DownloadSpending = sectorCost.Mul64(100).Mul64(20)
+	expected := uint64(20 + 40*renewUsageHeadroom)
+	if n := renewSectors(contract, host, 200, 300, 50, 10, 1000); n != expected {
+		t.Fatalf("expected %v sectors, got %v", expected, n)
+	}
+
+	// Heavy usage is capped at the maximum.
+	contract.DownloadSpending = sectorCost.Mul64(100).Mul64(1e6)
+	if n := renewSectors(contract, host, 200, 300, 50, 10, 1000); n != 1000 {
+		t.Fatal("expected maximum sector count for busy contract, got", n)
+	}
+}
+
+// TestNeedsRefresh tests that contracts are refreshed once their remaining
+// funds drop below the renewal threshold.
+func TestNeedsRefresh(t *testing.T) {
+	contract := modules.RenterContract{
+		TotalCost: types.NewCurrency64(1000),
+		LastRevision: types.FileContractRevision{
+			NewValidProofOutputs: []types.SiacoinOutput{
+				{Value: types.NewCurrency64(500)},
+				{Value: types.ZeroCurrency},
+			},
+		},
+	}
+	if needsRefresh(contract) {
+		t.Fatal("contract with half of its funds remaining should not be refreshed")
+	}
+	contract.LastRevision.NewValidProofOutputs[0].Value = types.NewCurrency64(10)
+	if !needsRefresh(contract) {
+		t.Fatal("contract with 1% of its funds remaining should be refreshed")
+	}
+}
+
+// TestLimitRefreshes tests that refreshes are shrunk or dropped so that the
+// spending of the current period stays within the allowance.
+func TestLimitRefreshes(t *testing.T) {
+	host := modules.HostDBEntry{}
+	host.PublicKey = types.SiaPublicKey{Key: []byte("foo")}
+	host.StoragePrice = types.NewCurrency64(1)
+	host.ContractPrice = types.NewCurrency64(1000)
+	hdb := mapHostDB{hosts: map[string]modules.HostDBEntry{"foo": host}}
+
+	// The contractor has spent 100 sectors' worth of funds for 100 blocks,
+	// and has 150 sectors' worth left.
+	sectorCost := renewalCost(host, 1, 100, types.ZeroCurrency).Sub(renewalCost(host, 0, 100, types.ZeroCurrency))
+	spent := sectorCost.Mul64(100)
+	c := &Contractor{
+		hdb:   hdb,
+		tpool: newStub{},
+		log:   persist.NewLogger(ioutil.Discard),
+
+		allowance:   modules.Allowance{Funds: spent.Add(sectorCost.Mul64(150))},
+		blockHeight: 100,
+		contracts: map[types.FileContractID]modules.RenterContract{
+			{1}: {ID: types.FileContractID{1}, HostPublicKey: host.PublicKey, StartHeight: 50, TotalCost: spent},
+			{2}: {ID: types.FileContractID{2}, HostPublicKey: host.PublicKey, StartHeight: 50},
+			{3}: {ID: types.FileContractID{3}, HostPublicKey: host.PublicKey, StartHeight: 50},
+		},
+		oldContracts: make(map[types.FileContractID]modules.RenterContract),
+	}
+
+	// The first refresh fits, the second is shrunk, and the third is dropped.
+	// Renewals that are not refreshes are not limited.
+	renewSet := []fileContractRenewal{
+		{id: types.FileContractID{1}, endHeight: 200, numSectors: 100, refresh: true},
+		{id: types.FileContractID{2}, endHeight: 200, numSectors: 100, refresh: true},
+		{id: types.FileContractID{3}, endHeight: 200, numSectors: 100, refresh: true},
+		{id: types.FileContractID{3}, endHeight: 300, numSectors: 100},
+	}
+	limited := c.managedLimitRefreshes(renewSet)
+	if len(limited) != 3 {
+		t.Fatal("expected 3 renewals, got", len(limited))
+	}
+	if limited[0].numSectors != 100 || limited[1].numSectors == 0 || limited[1].numSectors >= 50 || limited[2].refresh {
+		t.Fatalf("refreshes were not limited correctly: %+v", limited)
+	}
+	total := c.periodSpending()
+	for _, renewal := range limited[:2] {
+		total = total.Add(renewalCost(host, renewal.numSectors, renewal.endHeight-c.blockHeight, types.ZeroCurrency))
+	}
+	if total.Cmp(c.allowance.Funds) > 0 {
+		t.Fatalf("refreshes spend %v, more than the allowance of %v", total, c.allowance.Funds)
+	}
+
+	// Spending from a previous period does not count towards the allowance.
+	c.currentPeriod = 75
+	limited = c.managedLimitRefreshes(renewSet)
+	if len(limited) != 4 {
+		t.Fatal("expected 4 renewals, got", len(limited))
+	}
+}
@@ -2,8 +2,10 @@ package contractor
 
 import (
 	"path/filepath"
+	"time"
 
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -48,16 +50,21 @@ type (
 	hostDB interface {
 		AllHosts() []modules.HostDBEntry
 		ActiveHosts() []modules.HostDBEntry
+		FlagDishonestHost(key types.SiaPublicKey)
 		Host(types.SiaPublicKey) (modules.HostDBEntry, bool)
 		IncrementSuccessfulInteractions(key types.SiaPublicKey)
 		IncrementFailedInteractions(key types.SiaPublicKey)
 		RandomHosts(n int, exclude []types.SiaPublicKey) []modules.HostDBEntry
+		RecordDownloadThroughput(key types.SiaPublicKey, size uint64, elapsed time.Duration)
+		RecordUploadThroughput(key types.SiaPublicKey, size uint64, elapsed time.Duration)
 		ScoreBreakdown(modules.HostDBEntry) modules.HostScoreBreakdown
+		SetContractedHosts([]types.SiaPublicKey)
 	}
 
 	persister interface {
 		save(contractorPersist) error
 		update(...journalUpdate) error
+		needsCompaction() bool
 		load(*contractorPersist) error
 		Close() error
 	}
@@ -77,6 +84,7 @@ func (ws *walletBridge) StartTransaction() transactionBuilder         { return w
 type stdPersist struct {
 	journal  *journal
 	filename string
+	log      *persist.Logger
 }
 
 func (p *stdPersist) save(data contractorPersist) error {
@@ -92,10 +100,21 @@ func (p *stdPersist) update(us ...journalUpdate) error {
 	return p.journal.update(us)
 }
 
+func (p *stdPersist) needsCompaction() bool {
+	return p.journal.needsCompaction()
+}
+
 func (p *stdPersist) load(data *contractorPersist) error {
+	var report JournalReport
 	var err error
-	p.journal, err = openJournal(p.filename, data)
-	if err != nil {
+	p.journal, report, err = openJournal(p.filename, data)
+	if report.Corrupt() {
+		p.log.Printf("WARN: contractor journal is corrupt; the original was saved to %v_corrupt. Lost %v update sets (%v bytes), torn write: %v, first error: %v",
+			p.filename, report.LostUpdateSets, report.LostSize, report.TornWrite, report.FirstError)
+	}
+	if err == errJournalCorrupt {
+		return err
+	} else if err != nil {
 		// Try loading old persist.
 		err = loadv110persist(filepath.Dir(p.filename), data)
 		if err != nil {
@@ -110,8 +129,9 @@ func (p stdPersist) Close() error {
 	return p.journal.Close()
 }
 
-func newPersist(dir string) *stdPersist {
+func newPersist(dir string, log *persist.Logger) *stdPersist {
 	return &stdPersist{
 		filename: filepath.Join(dir, "contractor.journal"),
+		log:      log,
 	}
 }
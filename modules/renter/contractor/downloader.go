@@ -3,6 +3,7 @@ package contractor
 import (
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
@@ -22,18 +23,23 @@ type Downloader interface {
 	// retrieve.
 	Sector(root crypto.Hash) ([]byte, error)
 
+	// Range retrieves length bytes starting at offset within the sector with
+	// the specified Merkle root. If the host supports it, only the requested
+	// range is downloaded and paid for.
+	Range(root crypto.Hash, offset, length uint64) ([]byte, error)
+
 	// Close terminates the connection to the host.
 	Close() error
 }
 
-// A hostDownloader retrieves sectors by calling the download RPC on a host.
-// It implements the Downloader interface. hostDownloaders are safe for use by
-// multiple goroutines.
+// A hostDownloader retrieves sectors by performing downloads over a session
+// with a host. It implements the Downloader interface. hostDownloaders are
+// safe for use by multiple goroutines.
 type hostDownloader struct {
 	clients      int // safe to Close when 0
 	contractID   types.FileContractID
 	contractor   *Contractor
-	downloader   *proto.Downloader
+	session      *proto.Session
 	hostSettings modules.HostExternalSettings
 	invalid      bool   // true if invalidate has been called
 	speed        uint64 // Bytes per second.
@@ -41,14 +47,14 @@ type hostDownloader struct {
 }
 
 // invalidate sets the invalid flag and closes the underlying
-// proto.Downloader. Once invalidate returns, the hostDownloader is guaranteed
+// proto.Session. Once invalidate returns, the hostDownloader is guaranteed
 // to not further revise its contract. This is used during contract renewal to
 // prevent a Downloader from revising a contract mid-renewal.
 func (hd *hostDownloader) invalidate() {
 	hd.mu.Lock()
 	defer hd.mu.Unlock()
 	if !hd.invalid {
-		hd.downloader.Close()
+		hd.session.Close()
 		hd.invalid = true
 	}
 	hd.contractor.mu.Lock()
@@ -65,29 +71,54 @@ func (hd *hostDownloader) HostSettings() modules.HostExternalSettings {
 	return hd.hostSettings
 }
 
-// Sector retrieves the sector with the specified Merkle root, and revises
-// the underlying contract to pay the host proportionally to the data
-// retrieve.
-func (hd *hostDownloader) Sector(root crypto.Hash) ([]byte, error) {
+// download performs a download using fn, updating the contract and the
+// host's throughput afterwards.
+func (hd *hostDownloader) download(fn func() (modules.RenterContract, []byte, error)) ([]byte, error) {
 	hd.mu.Lock()
 	defer hd.mu.Unlock()
 	if hd.invalid {
 		return nil, errInvalidDownloader
 	}
-	contract, sector, err := hd.downloader.Sector(root)
+	start := time.Now()
+	contract, data, err := fn()
 	if err != nil {
 		return nil, err
 	}
 
+	// A range may be served from a sector that was already downloaded, in
+	// which case the contract was not revised.
 	hd.contractor.mu.Lock()
-	hd.contractor.contracts[contract.ID] = contract
-	hd.contractor.persist.update(updateDownloadRevision{
-		NewRevisionTxn:      contract.LastRevisionTxn,
-		NewDownloadSpending: contract.DownloadSpending,
-	})
+	revised := contract.LastRevision.NewRevisionNumber != hd.contractor.contracts[contract.ID].LastRevision.NewRevisionNumber
+	if revised {
+		hd.contractor.contracts[contract.ID] = contract
+		hd.contractor.updateJournal(updateDownloadRevision{
+			NewRevisionTxn:      contract.LastRevisionTxn,
+			NewDownloadSpending: contract.DownloadSpending,
+		})
+	}
 	hd.contractor.mu.Unlock()
+	if revised {
+		hd.contractor.hdb.RecordDownloadThroughput(contract.HostPublicKey, uint64(len(data)), time.Since(start))
+	}
+	return data, nil
+}
+
+// Sector retrieves the sector with the specified Merkle root, and revises
+// the underlying contract to pay the host proportionally to the data
+// retrieve.
+func (hd *hostDownloader) Sector(root crypto.Hash) ([]byte, error) {
+	return hd.download(func() (modules.RenterContract, []byte, error) {
+		return hd.session.Download(root)
+	})
+}
 
-	return sector, nil
+// Range retrieves length bytes starting at offset within the sector with the
+// specified Merkle root, and revises the underlying contract to pay the host
+// for the data retrieved.
+func (hd *hostDownloader) Range(root crypto.Hash, offset, length uint64) ([]byte, error) {
+	return hd.download(func() (modules.RenterContract, []byte, error) {
+		return hd.session.DownloadRange(root, offset, length)
+	})
 }
 
 // Close cleanly terminates the download loop with the host and closes the
@@ -106,7 +137,7 @@ func (hd *hostDownloader) Close() error {
 	delete(hd.contractor.downloaders, hd.contractID)
 	delete(hd.contractor.revising, hd.contractID)
 	hd.contractor.mu.Unlock()
-	return hd.downloader.Close()
+	return hd.session.Close()
 }
 
 // Downloader returns a Downloader object that can be used to download sectors
@@ -118,6 +149,7 @@ func (c *Contractor) Downloader(id types.FileContractID, cancel <-chan struct{})
 	height := c.blockHeight
 	contract, haveContract := c.contracts[id]
 	renewing := c.renewing[id]
+	maxIncrease := c.maxPriceIncrease()
 	c.mu.RUnlock()
 
 	if renewing {
@@ -141,6 +173,8 @@ func (c *Contractor) Downloader(id types.FileContractID, cancel <-chan struct{})
 		return nil, errors.New("no record of that host")
 	} else if host.DownloadBandwidthPrice.Cmp(maxDownloadPrice) > 0 {
 		return nil, errTooExpensive
+	} else if err := checkPriceIncrease(host, contract, maxIncrease); err != nil {
+		return nil, err
 	}
 	// Update the contract to the most recent net address for the host.
 	contract.NetAddress = host.NetAddress
@@ -175,39 +209,58 @@ func (c *Contractor) Downloader(id types.FileContractID, cancel <-chan struct{})
 		}
 	}
 
-	// create downloader
-	d, err := proto.NewDownloader(host, contract, c.hdb, cancel)
+	// open a session for downloads; hosts that do not support sessions are
+	// contacted with the standalone download RPC instead
+	newSession := func(contract modules.RenterContract) (*proto.Session, error) {
+		s, err := proto.NewSession(host, contract, height, c.hdb, cancel)
+		if err != nil {
+			return nil, err
+		}
+		// supply a SaveFn that saves the revision to the contractor's persist
+		// (the existing revision will be overwritten when SaveFn is called)
+		s.SaveFn = c.saveDownloadRevision(contract.ID)
+		if err := s.OpenDownloader(); err != nil {
+			s.Close()
+			return nil, err
+		}
+		return s, nil
+	}
+	s, err := newSession(contract)
 	if proto.IsRevisionMismatch(err) {
 		// try again with the cached revision
 		c.mu.RLock()
 		cached, ok := c.cachedRevisions[contract.ID]
 		c.mu.RUnlock()
-		if !ok {
-			// nothing we can do; return original error
-			c.log.Printf("wanted to recover contract %v with host %v, but no revision was cached", contract.ID, contract.NetAddress)
-			return nil, err
+		if ok {
+			c.log.Printf("host %v has different revision for %v; retrying with cached revision", contract.NetAddress, contract.ID)
+			contract.LastRevision = cached.Revision
+			s, err = newSession(contract)
 		}
-		c.log.Printf("host %v has different revision for %v; retrying with cached revision", contract.NetAddress, contract.ID)
-		contract.LastRevision = cached.Revision
-		d, err = proto.NewDownloader(host, contract, c.hdb, cancel)
-		// needs to be handled separately since a revision mismatch is not automatically a failed interaction
-		if proto.IsRevisionMismatch(err) {
+	}
+	if proto.IsRevisionMismatch(err) {
+		// the cached revision did not help; reconcile with the host
+		c.log.Printf("host %v has different revision for %v; reconciling", contract.NetAddress, contract.ID)
+		reconciled, rerr := c.managedReconcileContract(host, contract.ID)
+		if rerr != nil {
+			c.log.Printf("failed to reconcile contract %v with host %v: %v", contract.ID, contract.NetAddress, rerr)
+			// needs to be handled separately since a revision mismatch is not automatically a failed interaction
 			c.hdb.IncrementFailedInteractions(host.PublicKey)
+			return nil, err
 		}
+		contract = reconciled
+		contract.NetAddress = host.NetAddress
+		s, err = newSession(contract)
 	}
 	if err != nil {
 		return nil, err
 	}
-	// supply a SaveFn that saves the revision to the contractor's persist
-	// (the existing revision will be overwritten when SaveFn is called)
-	d.SaveFn = c.saveDownloadRevision(contract.ID)
 
 	// cache downloader
 	hd := &hostDownloader{
 		clients:      1,
 		contractID:   contract.ID,
 		contractor:   c,
-		downloader:   d,
+		session:      s,
 		hostSettings: host.HostExternalSettings,
 	}
 	c.mu.Lock()
@@ -3,6 +3,7 @@ package contractor
 import (
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
@@ -24,6 +25,13 @@ type Editor interface {
 	// returns the Merkle root of the data.
 	Upload(data []byte) (root crypto.Hash, err error)
 
+	// UploadBatch revises the underlying contract to store several sectors,
+	// sending as many of them per revision as the host allows. It returns
+	// the Merkle roots of the sectors that were uploaded, in order; if an
+	// error occurs, the roots of the sectors uploaded before the error are
+	// returned along with it.
+	UploadBatch(data [][]byte) (roots []crypto.Hash, err error)
+
 	// Delete removes a sector from the underlying contract.
 	Delete(crypto.Hash) error
 
@@ -45,19 +53,19 @@ type Editor interface {
 	Close() error
 }
 
-// A hostEditor modifies a Contract by calling the revise RPC on a host. It
-// implements the Editor interface. hostEditors are safe for use by
-// multiple goroutines.
+// A hostEditor modifies a Contract by performing revisions over a session
+// with a host. It implements the Editor interface. hostEditors are safe for
+// use by multiple goroutines.
 type hostEditor struct {
 	clients    int // safe to Close when 0
 	contract   modules.RenterContract
 	contractor *Contractor
-	editor     *proto.Editor
+	session    *proto.Session
 	invalid    bool // true if invalidate has been called
 	mu         sync.Mutex
 }
 
-// invalidate sets the invalid flag and closes the underlying proto.Editor.
+// invalidate sets the invalid flag and closes the underlying proto.Session.
 // Once invalidate returns, the hostEditor is guaranteed to not further revise
 // its contract. This is used during contract renewal to prevent an Editor
 // from revising a contract mid-renewal.
@@ -65,7 +73,7 @@ func (he *hostEditor) invalidate() {
 	he.mu.Lock()
 	defer he.mu.Unlock()
 	if !he.invalid {
-		he.editor.Close()
+		he.session.Close()
 		he.invalid = true
 	}
 	he.contractor.mu.Lock()
@@ -100,33 +108,64 @@ func (he *hostEditor) Close() error {
 	delete(he.contractor.editors, he.contract.ID)
 	delete(he.contractor.revising, he.contract.ID)
 	he.contractor.mu.Unlock()
-	return he.editor.Close()
+	return he.session.Close()
 }
 
 // Upload negotiates a revision that adds a sector to a file contract.
 func (he *hostEditor) Upload(data []byte) (_ crypto.Hash, err error) {
+	roots, err := he.UploadBatch([][]byte{data})
+	if err != nil {
+		return crypto.Hash{}, err
+	}
+	return roots[0], nil
+}
+
+// UploadBatch negotiates revisions that add several sectors to a file
+// contract, sending as many sectors per revision as the host allows.
+func (he *hostEditor) UploadBatch(data [][]byte) (roots []crypto.Hash, err error) {
 	he.mu.Lock()
 	defer he.mu.Unlock()
 	if he.invalid {
-		return crypto.Hash{}, errInvalidEditor
+		return nil, errInvalidEditor
 	}
-	contract, sectorRoot, err := he.editor.Upload(data)
-	if err != nil {
-		return crypto.Hash{}, err
-	}
-	he.contractor.mu.Lock()
-	he.contractor.contracts[contract.ID] = contract
-	he.contractor.persist.update(updateUploadRevision{
-		NewRevisionTxn:     contract.LastRevisionTxn,
-		NewSectorRoot:      sectorRoot,
-		NewSectorIndex:     len(contract.MerkleRoots) - 1,
-		NewUploadSpending:  contract.UploadSpending,
-		NewStorageSpending: contract.StorageSpending,
-	})
-	he.contractor.mu.Unlock()
-	he.contract = contract
+	for len(data) > 0 {
+		batch := data
+		if max := he.session.MaxUploadBatch(); len(batch) > max {
+			batch = batch[:max]
+		}
+		data = data[len(batch):]
 
-	return sectorRoot, nil
+		var size uint64
+		for _, sector := range batch {
+			size += uint64(len(sector))
+		}
+		start := time.Now()
+		contract, sectorRoots, err := he.session.UploadBatch(batch)
+		if err != nil {
+			return roots, err
+		}
+		he.contractor.hdb.RecordUploadThroughput(contract.HostPublicKey, size, time.Since(start))
+
+		// record each new sector in the journal
+		updates := make([]journalUpdate, len(sectorRoots))
+		firstIndex := len(contract.MerkleRoots) - len(sectorRoots)
+		for i, root := range sectorRoots {
+			updates[i] = updateUploadRevision{
+				NewRevisionTxn:     contract.LastRevisionTxn,
+				NewSectorRoot:      root,
+				NewSectorIndex:     firstIndex + i,
+				NewUploadSpending:  contract.UploadSpending,
+				NewStorageSpending: contract.StorageSpending,
+			}
+		}
+		he.contractor.mu.Lock()
+		he.contractor.contracts[contract.ID] = contract
+		he.contractor.updateJournal(updates...)
+		he.contractor.mu.Unlock()
+		he.contract = contract
+		roots = append(roots, sectorRoots...)
+	}
+	return roots, nil
 }
 
 // Delete negotiates a revision that removes a sector from a file contract.
@@ -136,7 +175,7 @@ func (he *hostEditor) Delete(root crypto.Hash) (err error) {
 	if he.invalid {
 		return errInvalidEditor
 	}
-	contract, err := he.editor.Delete(root)
+	contract, err := he.session.Delete(root)
 	if err != nil {
 		return err
 	}
@@ -157,7 +196,7 @@ func (he *hostEditor) Modify(oldRoot, newRoot crypto.Hash, offset uint64, newDat
 	if he.invalid {
 		return errInvalidEditor
 	}
-	contract, err := he.editor.Modify(oldRoot, newRoot, offset, newData)
+	contract, err := he.session.Modify(oldRoot, newRoot, offset, newData)
 	if err != nil {
 		return err
 	}
@@ -179,6 +218,7 @@ func (c *Contractor) Editor(id types.FileContractID, cancel <-chan struct{}) (_
 	height := c.blockHeight
 	contract, haveContract := c.contracts[id]
 	renewing := c.renewing[id]
+	maxIncrease := c.maxPriceIncrease()
 	c.mu.RUnlock()
 
 	if renewing {
@@ -204,6 +244,8 @@ func (c *Contractor) Editor(id types.FileContractID, cancel <-chan struct{}) (_
 		return nil, errTooExpensive
 	} else if host.UploadBandwidthPrice.Cmp(maxUploadPrice) > 0 {
 		return nil, errTooExpensive
+	} else if err := checkPriceIncrease(host, contract, maxIncrease); err != nil {
+		return nil, err
 	} else if build.VersionCmp(host.Version, "0.6.0") > 0 {
 		// COMPATv0.6.0: don't cap host.Collateral on old hosts
 		if host.Collateral.Cmp(maxUploadCollateral) > 0 {
@@ -242,40 +284,59 @@ func (c *Contractor) Editor(id types.FileContractID, cancel <-chan struct{}) (_
 		}
 	}
 
-	// create editor
-	e, err := proto.NewEditor(host, contract, height, c.hdb, cancel)
+	// open a session for revisions; hosts that do not support sessions are
+	// contacted with the standalone revision RPC instead
+	newSession := func(contract modules.RenterContract) (*proto.Session, error) {
+		s, err := proto.NewSession(host, contract, height, c.hdb, cancel)
+		if err != nil {
+			return nil, err
+		}
+		// supply a SaveFn that saves the revision to the contractor's persist
+		// (the existing revision will be overwritten when SaveFn is called)
+		s.SaveFn = c.saveUploadRevision(contract.ID)
+		if err := s.OpenEditor(); err != nil {
+			s.Close()
+			return nil, err
+		}
+		return s, nil
+	}
+	s, err := newSession(contract)
 	if proto.IsRevisionMismatch(err) {
 		// try again with the cached revision
 		c.mu.RLock()
 		cached, ok := c.cachedRevisions[contract.ID]
 		c.mu.RUnlock()
-		if !ok {
-			// nothing we can do; return original error
-			c.log.Printf("wanted to recover contract %v with host %v, but no revision was cached", contract.ID, contract.NetAddress)
-			return nil, err
+		if ok {
+			c.log.Printf("host %v has different revision for %v; retrying with cached revision", contract.NetAddress, contract.ID)
+			contract.LastRevision = cached.Revision
+			contract.MerkleRoots = cached.MerkleRoots
+			s, err = newSession(contract)
 		}
-		c.log.Printf("host %v has different revision for %v; retrying with cached revision", contract.NetAddress, contract.ID)
-		contract.LastRevision = cached.Revision
-		contract.MerkleRoots = cached.MerkleRoots
-		e, err = proto.NewEditor(host, contract, height, c.hdb, cancel)
-		// needs to be handled separately since a revision mismatch is not automatically a failed interaction
-		if proto.IsRevisionMismatch(err) {
+	}
+	if proto.IsRevisionMismatch(err) {
+		// the cached revision did not help; reconcile with the host
+		c.log.Printf("host %v has different revision for %v; reconciling", contract.NetAddress, contract.ID)
+		reconciled, rerr := c.managedReconcileContract(host, contract.ID)
+		if rerr != nil {
+			c.log.Printf("failed to reconcile contract %v with host %v: %v", contract.ID, contract.NetAddress, rerr)
+			// needs to be handled separately since a revision mismatch is not automatically a failed interaction
 			c.hdb.IncrementFailedInteractions(host.PublicKey)
+			return nil, err
 		}
+		contract = reconciled
+		contract.NetAddress = host.NetAddress
+		s, err = newSession(contract)
 	}
 	if err != nil {
 		return nil, err
 	}
-	// supply a SaveFn that saves the revision to the contractor's persist
-	// (the existing revision will be overwritten when SaveFn is called)
-	e.SaveFn = c.saveUploadRevision(contract.ID)
 
 	// cache editor
 	he := &hostEditor{
 		clients:    1,
 		contract:   contract,
 		contractor: c,
-		editor:     e,
+		session:    s,
 	}
 	c.mu.Lock()
 	c.editors[contract.ID] = he
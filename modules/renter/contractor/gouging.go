@@ -0,0 +1,76 @@
+package contractor
+
+// gouging.go protects the renter from hosts that raise their prices sharply
+// after a contract has been formed with them. The prices that a host
+// advertised when the contract was formed are stored with the contract, and
+// compared against the host's current prices.
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var errAllowanceMaxPriceIncrease = errors.New("max price increase must be at least 1")
+
+// formationPrices returns the prices that the host advertised when the
+// contract was formed, and false if they are unknown.
+func formationPrices(host modules.HostDBEntry, contract modules.RenterContract) (modules.HostPriceSnapshot, bool) {
+	if !contract.FormationPrices.Timestamp.IsZero() {
+		return contract.FormationPrices, true
+	}
+	// COMPATv1.3.0
+	// Contracts formed before the prices were stored with the contract are
+	// checked against the hostdb's price history. Use the last snapshot taken
+	// at or before the start of the contract. If the history does not reach
+	// back that far, the oldest snapshot is the best estimate.
+	if len(host.PriceHistory) == 0 {
+		return modules.HostPriceSnapshot{}, false
+	}
+	base := host.PriceHistory[0]
+	for _, snapshot := range host.PriceHistory {
+		if snapshot.BlockHeight > contract.StartHeight {
+			break
+		}
+		base = snapshot
+	}
+	return base, true
+}
+
+// checkPriceIncrease returns an error if any of the host's current prices has
+// risen by more than maxIncrease over the prices it advertised when the
+// contract was formed. Prices that were zero when the contract was formed are
+// not checked, as no ratio can be computed for them.
+func checkPriceIncrease(host modules.HostDBEntry, contract modules.RenterContract, maxIncrease float64) error {
+	base, known := formationPrices(host, contract)
+	if !known {
+		return nil
+	}
+
+	prices := []struct {
+		name      string
+		old, curr types.Currency
+	}{
+		{"contract", base.ContractPrice, host.ContractPrice},
+		{"download", base.DownloadBandwidthPrice, host.DownloadBandwidthPrice},
+		{"storage", base.StoragePrice, host.StoragePrice},
+		{"upload", base.UploadBandwidthPrice, host.UploadBandwidthPrice},
+	}
+	for _, p := range prices {
+		if !p.old.IsZero() && p.curr.Cmp(p.old.MulFloat(maxIncrease)) > 0 {
+			return fmt.Errorf("host raised its %v price from %v to %v, more than %v times the price when the contract was formed", p.name, p.old, p.curr, maxIncrease)
+		}
+	}
+	return nil
+}
+
+// maxPriceIncrease returns the ratio by which a host's prices may rise over
+// the course of a contract.
+func (c *Contractor) maxPriceIncrease() float64 {
+	if c.allowance.MaxPriceIncrease == 0 {
+		return defaultMaxPriceIncrease
+	}
+	return c.allowance.MaxPriceIncrease
+}
@@ -0,0 +1,94 @@
+package contractor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestCheckPriceIncrease tests that hosts are only rejected once their prices
+// rise past the allowed ratio over the prices from when the contract was
+// formed.
+func TestCheckPriceIncrease(t *testing.T) {
+	contractAt := func(height types.BlockHeight) modules.RenterContract {
+		return modules.RenterContract{StartHeight: height}
+	}
+	snapshot := func(height types.BlockHeight, storagePrice uint64) modules.HostPriceSnapshot {
+		return modules.HostPriceSnapshot{
+			BlockHeight:            height,
+			DownloadBandwidthPrice: types.NewCurrency64(5),
+			StoragePrice:           types.NewCurrency64(storagePrice),
+		}
+	}
+	var host modules.HostDBEntry
+	host.DownloadBandwidthPrice = types.NewCurrency64(5)
+	host.StoragePrice = types.NewCurrency64(100)
+
+	// A host without a price history cannot be checked.
+	if err := checkPriceIncrease(host, contractAt(10), 3); err != nil {
+		t.Fatal(err)
+	}
+
+	host.PriceHistory = []modules.HostPriceSnapshot{
+		snapshot(5, 10),
+		snapshot(20, 50),
+		snapshot(30, 100),
+	}
+	tests := []struct {
+		startHeight types.BlockHeight
+		maxIncrease float64
+		gouging     bool
+	}{
+		{0, 3, true},    // history starts after the contract; 100 > 3*10
+		{10, 3, true},   // 100 > 3*10
+		{10, 10, false}, // 100 <= 10*10
+		{25, 3, false},  // 100 <= 3*50
+		{25, 1.5, true}, // 100 > 1.5*50
+		{40, 1, false},  // prices have not changed
+	}
+	for _, test := range tests {
+		err := checkPriceIncrease(host, contractAt(test.startHeight), test.maxIncrease)
+		if (err != nil) != test.gouging {
+			t.Errorf("start height %v, max increase %v: expected gouging %v, got %v", test.startHeight, test.maxIncrease, test.gouging, err)
+		}
+	}
+
+	// Prices that were zero when the contract was formed are not checked.
+	host.UploadBandwidthPrice = types.NewCurrency64(1e6)
+	if err := checkPriceIncrease(host, contractAt(40), 1); err != nil {
+		t.Fatal(err)
+	}
+	host.UploadBandwidthPrice = types.ZeroCurrency
+
+	// The prices stored with the contract take precedence over the price
+	// history, which may no longer reach back to the start of the contract.
+	contract := contractAt(10)
+	contract.FormationPrices = snapshot(10, 10)
+	contract.FormationPrices.Timestamp = time.Now()
+	host.PriceHistory = []modules.HostPriceSnapshot{snapshot(30, 100)}
+	if err := checkPriceIncrease(host, contract, 3); err == nil {
+		t.Fatal("expected price increase over the formation prices to be rejected")
+	}
+	host.PriceHistory = nil
+	if err := checkPriceIncrease(host, contract, 3); err == nil {
+		t.Fatal("expected price increase to be rejected without a price history")
+	}
+	if err := checkPriceIncrease(host, contract, 10); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestMaxPriceIncrease tests that the contractor falls back to the default
+// ratio when the allowance does not specify one.
+func TestMaxPriceIncrease(t *testing.T) {
+	c := &Contractor{}
+	if c.maxPriceIncrease() != defaultMaxPriceIncrease {
+		t.Fatal("expected default max price increase, got", c.maxPriceIncrease())
+	}
+	c.allowance.MaxPriceIncrease = 1.5
+	if c.maxPriceIncrease() != 1.5 {
+		t.Fatal("expected max price increase of 1.5, got", c.maxPriceIncrease())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -195,6 +196,11 @@ func TestIntegrationFormContract(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	// the contract should have been formed over an encrypted connection
+	if h.NetworkMetrics().EncryptedCalls == 0 {
+		t.Fatal("contract was not formed over an encrypted connection")
+	}
 }
 
 // TestIntegrationReviseContract tests that the contractor can revise a
@@ -306,6 +312,169 @@ func TestIntegrationUploadDownload(t *testing.T) {
 	}
 }
 
+// TestIntegrationUploadBatch tests that the contractor can upload several
+// sectors to a host in a single revision.
+func TestIntegrationUploadBatch(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, _, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// form a contract with the host
+	contract, err := c.managedNewContract(hostEntry, 10, c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	c.contracts[contract.ID] = contract
+	c.mu.Unlock()
+
+	// upload a batch of sectors
+	editor, err := c.Editor(contract.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([][]byte, 3)
+	for i := range data {
+		data[i] = fastrand.Bytes(int(modules.SectorSize))
+	}
+	roots, err := editor.UploadBatch(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = editor.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != len(data) {
+		t.Fatal("expected", len(data), "roots, got", len(roots))
+	}
+
+	// the whole batch should have been uploaded in one revision
+	c.mu.RLock()
+	revised := c.contracts[contract.ID]
+	c.mu.RUnlock()
+	if revised.LastRevision.NewRevisionNumber != contract.LastRevision.NewRevisionNumber+1 {
+		t.Fatal("expected a single revision, got", revised.LastRevision.NewRevisionNumber-contract.LastRevision.NewRevisionNumber)
+	}
+	if revised.LastRevision.NewFileSize != uint64(len(data))*modules.SectorSize {
+		t.Fatal("revision has wrong file size:", revised.LastRevision.NewFileSize)
+	}
+
+	// download the data
+	downloader, err := c.Downloader(contract.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, root := range roots {
+		retrieved, err := downloader.Sector(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data[i], retrieved) {
+			t.Fatal("downloaded data does not match original")
+		}
+	}
+	err = downloader.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestIntegrationDownloadRange tests that the contractor can download a range
+// of a sector from a host, paying only for the segments covering the range.
+func TestIntegrationDownloadRange(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, _, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// form a contract with the host
+	contract, err := c.managedNewContract(hostEntry, 10, c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	c.contracts[contract.ID] = contract
+	c.mu.Unlock()
+
+	// upload a sector
+	nm := h.NetworkMetrics()
+	editor, err := c.Editor(contract.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(int(modules.SectorSize))
+	root, err := editor.Upload(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = editor.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// download a range that is not aligned to segment boundaries
+	downloader, err := c.Downloader(contract.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset, length := uint64(crypto.SegmentSize+10), uint64(3*crypto.SegmentSize)
+	retrieved, err := downloader.Range(root, offset, length)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data[offset:offset+length], retrieved) {
+		t.Fatal("downloaded data does not match original")
+	}
+	if _, err := downloader.Range(root, modules.SectorSize-10, 20); err == nil {
+		t.Fatal("expected error for range outside of sector")
+	}
+	err = downloader.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the Editor and the Downloader should each have used a session
+	if sessions := h.NetworkMetrics().SessionCalls - nm.SessionCalls; sessions != 2 {
+		t.Fatal("expected two session calls, got", sessions)
+	}
+
+	// only the 4 segments covering the range should have been paid for
+	c.mu.RLock()
+	spending := c.contracts[contract.ID].DownloadSpending
+	c.mu.RUnlock()
+	if spending.Cmp(hostEntry.DownloadBandwidthPrice.Mul64(modules.SectorSize)) >= 0 {
+		t.Fatal("range download was charged as a full sector:", spending)
+	}
+}
+
 // TestIntegrationDelete tests that the contractor can delete a sector from a
 // contract previously formed with a host.
 func TestIntegrationDelete(t *testing.T) {
@@ -683,16 +852,27 @@ func TestIntegrationResync(t *testing.T) {
 	c.contracts[badContract.ID] = badContract
 	c.mu.Unlock()
 
-	// Editor should fail with the bad contract
-	_, err = c.Editor(badContract.ID, nil)
-	if !proto.IsRevisionMismatch(err) {
-		t.Fatal("expected revision mismatch, got", err)
+	// Without a usable cached revision, the Editor should reconcile the bad
+	// contract with the host.
+	editor, err = c.Editor(badContract.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	editor.Close()
+	c.mu.Lock()
+	reconciled := c.contracts[contract.ID]
+	c.mu.Unlock()
+	if reconciled.LastRevision.NewRevisionNumber != contract.LastRevision.NewRevisionNumber {
+		t.Fatal("host revision was not adopted")
+	} else if len(reconciled.Reconciliations) != 1 || reconciled.Reconciliations[0].Outcome != modules.ReconciliationAdopted {
+		t.Fatal("reconciliation was not recorded:", reconciled.Reconciliations)
 	}
 
-	// add cachedRevision
+	// add cachedRevision, and corrupt the contract again
 	cachedRev := cachedRevision{contract.LastRevision, contract.MerkleRoots}
 	c.mu.Lock()
 	c.cachedRevisions[contract.ID] = cachedRev
+	c.contracts[badContract.ID] = badContract
 	c.mu.Unlock()
 
 	// Editor and Downloader should now succeed after loading the cachedRevision
@@ -708,27 +888,51 @@ func TestIntegrationResync(t *testing.T) {
 	}
 	downloader.Close()
 
-	// Add some corruption to the set of cached revisions.
-	badContract = contract
-	badContract.LastRevision.NewRevisionNumber--
-	badContract.LastRevisionTxn.TransactionSignatures = nil // delete signatures
+	// Add some corruption to the set of cached revisions, and corrupt the
+	// contract again.
 	c.mu.Lock()
 	cr = c.cachedRevisions[contract.ID]
 	cr.Revision.NewRevisionNumber = 0
 	cr.Revision.NewRevisionNumber--
+	cr.MerkleRoots = nil
 	c.cachedRevisions[contract.ID] = cr
 	c.contracts[badContract.ID] = badContract
 	c.mu.Unlock()
 
-	// Editor should fail with the bad contract
-	_, err = c.Editor(badContract.ID, nil)
-	if !proto.IsRevisionMismatch(err) {
-		t.Fatal("expected revision mismatch, got", err)
+	// The Editor should reconcile the contract with the host, adopting the
+	// host's revision and Merkle roots as the cached revision, and be able to
+	// upload.
+	editor, err = c.Editor(badContract.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	reconciled = c.contracts[contract.ID]
+	cr = c.cachedRevisions[contract.ID]
+	c.mu.Unlock()
+	if n := len(reconciled.Reconciliations); n == 0 || reconciled.Reconciliations[n-1].Outcome != modules.ReconciliationAdopted {
+		t.Fatal("reconciliation was not recorded:", reconciled.Reconciliations)
+	}
+	if cr.Revision.NewRevisionNumber != contract.LastRevision.NewRevisionNumber {
+		t.Fatal("host revision was not cached:", cr.Revision.NewRevisionNumber)
+	} else if len(cr.MerkleRoots) != 1 || cr.MerkleRoots[0] != root {
+		t.Fatal("host Merkle roots were not cached:", cr.MerkleRoots)
+	}
+	_, err = editor.Upload(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	editor.Close()
+	if hostEntry, _ = c.hdb.Host(h.PublicKey()); hostEntry.Dishonest {
+		t.Fatal("host was flagged after a successful reconciliation")
 	}
 
-	// add cachedRevision
+	// corrupt the contract again
 	c.mu.Lock()
-	c.cachedRevisions[contract.ID] = cachedRev
+	badContract = c.contracts[contract.ID]
+	badContract.LastRevision.NewRevisionNumber--
+	badContract.LastRevisionTxn.TransactionSignatures = nil // delete signatures
+	c.contracts[badContract.ID] = badContract
 	c.mu.Unlock()
 
 	// should be able to upload after loading the cachedRevision
@@ -743,6 +947,56 @@ func TestIntegrationResync(t *testing.T) {
 	editor.Close()
 }
 
+// TestIntegrationReconcileDishonest tests that a host is flagged as dishonest
+// if it presents a revision older than the renter's.
+func TestIntegrationReconcileDishonest(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, _, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// form a contract with the host
+	contract, err := c.managedNewContract(hostEntry, 10, c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// pretend that the renter has a newer revision than the host
+	contract.LastRevision.NewRevisionNumber++
+	c.mu.Lock()
+	c.contracts[contract.ID] = contract
+	c.cachedRevisions[contract.ID] = cachedRevision{contract.LastRevision, contract.MerkleRoots}
+	c.mu.Unlock()
+
+	// Editor should fail, and the host should be flagged
+	_, err = c.Editor(contract.ID, nil)
+	if !proto.IsRevisionMismatch(err) {
+		t.Fatal("expected revision mismatch, got", err)
+	}
+	c.mu.Lock()
+	reconciled := c.contracts[contract.ID]
+	c.mu.Unlock()
+	if len(reconciled.Reconciliations) != 1 || reconciled.Reconciliations[0].Outcome != modules.ReconciliationDishonest {
+		t.Fatal("reconciliation was not recorded:", reconciled.Reconciliations)
+	}
+	if hostEntry, _ = c.hdb.Host(h.PublicKey()); !hostEntry.Dishonest {
+		t.Fatal("host was not flagged as dishonest")
+	}
+}
+
 // TestIntegrationDownloaderCaching tests that downloaders are properly cached
 // by the contractor. When two downloaders are requested for the same
 // contract, only one underlying downloader should be created.
@@ -994,24 +1248,14 @@ func TestIntegrationCachedRenew(t *testing.T) {
 	}
 	contract = c.contracts[contract.ID]
 
-	// corrupt the contract and cachedRevision
+	// corrupt the contract
 	badContract := contract
 	badContract.LastRevision.NewRevisionNumber--
 	badContract.LastRevisionTxn.TransactionSignatures = nil // delete signatures
 	c.mu.Lock()
-	cr := c.cachedRevisions[contract.ID]
-	cr.Revision.NewRevisionNumber = 0
-	cr.Revision.NewRevisionNumber--
-	c.cachedRevisions[contract.ID] = cr
 	c.contracts[badContract.ID] = badContract
 	c.mu.Unlock()
 
-	// Renew should fail with the bad contract + cachedRevision
-	_, err = c.managedRenew(badContract, 20, c.blockHeight+200)
-	if !proto.IsRevisionMismatch(err) {
-		t.Fatal("expected revision mismatch, got", err)
-	}
-
 	// add cachedRevision
 	cachedRev := cachedRevision{contract.LastRevision, contract.MerkleRoots}
 	c.mu.Lock()
@@ -1023,4 +1267,528 @@ func TestIntegrationCachedRenew(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	// form a second contract, and corrupt both the contract and its
+	// cachedRevision
+	contract, err = c.managedNewContract(hostEntry, 10, c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	badContract = contract
+	badContract.LastRevision.NewRevisionNumber--
+	badContract.LastRevisionTxn.TransactionSignatures = nil // delete signatures
+	c.mu.Lock()
+	c.contracts[badContract.ID] = badContract
+	c.cachedRevisions[contract.ID] = cachedRevision{Revision: badContract.LastRevision}
+	c.mu.Unlock()
+
+	// Renew should reconcile the contract with the host, adopting the host's
+	// revision, and then succeed
+	_, err = c.managedRenew(badContract, 20, c.blockHeight+200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	reconciled := c.contracts[contract.ID]
+	cr := c.cachedRevisions[contract.ID]
+	c.mu.Unlock()
+	if reconciled.LastRevision.NewRevisionNumber != contract.LastRevision.NewRevisionNumber {
+		t.Fatal("host revision was not adopted")
+	} else if len(reconciled.Reconciliations) != 1 || reconciled.Reconciliations[0].Outcome != modules.ReconciliationAdopted {
+		t.Fatal("reconciliation was not recorded:", reconciled.Reconciliations)
+	} else if cr.Revision.NewRevisionNumber != contract.LastRevision.NewRevisionNumber {
+		t.Fatal("host revision was not cached:", cr.Revision.NewRevisionNumber)
+	}
+	if hostEntry, _ = c.hdb.Host(h.PublicKey()); hostEntry.Dishonest {
+		t.Fatal("host was flagged after a successful reconciliation")
+	}
+}
+
+// TestIntegrationSession tests that a session can carry a mix of uploads,
+// downloads and settings requests over a single connection, and that it
+// falls back to the standalone RPCs for hosts that predate sessions.
+func TestIntegrationSession(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, _, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// form a contract with the host
+	contract, err := c.managedNewContract(hostEntry, 10, c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// runSession uploads and downloads two sectors in an interleaved fashion,
+	// requesting the host's settings in between.
+	runSession := func(s *proto.Session) {
+		for i := 0; i < 2; i++ {
+			data := fastrand.Bytes(int(modules.SectorSize))
+			newContract, root, err := s.Upload(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			contract = newContract
+			if _, err := s.Settings(); err != nil {
+				t.Fatal(err)
+			}
+			newContract, retrieved, err := s.Download(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			contract = newContract
+			if !bytes.Equal(data, retrieved) {
+				t.Fatal("downloaded data does not match original")
+			}
+			newContract, retrieved, err = s.DownloadRange(root, 100, 1000)
+			if err != nil {
+				t.Fatal(err)
+			}
+			contract = newContract
+			if !bytes.Equal(data[100:1100], retrieved) {
+				t.Fatal("downloaded range does not match original")
+			}
+		}
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := proto.NewSession(hostEntry, contract, c.blockHeight, c.hdb, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runSession(s)
+	nm := h.NetworkMetrics()
+	if nm.SessionCalls != 1 {
+		t.Fatal("expected one session call, got", nm.SessionCalls)
+	}
+
+	// hosts that do not advertise sessions are contacted using the
+	// standalone RPCs
+	hostEntry.SupportedRPCs = []types.Specifier{modules.RPCEncryptedConn}
+	s, err = proto.NewSession(hostEntry, contract, c.blockHeight, c.hdb, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runSession(s)
+	if nm2 := h.NetworkMetrics(); nm2.SessionCalls != 1 {
+		t.Fatal("expected no further session calls, got", nm2.SessionCalls-1)
+	} else if nm2.ReviseCalls <= nm.ReviseCalls || nm2.DownloadCalls <= nm.DownloadCalls {
+		t.Fatal("standalone RPCs were not used")
+	}
+	if len(contract.MerkleRoots) != 4 {
+		t.Fatal("expected 4 sectors in contract, got", len(contract.MerkleRoots))
+	}
+}
+
+// TestIntegrationAudit tests that the contractor can audit a host, and that a
+// host which loses a sector fails its audit.
+func TestIntegrationAudit(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, _, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// form a contract with the host
+	contract, err := c.managedNewContract(hostEntry, 10, c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	c.contracts[contract.ID] = contract
+	c.mu.Unlock()
+
+	// an empty contract cannot be audited
+	if err := c.AuditContract(contract.ID); err != errAuditEmptyContract {
+		t.Fatal("expected errAuditEmptyContract, got", err)
+	}
+
+	// upload a sector
+	editor, err := c.Editor(contract.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := editor.Upload(fastrand.Bytes(int(modules.SectorSize)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the host should pass an audit
+	hostEntry, _ = c.hdb.Host(h.PublicKey())
+	successes := hostEntry.RecentSuccessfulInteractions
+	if err := c.AuditContract(contract.ID); err != nil {
+		t.Fatal(err)
+	}
+	if hostEntry, _ = c.hdb.Host(h.PublicKey()); hostEntry.RecentSuccessfulInteractions <= successes {
+		t.Fatal("successful audit was not recorded")
+	}
+
+	// the host should refuse another audit right away, without the refusal
+	// counting against it
+	successes = hostEntry.RecentSuccessfulInteractions
+	failures := hostEntry.RecentFailedInteractions
+	if err := c.AuditContract(contract.ID); err != modules.ErrAuditRateLimited {
+		t.Fatal("expected ErrAuditRateLimited, got", err)
+	}
+	hostEntry, _ = c.hdb.Host(h.PublicKey())
+	if hostEntry.RecentSuccessfulInteractions != successes || hostEntry.RecentFailedInteractions != failures {
+		t.Fatal("rate limited audit was recorded")
+	}
+
+	// delete the sector from the host; the host should fail the audit and be
+	// flagged
+	if err := h.DeleteSector(root); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(10, time.Second, func() error {
+		err := c.AuditContract(contract.ID)
+		if err == modules.ErrAuditRateLimited {
+			return err
+		}
+		if err != ErrAuditFailed {
+			t.Fatal("expected ErrAuditFailed, got", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostEntry, _ = c.hdb.Host(h.PublicKey()); !hostEntry.Dishonest {
+		t.Fatal("host was not flagged after failing an audit")
+	}
+	if c.GoodForRenew(contract.ID) {
+		t.Fatal("contract should not be renewed after a failed audit")
+	}
+
+	// an audit of a host that cannot be reached is inconclusive, but is
+	// recorded as a failed interaction
+	failures = hostEntry.RecentFailedInteractions
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AuditContract(contract.ID); err == nil || err == ErrAuditFailed {
+		t.Fatal("expected an inconclusive audit, got", err)
+	}
+	if hostEntry, _ = c.hdb.Host(h.PublicKey()); hostEntry.RecentFailedInteractions <= failures {
+		t.Fatal("inconclusive audit was not recorded")
+	}
+}
+
+// TestIntegrationRenterQuota tests that a host refuses downloads that would
+// take a renter over its download quota, and that the renter receives the
+// host's reason.
+func TestIntegrationRenterQuota(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, _, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// allow each renter to download a single sector per period
+	settings := h.InternalSettings()
+	settings.RenterDownloadQuota = modules.SectorSize
+	if err := h.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// form a contract with the host
+	contract, err := c.managedNewContract(hostEntry, 10, c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	c.contracts[contract.ID] = contract
+	c.mu.Unlock()
+
+	// upload a sector
+	editor, err := c.Editor(contract.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := editor.Upload(fastrand.Bytes(int(modules.SectorSize)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first download fits in the quota, the second does not
+	downloader, err := c.Downloader(contract.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer downloader.Close()
+	if _, err := downloader.Sector(root); err != nil {
+		t.Fatal(err)
+	}
+	_, err = downloader.Sector(root)
+	if err == nil || !strings.Contains(err.Error(), "download quota") {
+		t.Fatal("expected download to be refused for exceeding the quota, got", err)
+	}
+}
+
+// TestIntegrationRenterConnections tests that the RPCs which start with the
+// recent revision protocol release the renter's connection slot, so that a
+// host with a connection limit keeps serving the renter.
+func TestIntegrationRenterConnections(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, _, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// allow each renter a single connection
+	settings := h.InternalSettings()
+	settings.MaxRenterConnections = 1
+	if err := h.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// form a contract with the host and renew it
+	contract, err := c.managedNewContract(hostEntry, 10, c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract, err = c.managedRenew(contract, modules.SectorSize*10, c.blockHeight+200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	c.contracts[contract.ID] = contract
+	c.mu.Unlock()
+
+	// fetch the host's revision several times
+	for i := 0; i < 3; i++ {
+		err = build.Retry(50, 100*time.Millisecond, func() error {
+			_, err := proto.FetchHostRevision(hostEntry, contract, nil)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the renter should still be able to revise the contract
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		editor, err := c.Editor(contract.ID, nil)
+		if err != nil {
+			return err
+		}
+		return editor.Close()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestIntegrationRenterPolicy tests that a host forms and renews contracts
+// with the renters that its renter policy admits, identified by the
+// contractor's identity key, and refuses all other renters.
+func TestIntegrationRenterPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, _, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// a host with an empty allowlist should refuse all renters
+	err = h.SetRenterPolicy(modules.HostRenterPolicy{Mode: modules.HostRenterPolicyAllowlist})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.managedNewContract(hostEntry, 10, c.blockHeight+100)
+	if err == nil || !strings.Contains(err.Error(), "does not accept contracts from this renter") {
+		t.Fatal("expected contract formation to be refused, got", err)
+	}
+
+	// once the renter's identity key is allowed, contracts can be formed
+	// and renewed
+	renterKey := c.IdentityKey()
+	err = h.SetRenterPolicy(modules.HostRenterPolicy{
+		Mode:    modules.HostRenterPolicyAllowlist,
+		Renters: []types.SiaPublicKey{renterKey},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract, err := c.managedNewContract(hostEntry, 10, c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pk := contract.LastRevision.UnlockConditions.PublicKeys[0]; pk.String() != renterKey.String() {
+		t.Fatal("contract does not use the renter's identity key:", pk.String())
+	}
+	contract2, err := c.managedNewContract(hostEntry, 10, c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract, err = c.managedRenew(contract, modules.SectorSize*10, c.blockHeight+200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// block the renter's key; new contracts and renewals should be refused
+	err = h.SetRenterPolicy(modules.HostRenterPolicy{
+		Mode:    modules.HostRenterPolicyBlocklist,
+		Renters: []types.SiaPublicKey{renterKey},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.managedNewContract(hostEntry, 10, c.blockHeight+100)
+	if err == nil || !strings.Contains(err.Error(), "does not accept contracts from this renter") {
+		t.Fatal("expected contract formation to be refused, got", err)
+	}
+	_, err = c.managedRenew(contract2, modules.SectorSize*10, c.blockHeight+200)
+	if err == nil || !strings.Contains(err.Error(), "does not accept contracts from this renter") {
+		t.Fatal("expected renewal to be refused, got", err)
+	}
+}
+
+// TestIntegrationWindDown tests that a host that is winding down refuses new
+// contracts and renewals, but keeps serving its existing contracts.
+func TestIntegrationWindDown(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, _, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// form a contract with the host and upload a sector
+	contract, err := c.managedNewContract(hostEntry, 10, c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	c.contracts[contract.ID] = contract
+	c.mu.Unlock()
+	editor, err := c.Editor(contract.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(int(modules.SectorSize))
+	root, err := editor.Upload(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// start winding down the host
+	if err := h.SetWindDown(true); err != nil {
+		t.Fatal(err)
+	}
+	if h.ExternalSettings().AcceptingContracts {
+		t.Fatal("host that is winding down should not report that it accepts contracts")
+	}
+	status := h.WindDownStatus()
+	if !status.Enabled || status.OutstandingObligations != 1 || status.CompletionHeight < c.blockHeight+100 || status.Complete {
+		t.Fatal("wind-down status is incorrect:", status)
+	}
+
+	// new contracts and renewals should be refused
+	if _, err := c.managedNewContract(hostEntry, 10, c.blockHeight+100); err == nil {
+		t.Fatal("expected contract formation to be refused")
+	}
+	c.mu.RLock()
+	contract = c.contracts[contract.ID]
+	c.mu.RUnlock()
+	_, err = c.managedRenew(contract, modules.SectorSize*10, c.blockHeight+200)
+	if err == nil || !strings.Contains(err.Error(), "not accepting contracts") {
+		t.Fatal("expected renewal to be refused, got", err)
+	}
+
+	// the existing contract should still be served
+	downloader, err := c.Downloader(contract.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer downloader.Close()
+	retrieved, err := downloader.Sector(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, retrieved) {
+		t.Fatal("downloaded data does not match uploaded data")
+	}
 }
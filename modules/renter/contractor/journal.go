@@ -15,14 +15,25 @@ package contractor
 // a new initial object. This allows for compaction of the journal file.
 //
 // In the event of power failure or other serious disruption, the most recent
-// update set may be only partially written. Partially written update sets are
-// simply ignored when reading the journal.
+// update set may be only partially written. Each update set is stored as a
+// journal entry carrying a checksum of the set. When reading the journal, only
+// the longest valid prefix is applied: the first entry that is partially
+// written, malformed, or fails its checksum ends the journal. A partially
+// written final entry is discarded when the journal is opened, but a journal
+// with any other corrupt entry is not loaded until it has been repaired.
+//
+// To keep the journal from growing without bound, the contractor compacts it
+// with a checkpoint once enough update sets have been appended, or once the
+// appended update sets outgrow the initial object.
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"reflect"
 
@@ -33,26 +44,91 @@ import (
 	"github.com/NebulousLabs/Sia/types"
 )
 
-var journalMeta = persist.Metadata{
-	Header:  "Contractor Journal",
-	Version: "1.1.1",
-}
+var (
+	journalMeta = persist.Metadata{
+		Header:  "Contractor Journal",
+		Version: "1.3.1",
+	}
+
+	// COMPATv1.3.0
+	// journalMetaV111 is the metadata of journals whose update sets are
+	// written without an entry checksum.
+	journalMetaV111 = persist.Metadata{
+		Header:  "Contractor Journal",
+		Version: "1.1.1",
+	}
+
+	errEntryChecksum  = errors.New("journal entry has a bad checksum")
+	errJournalCorrupt = errors.New("contractor journal contains corrupt update sets; it must be repaired with 'siac renter journal repair'")
+)
 
 // A journal is a log of updates to a JSON object.
 type journal struct {
 	f        *os.File
 	filename string
+
+	// objectSize is the size of the initial object. updates and updateSize
+	// count the update sets appended since the initial object was written.
+	objectSize int64
+	updates    int
+	updateSize int64
+}
+
+// A journalEntry is a single line of the journal following the initial
+// object. It wraps an updateSet with a checksum of its encoding.
+type journalEntry struct {
+	Updates  json.RawMessage `json:"updates"`
+	Checksum crypto.Hash     `json:"checksum"`
+}
+
+// A JournalReport describes the contents of a contractor journal: the longest
+// valid prefix, which is what the contractor loads, and the data following it,
+// which is discarded.
+type JournalReport struct {
+	Version         string `json:"version"`
+	Contracts       int    `json:"contracts"`
+	CachedRevisions int    `json:"cachedrevisions"`
+	ValidUpdateSets int    `json:"validupdatesets"`
+	ValidSize       int64  `json:"validsize"`
+
+	LostUpdateSets int    `json:"lostupdatesets"`
+	LostSize       int64  `json:"lostsize"`
+	TornWrite      bool   `json:"tornwrite"`
+	FirstError     string `json:"firsterror,omitempty"`
+}
+
+// Corrupt returns true if the journal contains data that is not part of its
+// longest valid prefix.
+func (r JournalReport) Corrupt() bool {
+	return r.LostSize > 0
 }
 
 // update applies the updateSet atomically to j. It syncs the underlying file
 // before returning.
 func (j *journal) update(us updateSet) error {
-	if err := json.NewEncoder(j.f).Encode(us); err != nil {
+	entry, err := marshalJournalEntry(us)
+	if err != nil {
+		return err
+	}
+	if _, err := j.f.Write(entry); err != nil {
 		return err
 	}
+	j.updates++
+	j.updateSize += int64(len(entry))
 	return j.f.Sync()
 }
 
+// needsCompaction returns true if enough update sets have been appended to
+// the journal that it should be compacted with a checkpoint. The size
+// threshold scales with the initial object, so that the cost of rewriting the
+// object is amortized over the updates that triggered it.
+func (j *journal) needsCompaction() bool {
+	if j.updates >= journalCompactionUpdates {
+		return true
+	}
+	return j.updateSize >= journalCompactionMinSize && j.updateSize >= j.objectSize
+}
+
 // Checkpoint refreshes the journal with a new initial object. It syncs the
 // underlying file before returning.
 func (j *journal) checkpoint(data contractorPersist) error {
@@ -60,9 +136,8 @@ func (j *journal) checkpoint(data contractorPersist) error {
 		// Sanity check - applying the updates to the initial object should
 		// result in a contractorPersist that matches data.
 		var data2 contractorPersist
-		j2, err := openJournal(j.filename, &data2)
-		if err != nil {
-			panic("could not open journal for sanity check: " + err.Error())
+		if _, err := readJournal(j.filename, &data2); err != nil {
+			panic("could not read journal for sanity check: " + err.Error())
 		}
 		for id, c := range data.CachedRevisions {
 			if c2, ok := data2.CachedRevisions[id]; !ok {
@@ -82,7 +157,6 @@ func (j *journal) checkpoint(data contractorPersist) error {
 				panic("Contract Merkle roots mismatch: " + fmt.Sprint(len(c.MerkleRoots), len(c2.MerkleRoots)))
 			}
 		}
-		j2.Close()
 	}
 
 	// Write to a new temp file.
@@ -90,11 +164,8 @@ func (j *journal) checkpoint(data contractorPersist) error {
 	if err != nil {
 		return err
 	}
-	enc := json.NewEncoder(tmp)
-	if err := enc.Encode(journalMeta); err != nil {
-		return err
-	}
-	if err := enc.Encode(data); err != nil {
+	objectSize, err := writeInitialObject(tmp, data)
+	if err != nil {
 		return err
 	}
 	if err := tmp.Sync(); err != nil {
@@ -114,6 +185,9 @@ func (j *journal) checkpoint(data contractorPersist) error {
 
 	// Reopen the journal.
 	j.f, err = os.OpenFile(j.filename, os.O_RDWR|os.O_APPEND, 0)
+	j.objectSize = objectSize
+	j.updates = 0
+	j.updateSize = 0
 	return err
 }
 
@@ -129,44 +203,159 @@ func newJournal(filename string, data contractorPersist) (*journal, error) {
 	if err != nil {
 		return nil, err
 	}
-	enc := json.NewEncoder(f)
-	if err := enc.Encode(journalMeta); err != nil {
+	objectSize, err := writeInitialObject(f, data)
+	if err != nil {
 		return nil, err
 	}
+	if err := f.Sync(); err != nil {
+		return nil, err
+	}
+
+	return &journal{f: f, filename: filename, objectSize: objectSize}, nil
+}
+
+// writeInitialObject writes the journal metadata and the initial object to w,
+// returning the number of bytes written.
+func writeInitialObject(w io.Writer, data contractorPersist) (int64, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if err := enc.Encode(journalMeta); err != nil {
+		return 0, err
+	}
 	if err := enc.Encode(data); err != nil {
+		return 0, err
+	}
+	n, err := buf.WriteTo(w)
+	return n, err
+}
+
+// marshalJournalEntry encodes an updateSet as a journal entry, terminated by
+// a newline.
+func marshalJournalEntry(us updateSet) ([]byte, error) {
+	updates, err := json.Marshal(us)
+	if err != nil {
 		return nil, err
 	}
-	if err := f.Sync(); err != nil {
+	entry, err := json.Marshal(journalEntry{
+		Updates:  updates,
+		Checksum: crypto.HashBytes(updates),
+	})
+	if err != nil {
 		return nil, err
 	}
+	return append(entry, '\n'), nil
+}
 
-	return &journal{f: f, filename: filename}, nil
+// unmarshalJournalEntry decodes a journal entry written in the format of the
+// specified journal version.
+func unmarshalJournalEntry(b []byte, version string) (updateSet, error) {
+	var set updateSet
+	if version == journalMetaV111.Version {
+		// COMPATv1.3.0
+		// Older journals store the bare updateSet.
+		err := json.Unmarshal(b, &set)
+		return set, err
+	}
+	var entry journalEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return nil, err
+	}
+	if crypto.HashBytes(entry.Updates) != entry.Checksum {
+		return nil, errEntryChecksum
+	}
+	err := json.Unmarshal(entry.Updates, &set)
+	return set, err
 }
 
 // openJournal opens the supplied journal and decodes the reconstructed
-// contractorPersist into data.
-func openJournal(filename string, data *contractorPersist) (*journal, error) {
+// contractorPersist into data, returning a report of what was read.
+//
+// If the journal is corrupt, a copy of the original is kept next to it with
+// the suffix "_corrupt". A journal whose only damage is a partially written
+// final update set, which is the expected result of a crash during an update,
+// is truncated to its longest valid prefix so that new update sets are not
+// appended to the torn entry. Any other corruption is not expected, so the
+// journal is left as it is and errJournalCorrupt is returned; discarding the
+// corrupt update sets is left to RepairJournal.
+func openJournal(filename string, data *contractorPersist) (*journal, JournalReport, error) {
 	// Open file handle for reading and writing.
 	f, err := os.OpenFile(filename, os.O_RDWR, 0)
 	if err != nil {
-		return nil, err
+		return nil, JournalReport{}, err
+	}
+	j := &journal{
+		f:        f,
+		filename: filename,
+	}
+	report, err := j.read(data)
+	if err != nil {
+		f.Close()
+		return nil, report, err
+	}
+	if report.Corrupt() {
+		if err := backupJournal(filename); err != nil {
+			f.Close()
+			return nil, report, err
+		}
+		if !report.TornWrite {
+			f.Close()
+			return nil, report, errJournalCorrupt
+		}
+		if err := f.Truncate(report.ValidSize); err != nil {
+			f.Close()
+			return nil, report, err
+		}
+	}
+	if _, err := f.Seek(report.ValidSize, io.SeekStart); err != nil {
+		f.Close()
+		return nil, report, err
 	}
 
+	// COMPATv1.3.0
+	// Update sets cannot be appended to a journal written in an older format,
+	// so rewrite it as a checkpoint.
+	if report.Version != journalMeta.Version {
+		if err := j.checkpoint(*data); err != nil {
+			j.Close()
+			return nil, report, err
+		}
+	}
+	return j, report, nil
+}
+
+// read decodes the initial object of the journal into data and applies the
+// update sets of the longest valid prefix of the journal. It returns a report
+// describing what was read and what was discarded.
+func (j *journal) read(data *contractorPersist) (JournalReport, error) {
+	var report JournalReport
+	r := bufio.NewReader(j.f)
+
 	// Decode the metadata.
-	dec := json.NewDecoder(f)
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return report, err
+	}
 	var meta persist.Metadata
-	if err = dec.Decode(&meta); err != nil {
-		return nil, err
+	if err = json.Unmarshal(line, &meta); err != nil {
+		return report, err
 	} else if meta.Header != journalMeta.Header {
-		return nil, fmt.Errorf("expected header %q, got %q", journalMeta.Header, meta.Header)
-	} else if meta.Version != journalMeta.Version {
-		return nil, fmt.Errorf("journal version (%s) is incompatible with the current version (%s)", meta.Version, journalMeta.Version)
+		return report, fmt.Errorf("expected header %q, got %q", journalMeta.Header, meta.Header)
+	} else if meta.Version != journalMeta.Version && meta.Version != journalMetaV111.Version {
+		return report, fmt.Errorf("journal version (%s) is incompatible with the current version (%s)", meta.Version, journalMeta.Version)
 	}
+	report.Version = meta.Version
+	report.ValidSize += int64(len(line))
 
 	// Decode the initial object.
-	if err = dec.Decode(data); err != nil {
-		return nil, err
+	line, err = r.ReadBytes('\n')
+	if err != nil {
+		return report, err
+	}
+	if err = json.Unmarshal(line, data); err != nil {
+		return report, err
 	}
+	report.ValidSize += int64(len(line))
+	j.objectSize = report.ValidSize
 
 	// Make sure all maps are properly initialized.
 	if data.CachedRevisions == nil {
@@ -179,25 +368,119 @@ func openJournal(filename string, data *contractorPersist) (*journal, error) {
 		data.RenewedIDs = map[string]string{}
 	}
 
-	// Decode each set of updates and apply them to data.
+	// Decode each set of updates and apply them to data, stopping at the
+	// first entry that cannot be read.
 	for {
-		var set updateSet
-		if err = dec.Decode(&set); err == io.EOF || err == io.ErrUnexpectedEOF {
-			// unexpected EOF means the last update was corrupted; skip it
+		line, err = r.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			break
+		} else if err != nil && err != io.EOF {
+			return report, err
+		}
+		if err == io.EOF {
+			// The last entry was only partially written.
+			report.TornWrite = true
+			report.FirstError = "journal ends with a partially written update set"
+			break
+		}
+		set, err := unmarshalJournalEntry(line, meta.Version)
+		if err != nil {
+			report.FirstError = fmt.Sprintf("update set %v is corrupt: %v", report.ValidUpdateSets, err)
 			break
-		} else if err != nil {
-			// skip corrupted update sets
-			continue
 		}
 		for _, u := range set {
 			u.apply(data)
 		}
+		report.ValidUpdateSets++
+		report.ValidSize += int64(len(line))
+		j.updates++
+		j.updateSize += int64(len(line))
 	}
 
-	return &journal{
-		f:        f,
-		filename: filename,
-	}, nil
+	// Account for everything after the valid prefix.
+	if len(line) > 0 {
+		report.LostUpdateSets++
+		report.LostSize += int64(len(line))
+		for {
+			line, err = r.ReadBytes('\n')
+			if len(line) > 0 {
+				report.LostUpdateSets++
+				report.LostSize += int64(len(line))
+			}
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return report, err
+			}
+		}
+	}
+	report.Contracts = len(data.Contracts)
+	report.CachedRevisions = len(data.CachedRevisions)
+	return report, nil
+}
+
+// readJournal reads the journal at filename into data without modifying it.
+func readJournal(filename string, data *contractorPersist) (JournalReport, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return JournalReport{}, err
+	}
+	defer f.Close()
+	return (&journal{f: f, filename: filename}).read(data)
+}
+
+// VerifyJournal reads the contractor journal at filename without modifying it
+// and reports its longest valid prefix, along with the data that would be
+// discarded when the journal is loaded.
+func VerifyJournal(filename string) (JournalReport, error) {
+	var data contractorPersist
+	return readJournal(filename, &data)
+}
+
+// backupJournal copies the journal at filename to a file of the same name
+// suffixed with "_corrupt".
+func backupJournal(filename string) error {
+	original, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename+"_corrupt", original, 0600)
+}
+
+// RepairJournal recovers the longest valid prefix of the contractor journal
+// at filename and rewrites the journal as a checkpoint of the recovered data.
+// The discarded data is preserved in a copy of the original journal, whose
+// name is filename suffixed with "_corrupt". RepairJournal must not be called
+// while the contractor is using the journal.
+func RepairJournal(filename string) (JournalReport, error) {
+	var data contractorPersist
+	report, err := readJournal(filename, &data)
+	if err != nil || !report.Corrupt() {
+		return report, err
+	}
+
+	// Keep a copy of the original journal before rewriting it.
+	if err := backupJournal(filename); err != nil {
+		return report, err
+	}
+
+	// Write the recovered data as a fresh checkpoint.
+	tmp, err := os.Create(filename + "_tmp")
+	if err != nil {
+		return report, err
+	}
+	if _, err := writeInitialObject(tmp, data); err != nil {
+		tmp.Close()
+		return report, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return report, err
+	}
+	if err := tmp.Close(); err != nil {
+		return report, err
+	}
+	return report, os.Rename(tmp.Name(), filename)
 }
 
 type journalUpdate interface {
@@ -11,6 +11,7 @@ import (
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/persist"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -57,7 +58,7 @@ func TestJournal(t *testing.T) {
 	}
 
 	var data contractorPersist
-	j2, err := openJournal(j.filename, &data)
+	j2, _, err := openJournal(j.filename, &data)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -81,7 +82,7 @@ func TestJournalCheckpoint(t *testing.T) {
 	}
 
 	data.BlockHeight = 0
-	j2, err := openJournal(j.filename, &data)
+	j2, _, err := openJournal(j.filename, &data)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -105,17 +106,25 @@ func TestJournalMalformedJSON(t *testing.T) {
 	j.f.WriteString(`[{"t":"cachedDownloadRevision","d":{"revision":{"parentid":"1000000000000000000000000000000000000000000000000000000000000000"`)
 
 	// load log
+	defer os.RemoveAll(j.filename + "_corrupt")
 	var data contractorPersist
-	j, err = openJournal(j.filename, &data)
+	j, r, err := openJournal(j.filename, &data)
 	if err != nil {
 		t.Fatal(err)
 	}
 	j.Close()
 
-	// the last update set should have been discarded
+	// the last update set should have been discarded, and a copy of the
+	// original journal kept
 	if _, ok := data.CachedRevisions[crypto.Hash{}.String()]; !ok {
 		t.Fatal("log was not applied correctly:", data.CachedRevisions)
 	}
+	if !r.TornWrite {
+		t.Fatalf("unexpected journal report: %+v", r)
+	}
+	if _, err := os.Stat(j.filename + "_corrupt"); err != nil {
+		t.Fatal("original journal was not preserved:", err)
+	}
 }
 
 func TestJournalBadChecksum(t *testing.T) {
@@ -133,8 +142,9 @@ func TestJournalBadChecksum(t *testing.T) {
 	j.f.WriteString(`[{"t":"cachedDownloadRevision","d":{"revision":{"parentid":"2000000000000000000000000000000000000000000000000000000000000000"}},"c":"bad checksum"}]`)
 
 	// load log
+	defer os.RemoveAll(j.filename + "_corrupt")
 	var data contractorPersist
-	j, err = openJournal(j.filename, &data)
+	j, _, err = openJournal(j.filename, &data)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -146,6 +156,242 @@ func TestJournalBadChecksum(t *testing.T) {
 	}
 }
 
+// TestJournalCorruptEntry tests that a corrupt update set ends the journal,
+// discarding the update sets that follow it.
+func TestJournalCorruptEntry(t *testing.T) {
+	j, cleanup := tempJournal(t)
+	defer cleanup()
+
+	// write a valid update, a corrupt update, and another valid update
+	rev1 := types.FileContractRevision{ParentID: types.FileContractID{1}}
+	rev3 := types.FileContractRevision{ParentID: types.FileContractID{3}}
+	if err := j.update(updateSet{updateCachedDownloadRevision{Revision: rev1}}); err != nil {
+		t.Fatal(err)
+	}
+	j.f.WriteString(`{"updates":[],"checksum":"0000000000000000000000000000000000000000000000000000000000000000"}` + "\n")
+	if err := j.update(updateSet{updateCachedDownloadRevision{Revision: rev3}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// verify the journal
+	r, err := VerifyJournal(j.filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ValidUpdateSets != 1 || r.LostUpdateSets != 2 || r.TornWrite || !r.Corrupt() {
+		t.Fatalf("unexpected journal report: %+v", r)
+	}
+
+	// the journal should not be loaded, but a copy should be kept
+	defer os.RemoveAll(j.filename + "_corrupt")
+	var data contractorPersist
+	_, r, err = openJournal(j.filename, &data)
+	if err != errJournalCorrupt {
+		t.Fatal("expected errJournalCorrupt, got", err)
+	} else if r.LostUpdateSets != 2 {
+		t.Fatalf("unexpected journal report: %+v", r)
+	}
+	if _, err := os.Stat(j.filename + "_corrupt"); err != nil {
+		t.Fatal("original journal was not preserved:", err)
+	}
+
+	// opening the journal must not discard anything
+	r, err = VerifyJournal(j.filename)
+	if err != nil {
+		t.Fatal(err)
+	} else if r.LostUpdateSets != 2 {
+		t.Fatalf("opening the journal modified it: %+v", r)
+	}
+
+	// after a repair, only the first update should be applied
+	if _, err := RepairJournal(j.filename); err != nil {
+		t.Fatal(err)
+	}
+	data = contractorPersist{}
+	j2, _, err := openJournal(j.filename, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := data.CachedRevisions[rev1.ParentID.String()]; !ok {
+		t.Fatal("valid update was not applied:", data.CachedRevisions)
+	}
+	if _, ok := data.CachedRevisions[rev3.ParentID.String()]; ok {
+		t.Fatal("update following a corrupt update was applied:", data.CachedRevisions)
+	}
+
+	// updates written after loading should be readable
+	if err := j2.update(updateSet{updateCachedDownloadRevision{Revision: rev3}}); err != nil {
+		t.Fatal(err)
+	}
+	j2.Close()
+	data = contractorPersist{}
+	j3, _, err := openJournal(j.filename, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j3.Close()
+	if _, ok := data.CachedRevisions[rev3.ParentID.String()]; !ok {
+		t.Fatal("update appended after loading was not applied:", data.CachedRevisions)
+	}
+}
+
+// TestRepairJournal tests that RepairJournal recovers the longest valid prefix
+// of a journal and keeps a copy of the original.
+func TestRepairJournal(t *testing.T) {
+	j, cleanup := tempJournal(t)
+	defer cleanup()
+	defer os.RemoveAll(j.filename + "_corrupt")
+
+	if err := j.update(updateSet{updateCachedDownloadRevision{}}); err != nil {
+		t.Fatal(err)
+	}
+	j.f.WriteString(`{"updates":[{"type":"cachedDownloadRevision"`)
+	j.Close()
+
+	r, err := RepairJournal(j.filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ValidUpdateSets != 1 || r.LostUpdateSets != 1 || !r.TornWrite {
+		t.Fatalf("unexpected journal report: %+v", r)
+	}
+	if _, err := os.Stat(j.filename + "_corrupt"); err != nil {
+		t.Fatal("original journal was not preserved:", err)
+	}
+
+	// the repaired journal should be intact and contain the valid update
+	r, err = VerifyJournal(j.filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Corrupt() || r.CachedRevisions != 1 {
+		t.Fatalf("unexpected report for repaired journal: %+v", r)
+	}
+}
+
+// TestJournalCompaction tests that the journal reports that it needs
+// compaction after enough updates, and that a checkpoint resets it.
+func TestJournalCompaction(t *testing.T) {
+	j, cleanup := tempJournal(t)
+	defer cleanup()
+
+	// Compaction should be triggered by the number of updates when the
+	// initial object is large.
+	j.objectSize = 1 << 40
+	for i := 0; i < journalCompactionUpdates; i++ {
+		if j.needsCompaction() {
+			t.Fatal("journal needs compaction after", i, "updates")
+		}
+		if err := j.update(updateSet{updateCachedDownloadRevision{}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !j.needsCompaction() {
+		t.Fatal("journal does not need compaction after", journalCompactionUpdates, "updates")
+	}
+	if err := j.checkpoint(contractorPersist{}); err != nil {
+		t.Fatal(err)
+	}
+	if j.needsCompaction() {
+		t.Fatal("journal needs compaction after checkpoint")
+	}
+
+	// Compaction should be triggered by size once the updates outgrow the
+	// initial object.
+	for j.updateSize < journalCompactionMinSize {
+		if j.needsCompaction() {
+			t.Fatal("journal needs compaction after", j.updateSize, "bytes")
+		}
+		if err := j.update(updateSet{updateCachedDownloadRevision{}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !j.needsCompaction() {
+		t.Fatal("journal does not need compaction after", j.updateSize, "bytes")
+	}
+}
+
+// TestJournalUploadBatch tests that the cached revision of a contract can be
+// reloaded from the journal after uploading several sectors in one revision.
+func TestJournalUploadBatch(t *testing.T) {
+	dir := build.TempDir("contractor", t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	id := types.FileContractID{1}
+	c := &Contractor{
+		persist: newPersist(dir, nil),
+		cachedRevisions: map[types.FileContractID]cachedRevision{
+			id: {
+				Revision:    types.FileContractRevision{ParentID: id},
+				MerkleRoots: []crypto.Hash{{1}},
+			},
+		},
+	}
+	if err := c.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// upload a batch of sectors, then modify one of them
+	saveFn := c.saveUploadRevision(id)
+	batchRoots := []crypto.Hash{{1}, {2}, {3}, {4}}
+	if err := saveFn(types.FileContractRevision{ParentID: id, NewRevisionNumber: 1}, batchRoots); err != nil {
+		t.Fatal(err)
+	}
+	newRoots := []crypto.Hash{{1}, {2}, {5}, {4}}
+	if err := saveFn(types.FileContractRevision{ParentID: id, NewRevisionNumber: 2}, newRoots); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.persist.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// reload the journal
+	var data contractorPersist
+	j, _, err := openJournal(filepath.Join(dir, "contractor.journal"), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	cached := data.CachedRevisions[id.String()]
+	if cached.Revision.NewRevisionNumber != 2 {
+		t.Fatal("wrong revision number after reload:", cached.Revision.NewRevisionNumber)
+	}
+	if !reflect.DeepEqual(cached.MerkleRoots, newRoots) {
+		t.Fatal("wrong Merkle roots after reload:", cached.MerkleRoots)
+	}
+}
+
+// TestJournalLoadV111 tests that journals written in the 1.1.1 format, whose
+// update sets have no entry checksum, can be loaded and are rewritten in the
+// current format.
+func TestJournalLoadV111(t *testing.T) {
+	f, cleanup := tempFile(t)
+	defer cleanup()
+	enc := json.NewEncoder(f)
+	enc.Encode(journalMetaV111)
+	enc.Encode(contractorPersist{})
+	enc.Encode(updateSet{updateCachedDownloadRevision{}})
+
+	var data contractorPersist
+	j, _, err := openJournal(f.Name(), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+	if len(data.CachedRevisions) != 1 {
+		t.Fatal("openJournal applied updates incorrectly:", data)
+	}
+	r, err := VerifyJournal(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	} else if r.Version != journalMeta.Version {
+		t.Fatal("journal was not rewritten in the current format:", r.Version)
+	}
+}
+
 // TestJournalLoadCompat tests that the contractor can convert the previous
 // persist file to a journal.
 func TestJournalLoadCompat(t *testing.T) {
@@ -325,7 +571,7 @@ func TestJournalLoadCompat(t *testing.T) {
 
 	// load will fail to load journal, fall back to loading contractor.json,
 	// and save data as a new journal
-	p := newPersist(dir)
+	p := newPersist(dir, persist.NewLogger(ioutil.Discard))
 	var data contractorPersist
 	err = p.load(&data)
 	if err != nil {
@@ -335,7 +581,7 @@ func TestJournalLoadCompat(t *testing.T) {
 
 	// second load should find the journal
 	var data2 contractorPersist
-	p = newPersist(dir)
+	p = newPersist(dir, persist.NewLogger(ioutil.Discard))
 	err = p.load(&data2)
 	if err != nil {
 		t.Fatal(err)
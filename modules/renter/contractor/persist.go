@@ -19,6 +19,7 @@ type contractorPersist struct {
 	LastChange      modules.ConsensusChangeID         `json:"lastchange"`
 	OldContracts    []modules.RenterContract          `json:"oldcontracts"`
 	RenewedIDs      map[string]string                 `json:"renewedids"`
+	IdentityKey     crypto.SecretKey                  `json:"identitykey"`
 }
 
 // persistData returns the data in the Contractor that will be saved to disk.
@@ -31,6 +32,7 @@ func (c *Contractor) persistData() contractorPersist {
 		CurrentPeriod:   c.currentPeriod,
 		LastChange:      c.lastChange,
 		RenewedIDs:      make(map[string]string),
+		IdentityKey:     c.identityKey,
 	}
 	for _, rev := range c.cachedRevisions {
 		data.CachedRevisions[rev.Revision.ParentID.String()] = rev
@@ -57,6 +59,7 @@ func (c *Contractor) load() error {
 	}
 	c.allowance = data.Allowance
 	c.blockHeight = data.BlockHeight
+	c.identityKey = data.IdentityKey
 	for _, rev := range data.CachedRevisions {
 		c.cachedRevisions[rev.Revision.ParentID] = rev
 	}
@@ -129,19 +132,47 @@ func (c *Contractor) saveSync() error {
 	return c.persist.save(c.persistData())
 }
 
+// updateJournal appends the updates to the contractor's journal. If the
+// journal has grown large enough, it is then compacted by saving the
+// contractor. The caller must hold the contractor's lock.
+func (c *Contractor) updateJournal(us ...journalUpdate) error {
+	if err := c.persist.update(us...); err != nil {
+		return err
+	}
+	if c.persist.needsCompaction() {
+		return c.save()
+	}
+	return nil
+}
+
 // saveUploadRevision returns a function that saves an upload revision. It is
 // used by the Editor type to prevent desynchronizing with the host.
 func (c *Contractor) saveUploadRevision(id types.FileContractID) func(types.FileContractRevision, []crypto.Hash) error {
 	return func(rev types.FileContractRevision, newRoots []crypto.Hash) error {
 		c.mu.Lock()
 		defer c.mu.Unlock()
+		// record each root that differs from the previous cached revision. A
+		// batch upload adds several roots at once, all of which are new.
+		oldRoots := c.cachedRevisions[id].MerkleRoots
+		var updates []journalUpdate
+		for i, root := range newRoots {
+			if i < len(oldRoots) && oldRoots[i] == root {
+				continue
+			}
+			updates = append(updates, updateCachedUploadRevision{
+				Revision:    rev,
+				SectorRoot:  root,
+				SectorIndex: i,
+			})
+		}
+		if len(updates) == 0 {
+			// roots have not changed
+			updates = append(updates, updateCachedDownloadRevision{
+				Revision: rev,
+			})
+		}
 		c.cachedRevisions[id] = cachedRevision{rev, newRoots}
-		return c.persist.update(updateCachedUploadRevision{
-			Revision: rev,
-			// only the last root is new
-			SectorRoot:  newRoots[len(newRoots)-1],
-			SectorIndex: len(newRoots) - 1,
-		})
+		return c.updateJournal(updates...)
 	}
 }
 
@@ -155,7 +186,7 @@ func (c *Contractor) saveDownloadRevision(id types.FileContractID) func(types.Fi
 		cr := c.cachedRevisions[id]
 		cr.Revision = rev
 		c.cachedRevisions[id] = cr
-		return c.persist.update(updateCachedDownloadRevision{
+		return c.updateJournal(updateCachedDownloadRevision{
 			Revision: rev,
 		})
 	}
@@ -2,6 +2,7 @@ package contractor
 
 import (
 	"bytes"
+	"io/ioutil"
 	"os"
 	"strconv"
 	"testing"
@@ -9,6 +10,7 @@ import (
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -17,6 +19,7 @@ type memPersist contractorPersist
 
 func (m *memPersist) save(data contractorPersist) error { *m = memPersist(data); return nil }
 func (m *memPersist) update(...journalUpdate) error     { return nil }
+func (m *memPersist) needsCompaction() bool             { return false }
 func (m memPersist) load(data *contractorPersist) error { *data = contractorPersist(m); return nil }
 func (m memPersist) Close() error                       { return nil }
 
@@ -48,6 +51,8 @@ func TestSaveLoad(t *testing.T) {
 		{1}: {ID: types.FileContractID{1}, HostPublicKey: types.SiaPublicKey{Key: []byte("bar")}},
 		{2}: {ID: types.FileContractID{2}, HostPublicKey: types.SiaPublicKey{Key: []byte("baz")}},
 	}
+	c.identityKey, _ = crypto.GenerateKeyPair()
+	identityKey := c.identityKey
 
 	// save, clear, and reload
 	err := c.save()
@@ -59,11 +64,15 @@ func TestSaveLoad(t *testing.T) {
 	c.renewedIDs = make(map[types.FileContractID]types.FileContractID)
 	c.cachedRevisions = make(map[types.FileContractID]cachedRevision)
 	c.oldContracts = make(map[types.FileContractID]modules.RenterContract)
+	c.identityKey = crypto.SecretKey{}
 	err = c.load()
 	if err != nil {
 		t.Fatal(err)
 	}
 	// check that all fields were restored
+	if c.identityKey != identityKey {
+		t.Fatal("identity key was not restored")
+	}
 	_, ok0 := c.contracts[types.FileContractID{0}]
 	_, ok1 := c.contracts[types.FileContractID{1}]
 	_, ok2 := c.contracts[types.FileContractID{2}]
@@ -90,7 +99,7 @@ func TestSaveLoad(t *testing.T) {
 	}
 
 	// use stdPersist instead of mock
-	c.persist = newPersist(build.TempDir("contractor", t.Name()))
+	c.persist = newPersist(build.TempDir("contractor", t.Name()), persist.NewLogger(ioutil.Discard))
 	os.MkdirAll(build.TempDir("contractor", t.Name()), 0700)
 
 	// save, clear, and reload
@@ -0,0 +1,106 @@
+package contractor
+
+// reconcile.go resolves revision mismatches between the renter and its hosts.
+// If the host presents a newer revision signed by the renter, the renter
+// adopts it; if the host's revision contradicts the renter's records, the host
+// is flagged as dishonest.
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/renter/proto"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	errNoContract        = errors.New("no record of that contract")
+	errRevisedDuringSync = errors.New("contract was revised during reconciliation")
+)
+
+// adoptHostRevision returns a copy of contract updated to the host's
+// revision. Funds that left the renter's payout between the two revisions are
+// attributed to uploads if the host revision holds more sectors, and to
+// downloads otherwise.
+func adoptHostRevision(contract modules.RenterContract, hr proto.HostRevision) modules.RenterContract {
+	oldFunds := contract.RenterFunds()
+	oldSectors := len(contract.MerkleRoots)
+
+	contract.LastRevision = hr.Revision
+	contract.LastRevisionTxn = hr.Transaction()
+	contract.MerkleRoots = append(modules.MerkleRootSet(nil), hr.MerkleRoots...)
+
+	newFunds := contract.RenterFunds()
+	if oldFunds.Cmp(newFunds) > 0 {
+		spent := oldFunds.Sub(newFunds)
+		if len(contract.MerkleRoots) > oldSectors {
+			contract.UploadSpending = contract.UploadSpending.Add(spent)
+		} else {
+			contract.DownloadSpending = contract.DownloadSpending.Add(spent)
+		}
+	}
+	return contract
+}
+
+// managedReconcileContract resolves a revision mismatch between the renter and
+// the host of the contract with the given ID. If the host presents a newer
+// revision signed by the renter, along with matching Merkle roots, the
+// revision is adopted and the updated contract is returned. If the host's
+// revision contradicts the renter's records, the host is flagged as dishonest
+// in the hostdb. The outcome is recorded in the contract's reconciliation
+// events.
+func (c *Contractor) managedReconcileContract(host modules.HostDBEntry, id types.FileContractID) (modules.RenterContract, error) {
+	c.mu.RLock()
+	contract, exists := c.contracts[id]
+	c.mu.RUnlock()
+	if !exists {
+		return modules.RenterContract{}, errNoContract
+	}
+	revisionNumber := contract.LastRevision.NewRevisionNumber
+
+	hr, err := proto.FetchHostRevision(host, contract, c.tg.StopChan())
+	if err != nil && !proto.IsHostDishonest(err) {
+		// Nothing can be concluded about the host.
+		return modules.RenterContract{}, err
+	} else if err == nil && hr.Revision.NewRevisionNumber == contract.LastRevision.NewRevisionNumber {
+		// The host agrees with the renter's revision.
+		return contract, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// The contract may have changed while the host was queried.
+	contract, exists = c.contracts[id]
+	if !exists {
+		return modules.RenterContract{}, errNoContract
+	} else if contract.LastRevision.NewRevisionNumber != revisionNumber {
+		return modules.RenterContract{}, errRevisedDuringSync
+	}
+	event := modules.ContractReconciliation{
+		Height:         c.blockHeight,
+		RenterRevision: contract.LastRevision.NewRevisionNumber,
+		HostRevision:   hr.Revision.NewRevisionNumber,
+	}
+	if err != nil {
+		c.hdb.FlagDishonestHost(contract.HostPublicKey)
+		event.Outcome = modules.ReconciliationDishonest
+		event.Reason = err.Error()
+		contract.GoodForUpload = false
+		contract.GoodForRenew = false
+		c.log.Printf("host %v presented an irreconcilable revision for %v: %v", contract.NetAddress, contract.ID, err)
+	} else {
+		contract = adoptHostRevision(contract, hr)
+		event.Outcome = modules.ReconciliationAdopted
+		c.cachedRevisions[id] = cachedRevision{contract.LastRevision, contract.MerkleRoots}
+		c.log.Printf("adopted revision %v of %v from host %v", event.HostRevision, contract.ID, contract.NetAddress)
+	}
+	contract.Reconciliations = append(contract.Reconciliations, event)
+	c.contracts[id] = contract
+	if saveErr := c.saveSync(); saveErr != nil {
+		c.log.Println("Failed to save the contractor after reconciling a contract:", saveErr)
+	}
+	if err != nil {
+		return modules.RenterContract{}, err
+	}
+	return contract, nil
+}
@@ -0,0 +1,53 @@
+package contractor
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/renter/proto"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestAdoptHostRevision tests that adopting a host's revision updates the
+// contract and attributes the spent funds.
+func TestAdoptHostRevision(t *testing.T) {
+	contract := modules.RenterContract{
+		LastRevision: types.FileContractRevision{
+			NewRevisionNumber: 1,
+			NewValidProofOutputs: []types.SiacoinOutput{
+				{Value: types.NewCurrency64(100)},
+				{Value: types.ZeroCurrency},
+			},
+		},
+		MerkleRoots: modules.MerkleRootSet{{1}},
+	}
+	hr := proto.HostRevision{
+		Revision: types.FileContractRevision{
+			NewRevisionNumber: 2,
+			NewValidProofOutputs: []types.SiacoinOutput{
+				{Value: types.NewCurrency64(60)},
+				{Value: types.NewCurrency64(40)},
+			},
+		},
+		MerkleRoots: []crypto.Hash{{1}, {2}},
+	}
+
+	// The host revision holds a new sector, so the spending is attributed to
+	// uploads.
+	adopted := adoptHostRevision(contract, hr)
+	if adopted.LastRevision.NewRevisionNumber != 2 || adopted.LastRevisionTxn.FileContractRevisions[0].NewRevisionNumber != 2 {
+		t.Fatal("host revision was not adopted")
+	} else if len(adopted.MerkleRoots) != 2 {
+		t.Fatal("host Merkle roots were not adopted")
+	} else if !adopted.UploadSpending.Equals64(40) || !adopted.DownloadSpending.IsZero() {
+		t.Fatal("expected 40 upload spending, got", adopted.UploadSpending, adopted.DownloadSpending)
+	}
+
+	// Without new sectors, the spending is attributed to downloads.
+	hr.MerkleRoots = hr.MerkleRoots[:1]
+	adopted = adoptHostRevision(contract, hr)
+	if !adopted.DownloadSpending.Equals64(40) || !adopted.UploadSpending.IsZero() {
+		t.Fatal("expected 40 download spending, got", adopted.UploadSpending, adopted.DownloadSpending)
+	}
+}
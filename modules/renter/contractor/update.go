@@ -56,8 +56,15 @@ func (c *Contractor) ProcessConsensusChange(cc modules.ConsensusChange) {
 	if err != nil {
 		c.log.Println("Unable to save while processing a consensus change:", err)
 	}
+	contractedHosts := make([]types.SiaPublicKey, 0, len(c.contracts))
+	for _, contract := range c.contracts {
+		contractedHosts = append(contractedHosts, contract.HostPublicKey)
+	}
 	c.mu.Unlock()
 
+	// Let the hostdb know which hosts must not be pruned.
+	c.hdb.SetContractedHosts(contractedHosts)
+
 	// Only attempt contract formation/renewal if we are synced
 	// (harmless if not synced, since hosts will reject our renewal attempts,
 	// but very slow).
@@ -56,6 +56,12 @@ type (
 		// have tried to fetch a piece of the chunk.
 		completedPieces map[uint64][]byte
 		workerAttempts  map[types.FileContractID]bool
+
+		// pieceOffset and pieceLength are the range of each piece that is
+		// needed to recover the requested part of the chunk. If the range is
+		// shorter than a piece, only that range is downloaded.
+		pieceOffset uint64
+		pieceLength uint64
 	}
 
 	// A download is a file download that has been queued by the renter.
@@ -79,6 +85,7 @@ type (
 		fileSize    uint64
 		masterKey   crypto.TwofishKey
 		numChunks   uint64
+		pieceSize   uint64
 
 		// pieceSet contains a sparse map of the chunk indices to be downloaded to
 		// their piece data.
@@ -153,6 +160,7 @@ func newDownload(f *file, destination modules.DownloadWriter) *download {
 		fileSize:         f.size,
 		masterKey:        f.masterKey,
 		numChunks:        f.numChunks(),
+		pieceSize:        f.pieceSize,
 		siapath:          f.name,
 		downloadFinished: make(chan struct{}),
 		finishedChunks:   make(map[uint64]bool),
@@ -232,7 +240,10 @@ func (cd *chunkDownload) recoverChunk() error {
 		return build.ComposeErrors(errPrevErr, prevErr)
 	}
 
-	// Decrypt the chunk pieces.
+	// Decrypt the chunk pieces. A partial piece cannot be authenticated by
+	// its decryption, but the host proved that the range belongs to the
+	// sector.
+	partial := cd.pieceLength < cd.download.pieceSize
 	for i := range chunk {
 		// Skip pieces that were not downloaded.
 		if chunk[i] == nil {
@@ -241,7 +252,13 @@ func (cd *chunkDownload) recoverChunk() error {
 
 		// Decrypt the piece.
 		key := deriveKey(cd.download.masterKey, cd.index, uint64(i))
-		decryptedPiece, err := key.DecryptBytes(chunk[i])
+		var decryptedPiece []byte
+		var err error
+		if partial {
+			decryptedPiece, err = key.DecryptRange(chunk[i], cd.pieceOffset)
+		} else {
+			decryptedPiece, err = key.DecryptBytes(chunk[i])
+		}
 		if err != nil {
 			return build.ExtendErr("unable to decrypt piece", err)
 		}
@@ -251,7 +268,9 @@ func (cd *chunkDownload) recoverChunk() error {
 	// Recover the chunk into a byte slice.
 	recoverWriter := new(bytes.Buffer)
 	recoverSize := cd.download.chunkSize
-	if cd.index == cd.download.numChunks-1 && cd.download.fileSize%cd.download.chunkSize != 0 {
+	if partial {
+		recoverSize = cd.pieceLength * uint64(cd.download.erasureCode.MinPieces())
+	} else if cd.index == cd.download.numChunks-1 && cd.download.fileSize%cd.download.chunkSize != 0 {
 		recoverSize = cd.download.fileSize % cd.download.chunkSize
 	}
 	err := cd.download.erasureCode.Recover(chunk, recoverSize, recoverWriter)
@@ -260,6 +279,18 @@ func (cd *chunkDownload) recoverChunk() error {
 	}
 
 	result := recoverWriter.Bytes()
+	if partial {
+		// The recovered data is the same range of each data piece. Only the
+		// range of the piece holding the requested data is written.
+		chunkOffset, _ := cd.chunkRange()
+		dataPiece := chunkOffset / cd.download.pieceSize
+		result = result[dataPiece*cd.pieceLength : (dataPiece+1)*cd.pieceLength]
+		_, err = cd.download.destination.WriteAt(result, int64(cd.index*cd.download.chunkSize+chunkOffset))
+		if err != nil {
+			return build.ExtendErr("unable to write to download destination", err)
+		}
+		return cd.markFinished()
+	}
 
 	// Calculate the offset. If the offset is within the chunk, the
 	// requested offset is passed, otherwise the offset of the chunk
@@ -289,7 +320,37 @@ func (cd *chunkDownload) recoverChunk() error {
 	if err != nil {
 		return build.ExtendErr("unable to write to download destination", err)
 	}
+	return cd.markFinished()
+}
 
+// chunkRange returns the range of the chunk that is covered by the download.
+func (cd *chunkDownload) chunkRange() (offset, length uint64) {
+	start := cd.index * cd.download.chunkSize
+	end := start + cd.download.chunkSize
+	if cd.download.offset > start {
+		start = cd.download.offset
+	}
+	if cd.download.offset+cd.download.length < end {
+		end = cd.download.offset + cd.download.length
+	}
+	return start - cd.index*cd.download.chunkSize, end - start
+}
+
+// pieceRange returns the range of each piece that is needed to recover the
+// part of the chunk covered by the download. Only a range that falls within
+// a single data piece is smaller than a piece.
+func (cd *chunkDownload) pieceRange() (offset, length uint64) {
+	chunkOffset, chunkLength := cd.chunkRange()
+	pieceSize := cd.download.pieceSize
+	if chunkLength == 0 || chunkOffset/pieceSize != (chunkOffset+chunkLength-1)/pieceSize {
+		return 0, pieceSize
+	}
+	return chunkOffset % pieceSize, chunkLength
+}
+
+// markFinished records that the chunk has been written to the download
+// destination, completing the download if it was the last chunk.
+func (cd *chunkDownload) markFinished() error {
 	cd.download.mu.Lock()
 	defer cd.download.mu.Unlock()
 
@@ -343,6 +404,7 @@ func (r *Renter) addDownloadToChunkQueue(d *download) {
 			completedPieces: make(map[uint64][]byte),
 			workerAttempts:  make(map[types.FileContractID]bool),
 		}
+		cd.pieceOffset, cd.pieceLength = cd.pieceRange()
 		for fcid := range d.pieceSet[i] {
 			cd.workerAttempts[fcid] = false
 		}
@@ -452,6 +514,10 @@ loop:
 				chunkDownload: incompleteChunk,
 				resultChan:    ds.resultChan,
 			}
+			if incompleteChunk.pieceLength < incompleteChunk.download.pieceSize {
+				dw.offset = incompleteChunk.pieceOffset
+				dw.length = incompleteChunk.pieceLength
+			}
 			incompleteChunk.workerAttempts[worker.contractID] = true
 			ds.availableWorkers = append(ds.availableWorkers[:i], ds.availableWorkers[i+1:]...)
 			ds.activeWorkers[worker.contractID] = struct{}{}
@@ -0,0 +1,68 @@
+package renter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+
+	"github.com/NebulousLabs/fastrand"
+)
+
+// TestRecoverPartialChunk checks that a chunk can be recovered from ranges of
+// its pieces when the download only covers part of a single data piece.
+func TestRecoverPartialChunk(t *testing.T) {
+	rsc, err := NewRSCode(2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const pieceSize = 1000
+	f := newFile("foo", rsc, pieceSize, 2*pieceSize*2)
+	data := fastrand.Bytes(int(f.size))
+
+	// encode and encrypt the second chunk
+	chunkIndex := uint64(1)
+	pieces, err := rsc.Encode(data[f.chunkSize():])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range pieces {
+		pieces[i] = deriveKey(f.masterKey, chunkIndex, uint64(i)).EncryptBytes(pieces[i])
+	}
+
+	// download a range within the second data piece of the chunk
+	buf := NewDownloadBufferWriter(f.size)
+	d := newDownload(f, buf)
+	d.offset = f.chunkSize() + pieceSize + 100
+	d.length = 200
+	d.finishedChunks[chunkIndex] = false
+	cd := &chunkDownload{
+		download:        d,
+		index:           chunkIndex,
+		completedPieces: make(map[uint64][]byte),
+	}
+	cd.pieceOffset, cd.pieceLength = cd.pieceRange()
+	if cd.pieceOffset != 100 || cd.pieceLength != 200 {
+		t.Fatal("wrong piece range:", cd.pieceOffset, cd.pieceLength)
+	}
+
+	// recover the chunk without the data piece holding the range
+	for _, i := range []uint64{0, 2} {
+		start := crypto.TwofishNonceSize + cd.pieceOffset
+		piece := append([]byte(nil), pieces[i][:crypto.TwofishNonceSize]...)
+		cd.completedPieces[i] = append(piece, pieces[i][start:start+cd.pieceLength]...)
+	}
+	if err := cd.recoverChunk(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes()[d.offset:d.offset+d.length], data[d.offset:d.offset+d.length]) {
+		t.Fatal("recovered data does not match original")
+	}
+
+	// a range spanning two data pieces requires the entire pieces
+	d.offset = f.chunkSize() + pieceSize - 10
+	d.length = 20
+	if offset, length := cd.pieceRange(); offset != 0 || length != pieceSize {
+		t.Fatal("wrong piece range:", offset, length)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
 )
 
 const (
@@ -23,13 +25,27 @@ const (
 	hostScanDeadline = 4 * time.Minute
 
 	// maxHostDowntime specifies the maximum amount of time that a host is
-	// allowed to be offline while still being in the hostdb.
+	// allowed to be offline while still being in the hostdb, unless the
+	// pruning policy says otherwise. Scans older than maxHostDowntime are
+	// compressed into the historic uptime and downtime of the host.
 	maxHostDowntime = 10 * 24 * time.Hour
 
+	// maxPriceHistory is the number of price snapshots kept for each host.
+	// Older snapshots are dropped as new ones are added.
+	maxPriceHistory = 20
+
+	// maxPrunedHosts is the number of pruned hosts that the hostdb remembers
+	// and reports through the API.
+	maxPrunedHosts = 100
+
 	// maxSettingsLen indicates how long in bytes the host settings field is
 	// allowed to be before being ignored as a DoS attempt.
 	maxSettingsLen = 10e3
 
+	// measurementDecay is the weight given to a new latency or throughput
+	// measurement when it is folded into the moving average of a host.
+	measurementDecay = 0.2
+
 	// minScans specifies the number of scans that a host should have before the
 	// scans start getting compressed.
 	minScans = 12
@@ -91,3 +107,17 @@ var (
 		Testing:  time.Second * 14,
 	}).(time.Duration)
 )
+
+var (
+	// defaultPruningPolicy is the policy used to prune hosts until the user
+	// sets one. Hosts that have been offline for longer than maxHostDowntime
+	// are pruned, unless they announced themselves recently.
+	defaultPruningPolicy = modules.HostDBPruningPolicy{
+		MaxDowntime: maxHostDowntime,
+		AnnouncementWindow: build.Select(build.Var{
+			Standard: types.BlockHeight(1008), // 1 week
+			Dev:      types.BlockHeight(144),
+			Testing:  types.BlockHeight(0),
+		}).(types.BlockHeight),
+	}
+)
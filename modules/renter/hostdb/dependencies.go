@@ -15,7 +15,7 @@ type (
 		dialTimeout(modules.NetAddress, time.Duration) (net.Conn, error)
 		disrupt(string) bool
 		loadFile(persist.Metadata, interface{}, string) error
-		saveFileSync(persist.Metadata, interface{}, string) error
+		openDatabase(persist.Metadata, string) (*persist.BoltDatabase, error)
 		sleep(time.Duration)
 	}
 )
@@ -32,8 +32,8 @@ func (prodDependencies) loadFile(meta persist.Metadata, data interface{}, filena
 	return persist.LoadJSON(meta, data, filename)
 }
 
-func (prodDependencies) saveFileSync(meta persist.Metadata, data interface{}, filename string) error {
-	return persist.SaveJSON(meta, data, filename)
+func (prodDependencies) openDatabase(meta persist.Metadata, filename string) (*persist.BoltDatabase, error) {
+	return persist.OpenDatabase(meta, filename)
 }
 
 func (prodDependencies) sleep(d time.Duration) { time.Sleep(d) }
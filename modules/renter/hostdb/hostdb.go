@@ -30,6 +30,7 @@ var (
 type HostDB struct {
 	// dependencies
 	cs         modules.ConsensusSet
+	db         *persist.BoltDatabase
 	deps       dependencies
 	gateway    modules.Gateway
 	log        *persist.Logger
@@ -42,6 +43,11 @@ type HostDB struct {
 	// random.
 	hostTree *hosttree.HostTree
 
+	// dirtyHosts are the hosts that were inserted, modified or removed since
+	// the last save, keyed by the string representation of their public key.
+	// Only these hosts are written to the database when the hostdb saves.
+	dirtyHosts map[string]types.SiaPublicKey
+
 	// the scanPool is a set of hosts that need to be scanned. There are a
 	// handful of goroutines constantly waiting on the channel for hosts to
 	// scan. The scan map is used to prevent duplicates from entering the scan
@@ -52,6 +58,16 @@ type HostDB struct {
 	scanWait bool
 	online   bool
 
+	// weights configure how hosts are scored.
+	weights modules.HostDBWeights
+
+	// pruningPolicy determines which hosts are pruned. Hosts that the renter
+	// has contracts with are kept in contractedHosts, and are never pruned.
+	// The most recently pruned hosts are kept in prunedHosts.
+	pruningPolicy   modules.HostDBPruningPolicy
+	contractedHosts map[string]struct{}
+	prunedHosts     []modules.PrunedHost
+
 	blockHeight types.BlockHeight
 	lastChange  modules.ConsensusChangeID
 }
@@ -80,8 +96,12 @@ func newHostDB(g modules.Gateway, cs modules.ConsensusSet, persistDir string, de
 		gateway:    g,
 		persistDir: persistDir,
 
-		scanMap:  make(map[string]struct{}),
-		scanPool: make(chan modules.HostDBEntry),
+		contractedHosts: make(map[string]struct{}),
+		dirtyHosts:      make(map[string]types.SiaPublicKey),
+		pruningPolicy:   defaultPruningPolicy,
+		scanMap:         make(map[string]struct{}),
+		scanPool:        make(chan modules.HostDBEntry),
+		weights:         defaultWeights,
 	}
 
 	// Create the persist directory if it does not yet exist.
@@ -106,11 +126,21 @@ func newHostDB(g modules.Gateway, cs modules.ConsensusSet, persistDir string, de
 	// The host tree is used to manage hosts and query them at random.
 	hdb.hostTree = hosttree.New(hdb.calculateHostWeight)
 
+	// Open the database.
+	if err := hdb.initDB(); err != nil {
+		return nil, err
+	}
+	hdb.tg.AfterStop(func() {
+		if err := hdb.db.Close(); err != nil {
+			hdb.log.Println("Unable to close the hostdb database:", err)
+		}
+	})
+
 	// Load the prior persistence structures.
 	hdb.mu.Lock()
 	err = hdb.load()
 	hdb.mu.Unlock()
-	if err != nil && !os.IsNotExist(err) {
+	if err != nil {
 		return nil, err
 	}
 	hdb.tg.AfterStop(func() {
@@ -41,7 +41,11 @@ func bareHostDB() *HostDB {
 	hdb := &HostDB{
 		log: persist.NewLogger(ioutil.Discard),
 
-		scanPool: make(chan modules.HostDBEntry),
+		contractedHosts: make(map[string]struct{}),
+		dirtyHosts:      make(map[string]types.SiaPublicKey),
+		pruningPolicy:   defaultPruningPolicy,
+		scanPool:        make(chan modules.HostDBEntry),
+		weights:         defaultWeights,
 	}
 	hdb.hostTree = hosttree.New(hdb.calculateHostWeight)
 	return hdb
@@ -558,3 +562,42 @@ func TestUpdateHistoricInteractions(t *testing.T) {
 			host.HistoricFailedInteractions, host.HistoricSuccessfulInteractions)
 	}
 }
+
+// TestRecordPerformance checks that latency and throughput measurements are
+// folded into the moving averages of a host.
+func TestRecordPerformance(t *testing.T) {
+	hdb := bareHostDB()
+	host := makeHostDBEntry()
+	if err := hdb.hostTree.Insert(host); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first measurement is taken as is.
+	hdb.recordLatency(host.PublicKey, 100*time.Millisecond, time.Second)
+	hdb.RecordDownloadThroughput(host.PublicKey, 1<<20, time.Second)
+	hdb.RecordUploadThroughput(host.PublicKey, 1<<20, 2*time.Second)
+	host, _ = hdb.Host(host.PublicKey)
+	if host.ConnectLatency != 100*time.Millisecond || host.SettingsLatency != time.Second {
+		t.Fatal("latencies were not recorded:", host.ConnectLatency, host.SettingsLatency)
+	}
+	if host.DownloadThroughput != 1<<20 || host.UploadThroughput != 1<<19 {
+		t.Fatal("throughputs were not recorded:", host.DownloadThroughput, host.UploadThroughput)
+	}
+	if _, ok := hdb.dirtyHosts[host.PublicKey.String()]; !ok {
+		t.Fatal("host was not marked to be saved")
+	}
+
+	// Later measurements only move the average.
+	hdb.RecordDownloadThroughput(host.PublicKey, 2<<20, time.Second)
+	host, _ = hdb.Host(host.PublicKey)
+	if host.DownloadThroughput <= 1<<20 || host.DownloadThroughput >= 2<<20 {
+		t.Fatal("download throughput was not averaged:", host.DownloadThroughput)
+	}
+
+	// Transfers that took no time are ignored.
+	hdb.RecordUploadThroughput(host.PublicKey, 1<<20, 0)
+	host, _ = hdb.Host(host.PublicKey)
+	if host.UploadThroughput != 1<<19 {
+		t.Fatal("instant transfer changed the upload throughput:", host.UploadThroughput)
+	}
+}
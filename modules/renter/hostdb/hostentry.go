@@ -2,6 +2,7 @@ package hostdb
 
 import (
 	"math"
+	"time"
 
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
@@ -89,7 +90,7 @@ func (hdb *HostDB) IncrementSuccessfulInteractions(key types.SiaPublicKey) {
 
 	// Increment the successful interactions
 	host.RecentSuccessfulInteractions++
-	hdb.hostTree.Modify(host)
+	hdb.modifyHost(host)
 }
 
 // IncrementFailedInteractions increments the number of failed interactions with
@@ -110,5 +111,102 @@ func (hdb *HostDB) IncrementFailedInteractions(key types.SiaPublicKey) {
 
 	// Increment the failed interactions
 	host.RecentFailedInteractions++
-	hdb.hostTree.Modify(host)
+	hdb.modifyHost(host)
+}
+
+// FlagDishonestHost marks the host as dishonest, giving it the lowest possible
+// score. Hosts are flagged when they present a contract revision that
+// contradicts the renter's records.
+func (hdb *HostDB) FlagDishonestHost(key types.SiaPublicKey) {
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+
+	host, haveHost := hdb.hostTree.Select(key)
+	if !haveHost || host.Dishonest {
+		return
+	}
+	host.Dishonest = true
+	hdb.modifyHost(host)
+	hdb.log.Println("Host flagged as dishonest:", host.NetAddress)
+}
+
+// updatePriceHistory adds a snapshot of the host's current prices to its price
+// history if they differ from the most recent snapshot.
+func updatePriceHistory(host *modules.HostDBEntry, height types.BlockHeight) {
+	if n := len(host.PriceHistory); n > 0 {
+		last := host.PriceHistory[n-1]
+		if last.ContractPrice.Equals(host.ContractPrice) &&
+			last.DownloadBandwidthPrice.Equals(host.DownloadBandwidthPrice) &&
+			last.StoragePrice.Equals(host.StoragePrice) &&
+			last.UploadBandwidthPrice.Equals(host.UploadBandwidthPrice) {
+			return
+		}
+	}
+	host.PriceHistory = append(host.PriceHistory, modules.HostPriceSnapshot{
+		BlockHeight: height,
+		Timestamp:   time.Now(),
+
+		ContractPrice:          host.ContractPrice,
+		DownloadBandwidthPrice: host.DownloadBandwidthPrice,
+		StoragePrice:           host.StoragePrice,
+		UploadBandwidthPrice:   host.UploadBandwidthPrice,
+	})
+	if len(host.PriceHistory) > maxPriceHistory {
+		host.PriceHistory = host.PriceHistory[len(host.PriceHistory)-maxPriceHistory:]
+	}
+}
+
+// movingAverage folds a new measurement into a moving average. A zero average
+// means that nothing has been measured yet.
+func movingAverage(avg, sample float64) float64 {
+	if avg == 0 {
+		return sample
+	}
+	return avg*(1-measurementDecay) + sample*measurementDecay
+}
+
+// recordLatency records the latencies measured during a successful scan of
+// a host.
+func (hdb *HostDB) recordLatency(key types.SiaPublicKey, connect, settings time.Duration) {
+	host, haveHost := hdb.hostTree.Select(key)
+	if !haveHost {
+		return
+	}
+	host.ConnectLatency = time.Duration(movingAverage(float64(host.ConnectLatency), float64(connect)))
+	host.SettingsLatency = time.Duration(movingAverage(float64(host.SettingsLatency), float64(settings)))
+	hdb.modifyHost(host)
+}
+
+// RecordDownloadThroughput records that size bytes were downloaded from a
+// host in the given amount of time.
+func (hdb *HostDB) RecordDownloadThroughput(key types.SiaPublicKey, size uint64, elapsed time.Duration) {
+	if elapsed <= 0 {
+		return
+	}
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+
+	host, haveHost := hdb.hostTree.Select(key)
+	if !haveHost {
+		return
+	}
+	host.DownloadThroughput = movingAverage(host.DownloadThroughput, float64(size)/elapsed.Seconds())
+	hdb.modifyHost(host)
+}
+
+// RecordUploadThroughput records that size bytes were uploaded to a host in
+// the given amount of time.
+func (hdb *HostDB) RecordUploadThroughput(key types.SiaPublicKey, size uint64, elapsed time.Duration) {
+	if elapsed <= 0 {
+		return
+	}
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+
+	host, haveHost := hdb.hostTree.Select(key)
+	if !haveHost {
+		return
+	}
+	host.UploadThroughput = movingAverage(host.UploadThroughput, float64(size)/elapsed.Seconds())
+	hdb.modifyHost(host)
 }
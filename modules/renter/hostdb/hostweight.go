@@ -3,6 +3,7 @@ package hostdb
 import (
 	"math"
 	"math/big"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/modules"
@@ -45,6 +46,50 @@ var (
 	// the price.
 	priceExponentiation = 5
 
+	// defaultWeights leave every adjustment of the host's score unchanged,
+	// weigh all price categories equally, and set no max prices.
+	defaultWeights = modules.HostDBWeights{
+		AgeExponent:                1,
+		AgeMultiplier:              1,
+		BurnExponent:               1,
+		BurnMultiplier:             1,
+		CollateralExponent:         1,
+		CollateralMultiplier:       1,
+		InteractionExponent:        1,
+		InteractionMultiplier:      1,
+		PerformanceExponent:        1,
+		PerformanceMultiplier:      1,
+		PriceExponent:              1,
+		PriceMultiplier:            1,
+		StorageRemainingExponent:   1,
+		StorageRemainingMultiplier: 1,
+		UptimeExponent:             1,
+		UptimeMultiplier:           1,
+		VersionExponent:            1,
+		VersionMultiplier:          1,
+
+		ContractPriceWeight: 1,
+		DownloadPriceWeight: 1,
+		StoragePriceWeight:  1,
+		UploadPriceWeight:   1,
+	}
+
+	// maxSettingsLatency is the settings RPC round-trip time above which
+	// hosts start to be penalized for being slow to respond.
+	maxSettingsLatency = build.Select(build.Var{
+		Standard: 500 * time.Millisecond,
+		Dev:      500 * time.Millisecond,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+
+	// minThroughput is the throughput, in bytes per second, below which
+	// hosts start to be penalized for transferring data slowly.
+	minThroughput = build.Select(build.Var{
+		Standard: float64(1 << 20),
+		Dev:      float64(1 << 20),
+		Testing:  float64(1 << 16),
+	}).(float64)
+
 	// requiredStorage indicates the amount of storage that the host must be
 	// offering in order to be considered a valuable/worthwhile host.
 	requiredStorage = build.Select(build.Var{
@@ -92,6 +137,16 @@ func (hdb *HostDB) collateralAdjustments(entry modules.HostDBEntry) float64 {
 	return weight
 }
 
+// dishonestyAdjustments will give the lowest possible weight to a host that has
+// been caught presenting a contract revision that contradicts the renter's
+// records.
+func dishonestyAdjustments(entry modules.HostDBEntry) float64 {
+	if entry.Dishonest {
+		return 0
+	}
+	return 1
+}
+
 // interactionAdjustments determine the penalty to be applied to a host for the
 // historic and currnet interactions with that host. This function focuses on
 // historic interactions and ignores recent interactions.
@@ -114,9 +169,51 @@ func (hdb *HostDB) interactionAdjustments(entry modules.HostDBEntry) float64 {
 	return math.Pow(ratio, 15)
 }
 
+// performanceAdjustments penalizes the host for responding slowly to scans,
+// and for transferring data slowly. Measurements that have not been taken
+// yet are not penalized.
+func performanceAdjustments(entry modules.HostDBEntry) float64 {
+	base := float64(1)
+	if entry.SettingsLatency > maxSettingsLatency {
+		base = base * 0.9
+	}
+	if entry.SettingsLatency > 2*maxSettingsLatency {
+		base = base / 2 // 2x total penalty
+	}
+	if entry.SettingsLatency > 4*maxSettingsLatency {
+		base = base / 2 // 4x total penalty
+	}
+	if entry.SettingsLatency > 10*maxSettingsLatency {
+		base = base / 2 // 8x total penalty
+	}
+
+	// Penalize the slower of the two transfer directions that have been
+	// measured.
+	throughput := entry.DownloadThroughput
+	if throughput == 0 || (entry.UploadThroughput != 0 && entry.UploadThroughput < throughput) {
+		throughput = entry.UploadThroughput
+	}
+	if throughput == 0 {
+		return base
+	}
+	if throughput < minThroughput {
+		base = base * 0.9
+	}
+	if throughput < minThroughput/2 {
+		base = base / 2 // 2x total penalty
+	}
+	if throughput < minThroughput/4 {
+		base = base / 2 // 4x total penalty
+	}
+	if throughput < minThroughput/16 {
+		base = base / 2 // 8x total penalty
+	}
+	return base
+}
+
 // priceAdjustments will adjust the weight of the entry according to the prices
-// that it has set.
-func (hdb *HostDB) priceAdjustments(entry modules.HostDBEntry) float64 {
+// that it has set. Each price category is scaled by its weight.
+func (hdb *HostDB) priceAdjustments(entry modules.HostDBEntry, w modules.HostDBWeights) float64 {
 	// Sanity checks - the constants values need to have certain relationships
 	// to eachother
 	if build.DEBUG {
@@ -146,7 +243,13 @@ func (hdb *HostDB) priceAdjustments(entry modules.HostDBEntry) float64 {
 	adjustedUploadPrice := entry.UploadBandwidthPrice.Div64(24192)              // Adjust upload price to match a single upload over 24 weeks.
 	adjustedDownloadPrice := entry.DownloadBandwidthPrice.Div64(12096).Div64(3) // Adjust download price to match one download over 12 weeks, 1 redundancy.
 	siafundFee := adjustedContractPrice.Add(adjustedUploadPrice).Add(adjustedDownloadPrice).Add(entry.Collateral).MulTax()
-	totalPrice := entry.StoragePrice.Add(adjustedContractPrice).Add(adjustedUploadPrice).Add(adjustedDownloadPrice).Add(siafundFee)
+
+	// Scale each category by its weight.
+	adjustedContractPrice = adjustedContractPrice.MulFloat(w.ContractPriceWeight)
+	adjustedUploadPrice = adjustedUploadPrice.MulFloat(w.UploadPriceWeight)
+	adjustedDownloadPrice = adjustedDownloadPrice.MulFloat(w.DownloadPriceWeight)
+	adjustedStoragePrice := entry.StoragePrice.MulFloat(w.StoragePriceWeight)
+	totalPrice := adjustedStoragePrice.Add(adjustedContractPrice).Add(adjustedUploadPrice).Add(adjustedDownloadPrice).Add(siafundFee)
 
 	// Set a minimum on the price, then normalize to a sane precision.
 	if totalPrice.Cmp(minTotalPrice) < 0 {
@@ -170,6 +273,21 @@ func (hdb *HostDB) priceAdjustments(entry modules.HostDBEntry) float64 {
 	return weight
 }
 
+// maxPriceAdjustments will give the lowest possible weight to a host that
+// charges more than any of the max prices in the weights.
+func maxPriceAdjustments(entry modules.HostDBEntry, w modules.HostDBWeights) float64 {
+	exceeds := func(price, max types.Currency) bool {
+		return !max.IsZero() && price.Cmp(max) > 0
+	}
+	if exceeds(entry.ContractPrice, w.MaxContractPrice) ||
+		exceeds(entry.DownloadBandwidthPrice, w.MaxDownloadBandwidthPrice) ||
+		exceeds(entry.StoragePrice, w.MaxStoragePrice) ||
+		exceeds(entry.UploadBandwidthPrice, w.MaxUploadBandwidthPrice) {
+		return 0
+	}
+	return 1
+}
+
 // storageRemainingAdjustments adjusts the weight of the entry according to how
 // much storage it has remaining.
 func storageRemainingAdjustments(entry modules.HostDBEntry) float64 {
@@ -363,28 +481,48 @@ func (hdb *HostDB) uptimeAdjustments(entry modules.HostDBEntry) float64 {
 	return math.Pow(uptimeRatio, exp)
 }
 
-// calculateHostWeight returns the weight of a host according to the settings of
-// the host database entry.
-func (hdb *HostDB) calculateHostWeight(entry modules.HostDBEntry) types.Currency {
-	collateralReward := hdb.collateralAdjustments(entry)
-	interactionPenalty := hdb.interactionAdjustments(entry)
-	lifetimePenalty := hdb.lifetimeAdjustments(entry)
-	pricePenalty := hdb.priceAdjustments(entry)
-	storageRemainingPenalty := storageRemainingAdjustments(entry)
-	uptimePenalty := hdb.uptimeAdjustments(entry)
-	versionPenalty := versionAdjustments(entry)
+// weigh raises an adjustment to the power of exponent, then multiplies it by
+// multiplier.
+func weigh(adjustment, exponent, multiplier float64) float64 {
+	return multiplier * math.Pow(adjustment, exponent)
+}
 
-	// Combine the adjustments.
-	fullPenalty := collateralReward * interactionPenalty * lifetimePenalty *
-		pricePenalty * storageRemainingPenalty * uptimePenalty * versionPenalty
+// scoreBreakdown returns the score of a host, along with each of the weighted
+// adjustments that make up the score, using the provided weights. The
+// conversion rate is not computed.
+func (hdb *HostDB) scoreBreakdown(entry modules.HostDBEntry, w modules.HostDBWeights) modules.HostScoreBreakdown {
+	sb := modules.HostScoreBreakdown{
+		AgeAdjustment:              weigh(hdb.lifetimeAdjustments(entry), w.AgeExponent, w.AgeMultiplier),
+		BurnAdjustment:             weigh(1, w.BurnExponent, w.BurnMultiplier),
+		CollateralAdjustment:       weigh(hdb.collateralAdjustments(entry), w.CollateralExponent, w.CollateralMultiplier),
+		DishonestyAdjustment:       dishonestyAdjustments(entry),
+		InteractionAdjustment:      weigh(hdb.interactionAdjustments(entry), w.InteractionExponent, w.InteractionMultiplier),
+		PerformanceAdjustment:      weigh(performanceAdjustments(entry), w.PerformanceExponent, w.PerformanceMultiplier),
+		PriceAdjustment:            weigh(hdb.priceAdjustments(entry, w), w.PriceExponent, w.PriceMultiplier) * maxPriceAdjustments(entry, w),
+		StorageRemainingAdjustment: weigh(storageRemainingAdjustments(entry), w.StorageRemainingExponent, w.StorageRemainingMultiplier),
+		UptimeAdjustment:           weigh(hdb.uptimeAdjustments(entry), w.UptimeExponent, w.UptimeMultiplier),
+		VersionAdjustment:          weigh(versionAdjustments(entry), w.VersionExponent, w.VersionMultiplier),
+	}
 
-	// Return a types.Currency.
-	weight := baseWeight.MulFloat(fullPenalty)
-	if weight.IsZero() {
+	// Combine the adjustments.
+	fullPenalty := sb.AgeAdjustment * sb.BurnAdjustment * sb.CollateralAdjustment *
+		sb.DishonestyAdjustment * sb.InteractionAdjustment * sb.PerformanceAdjustment *
+		sb.PriceAdjustment * sb.StorageRemainingAdjustment * sb.UptimeAdjustment *
+		sb.VersionAdjustment
+
+	// Convert to a types.Currency.
+	sb.Score = baseWeight.MulFloat(fullPenalty)
+	if sb.Score.IsZero() {
 		// A weight of zero is problematic for for the host tree.
-		return types.NewCurrency64(1)
+		sb.Score = types.NewCurrency64(1)
 	}
-	return weight
+	return sb
+}
+
+// calculateHostWeight returns the weight of a host according to the settings of
+// the host database entry.
+func (hdb *HostDB) calculateHostWeight(entry modules.HostDBEntry) types.Currency {
+	return hdb.scoreBreakdown(entry, hdb.weights).Score
 }
 
 // calculateConversionRate calculates the conversion rate of the provided
@@ -408,16 +546,27 @@ func (hdb *HostDB) calculateConversionRate(score types.Currency) float64 {
 // EstimateHostScore takes a HostExternalSettings and returns the estimated
 // score of that host in the hostdb, assuming no penalties for age or uptime.
 func (hdb *HostDB) EstimateHostScore(entry modules.HostDBEntry) modules.HostScoreBreakdown {
-	// Grab the adjustments. Age, and uptime penalties are set to '1', to
-	// assume best behavior from the host.
-	collateralReward := hdb.collateralAdjustments(entry)
-	pricePenalty := hdb.priceAdjustments(entry)
-	storageRemainingPenalty := storageRemainingAdjustments(entry)
-	versionPenalty := versionAdjustments(entry)
+	// The weights can be changed by SetWeights, and are also read when
+	// calculating the conversion rate.
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+
+	// Grab the adjustments. Age, performance and uptime penalties are set to
+	// '1', to assume best behavior from the host.
+	w := hdb.weights
+	ageReward := weigh(1, w.AgeExponent, w.AgeMultiplier)
+	burnReward := weigh(1, w.BurnExponent, w.BurnMultiplier)
+	collateralReward := weigh(hdb.collateralAdjustments(entry), w.CollateralExponent, w.CollateralMultiplier)
+	performanceReward := weigh(1, w.PerformanceExponent, w.PerformanceMultiplier)
+	pricePenalty := weigh(hdb.priceAdjustments(entry, w), w.PriceExponent, w.PriceMultiplier) * maxPriceAdjustments(entry, w)
+	storageRemainingPenalty := weigh(storageRemainingAdjustments(entry), w.StorageRemainingExponent, w.StorageRemainingMultiplier)
+	uptimeReward := weigh(1, w.UptimeExponent, w.UptimeMultiplier)
+	versionPenalty := weigh(versionAdjustments(entry), w.VersionExponent, w.VersionMultiplier)
 
 	// Combine into a full penalty, then determine the resulting estimated
 	// score.
-	fullPenalty := collateralReward * pricePenalty * storageRemainingPenalty * versionPenalty
+	fullPenalty := ageReward * burnReward * collateralReward * performanceReward *
+		pricePenalty * storageRemainingPenalty * uptimeReward * versionPenalty
 	estimatedScore := baseWeight.MulFloat(fullPenalty)
 	if estimatedScore.IsZero() {
 		estimatedScore = types.NewCurrency64(1)
@@ -428,12 +577,14 @@ func (hdb *HostDB) EstimateHostScore(entry modules.HostDBEntry) modules.HostScor
 		Score:          estimatedScore,
 		ConversionRate: hdb.calculateConversionRate(estimatedScore),
 
-		AgeAdjustment:              1,
-		BurnAdjustment:             1,
+		AgeAdjustment:              ageReward,
+		BurnAdjustment:             burnReward,
 		CollateralAdjustment:       collateralReward,
+		DishonestyAdjustment:       1,
+		PerformanceAdjustment:      performanceReward,
 		PriceAdjustment:            pricePenalty,
 		StorageRemainingAdjustment: storageRemainingPenalty,
-		UptimeAdjustment:           1,
+		UptimeAdjustment:           uptimeReward,
 		VersionAdjustment:          versionPenalty,
 	}
 }
@@ -444,18 +595,16 @@ func (hdb *HostDB) ScoreBreakdown(entry modules.HostDBEntry) modules.HostScoreBr
 	hdb.mu.Lock()
 	defer hdb.mu.Unlock()
 
-	score := hdb.calculateHostWeight(entry)
-	return modules.HostScoreBreakdown{
-		Score:          score,
-		ConversionRate: hdb.calculateConversionRate(score),
-
-		AgeAdjustment:              hdb.lifetimeAdjustments(entry),
-		BurnAdjustment:             1,
-		CollateralAdjustment:       hdb.collateralAdjustments(entry),
-		InteractionAdjustment:      hdb.interactionAdjustments(entry),
-		PriceAdjustment:            hdb.priceAdjustments(entry),
-		StorageRemainingAdjustment: storageRemainingAdjustments(entry),
-		UptimeAdjustment:           hdb.uptimeAdjustments(entry),
-		VersionAdjustment:          versionAdjustments(entry),
-	}
+	sb := hdb.scoreBreakdown(entry, hdb.weights)
+	sb.ConversionRate = hdb.calculateConversionRate(sb.Score)
+	return sb
+}
+
+// ScoreBreakdownWithWeights returns the score breakdown of the host using the
+// provided weights instead of the hostdb's. The conversion rate is not
+// computed.
+func (hdb *HostDB) ScoreBreakdownWithWeights(entry modules.HostDBEntry, w modules.HostDBWeights) modules.HostScoreBreakdown {
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	return hdb.scoreBreakdown(entry, w)
 }
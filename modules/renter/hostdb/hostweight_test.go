@@ -102,6 +102,55 @@ func TestHostWeightStorageRemainingDifferences(t *testing.T) {
 	}
 }
 
+func TestHostWeightDishonesty(t *testing.T) {
+	hdb := bareHostDB()
+	var entry modules.HostDBEntry
+	entry.RemainingStorage = 250e3
+	entry.StoragePrice = types.NewCurrency64(1000).Mul(types.SiacoinPrecision)
+	entry.Collateral = types.NewCurrency64(1000).Mul(types.SiacoinPrecision)
+
+	entry2 := entry
+	entry2.Dishonest = true
+	w1 := hdb.calculateHostWeight(entry)
+	w2 := hdb.calculateHostWeight(entry2)
+
+	if w1.Cmp(w2) <= 0 {
+		t.Error("Dishonest host should have less weight")
+	}
+	if !w2.Equals(types.NewCurrency64(1)) {
+		t.Error("Dishonest host should have the lowest possible weight, got", w2)
+	}
+}
+
+// TestHostWeightPerformanceDifferences checks that slow hosts have less
+// weight, and that hosts that have not been measured are not penalized.
+func TestHostWeightPerformanceDifferences(t *testing.T) {
+	hdb := bareHostDB()
+	var entry modules.HostDBEntry
+	entry.RemainingStorage = 250e3
+	entry.StoragePrice = types.NewCurrency64(1000).Mul(types.SiacoinPrecision)
+	entry.Collateral = types.NewCurrency64(1000).Mul(types.SiacoinPrecision)
+
+	fast := entry
+	fast.SettingsLatency = maxSettingsLatency / 2
+	fast.DownloadThroughput = minThroughput * 2
+	slowLatency := fast
+	slowLatency.SettingsLatency = maxSettingsLatency * 5
+	slowThroughput := fast
+	slowThroughput.UploadThroughput = minThroughput / 8
+
+	w := hdb.calculateHostWeight(entry)
+	if !w.Equals(hdb.calculateHostWeight(fast)) {
+		t.Error("Fast host should have the same weight as an unmeasured host")
+	}
+	if w.Cmp(hdb.calculateHostWeight(slowLatency)) <= 0 {
+		t.Error("Host with high latency should have less weight")
+	}
+	if w.Cmp(hdb.calculateHostWeight(slowThroughput)) <= 0 {
+		t.Error("Host with low throughput should have less weight")
+	}
+}
+
 func TestHostWeightVersionDifferences(t *testing.T) {
 	if testing.Short() {
 		t.SkipNow()
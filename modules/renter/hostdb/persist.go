@@ -1,17 +1,32 @@
 package hostdb
 
 import (
+	"encoding/json"
+	"errors"
+	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/persist"
 	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
 )
 
 var (
-	// persistFilename defines the name of the file that holds the hostdb's
+	// dbFilename is the name of the database that holds the hostdb's
 	// persistence.
+	dbFilename = "hostdb.db"
+
+	// dbMetadata is the metadata of the hostdb's database.
+	dbMetadata = persist.Metadata{
+		Header:  "HostDB Database",
+		Version: "1.3.0",
+	}
+
+	// persistFilename defines the name of the file that held the hostdb's
+	// persistence before the hostdb moved to a database.
 	persistFilename = "hostdb.json"
 
 	// persistMetadata defines the metadata that tags along with the most recent
@@ -22,61 +37,275 @@ var (
 	}
 )
 
-// hdbPersist defines what HostDB data persists across sessions.
+var (
+	// bucketHosts holds one record per host, keyed by the string
+	// representation of the host's public key.
+	bucketHosts = []byte("Hosts")
+
+	// bucketSettings holds the persistent fields of the hostdb other than
+	// the hosts.
+	bucketSettings = []byte("Settings")
+
+	keyBlockHeight     = []byte("BlockHeight")
+	keyContractedHosts = []byte("ContractedHosts")
+	keyLastChange      = []byte("LastChange")
+	keyPrunedHosts     = []byte("PrunedHosts")
+	keyPruningPolicy   = []byte("PruningPolicy")
+	keyWeights         = []byte("Weights")
+)
+
+var errNilBucket = errors.New("hostdb database is missing a bucket")
+
+// hdbPersist defines what HostDB data persisted across sessions before the
+// hostdb moved to a database.
 type hdbPersist struct {
 	AllHosts    []modules.HostDBEntry
 	BlockHeight types.BlockHeight
 	LastChange  modules.ConsensusChangeID
+	Weights     modules.HostDBWeights
 }
 
-// persistData returns the data in the hostdb that will be saved to disk.
-func (hdb *HostDB) persistData() (data hdbPersist) {
-	data.AllHosts = hdb.hostTree.All()
-	data.BlockHeight = hdb.blockHeight
-	data.LastChange = hdb.lastChange
-	return data
+// insertHost inserts a host into the host tree, marking it to be written to
+// the database on the next save.
+func (hdb *HostDB) insertHost(host modules.HostDBEntry) error {
+	if err := hdb.hostTree.Insert(host); err != nil {
+		return err
+	}
+	hdb.dirtyHosts[host.PublicKey.String()] = host.PublicKey
+	return nil
 }
 
-// saveSync saves the hostdb persistence data to disk and then syncs to disk.
-func (hdb *HostDB) saveSync() error {
-	return hdb.deps.saveFileSync(persistMetadata, hdb.persistData(), filepath.Join(hdb.persistDir, persistFilename))
+// modifyHost modifies a host in the host tree, marking it to be written to
+// the database on the next save.
+func (hdb *HostDB) modifyHost(host modules.HostDBEntry) error {
+	if err := hdb.hostTree.Modify(host); err != nil {
+		return err
+	}
+	hdb.dirtyHosts[host.PublicKey.String()] = host.PublicKey
+	return nil
 }
 
-// load loads the hostdb persistence data from disk.
-func (hdb *HostDB) load() error {
-	// Fetch the data from the file.
-	var data hdbPersist
-	err := hdb.deps.loadFile(persistMetadata, &data, filepath.Join(hdb.persistDir, persistFilename))
-	if err != nil {
+// removeHost removes a host from the host tree, marking it to be deleted from
+// the database on the next save.
+func (hdb *HostDB) removeHost(pk types.SiaPublicKey) error {
+	if err := hdb.hostTree.Remove(pk); err != nil {
 		return err
 	}
+	hdb.dirtyHosts[pk.String()] = pk
+	return nil
+}
+
+// putSettings writes the persistent fields of the hostdb, other than the
+// hosts, to the database.
+func (hdb *HostDB) putSettings(tx *bolt.Tx) error {
+	b := tx.Bucket(bucketSettings)
+	if b == nil {
+		return errNilBucket
+	}
+	contractedHosts := make([]string, 0, len(hdb.contractedHosts))
+	for key := range hdb.contractedHosts {
+		contractedHosts = append(contractedHosts, key)
+	}
+	settings := map[string]interface{}{
+		string(keyBlockHeight):     hdb.blockHeight,
+		string(keyContractedHosts): contractedHosts,
+		string(keyLastChange):      hdb.lastChange,
+		string(keyPrunedHosts):     hdb.prunedHosts,
+		string(keyPruningPolicy):   hdb.pruningPolicy,
+		string(keyWeights):         hdb.weights,
+	}
+	for key, val := range settings {
+		valBytes, err := json.Marshal(val)
+		if err != nil {
+			return err
+		}
+		if err := b.Put([]byte(key), valBytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	// Set the hostdb internal values.
-	hdb.blockHeight = data.BlockHeight
-	hdb.lastChange = data.LastChange
+// putHost writes a host to the database.
+func putHost(tx *bolt.Tx, host modules.HostDBEntry) error {
+	b := tx.Bucket(bucketHosts)
+	if b == nil {
+		return errNilBucket
+	}
+	hostBytes, err := json.Marshal(host)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(host.PublicKey.String()), hostBytes)
+}
 
-	// Load each of the hosts into the host tree.
-	for _, host := range data.AllHosts {
-		// COMPATv1.1.0
-		//
-		// The host did not always track its block height correctly, meaning
-		// that previously the FirstSeen values and the blockHeight values
-		// could get out of sync.
-		if hdb.blockHeight < host.FirstSeen {
-			host.FirstSeen = hdb.blockHeight
+// saveSync writes the hosts that changed since the last save, along with the
+// rest of the hostdb's persistent fields, to the database and syncs it to
+// disk.
+func (hdb *HostDB) saveSync() error {
+	err := hdb.db.Update(func(tx *bolt.Tx) error {
+		if err := hdb.putSettings(tx); err != nil {
+			return err
+		}
+		for key, pk := range hdb.dirtyHosts {
+			host, exists := hdb.hostTree.Select(pk)
+			if !exists {
+				if err := tx.Bucket(bucketHosts).Delete([]byte(key)); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := putHost(tx, host); err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	hdb.dirtyHosts = make(map[string]types.SiaPublicKey)
+	return nil
+}
 
-		err := hdb.hostTree.Insert(host)
-		if err != nil {
-			hdb.log.Debugln("ERROR: could not insert host while loading:", host.NetAddress)
+// initDB opens the hostdb's database, creating its buckets if they do not
+// exist yet.
+func (hdb *HostDB) initDB() (err error) {
+	hdb.db, err = hdb.deps.openDatabase(dbMetadata, filepath.Join(hdb.persistDir, dbFilename))
+	if err != nil {
+		return err
+	}
+	return hdb.db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{bucketHosts, bucketSettings} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+}
 
-		// Make sure that all hosts have gone through the initial scanning.
-		if len(host.ScanHistory) < 2 {
-			hdb.queueScan(host)
+// COMPATv1.3.0
+//
+// migrateJSON moves the hosts and settings of the old hostdb persist file into
+// the database, then renames the file so that it is not migrated again. The
+// file is kept as a backup.
+func (hdb *HostDB) migrateJSON() error {
+	filename := filepath.Join(hdb.persistDir, persistFilename)
+	var data hdbPersist
+	err := hdb.deps.loadFile(persistMetadata, &data, filename)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// Older persist files do not contain weights; keep the defaults.
+	weights := hdb.weights
+	if validateWeights(data.Weights) == nil {
+		weights = data.Weights
+	}
+	err = hdb.db.Update(func(tx *bolt.Tx) error {
+		// Don't overwrite a database that already holds hosts.
+		if k, _ := tx.Bucket(bucketHosts).Cursor().First(); k != nil {
+			return nil
+		}
+		hdb.blockHeight = data.BlockHeight
+		hdb.lastChange = data.LastChange
+		hdb.weights = weights
+		if err := hdb.putSettings(tx); err != nil {
+			return err
 		}
+		for _, host := range data.AllHosts {
+			if err := putHost(tx, host); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
-	return nil
+	hdb.log.Printf("Migrated %v hosts from %v to %v", len(data.AllHosts), persistFilename, dbFilename)
+	return os.Rename(filename, filename+".bak")
+}
+
+// load loads the hostdb persistence data from the database.
+func (hdb *HostDB) load() error {
+	if err := hdb.migrateJSON(); err != nil {
+		return err
+	}
+
+	return hdb.db.View(func(tx *bolt.Tx) error {
+		// Load the settings. The settings are absent from a new database.
+		b := tx.Bucket(bucketSettings)
+		if v := b.Get(keyBlockHeight); v != nil {
+			if err := json.Unmarshal(v, &hdb.blockHeight); err != nil {
+				return err
+			}
+		}
+		if v := b.Get(keyLastChange); v != nil {
+			if err := json.Unmarshal(v, &hdb.lastChange); err != nil {
+				return err
+			}
+		}
+		if v := b.Get(keyWeights); v != nil {
+			var weights modules.HostDBWeights
+			if err := json.Unmarshal(v, &weights); err == nil && validateWeights(weights) == nil {
+				hdb.weights = weights
+			}
+		}
+		if v := b.Get(keyPruningPolicy); v != nil {
+			if err := json.Unmarshal(v, &hdb.pruningPolicy); err != nil {
+				return err
+			}
+		}
+		if v := b.Get(keyPrunedHosts); v != nil {
+			if err := json.Unmarshal(v, &hdb.prunedHosts); err != nil {
+				return err
+			}
+		}
+		if v := b.Get(keyContractedHosts); v != nil {
+			var contractedHosts []string
+			if err := json.Unmarshal(v, &contractedHosts); err != nil {
+				return err
+			}
+			for _, key := range contractedHosts {
+				hdb.contractedHosts[key] = struct{}{}
+			}
+		}
+
+		// Load each of the hosts into the host tree. A corrupted record only
+		// loses that host, rather than the whole database.
+		return tx.Bucket(bucketHosts).ForEach(func(k, v []byte) error {
+			var host modules.HostDBEntry
+			if err := json.Unmarshal(v, &host); err != nil {
+				hdb.log.Printf("ERROR: could not decode host %s while loading: %v", k, err)
+				return nil
+			}
+
+			// COMPATv1.1.0
+			//
+			// The host did not always track its block height correctly,
+			// meaning that previously the FirstSeen values and the
+			// blockHeight values could get out of sync.
+			if hdb.blockHeight < host.FirstSeen {
+				host.FirstSeen = hdb.blockHeight
+				hdb.dirtyHosts[host.PublicKey.String()] = host.PublicKey
+			}
+
+			err := hdb.hostTree.Insert(host)
+			if err != nil {
+				hdb.log.Debugln("ERROR: could not insert host while loading:", host.NetAddress)
+			}
+
+			// Make sure that all hosts have gone through the initial scanning.
+			if len(host.ScanHistory) < 2 {
+				hdb.queueScan(host)
+			}
+			return nil
+		})
+	})
 }
 
 // threadedSaveLoop saves the hostdb to disk every 2 minutes, also saving when
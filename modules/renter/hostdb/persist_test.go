@@ -1,10 +1,15 @@
 package hostdb
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
 )
 
 // quitAfterLoadDeps will quit startup in newHostDB
@@ -54,9 +59,11 @@ func TestSaveLoad(t *testing.T) {
 	host1.PublicKey.Key = []byte("foo")
 	host2.PublicKey.Key = []byte("bar")
 	host3.PublicKey.Key = []byte("baz")
-	hdbt.hdb.hostTree.Insert(host1)
-	hdbt.hdb.hostTree.Insert(host2)
-	hdbt.hdb.hostTree.Insert(host3)
+	hdbt.hdb.mu.Lock()
+	hdbt.hdb.insertHost(host1)
+	hdbt.hdb.insertHost(host2)
+	hdbt.hdb.insertHost(host3)
+	hdbt.hdb.mu.Unlock()
 
 	// Save, close, and reload.
 	hdbt.hdb.mu.Lock()
@@ -102,6 +109,170 @@ func TestSaveLoad(t *testing.T) {
 	}
 }
 
+// TestSaveLoadIncremental tests that modified and removed hosts are written to
+// the database when the hostdb saves, and that corrupted host records are
+// skipped when loading.
+func TestSaveLoadIncremental(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// Disable the scan loop, so that scans do not modify the hosts while
+	// they are checked.
+	hdbt, err := newHDBTesterDeps(t.Name(), disableScanLoopDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var host1, host2, host3 modules.HostDBEntry
+	host1.PublicKey.Key = []byte("foo")
+	host2.PublicKey.Key = []byte("bar")
+	host3.PublicKey.Key = []byte("baz")
+	hdbt.hdb.mu.Lock()
+	for _, host := range []modules.HostDBEntry{host1, host2, host3} {
+		if err := hdbt.hdb.insertHost(host); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = hdbt.hdb.saveSync()
+	if len(hdbt.hdb.dirtyHosts) != 0 {
+		t.Error("dirty hosts were not cleared by saving")
+	}
+	hdbt.hdb.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Modify one host and remove another, then save again.
+	host1.NetAddress = "foo.com:1234"
+	hdbt.hdb.mu.Lock()
+	if err := hdbt.hdb.modifyHost(host1); err != nil {
+		t.Fatal(err)
+	}
+	if err := hdbt.hdb.removeHost(host2.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+	if len(hdbt.hdb.dirtyHosts) != 2 {
+		t.Error("expected 2 dirty hosts, got", len(hdbt.hdb.dirtyHosts))
+	}
+	err = hdbt.hdb.saveSync()
+	hdbt.hdb.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Close the hostdb and corrupt the record of the third host, so that the
+	// hostdb cannot overwrite the corrupted record before it is reloaded.
+	if err := hdbt.hdb.Close(); err != nil {
+		t.Fatal(err)
+	}
+	hdbPersistDir := filepath.Join(hdbt.persistDir, modules.RenterDir)
+	db, err := persist.OpenDatabase(dbMetadata, filepath.Join(hdbPersistDir, dbFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketHosts).Put([]byte(host3.PublicKey.String()), []byte("{"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reload the hostdb.
+	hdbt.hdb, err = newHostDB(hdbt.gateway, hdbt.cs, hdbPersistDir, quitAfterLoadDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hdbt.hdb.Close()
+	if h1, ok := hdbt.hdb.hostTree.Select(host1.PublicKey); !ok || h1.NetAddress != host1.NetAddress {
+		t.Error("modified host was not saved")
+	}
+	if _, ok := hdbt.hdb.hostTree.Select(host2.PublicKey); ok {
+		t.Error("removed host was not deleted")
+	}
+	if _, ok := hdbt.hdb.hostTree.Select(host3.PublicKey); ok {
+		t.Error("corrupted host was loaded")
+	}
+	if len(hdbt.hdb.hostTree.All()) != 1 {
+		t.Error("expected 1 host, got", len(hdbt.hdb.hostTree.All()))
+	}
+}
+
+// TestMigrateJSON tests that the hostdb migrates its old persist file into the
+// database.
+func TestMigrateJSON(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdbt, err := newHDBTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hdbt.hdb.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Write a persist file in the format used before the database.
+	var host1, host2 modules.HostDBEntry
+	host1.FirstSeen = 1
+	host2.FirstSeen = 2
+	host1.PublicKey.Key = []byte("foo")
+	host2.PublicKey.Key = []byte("bar")
+	weights := defaultWeights
+	weights.DownloadPriceWeight = 3
+	data := hdbPersist{
+		AllHosts:    []modules.HostDBEntry{host1, host2},
+		BlockHeight: 5,
+		LastChange:  modules.ConsensusChangeID{1, 2, 3},
+		Weights:     weights,
+	}
+	dir := filepath.Join(hdbt.persistDir, "migrate")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := persist.SaveJSON(persistMetadata, data, filepath.Join(dir, persistFilename)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Load the hostdb, which should migrate the file.
+	hdb, err := newHostDB(hdbt.gateway, hdbt.cs, dir, quitAfterLoadDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hdb.hostTree.All()) != 2 {
+		t.Error("expected 2 hosts after migration, got", len(hdb.hostTree.All()))
+	}
+	if hdb.blockHeight != 5 || hdb.lastChange != data.LastChange {
+		t.Error("settings were not migrated")
+	}
+	if hdb.Weights().DownloadPriceWeight != 3 {
+		t.Error("weights were not migrated")
+	}
+	if _, err := os.Stat(filepath.Join(dir, persistFilename)); !os.IsNotExist(err) {
+		t.Error("persist file was not moved aside after migration")
+	}
+	if _, err := os.Stat(filepath.Join(dir, persistFilename+".bak")); err != nil {
+		t.Error("persist file backup is missing:", err)
+	}
+	if err := hdb.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reloading should keep the migrated hosts.
+	hdb, err = newHostDB(hdbt.gateway, hdbt.cs, dir, quitAfterLoadDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hdb.Close()
+	if _, ok := hdb.hostTree.Select(types.SiaPublicKey{Key: []byte("bar")}); !ok || len(hdb.hostTree.All()) != 2 {
+		t.Error("migrated hosts were not persisted")
+	}
+}
+
 // TestRescan tests that the hostdb will rescan the blockchain properly, picking
 // up new hosts which appear in an alternate past.
 func TestRescan(t *testing.T) {
@@ -0,0 +1,150 @@
+package hostdb
+
+// pruning.go removes hosts that have been offline for a long time from the
+// hostdb, so that they are no longer scanned or kept in memory. Hosts that the
+// renter has contracts with, and hosts that announced themselves recently, are
+// never pruned.
+
+import (
+	"errors"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var errNegativeDowntime = errors.New("max downtime must not be negative")
+
+// compactScanHistory compresses the scans older than maxHostDowntime into the
+// historic uptime and downtime of the host, keeping at least minScans scans.
+func compactScanHistory(entry *modules.HostDBEntry) {
+	for len(entry.ScanHistory) > minScans && time.Now().Sub(entry.ScanHistory[0].Timestamp) > maxHostDowntime {
+		timePassed := entry.ScanHistory[1].Timestamp.Sub(entry.ScanHistory[0].Timestamp)
+		if entry.ScanHistory[0].Success {
+			entry.HistoricUptime += timePassed
+		} else {
+			entry.HistoricDowntime += timePassed
+		}
+		entry.ScanHistory = entry.ScanHistory[1:]
+	}
+}
+
+// offlineSince returns the time of the first failed scan since the host was
+// last seen online, or the zero time if the most recent scan succeeded. The
+// host's OfflineSince field is consulted so that scans that have already been
+// compressed are taken into account.
+func offlineSince(entry modules.HostDBEntry) time.Time {
+	scans := entry.ScanHistory
+	if len(scans) == 0 || scans[len(scans)-1].Success {
+		return time.Time{}
+	}
+	first := len(scans) - 1
+	for first > 0 && !scans[first-1].Success {
+		first--
+	}
+	if first == 0 && !entry.OfflineSince.IsZero() && entry.OfflineSince.Before(scans[0].Timestamp) {
+		return entry.OfflineSince
+	}
+	return scans[first].Timestamp
+}
+
+// prunable returns true if the pruning policy calls for the host to be
+// removed from the hostdb. The host's OfflineSince field must be up to date.
+func (hdb *HostDB) prunable(entry modules.HostDBEntry) bool {
+	policy := hdb.pruningPolicy
+	scans := entry.ScanHistory
+	if policy.MaxDowntime == 0 || len(scans) < minScans || scans[len(scans)-1].Success {
+		return false
+	} else if time.Since(entry.OfflineSince) <= policy.MaxDowntime {
+		return false
+	} else if _, contracted := hdb.contractedHosts[entry.PublicKey.String()]; contracted {
+		return false
+	}
+	// Hosts that were announced before LastAnnouncement was tracked only have
+	// a FirstSeen height.
+	lastAnnouncement := entry.LastAnnouncement
+	if lastAnnouncement < entry.FirstSeen {
+		lastAnnouncement = entry.FirstSeen
+	}
+	return hdb.blockHeight >= lastAnnouncement+policy.AnnouncementWindow
+}
+
+// pruneHost removes a host from the hostdb, recording it in the list of
+// pruned hosts.
+func (hdb *HostDB) pruneHost(entry modules.HostDBEntry) {
+	if err := hdb.removeHost(entry.PublicKey); err != nil {
+		hdb.log.Println("ERROR: unable to prune host:", entry.NetAddress, err)
+		return
+	}
+	hdb.prunedHosts = append(hdb.prunedHosts, modules.PrunedHost{
+		PublicKey:    entry.PublicKey,
+		NetAddress:   entry.NetAddress,
+		OfflineSince: entry.OfflineSince,
+		BlockHeight:  hdb.blockHeight,
+		Timestamp:    time.Now(),
+	})
+	if len(hdb.prunedHosts) > maxPrunedHosts {
+		hdb.prunedHosts = hdb.prunedHosts[len(hdb.prunedHosts)-maxPrunedHosts:]
+	}
+	hdb.log.Printf("Pruned host %v (%v), which has been offline since %v", entry.PublicKey, entry.NetAddress, entry.OfflineSince)
+}
+
+// pruneHosts compacts the scan history of every host in the hostdb, then
+// prunes the hosts that the pruning policy calls for.
+func (hdb *HostDB) pruneHosts() {
+	for _, host := range hdb.hostTree.All() {
+		entry := host
+		entry.OfflineSince = offlineSince(entry)
+		compactScanHistory(&entry)
+		if hdb.prunable(entry) {
+			hdb.pruneHost(entry)
+		} else if len(entry.ScanHistory) != len(host.ScanHistory) || !entry.OfflineSince.Equal(host.OfflineSince) {
+			if err := hdb.modifyHost(entry); err != nil {
+				hdb.log.Println("ERROR: unable to compact scan history of host:", entry.NetAddress, err)
+			}
+		}
+	}
+}
+
+// PruningPolicy returns the policy that the hostdb uses to prune hosts.
+func (hdb *HostDB) PruningPolicy() modules.HostDBPruningPolicy {
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	return hdb.pruningPolicy
+}
+
+// SetPruningPolicy sets the policy that the hostdb uses to prune hosts. The
+// policy is applied the next time that the hostdb scans its hosts.
+func (hdb *HostDB) SetPruningPolicy(p modules.HostDBPruningPolicy) error {
+	if p.MaxDowntime < 0 {
+		return errNegativeDowntime
+	}
+	if err := hdb.tg.Add(); err != nil {
+		return err
+	}
+	defer hdb.tg.Done()
+
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	hdb.pruningPolicy = p
+	return hdb.saveSync()
+}
+
+// PrunedHosts returns the hosts most recently pruned from the hostdb, oldest
+// first.
+func (hdb *HostDB) PrunedHosts() []modules.PrunedHost {
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	return append([]modules.PrunedHost(nil), hdb.prunedHosts...)
+}
+
+// SetContractedHosts sets the hosts that the renter has contracts with. These
+// hosts are never pruned.
+func (hdb *HostDB) SetContractedHosts(keys []types.SiaPublicKey) {
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	hdb.contractedHosts = make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		hdb.contractedHosts[key.String()] = struct{}{}
+	}
+}
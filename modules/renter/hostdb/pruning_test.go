@@ -0,0 +1,208 @@
+package hostdb
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// offlineHost returns a host entry whose scans have all failed, spanning the
+// given amount of time.
+func offlineHost(offline time.Duration) modules.HostDBEntry {
+	host := makeHostDBEntry()
+	start := time.Now().Add(-offline)
+	host.ScanHistory = nil
+	for i := 0; i < minScans; i++ {
+		host.ScanHistory = append(host.ScanHistory, modules.HostDBScan{
+			Timestamp: start.Add(offline * time.Duration(i) / minScans),
+		})
+	}
+	host.OfflineSince = offlineSince(host)
+	return host
+}
+
+// TestOfflineSince checks that offlineSince finds the start of the host's
+// current streak of failed scans.
+func TestOfflineSince(t *testing.T) {
+	now := time.Now()
+	scans := modules.HostDBScans{
+		{Timestamp: now.Add(-4 * time.Hour), Success: false},
+		{Timestamp: now.Add(-3 * time.Hour), Success: true},
+		{Timestamp: now.Add(-2 * time.Hour), Success: false},
+		{Timestamp: now.Add(-1 * time.Hour), Success: false},
+	}
+	var host modules.HostDBEntry
+	if !offlineSince(host).IsZero() {
+		t.Error("host without scans should not be offline")
+	}
+
+	host.ScanHistory = scans
+	if since := offlineSince(host); !since.Equal(scans[2].Timestamp) {
+		t.Error("wrong offline time:", since)
+	}
+
+	// An OfflineSince from before the first scan is only used if every scan
+	// failed.
+	host.OfflineSince = now.Add(-time.Hour * 24)
+	if since := offlineSince(host); !since.Equal(scans[2].Timestamp) {
+		t.Error("wrong offline time:", since)
+	}
+	host.ScanHistory = scans[2:]
+	if since := offlineSince(host); !since.Equal(host.OfflineSince) {
+		t.Error("wrong offline time:", since)
+	}
+
+	host.ScanHistory = append(host.ScanHistory, modules.HostDBScan{Timestamp: now, Success: true})
+	if !offlineSince(host).IsZero() {
+		t.Error("online host should not be offline")
+	}
+}
+
+// TestPrunable checks that the pruning policy is applied correctly.
+func TestPrunable(t *testing.T) {
+	hdb := bareHostDB()
+	hdb.blockHeight = 1000
+	hdb.pruningPolicy = modules.HostDBPruningPolicy{
+		MaxDowntime:        24 * time.Hour,
+		AnnouncementWindow: 100,
+	}
+
+	host := offlineHost(48 * time.Hour)
+	host.FirstSeen = 10
+	if !hdb.prunable(host) {
+		t.Fatal("expected host to be prunable")
+	}
+
+	// A host that has not been offline for long enough is kept.
+	recent := offlineHost(12 * time.Hour)
+	if hdb.prunable(recent) {
+		t.Error("host that went offline recently should not be prunable")
+	}
+
+	// A host that was announced recently is kept.
+	announced := host
+	announced.LastAnnouncement = 950
+	if hdb.prunable(announced) {
+		t.Error("recently announced host should not be prunable")
+	}
+	announced.LastAnnouncement = 0
+	announced.FirstSeen = 950
+	if hdb.prunable(announced) {
+		t.Error("recently seen host should not be prunable")
+	}
+
+	// A host that the renter has a contract with is kept.
+	hdb.SetContractedHosts([]types.SiaPublicKey{host.PublicKey})
+	if hdb.prunable(host) {
+		t.Error("contracted host should not be prunable")
+	}
+	hdb.SetContractedHosts(nil)
+
+	// A host that is online again is kept.
+	online := host
+	online.ScanHistory = append(online.ScanHistory, modules.HostDBScan{Timestamp: time.Now(), Success: true})
+	online.OfflineSince = offlineSince(online)
+	if hdb.prunable(online) {
+		t.Error("online host should not be prunable")
+	}
+
+	// Pruning can be disabled.
+	hdb.pruningPolicy.MaxDowntime = 0
+	if hdb.prunable(host) {
+		t.Error("host should not be prunable when pruning is disabled")
+	}
+}
+
+// TestPruneHosts checks that pruneHosts compacts the scan history of the
+// hosts it keeps, and reports the hosts it prunes.
+func TestPruneHosts(t *testing.T) {
+	hdb := bareHostDB()
+	hdb.pruningPolicy = modules.HostDBPruningPolicy{MaxDowntime: 30 * 24 * time.Hour}
+
+	// The first host has been offline for longer than the max downtime. The
+	// second has been offline for longer than maxHostDowntime, meaning that
+	// its scans will be compacted, but not for longer than the max downtime.
+	pruned := offlineHost(40 * 24 * time.Hour)
+	kept := offlineHost(20 * 24 * time.Hour)
+	kept.ScanHistory = append(kept.ScanHistory, modules.HostDBScan{Timestamp: time.Now()})
+	for _, host := range []modules.HostDBEntry{pruned, kept} {
+		if err := hdb.insertHost(host); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hdb.pruneHosts()
+
+	if _, exists := hdb.hostTree.Select(pruned.PublicKey); exists {
+		t.Error("host offline for longer than the max downtime was not pruned")
+	}
+	prunedHosts := hdb.PrunedHosts()
+	if len(prunedHosts) != 1 || prunedHosts[0].PublicKey.String() != pruned.PublicKey.String() {
+		t.Fatal("pruned host was not reported:", prunedHosts)
+	} else if !prunedHosts[0].OfflineSince.Equal(pruned.OfflineSince) {
+		t.Error("pruned host reported with wrong offline time:", prunedHosts[0].OfflineSince)
+	}
+
+	host, exists := hdb.hostTree.Select(kept.PublicKey)
+	if !exists {
+		t.Fatal("host offline for less than the max downtime was pruned")
+	}
+	if len(host.ScanHistory) >= len(kept.ScanHistory) || host.HistoricDowntime == 0 {
+		t.Error("scan history of host was not compacted")
+	}
+	// The offline time must survive the compaction.
+	if since := offlineSince(host); !since.Equal(kept.OfflineSince) {
+		t.Error("compaction lost the offline time of the host:", since)
+	}
+}
+
+// TestSetPruningPolicy checks that the pruning policy, the pruned hosts and
+// the contracted hosts persist.
+func TestSetPruningPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	hdbt, err := newHDBTesterDeps(t.Name(), disableScanLoopDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := hdbt.hdb.SetPruningPolicy(modules.HostDBPruningPolicy{MaxDowntime: -1}); err != errNegativeDowntime {
+		t.Fatal("expected errNegativeDowntime, got", err)
+	}
+	policy := modules.HostDBPruningPolicy{
+		MaxDowntime:        time.Hour,
+		AnnouncementWindow: 5,
+	}
+	contracted := makeHostDBEntry()
+	hdbt.hdb.SetContractedHosts([]types.SiaPublicKey{contracted.PublicKey})
+	hdbt.hdb.mu.Lock()
+	host := offlineHost(2 * time.Hour)
+	hdbt.hdb.insertHost(host)
+	hdbt.hdb.pruneHost(host)
+	hdbt.hdb.mu.Unlock()
+	if err := hdbt.hdb.SetPruningPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reload the hostdb.
+	if err := hdbt.hdb.Close(); err != nil {
+		t.Fatal(err)
+	}
+	hdb, err := newHostDB(hdbt.gateway, hdbt.cs, filepath.Join(hdbt.persistDir, modules.RenterDir), disableScanLoopDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hdb.Close()
+	if hdb.PruningPolicy() != policy {
+		t.Fatal("pruning policy did not persist:", hdb.PruningPolicy())
+	}
+	if prunedHosts := hdb.PrunedHosts(); len(prunedHosts) != 1 || prunedHosts[0].PublicKey.String() != host.PublicKey.String() {
+		t.Fatal("pruned hosts did not persist:", prunedHosts)
+	}
+	if _, ok := hdb.contractedHosts[contracted.PublicKey.String()]; !ok {
+		t.Fatal("contracted hosts did not persist")
+	}
+}
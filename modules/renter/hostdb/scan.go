@@ -103,6 +103,10 @@ func (hdb *HostDB) updateEntry(entry modules.HostDBEntry, netErr error) {
 	} else {
 		newEntry = entry
 	}
+	// Only a successful scan reports the host's current prices.
+	if netErr == nil {
+		updatePriceHistory(&newEntry, hdb.blockHeight)
+	}
 
 	// Add the datapoints for the scan.
 	if len(newEntry.ScanHistory) < 2 {
@@ -138,51 +142,33 @@ func (hdb *HostDB) updateEntry(entry modules.HostDBEntry, netErr error) {
 		newEntry.ScanHistory = append(newEntry.ScanHistory, modules.HostDBScan{Timestamp: newTimestamp, Success: netErr == nil})
 	}
 
-	// Check whether any of the recent scans demonstrate uptime. The pruning and
-	// compression of the history ensure that there are only relatively recent
-	// scans represented.
-	var recentUptime bool
-	for _, scan := range newEntry.ScanHistory {
-		if scan.Success {
-			recentUptime = true
-		}
-	}
+	// Compress any old scans into the historic values.
+	newEntry.OfflineSince = offlineSince(newEntry)
+	compactScanHistory(&newEntry)
 
 	// If the host has been offline for too long, delete the host from the
-	// hostdb. Only delete if there have been enough scans over a long enough
-	// period to be confident that the host really is offline for good.
-	if time.Now().Sub(newEntry.ScanHistory[0].Timestamp) > maxHostDowntime && !recentUptime && len(newEntry.ScanHistory) >= minScans {
-		err := hdb.hostTree.Remove(newEntry.PublicKey)
-		if err != nil {
-			hdb.log.Println("ERROR: unable to remove host newEntry which has had a ton of downtime:", err)
+	// hostdb, as long as the pruning policy allows it. Only delete if there
+	// have been enough scans to be confident that the host really is offline
+	// for good.
+	if hdb.prunable(newEntry) {
+		if exists {
+			hdb.pruneHost(newEntry)
 		}
-
 		// The function should terminate here as no more interaction is needed
 		// with this host.
 		return
 	}
 
-	// Compress any old scans into the historic values.
-	for len(newEntry.ScanHistory) > minScans && time.Now().Sub(newEntry.ScanHistory[0].Timestamp) > maxHostDowntime {
-		timePassed := newEntry.ScanHistory[1].Timestamp.Sub(newEntry.ScanHistory[0].Timestamp)
-		if newEntry.ScanHistory[0].Success {
-			newEntry.HistoricUptime += timePassed
-		} else {
-			newEntry.HistoricDowntime += timePassed
-		}
-		newEntry.ScanHistory = newEntry.ScanHistory[1:]
-	}
-
 	// Add the updated entry
 	if !exists {
-		err := hdb.hostTree.Insert(newEntry)
+		err := hdb.insertHost(newEntry)
 		if err != nil {
 			hdb.log.Println("ERROR: unable to insert entry which is was thought to be new:", err)
 		} else {
 			hdb.log.Debugf("Adding host %v to the hostdb. Net error: %v\n", newEntry.PublicKey.String(), netErr)
 		}
 	} else {
-		err := hdb.hostTree.Modify(newEntry)
+		err := hdb.modifyHost(newEntry)
 		if err != nil {
 			hdb.log.Println("ERROR: unable to modify entry which is thought to exist:", err)
 		} else {
@@ -205,15 +191,18 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 	hdb.mu.RUnlock()
 
 	var settings modules.HostExternalSettings
+	var connectLatency, settingsLatency time.Duration
 	err := func() error {
 		dialer := &net.Dialer{
 			Cancel:  hdb.tg.StopChan(),
 			Timeout: hostRequestTimeout,
 		}
+		start := time.Now()
 		conn, err := dialer.Dial("tcp", string(netAddr))
 		if err != nil {
 			return err
 		}
+		connectLatency = time.Since(start)
 		connCloseChan := make(chan struct{})
 		go func() {
 			select {
@@ -225,13 +214,19 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 		defer close(connCloseChan)
 		conn.SetDeadline(time.Now().Add(hostScanDeadline))
 
+		start = time.Now()
 		err = encoding.WriteObject(conn, modules.RPCSettings)
 		if err != nil {
 			return err
 		}
 		var pubkey crypto.PublicKey
 		copy(pubkey[:], pubKey.Key)
-		return crypto.ReadSignedObject(conn, &settings, maxSettingsLen, pubkey)
+		err = crypto.ReadSignedObject(conn, &settings, maxSettingsLen, pubkey)
+		if err != nil {
+			return err
+		}
+		settingsLatency = time.Since(start)
+		return nil
 	}()
 	if err != nil {
 		hdb.log.Debugf("Scan of host at %v failed: %v", netAddr, err)
@@ -252,6 +247,9 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 	// delete the entry from the scan map as the scan has been successful.
 	hdb.mu.Lock()
 	hdb.updateEntry(entry, err)
+	if err == nil {
+		hdb.recordLatency(pubKey, connectLatency, settingsLatency)
+	}
 	hdb.mu.Unlock()
 }
 
@@ -309,6 +307,12 @@ func (hdb *HostDB) threadedScan() {
 		// most part only online hosts are getting scanned unless there are
 		// fewer than hostCheckupQuantity of them.
 
+		// Prune the hosts that have been offline for too long before picking
+		// the hosts to scan.
+		hdb.mu.Lock()
+		hdb.pruneHosts()
+		hdb.mu.Unlock()
+
 		// Grab a set of hosts to scan, grab hosts that are active, inactive,
 		// and offline to get high diversity.
 		var onlineHosts, offlineHosts []modules.HostDBEntry
@@ -182,3 +182,53 @@ func TestUpdateEntry(t *testing.T) {
 		t.Error("host not reporting historic uptime?")
 	}
 }
+
+// TestUpdateEntryPriceHistory checks that updateEntry records a price snapshot
+// whenever a successful scan shows that the host's prices have changed.
+func TestUpdateEntryPriceHistory(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	hdbt, err := newHDBTesterDeps(t.Name(), disableScanLoopDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := modules.HostDBEntry{
+		PublicKey: types.SiaPublicKey{
+			Key: []byte{1},
+		},
+	}
+	entry.StoragePrice = types.NewCurrency64(10)
+	hdbt.hdb.updateEntry(entry, nil)
+
+	// A scan with unchanged prices should not add a snapshot.
+	hdbt.hdb.updateEntry(entry, nil)
+	host, _ := hdbt.hdb.hostTree.Select(entry.PublicKey)
+	if len(host.PriceHistory) != 1 {
+		t.Fatal("expected 1 price snapshot, got", len(host.PriceHistory))
+	} else if !host.PriceHistory[0].StoragePrice.Equals64(10) {
+		t.Fatal("snapshot has the wrong storage price:", host.PriceHistory[0].StoragePrice)
+	}
+
+	// A failed scan should not add a snapshot, even if the prices differ.
+	entry.StoragePrice = types.NewCurrency64(20)
+	hdbt.hdb.updateEntry(entry, errors.New("testing err"))
+	host, _ = hdbt.hdb.hostTree.Select(entry.PublicKey)
+	if len(host.PriceHistory) != 1 {
+		t.Fatal("expected 1 price snapshot, got", len(host.PriceHistory))
+	}
+
+	// Successful scans with new prices should add snapshots, keeping only the
+	// most recent ones.
+	for i := 0; i < maxPriceHistory+5; i++ {
+		entry.StoragePrice = types.NewCurrency64(uint64(100 + i))
+		hdbt.hdb.updateEntry(entry, nil)
+	}
+	host, _ = hdbt.hdb.hostTree.Select(entry.PublicKey)
+	if len(host.PriceHistory) != maxPriceHistory {
+		t.Fatalf("expected %v price snapshots, got %v", maxPriceHistory, len(host.PriceHistory))
+	} else if last := host.PriceHistory[maxPriceHistory-1]; !last.StoragePrice.Equals(entry.StoragePrice) {
+		t.Fatal("most recent snapshot has the wrong storage price:", last.StoragePrice)
+	}
+}
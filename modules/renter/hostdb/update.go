@@ -56,13 +56,15 @@ func (hdb *HostDB) insertBlockchainHost(host modules.HostDBEntry) {
 		if oldEntry.FirstSeen == 0 {
 			oldEntry.FirstSeen = hdb.blockHeight
 		}
-		err := hdb.hostTree.Modify(oldEntry)
+		oldEntry.LastAnnouncement = hdb.blockHeight
+		err := hdb.modifyHost(oldEntry)
 		if err != nil {
 			hdb.log.Println("ERROR: unable to modify host entry of host tree after a blockchain scan:", err)
 		}
 	} else {
 		host.FirstSeen = hdb.blockHeight
-		err := hdb.hostTree.Insert(host)
+		host.LastAnnouncement = hdb.blockHeight
+		err := hdb.insertHost(host)
 		if err != nil {
 			hdb.log.Println("ERROR: unable to insert host entry into host tree after a blockchain scan:", err)
 		}
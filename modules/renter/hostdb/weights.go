@@ -0,0 +1,73 @@
+package hostdb
+
+import (
+	"errors"
+	"math"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+var (
+	errNegativeWeight   = errors.New("exponents and price weights must be non-negative numbers")
+	errNonPositiveScale = errors.New("multipliers must be positive numbers")
+	errZeroPriceWeights = errors.New("at least one price weight must be positive")
+)
+
+// validateWeights returns an error if the weights cannot be used to score
+// hosts.
+func validateWeights(w modules.HostDBWeights) error {
+	nonNegative := []float64{
+		w.AgeExponent, w.BurnExponent, w.CollateralExponent, w.InteractionExponent,
+		w.PerformanceExponent, w.PriceExponent, w.StorageRemainingExponent, w.UptimeExponent,
+		w.VersionExponent,
+		w.ContractPriceWeight, w.DownloadPriceWeight, w.StoragePriceWeight, w.UploadPriceWeight,
+	}
+	for _, f := range nonNegative {
+		if f < 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+			return errNegativeWeight
+		}
+	}
+	positive := []float64{
+		w.AgeMultiplier, w.BurnMultiplier, w.CollateralMultiplier, w.InteractionMultiplier,
+		w.PerformanceMultiplier, w.PriceMultiplier, w.StorageRemainingMultiplier, w.UptimeMultiplier,
+		w.VersionMultiplier,
+	}
+	for _, f := range positive {
+		if f <= 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+			return errNonPositiveScale
+		}
+	}
+	if w.ContractPriceWeight+w.DownloadPriceWeight+w.StoragePriceWeight+w.UploadPriceWeight == 0 {
+		return errZeroPriceWeights
+	}
+	return nil
+}
+
+// Weights returns the weights that the hostdb uses to score hosts.
+func (hdb *HostDB) Weights() modules.HostDBWeights {
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	return hdb.weights
+}
+
+// SetWeights sets the weights that the hostdb uses to score hosts, rescoring
+// every host in the database.
+func (hdb *HostDB) SetWeights(w modules.HostDBWeights) error {
+	if err := validateWeights(w); err != nil {
+		return err
+	}
+	if err := hdb.tg.Add(); err != nil {
+		return err
+	}
+	defer hdb.tg.Done()
+
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	hdb.weights = w
+	for _, host := range hdb.hostTree.All() {
+		if err := hdb.hostTree.Modify(host); err != nil {
+			hdb.log.Println("ERROR: could not rescore host:", host.NetAddress, err)
+		}
+	}
+	return hdb.saveSync()
+}
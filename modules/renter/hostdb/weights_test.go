@@ -0,0 +1,145 @@
+package hostdb
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestValidateWeights probes the validateWeights function.
+func TestValidateWeights(t *testing.T) {
+	if err := validateWeights(defaultWeights); err != nil {
+		t.Fatal("default weights are invalid:", err)
+	}
+	tests := []struct {
+		modify func(*modules.HostDBWeights)
+		err    error
+	}{
+		{func(w *modules.HostDBWeights) { w.UptimeExponent = -1 }, errNegativeWeight},
+		{func(w *modules.HostDBWeights) { w.PriceExponent = math.NaN() }, errNegativeWeight},
+		{func(w *modules.HostDBWeights) { w.DownloadPriceWeight = -1 }, errNegativeWeight},
+		{func(w *modules.HostDBWeights) { w.AgeMultiplier = 0 }, errNonPositiveScale},
+		{func(w *modules.HostDBWeights) {
+			w.ContractPriceWeight, w.DownloadPriceWeight, w.StoragePriceWeight, w.UploadPriceWeight = 0, 0, 0, 0
+		}, errZeroPriceWeights},
+		{func(w *modules.HostDBWeights) { w.UptimeExponent = 0 }, nil},
+	}
+	for i, test := range tests {
+		w := defaultWeights
+		test.modify(&w)
+		if err := validateWeights(w); err != test.err {
+			t.Errorf("%v: expected %v, got %v", i, test.err, err)
+		}
+	}
+}
+
+// TestHostWeightDefaultWeights checks that the default weights leave the
+// score of a host unchanged.
+func TestHostWeightDefaultWeights(t *testing.T) {
+	hdb := bareHostDB()
+	var entry modules.HostDBEntry
+	entry.RemainingStorage = 250e3
+	entry.StoragePrice = types.NewCurrency64(1000).Mul(types.SiacoinPrecision)
+	entry.Collateral = types.NewCurrency64(1000).Mul(types.SiacoinPrecision)
+
+	sb := hdb.ScoreBreakdown(entry)
+	product := sb.AgeAdjustment * sb.BurnAdjustment * sb.CollateralAdjustment *
+		sb.DishonestyAdjustment * sb.InteractionAdjustment * sb.PerformanceAdjustment *
+		sb.PriceAdjustment * sb.StorageRemainingAdjustment * sb.UptimeAdjustment *
+		sb.VersionAdjustment
+	if !sb.Score.Equals(baseWeight.MulFloat(product)) {
+		t.Fatal("score does not match the product of the adjustments")
+	}
+	if sb.BurnAdjustment != 1 {
+		t.Fatal("burn adjustment should be 1 with the default weights, got", sb.BurnAdjustment)
+	}
+}
+
+// TestHostWeightPriceWeights checks that the price weights and max prices
+// change the relative scores of hosts.
+func TestHostWeightPriceWeights(t *testing.T) {
+	hdb := bareHostDB()
+	var cheapDownload modules.HostDBEntry
+	cheapDownload.RemainingStorage = 250e3
+	cheapDownload.StoragePrice = types.SiacoinPrecision.Mul64(1000).Div64(tbMonth)
+	cheapDownload.DownloadBandwidthPrice = types.SiacoinPrecision.Mul64(10).Div64(1e12)
+	cheapStorage := cheapDownload
+	cheapStorage.StoragePrice = cheapStorage.StoragePrice.Div64(2)
+	cheapStorage.DownloadBandwidthPrice = cheapStorage.DownloadBandwidthPrice.Mul64(100)
+
+	// Under the default weights, the host with cheap storage wins.
+	if hdb.calculateHostWeight(cheapStorage).Cmp(hdb.calculateHostWeight(cheapDownload)) <= 0 {
+		t.Fatal("expected cheap storage to win under the default weights")
+	}
+
+	// Emphasizing download price makes the host with cheap downloads win.
+	w := defaultWeights
+	w.DownloadPriceWeight = 100
+	if hdb.scoreBreakdown(cheapStorage, w).Score.Cmp(hdb.scoreBreakdown(cheapDownload, w).Score) >= 0 {
+		t.Fatal("expected cheap downloads to win when download price is emphasized")
+	}
+
+	// Hosts above the max price get the lowest possible score.
+	w = defaultWeights
+	w.MaxDownloadBandwidthPrice = cheapDownload.DownloadBandwidthPrice
+	if !hdb.scoreBreakdown(cheapStorage, w).Score.Equals64(1) {
+		t.Fatal("expected host above the max price to have the lowest score")
+	}
+	if hdb.scoreBreakdown(cheapDownload, w).Score.Equals64(1) {
+		t.Fatal("expected host at the max price to keep its score")
+	}
+}
+
+// TestSetWeights checks that setting the weights rescores the hosts in the
+// database, and that the weights persist.
+func TestSetWeights(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	hdbt, err := newHDBTesterDeps(t.Name(), disableScanLoopDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host := makeHostDBEntry()
+	host.FirstSeen = 1
+	hdbt.hdb.mu.Lock()
+	err = hdbt.hdb.hostTree.Insert(host)
+	hdbt.hdb.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := hdbt.hdb.ScoreBreakdown(host).Score
+
+	// Invalid weights are rejected.
+	w := hdbt.hdb.Weights()
+	w.UptimeMultiplier = 0
+	if err := hdbt.hdb.SetWeights(w); err != errNonPositiveScale {
+		t.Fatal("expected errNonPositiveScale, got", err)
+	}
+
+	w.UptimeMultiplier = 0.5
+	if err := hdbt.hdb.SetWeights(w); err != nil {
+		t.Fatal(err)
+	}
+	after := hdbt.hdb.ScoreBreakdown(host).Score
+	if after.Cmp(before) >= 0 {
+		t.Fatal("expected score to drop after halving the uptime multiplier")
+	}
+
+	// Reload the hostdb and check that the weights persisted.
+	if err := hdbt.hdb.Close(); err != nil {
+		t.Fatal(err)
+	}
+	hdb, err := newHostDB(hdbt.gateway, hdbt.cs, filepath.Join(hdbt.persistDir, modules.RenterDir), disableScanLoopDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hdb.Close()
+	if hdb.Weights().UptimeMultiplier != 0.5 {
+		t.Fatal("weights did not persist")
+	}
+}
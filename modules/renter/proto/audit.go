@@ -0,0 +1,78 @@
+package proto
+
+import (
+	"errors"
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// AuditSector asks the host to prove that it is storing the segment with
+// index segmentIndex of the sector with the given Merkle root, which must be
+// covered by contract. If the host admits that it is not storing the sector,
+// claims that the contract does not cover it, or provides an invalid proof,
+// an error is returned for which IsAuditFailure is true. If the host refuses
+// because the contract was audited too recently, modules.ErrAuditRateLimited
+// is returned. If the host does not complete the audit after the recent
+// revision protocol, for example by rejecting it for another reason or by
+// closing the connection, an error is returned for which IsAuditIncomplete is
+// true. Other errors, such as a revision mismatch or a failure to connect,
+// say nothing about whether the host is storing the sector.
+func AuditSector(host modules.HostDBEntry, contract modules.RenterContract, root crypto.Hash, segmentIndex uint64, cancel <-chan struct{}) error {
+	conn, err := dialHost(host.NetAddress, host.PublicKey, host.SupportsRPC(modules.RPCEncryptedConn), 15*time.Second, cancel)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// allot 2 minutes for RPC request + revision exchange
+	extendDeadline(conn, modules.NegotiateRecentRevisionTime)
+	if err := encoding.WriteObject(conn, modules.RPCAudit); err != nil {
+		return errors.New("couldn't initiate RPC: " + err.Error())
+	}
+	if err := verifyRecentRevision(conn, contract, host.Version); err != nil {
+		return err
+	}
+
+	// request the segment
+	extendDeadline(conn, modules.NegotiateDownloadTime)
+	req := modules.AuditRequest{
+		MerkleRoot:   root,
+		SegmentIndex: segmentIndex,
+	}
+	if err := encoding.WriteObject(conn, req); err != nil {
+		return &auditIncompleteError{"couldn't send audit request: " + err.Error()}
+	}
+	// The response is read directly so that the host's reason for a
+	// rejection can be examined. Only a rejection admitting that the sector
+	// is missing is treated as a failure.
+	var resp string
+	if err := encoding.ReadObject(conn, &resp, modules.NegotiateMaxErrorSize); err != nil {
+		return &auditIncompleteError{"couldn't read audit response: " + err.Error()}
+	}
+	switch resp {
+	case modules.AcceptResponse:
+	case modules.ErrAuditMissingSector.Error(), modules.ErrAuditUnknownSector.Error():
+		return &auditFailureError{"host rejected audit: " + resp}
+	case modules.ErrAuditRateLimited.Error():
+		return modules.ErrAuditRateLimited
+	default:
+		return &auditIncompleteError{"host rejected audit: " + resp}
+	}
+
+	// read and verify the segment and proof
+	var base []byte
+	var hashSet []crypto.Hash
+	if err := encoding.ReadObject(conn, &base, 8+crypto.SegmentSize); err != nil {
+		return &auditIncompleteError{"couldn't read audited segment: " + err.Error()}
+	}
+	if err := encoding.ReadObject(conn, &hashSet, 8+(sectorHeight+1)*crypto.HashSize); err != nil {
+		return &auditIncompleteError{"couldn't read audit proof: " + err.Error()}
+	}
+	if !crypto.VerifySegment(base, hashSet, modules.SectorSize/crypto.SegmentSize, segmentIndex, root) {
+		return &auditFailureError{"invalid proof for segment of sector " + root.String()}
+	}
+	return nil
+}
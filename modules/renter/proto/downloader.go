@@ -12,28 +12,44 @@ import (
 	"github.com/NebulousLabs/Sia/modules"
 )
 
+// maxRangeProofSize is the maximum size of the encoded Merkle range proofs
+// that the host sends for a single download request.
+const maxRangeProofSize = 1 << 12
+
+var errBadRange = errors.New("requested range is not within the sector")
+
 // A Downloader retrieves sectors by calling the download RPC on a host.
 // Downloaders are NOT thread- safe; calls to Sector must be serialized.
 type Downloader struct {
-	host      modules.HostDBEntry
-	contract  modules.RenterContract // updated after each revision
-	conn      net.Conn
-	closeChan chan struct{}
-	once      sync.Once
-	hdb       hostDB
+	host        modules.HostDBEntry
+	contract    modules.RenterContract // updated after each revision
+	conn        net.Conn
+	closeChan   chan struct{}
+	once        sync.Once
+	hdb         hostDB
+	rangeProofs bool // true if the host supports range downloads
+
+	// Hosts that do not support range downloads send entire sectors. The
+	// most recent one is kept so that further ranges of the same sector are
+	// not downloaded and paid for again.
+	lastRoot   crypto.Hash
+	lastSector []byte
 
 	SaveFn revisionSaver
 }
 
-// Sector retrieves the sector with the specified Merkle root, and revises
-// the underlying contract to pay the host proportionally to the data
-// retrieve.
-func (hd *Downloader) Sector(root crypto.Hash) (_ modules.RenterContract, _ []byte, err error) {
+// download retrieves length bytes starting at offset within the sector with
+// the specified Merkle root, and revises the underlying contract to pay the
+// host for the data. If the host supports range downloads, the data is
+// verified using the Merkle range proof sent by the host, and the range must
+// be aligned to segment boundaries. Otherwise only full sectors can be
+// verified.
+func (hd *Downloader) download(root crypto.Hash, offset, length uint64) (_ modules.RenterContract, _ []byte, err error) {
 	extendDeadline(hd.conn, modules.NegotiateDownloadTime)
 	defer extendDeadline(hd.conn, time.Hour) // reset deadline when finished
 
 	// calculate price
-	sectorPrice := hd.host.DownloadBandwidthPrice.Mul64(modules.SectorSize)
+	sectorPrice := hd.host.DownloadBandwidthPrice.Mul64(length)
 	if hd.contract.RenterFunds().Cmp(sectorPrice) < 0 {
 		return modules.RenterContract{}, nil, errors.New("contract has insufficient funds to support download")
 	}
@@ -66,8 +82,8 @@ func (hd *Downloader) Sector(root crypto.Hash) (_ modules.RenterContract, _ []by
 	// send download action
 	err = encoding.WriteObject(hd.conn, []modules.DownloadAction{{
 		MerkleRoot: root,
-		Offset:     0,
-		Length:     modules.SectorSize,
+		Offset:     offset,
+		Length:     length,
 	}})
 	if err != nil {
 		return modules.RenterContract{}, nil, err
@@ -95,14 +111,27 @@ func (hd *Downloader) Sector(root crypto.Hash) (_ modules.RenterContract, _ []by
 
 	// read sector data, completing one iteration of the download loop
 	var sectors [][]byte
-	if err := encoding.ReadObject(hd.conn, &sectors, modules.SectorSize+16); err != nil {
+	if err := encoding.ReadObject(hd.conn, &sectors, length+16); err != nil {
 		return modules.RenterContract{}, nil, err
 	} else if len(sectors) != 1 {
 		return modules.RenterContract{}, nil, errors.New("host did not send enough sectors")
 	}
 	sector := sectors[0]
-	if uint64(len(sector)) != modules.SectorSize {
+	if uint64(len(sector)) != length {
 		return modules.RenterContract{}, nil, errors.New("host did not send enough sector data")
+	}
+	if hd.rangeProofs {
+		var proofs [][]crypto.Hash
+		if err := encoding.ReadObject(hd.conn, &proofs, maxRangeProofSize); err != nil {
+			return modules.RenterContract{}, nil, err
+		} else if len(proofs) != 1 {
+			return modules.RenterContract{}, nil, errors.New("host did not send enough range proofs")
+		}
+		start := offset / crypto.SegmentSize
+		end := (offset + length) / crypto.SegmentSize
+		if !crypto.VerifyMerkleRangeProof(sector, proofs[0], modules.SectorSize/crypto.SegmentSize, start, end, root) {
+			return modules.RenterContract{}, nil, errors.New("host sent bad sector data")
+		}
 	} else if crypto.MerkleRoot(sector) != root {
 		return modules.RenterContract{}, nil, errors.New("host sent bad sector data")
 	}
@@ -115,6 +144,43 @@ func (hd *Downloader) Sector(root crypto.Hash) (_ modules.RenterContract, _ []by
 	return hd.contract, sector, nil
 }
 
+// Sector retrieves the sector with the specified Merkle root, and revises
+// the underlying contract to pay the host proportionally to the data
+// retrieve.
+func (hd *Downloader) Sector(root crypto.Hash) (modules.RenterContract, []byte, error) {
+	return hd.download(root, 0, modules.SectorSize)
+}
+
+// Range retrieves length bytes starting at offset within the sector with the
+// specified Merkle root. If the host supports range downloads, only the
+// segments covering the range are downloaded and paid for, and the data is
+// verified using a Merkle range proof. Otherwise, the entire sector is
+// downloaded, and kept for further ranges of the same sector.
+func (hd *Downloader) Range(root crypto.Hash, offset, length uint64) (modules.RenterContract, []byte, error) {
+	if length == 0 || offset >= modules.SectorSize || length > modules.SectorSize-offset {
+		return modules.RenterContract{}, nil, errBadRange
+	}
+	if !hd.rangeProofs {
+		if hd.lastSector == nil || hd.lastRoot != root {
+			_, sector, err := hd.download(root, 0, modules.SectorSize)
+			if err != nil {
+				return modules.RenterContract{}, nil, err
+			}
+			hd.lastRoot, hd.lastSector = root, sector
+		}
+		return hd.contract, append([]byte(nil), hd.lastSector[offset:offset+length]...), nil
+	}
+
+	// round the range out to segment boundaries
+	start := offset / crypto.SegmentSize * crypto.SegmentSize
+	end := (offset + length + crypto.SegmentSize - 1) / crypto.SegmentSize * crypto.SegmentSize
+	contract, data, err := hd.download(root, start, end-start)
+	if err != nil {
+		return modules.RenterContract{}, nil, err
+	}
+	return contract, data[offset-start : offset-start+length], nil
+}
+
 // shutdown terminates the revision loop and signals the goroutine spawned in
 // NewDownloader to return.
 func (hd *Downloader) shutdown() {
@@ -155,15 +221,40 @@ func NewDownloader(host modules.HostDBEntry, contract modules.RenterContract, hd
 		}
 	}()
 
-	// initiate download loop
-	conn, err := (&net.Dialer{
-		Cancel:  cancel,
-		Timeout: 15 * time.Second,
-	}).Dial("tcp", string(contract.NetAddress))
+	// initiate download loop. Hosts that advertise range downloads are asked
+	// for them first. If such a host does not recognize the RPC after all,
+	// it closes the connection, and the standard download RPC is used
+	// instead.
+	rangeProofs := host.SupportsRPC(modules.RPCDownloadRange)
+	conn, closeChan, err := initiateDownload(contract, host, rangeProofs, cancel)
+	_, dialErr := err.(*net.OpError)
+	if err != nil && rangeProofs && !dialErr && !IsRevisionMismatch(err) {
+		rangeProofs = false
+		conn, closeChan, err = initiateDownload(contract, host, rangeProofs, cancel)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	// the host is now ready to accept revisions
+	return &Downloader{
+		contract:    contract,
+		host:        host,
+		conn:        conn,
+		closeChan:   closeChan,
+		hdb:         hdb,
+		rangeProofs: rangeProofs,
+	}, nil
+}
+
+// initiateDownload dials the host and initiates the download loop, using
+// RPCDownloadRange if rangeProofs is set.
+func initiateDownload(contract modules.RenterContract, host modules.HostDBEntry, rangeProofs bool, cancel <-chan struct{}) (net.Conn, chan struct{}, error) {
+	conn, err := dialHost(contract.NetAddress, contract.HostPublicKey, host.SupportsRPC(modules.RPCEncryptedConn), 15*time.Second, cancel)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	closeChan := make(chan struct{})
 	go func() {
 		select {
@@ -174,25 +265,21 @@ func NewDownloader(host modules.HostDBEntry, contract modules.RenterContract, hd
 	}()
 
 	// allot 2 minutes for RPC request + revision exchange
+	rpc := modules.RPCDownload
+	if rangeProofs {
+		rpc = modules.RPCDownloadRange
+	}
 	extendDeadline(conn, modules.NegotiateRecentRevisionTime)
 	defer extendDeadline(conn, time.Hour)
-	if err := encoding.WriteObject(conn, modules.RPCDownload); err != nil {
+	if err := encoding.WriteObject(conn, rpc); err != nil {
 		conn.Close()
 		close(closeChan)
-		return nil, errors.New("couldn't initiate RPC: " + err.Error())
+		return nil, nil, errors.New("couldn't initiate RPC: " + err.Error())
 	}
 	if err := verifyRecentRevision(conn, contract, host.Version); err != nil {
 		conn.Close() // TODO: close gracefully if host has entered revision loop
 		close(closeChan)
-		return nil, err
+		return nil, nil, err
 	}
-
-	// the host is now ready to accept revisions
-	return &Downloader{
-		contract:  contract,
-		host:      host,
-		conn:      conn,
-		closeChan: closeChan,
-		hdb:       hdb,
-	}, nil
+	return conn, closeChan, nil
 }
@@ -19,6 +19,8 @@ var hostPriceLeeway = build.Select(build.Var{
 	Testing:  0.002,
 }).(float64)
 
+var errBatchTooLarge = errors.New("upload batch exceeds the host's maximum revision batch size")
+
 var (
 	// sectorHeight is the height of a Merkle tree that covers a single
 	// sector. It is log2(modules.SectorSize / crypto.SegmentSize)
@@ -125,17 +127,59 @@ func (he *Editor) runRevisionIteration(actions []modules.RevisionAction, rev typ
 	return nil
 }
 
+// uploadActionOverhead is the number of bytes that an ActionInsert adds to
+// the encoded revision batch in addition to the sector data: the action type,
+// the sector index, the offset, and the length prefix of the data.
+const uploadActionOverhead = types.SpecifierLen + 8 + 8 + 8
+
+// MaxUploadBatch returns the number of sectors that fit in a single revision
+// sent to the host, according to the host's MaxReviseBatchSize. At least one
+// sector is always allowed.
+func (he *Editor) MaxUploadBatch() int {
+	return maxUploadBatch(he.host)
+}
+
+// maxUploadBatch returns the number of sectors that fit in a single revision
+// sent to host.
+func maxUploadBatch(host modules.HostDBEntry) int {
+	// the batch is encoded as a slice, which is prefixed by its length
+	n := (host.MaxReviseBatchSize - 8) / (modules.SectorSize + uploadActionOverhead)
+	if host.MaxReviseBatchSize < 8 || n < 1 {
+		return 1
+	}
+	return int(n)
+}
+
 // Upload negotiates a revision that adds a sector to a file contract.
 func (he *Editor) Upload(data []byte) (modules.RenterContract, crypto.Hash, error) {
-	// allot 10 minutes for this exchange; sufficient to transfer 4 MB over 50 kbps
-	extendDeadline(he.conn, modules.NegotiateFileContractRevisionTime)
+	contract, roots, err := he.UploadBatch([][]byte{data})
+	if err != nil {
+		return modules.RenterContract{}, crypto.Hash{}, err
+	}
+	return contract, roots[0], nil
+}
+
+// UploadBatch negotiates a single revision that adds several sectors to a
+// file contract. The batch must not contain more than MaxUploadBatch sectors.
+// The Merkle roots of the sectors are returned in the same order as the data.
+func (he *Editor) UploadBatch(data [][]byte) (modules.RenterContract, []crypto.Hash, error) {
+	if len(data) == 0 {
+		return he.contract, nil, nil
+	} else if len(data) > 1 && len(data) > he.MaxUploadBatch() {
+		return modules.RenterContract{}, nil, errBatchTooLarge
+	}
+
+	// allot 10 minutes per sector for this exchange; sufficient to transfer
+	// 4 MB over 50 kbps
+	extendDeadline(he.conn, modules.NegotiateFileContractRevisionTime*time.Duration(len(data)))
 	defer extendDeadline(he.conn, time.Hour) // reset deadline
 
 	// calculate price
 	// TODO: height is never updated, so we'll wind up overpaying on long-running uploads
-	blockBytes := types.NewCurrency64(modules.SectorSize * uint64(he.contract.FileContract.WindowEnd-he.height))
+	numSectors := uint64(len(data))
+	blockBytes := types.NewCurrency64(modules.SectorSize * numSectors * uint64(he.contract.FileContract.WindowEnd-he.height))
 	sectorStoragePrice := he.host.StoragePrice.Mul(blockBytes)
-	sectorBandwidthPrice := he.host.UploadBandwidthPrice.Mul64(modules.SectorSize)
+	sectorBandwidthPrice := he.host.UploadBandwidthPrice.Mul64(modules.SectorSize * numSectors)
 	sectorCollateral := he.host.Collateral.Mul(blockBytes)
 
 	// to mitigate small errors (e.g. differing block heights), fudge the
@@ -149,35 +193,40 @@ func (he *Editor) Upload(data []byte) (modules.RenterContract, crypto.Hash, erro
 
 	sectorPrice := sectorStoragePrice.Add(sectorBandwidthPrice)
 	if he.contract.RenterFunds().Cmp(sectorPrice) < 0 {
-		return modules.RenterContract{}, crypto.Hash{}, errors.New("contract has insufficient funds to support upload")
+		return modules.RenterContract{}, nil, errors.New("contract has insufficient funds to support upload")
 	}
 	if he.contract.LastRevision.NewMissedProofOutputs[1].Value.Cmp(sectorCollateral) < 0 {
-		return modules.RenterContract{}, crypto.Hash{}, errors.New("contract has insufficient collateral to support upload")
+		return modules.RenterContract{}, nil, errors.New("contract has insufficient collateral to support upload")
 	}
 
-	// calculate the new Merkle root
-	sectorRoot := crypto.MerkleRoot(data)
-	newRoots := append(he.contract.MerkleRoots, sectorRoot)
+	// calculate the new Merkle roots and create the actions
+	sectorRoots := make([]crypto.Hash, len(data))
+	actions := make([]modules.RevisionAction, len(data))
+	newRoots := append([]crypto.Hash(nil), he.contract.MerkleRoots...)
+	for i, sector := range data {
+		sectorRoots[i] = crypto.MerkleRoot(sector)
+		actions[i] = modules.RevisionAction{
+			Type:        modules.ActionInsert,
+			SectorIndex: uint64(len(newRoots)),
+			Data:        sector,
+		}
+		newRoots = append(newRoots, sectorRoots[i])
+	}
 	merkleRoot := cachedMerkleRoot(newRoots)
 
-	// create the action and revision
-	actions := []modules.RevisionAction{{
-		Type:        modules.ActionInsert,
-		SectorIndex: uint64(len(he.contract.MerkleRoots)),
-		Data:        data,
-	}}
-	rev := newUploadRevision(he.contract.LastRevision, merkleRoot, sectorPrice, sectorCollateral)
+	// create the revision
+	rev := newUploadRevision(he.contract.LastRevision, merkleRoot, sectorPrice, sectorCollateral, numSectors)
 
 	// run the revision iteration
 	if err := he.runRevisionIteration(actions, rev, newRoots); err != nil {
-		return modules.RenterContract{}, crypto.Hash{}, err
+		return modules.RenterContract{}, nil, err
 	}
 
 	// update metrics
 	he.contract.StorageSpending = he.contract.StorageSpending.Add(sectorStoragePrice)
 	he.contract.UploadSpending = he.contract.UploadSpending.Add(sectorBandwidthPrice)
 
-	return he.contract, sectorRoot, nil
+	return he.contract, sectorRoots, nil
 }
 
 // Delete negotiates a revision that removes a sector from a file contract.
@@ -282,10 +331,7 @@ func NewEditor(host modules.HostDBEntry, contract modules.RenterContract, curren
 	}()
 
 	// initiate revision loop
-	conn, err := (&net.Dialer{
-		Cancel:  cancel,
-		Timeout: 15 * time.Second,
-	}).Dial("tcp", string(contract.NetAddress))
+	conn, err := dialHost(contract.NetAddress, host.PublicKey, host.SupportsRPC(modules.RPCEncryptedConn), 15*time.Second, cancel)
 	if err != nil {
 		return nil, err
 	}
@@ -2,7 +2,6 @@ package proto
 
 import (
 	"errors"
-	"net"
 
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/encoding"
@@ -22,8 +21,13 @@ func FormContract(params ContractParams, txnBuilder transactionBuilder, tpool tr
 	// Extract vars from params, for convenience.
 	host, filesize, startHeight, endHeight, refundAddress := params.Host, params.Filesize, params.StartHeight, params.EndHeight, params.RefundAddress
 
-	// Create our key.
-	ourSK, ourPK := crypto.GenerateKeyPair()
+	// Create our key, unless the renter uses the same key for all of its
+	// contracts.
+	ourSK := params.SecretKey
+	if ourSK == (crypto.SecretKey{}) {
+		ourSK, _ = crypto.GenerateKeyPair()
+	}
+	ourPK := ourSK.PublicKey()
 	// Create unlock conditions.
 	uc := types.UnlockConditions{
 		PublicKeys: []types.SiaPublicKey{
@@ -104,11 +108,7 @@ func FormContract(params ContractParams, txnBuilder transactionBuilder, tpool tr
 	}()
 
 	// Initiate connection.
-	dialer := &net.Dialer{
-		Cancel:  cancel,
-		Timeout: connTimeout,
-	}
-	conn, err := dialer.Dial("tcp", string(host.NetAddress))
+	conn, err := dialHost(host.NetAddress, host.PublicKey, host.SupportsRPC(modules.RPCEncryptedConn), connTimeout, cancel)
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
@@ -272,5 +272,7 @@ func FormContract(params ContractParams, txnBuilder transactionBuilder, tpool tr
 		ContractFee: host.ContractPrice,
 		TxnFee:      txnFee,
 		SiafundFee:  types.Tax(startHeight, fc.Payout),
+
+		FormationPrices: hostPrices(host, startHeight),
 	}, nil
 }
@@ -15,6 +15,30 @@ import (
 // extendDeadline is a helper function for extending the connection timeout.
 func extendDeadline(conn net.Conn, d time.Duration) { _ = conn.SetDeadline(time.Now().Add(d)) }
 
+// dialHost connects to the host at addr. If encrypt is set, which it should
+// be for every host that advertises RPCEncryptedConn, the connection is
+// encrypted, and authenticated using the host's public key. If the handshake
+// fails, an error is returned; such a host is never contacted in plaintext,
+// because otherwise anyone able to interrupt the handshake could downgrade
+// the connection.
+func dialHost(addr modules.NetAddress, hostKey types.SiaPublicKey, encrypt bool, timeout time.Duration, cancel <-chan struct{}) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Cancel:  cancel,
+		Timeout: timeout,
+	}
+	conn, err := dialer.Dial("tcp", string(addr))
+	if err != nil || !encrypt {
+		return conn, err
+	}
+	extendDeadline(conn, modules.NegotiateSettingsTime)
+	encryptedConn, err := modules.EncryptRenterConn(conn, hostKey)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return encryptedConn, nil
+}
+
 // startRevision is run at the beginning of each revision iteration. It reads
 // the host's settings confirms that the values are acceptable, and writes an acceptance.
 func startRevision(conn net.Conn, host modules.HostDBEntry) error {
@@ -37,6 +61,20 @@ func startDownload(conn net.Conn, host modules.HostDBEntry) error {
 	return modules.WriteNegotiationAcceptance(conn)
 }
 
+// hostPrices returns a snapshot of the prices in the host's settings, as seen
+// at the given height.
+func hostPrices(host modules.HostDBEntry, height types.BlockHeight) modules.HostPriceSnapshot {
+	return modules.HostPriceSnapshot{
+		BlockHeight: height,
+		Timestamp:   time.Now(),
+
+		ContractPrice:          host.ContractPrice,
+		DownloadBandwidthPrice: host.DownloadBandwidthPrice,
+		StoragePrice:           host.StoragePrice,
+		UploadBandwidthPrice:   host.UploadBandwidthPrice,
+	}
+}
+
 // verifySettings reads a signed HostSettings object from conn, validates the
 // signature, and checks for discrepancies between the known settings and the
 // received settings. If there is a discrepancy, the hostDB is notified. The
@@ -66,17 +104,18 @@ func verifySettings(conn net.Conn, host modules.HostDBEntry) (modules.HostDBEntr
 	return host, nil
 }
 
-// verifyRecentRevision confirms that the host and contractor agree upon the current
-// state of the contract being revised.
-func verifyRecentRevision(conn net.Conn, contract modules.RenterContract, hostVersion string) error {
+// readRecentRevision performs the recent revision protocol, returning the
+// most recent revision of the contract known to the host along with its
+// signatures. The signatures are not verified.
+func readRecentRevision(conn net.Conn, contract modules.RenterContract, hostVersion string) (types.FileContractRevision, []types.TransactionSignature, error) {
 	// send contract ID
 	if err := encoding.WriteObject(conn, contract.ID); err != nil {
-		return errors.New("couldn't send contract ID: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("couldn't send contract ID: " + err.Error())
 	}
 	// read challenge
 	var challenge crypto.Hash
 	if err := encoding.ReadObject(conn, &challenge, 32); err != nil {
-		return errors.New("couldn't read challenge: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("couldn't read challenge: " + err.Error())
 	}
 	if build.VersionCmp(hostVersion, "1.3.0") >= 0 {
 		crypto.SecureWipe(challenge[:16])
@@ -84,25 +123,35 @@ func verifyRecentRevision(conn net.Conn, contract modules.RenterContract, hostVe
 	// sign and return
 	sig := crypto.SignHash(challenge, contract.SecretKey)
 	if err := encoding.WriteObject(conn, sig); err != nil {
-		return errors.New("couldn't send challenge response: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("couldn't send challenge response: " + err.Error())
 	}
 	// read acceptance
 	if err := modules.ReadNegotiationAcceptance(conn); err != nil {
-		return errors.New("host did not accept revision request: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("host did not accept revision request: " + err.Error())
 	}
 	// read last revision and signatures
 	var lastRevision types.FileContractRevision
 	var hostSignatures []types.TransactionSignature
 	if err := encoding.ReadObject(conn, &lastRevision, 2048); err != nil {
-		return errors.New("couldn't read last revision: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("couldn't read last revision: " + err.Error())
 	}
 	if err := encoding.ReadObject(conn, &hostSignatures, 2048); err != nil {
-		return errors.New("couldn't read host signatures: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("couldn't read host signatures: " + err.Error())
 	}
 	// Check that the unlock hashes match; if they do not, something is
-	// seriously wrong. Otherwise, check that the revision numbers match.
+	// seriously wrong.
 	if lastRevision.UnlockConditions.UnlockHash() != contract.LastRevision.UnlockConditions.UnlockHash() {
-		return errors.New("unlock conditions do not match")
+		return types.FileContractRevision{}, nil, errors.New("unlock conditions do not match")
+	}
+	return lastRevision, hostSignatures, nil
+}
+
+// verifyRecentRevision confirms that the host and contractor agree upon the current
+// state of the contract being revised.
+func verifyRecentRevision(conn net.Conn, contract modules.RenterContract, hostVersion string) error {
+	lastRevision, hostSignatures, err := readRecentRevision(conn, contract, hostVersion)
+	if err != nil {
+		return err
 	} else if lastRevision.NewRevisionNumber != contract.LastRevision.NewRevisionNumber {
 		return &recentRevisionError{contract.LastRevision.NewRevisionNumber, lastRevision.NewRevisionNumber}
 	}
@@ -199,8 +248,8 @@ func newDownloadRevision(current types.FileContractRevision, downloadCost types.
 }
 
 // newUploadRevision revises the current revision to cover the cost of
-// uploading a sector.
-func newUploadRevision(current types.FileContractRevision, merkleRoot crypto.Hash, price, collateral types.Currency) types.FileContractRevision {
+// uploading numSectors sectors.
+func newUploadRevision(current types.FileContractRevision, merkleRoot crypto.Hash, price, collateral types.Currency, numSectors uint64) types.FileContractRevision {
 	rev := newRevision(current, price)
 
 	// move collateral from host to void
@@ -208,7 +257,7 @@ func newUploadRevision(current types.FileContractRevision, merkleRoot crypto.Has
 	rev.NewMissedProofOutputs[2].Value = rev.NewMissedProofOutputs[2].Value.Add(collateral)
 
 	// set new filesize and Merkle root
-	rev.NewFileSize += modules.SectorSize
+	rev.NewFileSize += modules.SectorSize * numSectors
 	rev.NewFileMerkleRoot = merkleRoot
 	return rev
 }
@@ -41,7 +41,11 @@ type ContractParams struct {
 	StartHeight   types.BlockHeight
 	EndHeight     types.BlockHeight
 	RefundAddress types.UnlockHash
-	// TODO: add optional keypair
+
+	// SecretKey is the key that the renter uses in the unlock conditions of
+	// the contract. Hosts identify the renter by its public key. If it is
+	// not set, a new key is generated for the contract.
+	SecretKey crypto.SecretKey
 }
 
 // A revisionSaver is called just before we send our revision signature to the host; this
@@ -64,3 +68,57 @@ func IsRevisionMismatch(err error) bool {
 	_, ok := err.(*recentRevisionError)
 	return ok
 }
+
+// A hostDishonestyError occurs if the host presents a contract revision that
+// contradicts the renter's records.
+type hostDishonestyError struct {
+	reason string
+}
+
+func (e *hostDishonestyError) Error() string {
+	return "host presented an invalid revision: " + e.reason
+}
+
+// IsHostDishonest returns true if err was caused by the host presenting a
+// contract revision that contradicts the renter's records.
+func IsHostDishonest(err error) bool {
+	_, ok := err.(*hostDishonestyError)
+	return ok
+}
+
+// An auditFailureError occurs if the host is unable to prove that it is
+// storing a sector that it has agreed to store.
+type auditFailureError struct {
+	reason string
+}
+
+func (e *auditFailureError) Error() string {
+	return "host failed storage audit: " + e.reason
+}
+
+// IsAuditFailure returns true if err was caused by the host failing to prove
+// that it is storing a sector covered by the contract.
+func IsAuditFailure(err error) bool {
+	_, ok := err.(*auditFailureError)
+	return ok
+}
+
+// An auditIncompleteError occurs if the host authenticates the contract
+// being audited, but then does not complete the audit, for example by
+// rejecting it for an unrecognized reason or closing the connection.
+type auditIncompleteError struct {
+	reason string
+}
+
+func (e *auditIncompleteError) Error() string {
+	return "host did not complete storage audit: " + e.reason
+}
+
+// IsAuditIncomplete returns true if err was caused by the host not completing
+// an audit after the recent revision protocol succeeded. Unlike a failure, an
+// incomplete audit is not proof that the host lost the data, but a host that
+// repeatedly leaves audits incomplete may be hiding that it has.
+func IsAuditIncomplete(err error) bool {
+	_, ok := err.(*auditIncompleteError)
+	return ok
+}
@@ -0,0 +1,99 @@
+package proto
+
+import (
+	"errors"
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// A HostRevision is the most recent revision of a contract known to its
+// host, along with the signatures of the revision and the Merkle roots of the
+// sectors it covers.
+type HostRevision struct {
+	Revision    types.FileContractRevision
+	Signatures  []types.TransactionSignature
+	MerkleRoots []crypto.Hash
+}
+
+// Transaction returns a transaction containing the revision and its
+// signatures.
+func (hr HostRevision) Transaction() types.Transaction {
+	return types.Transaction{
+		FileContractRevisions: []types.FileContractRevision{hr.Revision},
+		TransactionSignatures: hr.Signatures,
+	}
+}
+
+// verifyHostRevision checks the revision presented by the host against the
+// renter's copy of the contract. A revision older than the renter's, or a
+// newer revision that the renter did not sign, is reported as a
+// hostDishonestyError.
+func verifyHostRevision(contract modules.RenterContract, rev types.FileContractRevision, sigs []types.TransactionSignature) error {
+	if rev.ParentID != contract.ID {
+		return &hostDishonestyError{"revision is for a different contract"}
+	} else if rev.NewRevisionNumber < contract.LastRevision.NewRevisionNumber {
+		return &hostDishonestyError{"revision is older than the renter's"}
+	}
+	// NOTE: as in verifyRecentRevision, the blockheight only needs to be
+	// above the fork height and below the contract expiration.
+	if err := modules.VerifyFileContractRevisionTransactionSignatures(rev, sigs, contract.FileContract.WindowStart-1); err != nil {
+		return &hostDishonestyError{"invalid signatures: " + err.Error()}
+	}
+	return nil
+}
+
+// verifyHostRoots checks that the sector roots presented by the host match
+// the file size and Merkle root of the revision.
+func verifyHostRoots(rev types.FileContractRevision, roots []crypto.Hash) error {
+	if uint64(len(roots))*modules.SectorSize != rev.NewFileSize {
+		return &hostDishonestyError{"sector roots do not match file size"}
+	} else if cachedMerkleRoot(roots) != rev.NewFileMerkleRoot {
+		return &hostDishonestyError{"sector roots do not match Merkle root"}
+	}
+	return nil
+}
+
+// FetchHostRevision requests the most recent revision of contract from its
+// host, along with the Merkle roots of its sectors. The revision is verified
+// against the renter's copy of the contract; if the host's revision cannot be
+// reconciled with it, the error satisfies IsHostDishonest, and the offending
+// revision is returned alongside it. The returned revision may have the same
+// revision number as the renter's.
+func FetchHostRevision(host modules.HostDBEntry, contract modules.RenterContract, cancel <-chan struct{}) (HostRevision, error) {
+	conn, err := dialHost(host.NetAddress, host.PublicKey, host.SupportsRPC(modules.RPCEncryptedConn), 15*time.Second, cancel)
+	if err != nil {
+		return HostRevision{}, err
+	}
+	defer conn.Close()
+
+	// allot 2 minutes for RPC request + revision exchange
+	extendDeadline(conn, modules.NegotiateRecentRevisionTime)
+	if err := encoding.WriteObject(conn, modules.RPCSectorRoots); err != nil {
+		return HostRevision{}, errors.New("couldn't initiate RPC: " + err.Error())
+	}
+	var hr HostRevision
+	hr.Revision, hr.Signatures, err = readRecentRevision(conn, contract, host.Version)
+	if err != nil {
+		return HostRevision{}, err
+	}
+	// Verify the revision before reading the roots, so that the number of
+	// roots is bounded by a file size that the renter signed.
+	if err := verifyHostRevision(contract, hr.Revision, hr.Signatures); err != nil {
+		return hr, err
+	}
+
+	// read the sector roots
+	extendDeadline(conn, modules.NegotiateDownloadTime)
+	numSectors := hr.Revision.NewFileSize / modules.SectorSize
+	if err := encoding.ReadObject(conn, &hr.MerkleRoots, 8+(numSectors+1)*crypto.HashSize); err != nil {
+		return HostRevision{}, errors.New("couldn't read sector roots: " + err.Error())
+	}
+	if err := verifyHostRoots(hr.Revision, hr.MerkleRoots); err != nil {
+		return hr, err
+	}
+	return hr, nil
+}
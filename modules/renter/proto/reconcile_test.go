@@ -0,0 +1,95 @@
+package proto
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// signRevision returns the renter and host signatures of rev.
+func signRevision(rev types.FileContractRevision, renterKey, hostKey crypto.SecretKey) []types.TransactionSignature {
+	txn := types.Transaction{
+		FileContractRevisions: []types.FileContractRevision{rev},
+	}
+	for i, sk := range []crypto.SecretKey{renterKey, hostKey} {
+		txn.TransactionSignatures = append(txn.TransactionSignatures, types.TransactionSignature{
+			ParentID:       crypto.Hash(rev.ParentID),
+			CoveredFields:  types.CoveredFields{FileContractRevisions: []uint64{0}},
+			PublicKeyIndex: uint64(i),
+		})
+		sig := crypto.SignHash(txn.SigHash(i), sk)
+		txn.TransactionSignatures[i].Signature = sig[:]
+	}
+	return txn.TransactionSignatures
+}
+
+// TestVerifyHostRevision tests that revisions presented by the host are only
+// accepted if they are newer than the renter's and signed by both parties.
+func TestVerifyHostRevision(t *testing.T) {
+	renterKey, renterPK := crypto.GenerateKeyPair()
+	hostKey, hostPK := crypto.GenerateKeyPair()
+	outputs := []types.SiacoinOutput{{Value: types.NewCurrency64(10)}, {Value: types.NewCurrency64(10)}}
+	contract := modules.RenterContract{
+		ID:           types.FileContractID{1},
+		FileContract: types.FileContract{WindowStart: 100},
+		LastRevision: types.FileContractRevision{
+			ParentID: types.FileContractID{1},
+			UnlockConditions: types.UnlockConditions{
+				PublicKeys:         []types.SiaPublicKey{types.Ed25519PublicKey(renterPK), types.Ed25519PublicKey(hostPK)},
+				SignaturesRequired: 2,
+			},
+			NewRevisionNumber:     5,
+			NewWindowStart:        100,
+			NewWindowEnd:          200,
+			NewValidProofOutputs:  outputs,
+			NewMissedProofOutputs: outputs,
+		},
+	}
+
+	// A newer revision signed by both parties is accepted.
+	rev := contract.LastRevision
+	rev.NewRevisionNumber = 6
+	if err := verifyHostRevision(contract, rev, signRevision(rev, renterKey, hostKey)); err != nil {
+		t.Fatal(err)
+	}
+
+	// A revision that the renter did not sign is rejected.
+	forgerKey, _ := crypto.GenerateKeyPair()
+	if err := verifyHostRevision(contract, rev, signRevision(rev, forgerKey, hostKey)); !IsHostDishonest(err) {
+		t.Fatal("expected forged revision to be rejected as dishonest, got", err)
+	}
+
+	// An older revision is rejected.
+	rev.NewRevisionNumber = 4
+	if err := verifyHostRevision(contract, rev, signRevision(rev, renterKey, hostKey)); !IsHostDishonest(err) {
+		t.Fatal("expected older revision to be rejected as dishonest, got", err)
+	}
+
+	// A revision of a different contract is rejected.
+	rev.NewRevisionNumber = 6
+	rev.ParentID = types.FileContractID{2}
+	if err := verifyHostRevision(contract, rev, signRevision(rev, renterKey, hostKey)); !IsHostDishonest(err) {
+		t.Fatal("expected revision of another contract to be rejected as dishonest, got", err)
+	}
+}
+
+// TestVerifyHostRoots tests that the sector roots presented by the host must
+// match the revision.
+func TestVerifyHostRoots(t *testing.T) {
+	roots := []crypto.Hash{{1}, {2}, {3}}
+	rev := types.FileContractRevision{
+		NewFileSize:       uint64(len(roots)) * modules.SectorSize,
+		NewFileMerkleRoot: cachedMerkleRoot(roots),
+	}
+	if err := verifyHostRoots(rev, roots); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyHostRoots(rev, roots[:2]); !IsHostDishonest(err) {
+		t.Fatal("expected missing root to be rejected as dishonest, got", err)
+	}
+	if err := verifyHostRoots(rev, []crypto.Hash{{1}, {2}, {4}}); !IsHostDishonest(err) {
+		t.Fatal("expected wrong root to be rejected as dishonest, got", err)
+	}
+}
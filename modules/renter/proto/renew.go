@@ -2,7 +2,6 @@ package proto
 
 import (
 	"errors"
-	"net"
 
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/encoding"
@@ -16,6 +15,20 @@ func Renew(contract modules.RenterContract, params ContractParams, txnBuilder tr
 	// extract vars from params, for convenience
 	host, filesize, startHeight, endHeight, refundAddress := params.Host, params.Filesize, params.StartHeight, params.EndHeight, params.RefundAddress
 	ourSK := contract.SecretKey
+	uc := contract.LastRevision.UnlockConditions
+
+	// If the renter's key has changed since the contract was formed, the
+	// renewed contract uses the new key.
+	if params.SecretKey != (crypto.SecretKey{}) && params.SecretKey != ourSK {
+		ourSK = params.SecretKey
+		uc = types.UnlockConditions{
+			PublicKeys: []types.SiaPublicKey{
+				types.Ed25519PublicKey(ourSK.PublicKey()),
+				host.PublicKey,
+			},
+			SignaturesRequired: 2,
+		}
+	}
 
 	// calculate cost to renter and cost to host
 	storageAllocation := host.StoragePrice.Mul64(filesize).Mul64(uint64(endHeight - startHeight))
@@ -52,7 +65,7 @@ func Renew(contract modules.RenterContract, params ContractParams, txnBuilder tr
 		WindowStart:    endHeight,
 		WindowEnd:      endHeight + host.WindowSize,
 		Payout:         payout,
-		UnlockHash:     contract.LastRevision.NewUnlockHash,
+		UnlockHash:     uc.UnlockHash(),
 		RevisionNumber: 0,
 		ValidProofOutputs: []types.SiacoinOutput{
 			// renter
@@ -100,11 +113,7 @@ func Renew(contract modules.RenterContract, params ContractParams, txnBuilder tr
 	}()
 
 	// initiate connection
-	dialer := &net.Dialer{
-		Cancel:  cancel,
-		Timeout: connTimeout,
-	}
-	conn, err := dialer.Dial("tcp", string(host.NetAddress))
+	conn, err := dialHost(host.NetAddress, host.PublicKey, host.SupportsRPC(modules.RPCEncryptedConn), connTimeout, cancel)
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
@@ -188,7 +197,7 @@ func Renew(contract modules.RenterContract, params ContractParams, txnBuilder tr
 	// create initial (no-op) revision, transaction, and signature
 	initRevision := types.FileContractRevision{
 		ParentID:          signedTxnSet[len(signedTxnSet)-1].FileContractID(0),
-		UnlockConditions:  contract.LastRevision.UnlockConditions,
+		UnlockConditions:  uc,
 		NewRevisionNumber: 1,
 
 		NewFileSize:           fc.FileSize,
@@ -274,5 +283,7 @@ func Renew(contract modules.RenterContract, params ContractParams, txnBuilder tr
 		ContractFee: host.ContractPrice,
 		TxnFee:      txnFee,
 		SiafundFee:  types.Tax(startHeight, fc.Payout),
+
+		FormationPrices: hostPrices(host, startHeight),
 	}, nil
 }
@@ -0,0 +1,421 @@
+package proto
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// errSessionUnsupported is returned by openSession if the host does not
+// acknowledge the session RPC.
+var errSessionUnsupported = errors.New("host does not support sessions")
+
+// A Session performs a sequence of settings, upload, revision and download
+// operations on a contract. Hosts that support the session RPC authenticate
+// the renter once, and carry every operation over the same connection. Older
+// hosts are contacted using the standalone revision and download RPCs
+// instead. Sessions are NOT thread-safe; calls must be serialized.
+type Session struct {
+	host     modules.HostDBEntry
+	contract modules.RenterContract // updated after each revision
+	height   types.BlockHeight
+	hdb      hostDB
+	cancel   <-chan struct{}
+	once     sync.Once
+
+	// If the host does not support sessions, the editor and downloader are
+	// opened as needed instead. The host only allows one connection per
+	// contract, so at most one of them is open at a time.
+	legacy     bool
+	conn       net.Conn
+	closeChan  chan struct{}
+	editor     *Editor
+	downloader *Downloader
+
+	SaveFn revisionSaver
+}
+
+// openSession dials the host and opens a session on the contract. If the
+// host does not acknowledge the session, errSessionUnsupported is returned.
+func (s *Session) openSession() error {
+	conn, err := dialHost(s.contract.NetAddress, s.host.PublicKey, s.host.SupportsRPC(modules.RPCEncryptedConn), 15*time.Second, s.cancel)
+	if err != nil {
+		return err
+	}
+
+	closeChan := make(chan struct{})
+	go func() {
+		select {
+		case <-s.cancel:
+			conn.Close()
+		case <-closeChan:
+		}
+	}()
+
+	// allot 2 minutes for RPC request + revision exchange
+	extendDeadline(conn, modules.NegotiateRecentRevisionTime)
+	defer extendDeadline(conn, time.Hour)
+	if err := encoding.WriteObject(conn, modules.RPCSession); err != nil {
+		conn.Close()
+		close(closeChan)
+		return errors.New("couldn't initiate RPC: " + err.Error())
+	}
+	// hosts that do not recognize the RPC close the connection
+	if err := modules.ReadNegotiationAcceptance(conn); err != nil {
+		conn.Close()
+		close(closeChan)
+		return errSessionUnsupported
+	}
+	if err := verifyRecentRevision(conn, s.contract, s.host.Version); err != nil {
+		conn.Close()
+		close(closeChan)
+		return err
+	}
+	s.conn = conn
+	s.closeChan = closeChan
+	return nil
+}
+
+// closeSession closes the connection to the host. The session is reopened by
+// the next operation.
+func (s *Session) closeSession() error {
+	close(s.closeChan)
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// requestOp requests an operation from the host over the session
+// connection.
+func (s *Session) requestOp(op types.Specifier) error {
+	extendDeadline(s.conn, modules.NegotiateSettingsTime)
+	if err := encoding.WriteObject(s.conn, op); err != nil {
+		return err
+	}
+	return modules.ReadNegotiationAcceptance(s.conn)
+}
+
+// startOp requests an operation from the host. The host ends sessions that
+// have been open for too long, in which case a new session is opened and the
+// request is repeated.
+func (s *Session) startOp(op types.Specifier) error {
+	if s.conn != nil {
+		if err := s.requestOp(op); err == nil {
+			return nil
+		}
+		s.closeSession()
+	}
+	if err := s.openSession(); err != nil {
+		return errors.New("couldn't open session: " + err.Error())
+	}
+	return s.requestOp(op)
+}
+
+// endOp is called after each operation performed over the session
+// connection. If the operation failed, the renter and host may disagree about
+// the state of the protocol, so the session is closed.
+func (s *Session) endOp(err error) {
+	if err != nil && s.conn != nil {
+		s.closeSession()
+	}
+}
+
+// sessionEditor returns an Editor that performs a single revision over the
+// session connection.
+func (s *Session) sessionEditor() (*Editor, error) {
+	if err := s.startOp(modules.RPCReviseContract); err != nil {
+		return nil, err
+	}
+	return &Editor{
+		conn:     s.conn,
+		host:     s.host,
+		hdb:      s.hdb,
+		height:   s.height,
+		contract: s.contract,
+		SaveFn:   s.SaveFn,
+	}, nil
+}
+
+// legacyEditor returns an Editor that uses the standalone revision RPC,
+// closing the downloader if it is open.
+func (s *Session) legacyEditor() (*Editor, error) {
+	if s.downloader != nil {
+		s.downloader.Close()
+		s.downloader = nil
+	}
+	if s.editor == nil {
+		he, err := NewEditor(s.host, s.contract, s.height, s.hdb, s.cancel)
+		if err != nil {
+			return nil, err
+		}
+		he.SaveFn = s.SaveFn
+		s.editor = he
+	}
+	return s.editor, nil
+}
+
+// reviseContract runs fn with an Editor for the contract, updating the
+// contract with the result.
+func (s *Session) reviseContract(fn func(*Editor) (modules.RenterContract, error)) (modules.RenterContract, error) {
+	var he *Editor
+	var err error
+	if s.legacy {
+		he, err = s.legacyEditor()
+	} else {
+		he, err = s.sessionEditor()
+	}
+	if err != nil {
+		return modules.RenterContract{}, err
+	}
+	contract, err := fn(he)
+	s.endOp(err)
+	if err != nil {
+		return modules.RenterContract{}, err
+	}
+	s.contract = contract
+	return contract, nil
+}
+
+// OpenEditor prepares the session for revisions. For hosts that do not
+// support sessions, the standalone revision RPC is opened right away, so that
+// problems such as a revision mismatch are reported before any data is sent.
+func (s *Session) OpenEditor() error {
+	if !s.legacy {
+		return nil
+	}
+	_, err := s.legacyEditor()
+	return err
+}
+
+// MaxUploadBatch returns the number of sectors that fit in a single revision
+// sent to the host. At least one sector is always allowed.
+func (s *Session) MaxUploadBatch() int {
+	return maxUploadBatch(s.host)
+}
+
+// Upload negotiates a revision that adds a sector to the contract.
+func (s *Session) Upload(data []byte) (modules.RenterContract, crypto.Hash, error) {
+	var root crypto.Hash
+	contract, err := s.reviseContract(func(he *Editor) (contract modules.RenterContract, err error) {
+		contract, root, err = he.Upload(data)
+		return contract, err
+	})
+	return contract, root, err
+}
+
+// UploadBatch negotiates a single revision that adds several sectors to the
+// contract.
+func (s *Session) UploadBatch(data [][]byte) (modules.RenterContract, []crypto.Hash, error) {
+	var roots []crypto.Hash
+	contract, err := s.reviseContract(func(he *Editor) (contract modules.RenterContract, err error) {
+		contract, roots, err = he.UploadBatch(data)
+		return contract, err
+	})
+	return contract, roots, err
+}
+
+// Delete negotiates a revision that removes a sector from the contract.
+func (s *Session) Delete(root crypto.Hash) (modules.RenterContract, error) {
+	return s.reviseContract(func(he *Editor) (modules.RenterContract, error) {
+		return he.Delete(root)
+	})
+}
+
+// Modify negotiates a revision that edits a sector in the contract.
+func (s *Session) Modify(oldRoot, newRoot crypto.Hash, offset uint64, newData []byte) (modules.RenterContract, error) {
+	return s.reviseContract(func(he *Editor) (modules.RenterContract, error) {
+		return he.Modify(oldRoot, newRoot, offset, newData)
+	})
+}
+
+// openDownloader returns a Downloader for the contract. Over a session
+// connection, a Downloader that performs a single download is returned,
+// requesting range proofs if rangeProofs is set.
+func (s *Session) openDownloader(rangeProofs bool) (*Downloader, error) {
+	if !s.legacy {
+		rpc := modules.RPCDownload
+		if rangeProofs {
+			rpc = modules.RPCDownloadRange
+		}
+		if err := s.startOp(rpc); err != nil {
+			return nil, err
+		}
+		return &Downloader{
+			host:        s.host,
+			contract:    s.contract,
+			conn:        s.conn,
+			hdb:         s.hdb,
+			rangeProofs: rangeProofs,
+			SaveFn:      s.SaveFn,
+		}, nil
+	}
+
+	if s.editor != nil {
+		s.editor.Close()
+		s.editor = nil
+	}
+	if s.downloader == nil {
+		hd, err := NewDownloader(s.host, s.contract, s.hdb, s.cancel)
+		if err != nil {
+			return nil, err
+		}
+		hd.SaveFn = s.SaveFn
+		s.downloader = hd
+	}
+	return s.downloader, nil
+}
+
+// OpenDownloader prepares the session for downloads. For hosts that do not
+// support sessions, the standalone download RPC is opened right away, so that
+// problems such as a revision mismatch are reported before the first
+// download.
+func (s *Session) OpenDownloader() error {
+	if !s.legacy {
+		return nil
+	}
+	_, err := s.openDownloader(false)
+	return err
+}
+
+// Download retrieves the sector with the specified Merkle root, paying the
+// host from the contract.
+func (s *Session) Download(root crypto.Hash) (modules.RenterContract, []byte, error) {
+	hd, err := s.openDownloader(false)
+	if err != nil {
+		return modules.RenterContract{}, nil, err
+	}
+	contract, sector, err := hd.Sector(root)
+	s.endOp(err)
+	if err != nil {
+		return modules.RenterContract{}, nil, err
+	}
+	s.contract = contract
+	return contract, sector, nil
+}
+
+// DownloadRange retrieves length bytes starting at offset within the sector
+// with the specified Merkle root, paying the host only for the segments
+// covering the range.
+func (s *Session) DownloadRange(root crypto.Hash, offset, length uint64) (modules.RenterContract, []byte, error) {
+	if length == 0 || offset >= modules.SectorSize || length > modules.SectorSize-offset {
+		return modules.RenterContract{}, nil, errBadRange
+	}
+	hd, err := s.openDownloader(true)
+	if err != nil {
+		return modules.RenterContract{}, nil, err
+	}
+	contract, data, err := hd.Range(root, offset, length)
+	s.endOp(err)
+	if err != nil {
+		return modules.RenterContract{}, nil, err
+	}
+	s.contract = contract
+	return contract, data, nil
+}
+
+// Settings retrieves the host's current settings. Subsequent operations are
+// priced using the new settings.
+func (s *Session) Settings() (modules.HostDBEntry, error) {
+	var host modules.HostDBEntry
+	var err error
+	if s.legacy {
+		host, err = requestSettings(s.host, s.cancel)
+	} else {
+		if err = s.startOp(modules.RPCSettings); err != nil {
+			return modules.HostDBEntry{}, err
+		}
+		extendDeadline(s.conn, modules.NegotiateSettingsTime)
+		host, err = verifySettings(s.conn, s.host)
+		extendDeadline(s.conn, time.Hour)
+		s.endOp(err)
+	}
+	if err != nil {
+		return modules.HostDBEntry{}, err
+	}
+	s.host = host
+	if s.editor != nil {
+		s.editor.host = host
+	}
+	if s.downloader != nil {
+		s.downloader.host = host
+	}
+	return host, nil
+}
+
+// Close ends the session and closes the connection to the host.
+func (s *Session) Close() (err error) {
+	// using once ensures that Close is idempotent
+	s.once.Do(func() {
+		if s.conn != nil {
+			err = s.closeSession()
+		}
+		if s.editor != nil {
+			err = build.ComposeErrors(err, s.editor.Close())
+		}
+		if s.downloader != nil {
+			err = build.ComposeErrors(err, s.downloader.Close())
+		}
+	})
+	return err
+}
+
+// requestSettings retrieves the settings of a host using the standalone
+// settings RPC.
+func requestSettings(host modules.HostDBEntry, cancel <-chan struct{}) (modules.HostDBEntry, error) {
+	conn, err := dialHost(host.NetAddress, host.PublicKey, host.SupportsRPC(modules.RPCEncryptedConn), 15*time.Second, cancel)
+	if err != nil {
+		return modules.HostDBEntry{}, err
+	}
+	defer conn.Close()
+	extendDeadline(conn, modules.NegotiateSettingsTime)
+	if err := encoding.WriteObject(conn, modules.RPCSettings); err != nil {
+		return modules.HostDBEntry{}, errors.New("couldn't initiate RPC: " + err.Error())
+	}
+	return verifySettings(conn, host)
+}
+
+// NewSession opens a session on a contract with a host. If the host does not
+// support sessions, the returned Session falls back to the standalone RPCs.
+func NewSession(host modules.HostDBEntry, contract modules.RenterContract, currentHeight types.BlockHeight, hdb hostDB, cancel <-chan struct{}) (_ *Session, err error) {
+	// check that contract has enough value to support an upload
+	if len(contract.LastRevision.NewValidProofOutputs) != 2 {
+		return nil, errors.New("invalid contract")
+	}
+	s := &Session{
+		host:     host,
+		contract: contract,
+		height:   currentHeight,
+		hdb:      hdb,
+		cancel:   cancel,
+	}
+	if !host.SupportsRPC(modules.RPCSession) {
+		s.legacy = true
+		return s, nil
+	}
+
+	// Increase Successful/Failed interactions accordingly
+	defer func() {
+		// a revision mismatch is not necessarily the host's fault
+		if err != nil && !IsRevisionMismatch(err) {
+			hdb.IncrementFailedInteractions(contract.HostPublicKey)
+		} else if err == nil {
+			hdb.IncrementSuccessfulInteractions(contract.HostPublicKey)
+		}
+	}()
+
+	err = s.openSession()
+	if err == errSessionUnsupported {
+		s.legacy = true
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -67,6 +67,25 @@ type hostDB interface {
 	// of the host.
 	ScoreBreakdown(modules.HostDBEntry) modules.HostScoreBreakdown
 
+	// ScoreBreakdownWithWeights returns a detailed explanation of the various
+	// properties of the host, scored using the provided weights.
+	ScoreBreakdownWithWeights(modules.HostDBEntry, modules.HostDBWeights) modules.HostScoreBreakdown
+
+	// PrunedHosts returns the hosts most recently pruned from the hostdb.
+	PrunedHosts() []modules.PrunedHost
+
+	// PruningPolicy returns the policy used to prune hosts.
+	PruningPolicy() modules.HostDBPruningPolicy
+
+	// SetPruningPolicy sets the policy used to prune hosts.
+	SetPruningPolicy(modules.HostDBPruningPolicy) error
+
+	// SetWeights sets the weights used to score hosts.
+	SetWeights(modules.HostDBWeights) error
+
+	// Weights returns the weights used to score hosts.
+	Weights() modules.HostDBWeights
+
 	// EstimateHostScore returns the estimated score breakdown of a host with the
 	// provided settings.
 	EstimateHostScore(modules.HostDBEntry) modules.HostScoreBreakdown
@@ -96,10 +115,19 @@ type hostContractor interface {
 	// ContractByID returns the contract associated with the file contract id.
 	ContractByID(types.FileContractID) (modules.RenterContract, bool)
 
+	// AuditContract asks the host of a contract to prove that it is storing
+	// a random segment of the contract's data. contractor.ErrAuditFailed is
+	// returned if the host fails the audit.
+	AuditContract(types.FileContractID) error
+
 	// CurrentPeriod returns the height at which the current allowance period
 	// began.
 	CurrentPeriod() types.BlockHeight
 
+	// IdentityKey returns the public key that the contractor uses in all of
+	// its contracts.
+	IdentityKey() types.SiaPublicKey
+
 	// Editor creates an Editor from the specified contract ID, allowing the
 	// insertion, deletion, and modification of sectors.
 	Editor(types.FileContractID, <-chan struct{}) (contractor.Editor, error)
@@ -219,6 +247,7 @@ func newRenter(cs modules.ConsensusSet, tpool modules.TransactionPool, hdb hostD
 	go r.threadedRepairLoop()
 	go r.threadedDownloadLoop()
 	go r.threadedQueueRepairs()
+	go r.threadedAuditLoop()
 
 	// Kill workers on shutdown.
 	r.tg.OnStop(func() {
@@ -320,10 +349,21 @@ func (r *Renter) ScoreBreakdown(e modules.HostDBEntry) modules.HostScoreBreakdow
 func (r *Renter) EstimateHostScore(e modules.HostDBEntry) modules.HostScoreBreakdown {
 	return r.hostDB.EstimateHostScore(e)
 }
+func (r *Renter) HostDBWeights() modules.HostDBWeights { return r.hostDB.Weights() }
+func (r *Renter) ScoreBreakdownWithWeights(e modules.HostDBEntry, w modules.HostDBWeights) modules.HostScoreBreakdown {
+	return r.hostDB.ScoreBreakdownWithWeights(e, w)
+}
+func (r *Renter) SetHostDBWeights(w modules.HostDBWeights) error   { return r.hostDB.SetWeights(w) }
+func (r *Renter) HostDBPruningPolicy() modules.HostDBPruningPolicy { return r.hostDB.PruningPolicy() }
+func (r *Renter) PrunedHosts() []modules.PrunedHost                { return r.hostDB.PrunedHosts() }
+func (r *Renter) SetHostDBPruningPolicy(p modules.HostDBPruningPolicy) error {
+	return r.hostDB.SetPruningPolicy(p)
+}
 
 // contractor passthroughs
 func (r *Renter) Contracts() []modules.RenterContract { return r.hostContractor.Contracts() }
 func (r *Renter) CurrentPeriod() types.BlockHeight    { return r.hostContractor.CurrentPeriod() }
+func (r *Renter) IdentityKey() types.SiaPublicKey     { return r.hostContractor.IdentityKey() }
 func (r *Renter) Settings() modules.RenterSettings {
 	return modules.RenterSettings{
 		Allowance: r.hostContractor.Allowance(),
@@ -69,14 +69,24 @@ type (
 		// cachedChunks tracks the set of chunks that have recently been retreived
 		// from hosts.
 		//
+		// outstandingUploads tracks how many pieces each active worker has
+		// yet to report on. A worker becomes available again once all of the
+		// pieces it was given have been reported.
+		//
+		// queuedUploads tracks the pieces that have been assigned to workers
+		// during the current repair iteration, but have not yet been
+		// delivered. Each worker receives its pieces as a single batch.
+		//
 		// workerSet tracks the set of workers which can be used for uploading.
-		activeWorkers     map[types.FileContractID]*worker
-		availableWorkers  map[types.FileContractID]*worker
-		gapCounts         map[int]int
-		incompleteChunks  map[chunkID]*chunkStatus
-		downloadingChunks map[chunkID]struct{}
-		cachedChunks      map[chunkID][]byte
-		resultChan        chan finishedUpload
+		activeWorkers      map[types.FileContractID]*worker
+		availableWorkers   map[types.FileContractID]*worker
+		gapCounts          map[int]int
+		incompleteChunks   map[chunkID]*chunkStatus
+		downloadingChunks  map[chunkID]struct{}
+		cachedChunks       map[chunkID][]byte
+		outstandingUploads map[types.FileContractID]int
+		queuedUploads      map[types.FileContractID][]uploadWork
+		resultChan         chan finishedUpload
 	}
 )
 
@@ -283,6 +293,14 @@ func (r *Renter) managedRepairIteration(rs *repairState) {
 				usefulWorkers = append(usefulWorkers, workerID)
 			}
 		}
+		// Workers that were given pieces of other chunks during this
+		// iteration can take more pieces, up to the batch limit.
+		for workerID, queued := range rs.queuedUploads {
+			_, exists := chunkStatus.contracts[workerID]
+			if !exists && len(queued) < maxUploadBatchPieces {
+				usefulWorkers = append(usefulWorkers, workerID)
+			}
+		}
 
 		// Skip this chunk if the set of useful workers does not meet the
 		// minimum pieces requirement.
@@ -308,6 +326,9 @@ func (r *Renter) managedRepairIteration(rs *repairState) {
 		delete(rs.incompleteChunks, cid)
 	}
 
+	// Give the workers the pieces that were assigned to them.
+	r.managedDeliverQueuedUploads(rs)
+
 	// Block until some of the workers return.
 	r.managedWaitOnRepairWork(rs)
 }
@@ -437,7 +458,7 @@ func (r *Renter) managedScheduleChunkRepair(rs *repairState, chunkID chunkID, ch
 		pieces[missingPiece] = key.EncryptBytes(pieces[missingPiece])
 	}
 
-	// Give each piece to a worker in the set of useful workers.
+	// Queue each piece for a worker in the set of useful workers.
 	for len(usefulWorkers) > 0 && len(missingPieces) > 0 {
 		uw := uploadWork{
 			chunkID:    chunkID,
@@ -448,9 +469,13 @@ func (r *Renter) managedScheduleChunkRepair(rs *repairState, chunkID chunkID, ch
 			resultChan: rs.resultChan,
 		}
 		// Grab the worker, and update the worker tracking in the repair state.
-		worker := rs.availableWorkers[usefulWorkers[0]]
-		rs.activeWorkers[usefulWorkers[0]] = worker
-		delete(rs.availableWorkers, usefulWorkers[0])
+		// The worker may already be active if it has been given pieces of
+		// other chunks during this iteration.
+		if worker, ok := rs.availableWorkers[usefulWorkers[0]]; ok {
+			rs.activeWorkers[usefulWorkers[0]] = worker
+			delete(rs.availableWorkers, usefulWorkers[0])
+		}
+		rs.queuedUploads[usefulWorkers[0]] = append(rs.queuedUploads[usefulWorkers[0]], uw)
 
 		chunkStatus.activePieces++
 		chunkStatus.contracts[usefulWorkers[0]] = struct{}{}
@@ -465,16 +490,41 @@ func (r *Renter) managedScheduleChunkRepair(rs *repairState, chunkID chunkID, ch
 		// Update the set of useful workers and the set of missing pieces.
 		missingPieces = missingPieces[1:]
 		usefulWorkers = usefulWorkers[1:]
+	}
+	return nil
+}
+
+// managedDeliverQueuedUploads sends each worker the batch of pieces that was
+// queued for it during the current repair iteration.
+func (r *Renter) managedDeliverQueuedUploads(rs *repairState) {
+	for workerID, batch := range rs.queuedUploads {
+		worker := rs.activeWorkers[workerID]
+		rs.outstandingUploads[workerID] += len(batch)
+		delete(rs.queuedUploads, workerID)
 
 		// Deliver the payload to the worker.
 		select {
-		case worker.uploadChan <- uw:
+		case worker.uploadChan <- batch:
 		default:
 			r.log.Critical("Worker is supposed to be available, but upload work channel is full")
-			worker.uploadChan <- uw
+			worker.uploadChan <- batch
 		}
 	}
-	return nil
+}
+
+// releaseUploadWorker records that a worker has reported on one of its
+// pieces. Once all of its pieces have been reported, the worker is added back
+// to the set of available workers, unless it has been retired.
+func (rs *repairState) releaseUploadWorker(workerID types.FileContractID) {
+	rs.outstandingUploads[workerID]--
+	if rs.outstandingUploads[workerID] > 0 {
+		return
+	}
+	delete(rs.outstandingUploads, workerID)
+	if worker, ok := rs.activeWorkers[workerID]; ok {
+		rs.availableWorkers[workerID] = worker
+		delete(rs.activeWorkers, workerID)
+	}
 }
 
 // managedWaitOnRepairWork will block until a worker returns from an upload,
@@ -500,8 +550,7 @@ func (r *Renter) managedWaitOnRepairWork(rs *repairState) {
 	if cs, ok := rs.incompleteChunks[finishedUpload.chunkID]; !ok {
 		// The file was deleted mid-upload. Add the worker back to the set of
 		// available workers.
-		rs.availableWorkers[finishedUpload.workerID] = rs.activeWorkers[finishedUpload.workerID]
-		delete(rs.activeWorkers, finishedUpload.workerID)
+		rs.releaseUploadWorker(finishedUpload.workerID)
 		return
 	} else {
 		cs.activePieces--
@@ -510,14 +559,14 @@ func (r *Renter) managedWaitOnRepairWork(rs *repairState) {
 	// If there was no error, add the worker back to the set of
 	// available workers and wait for the next worker.
 	if finishedUpload.err == nil {
-		rs.availableWorkers[finishedUpload.workerID] = rs.activeWorkers[finishedUpload.workerID]
-		delete(rs.activeWorkers, finishedUpload.workerID)
+		rs.releaseUploadWorker(finishedUpload.workerID)
 		return
 	}
 
 	// Log the error and retire the worker.
 	r.log.Debugln("Error while performing upload to", finishedUpload.workerID, "::", finishedUpload.err)
 	delete(rs.activeWorkers, finishedUpload.workerID)
+	rs.releaseUploadWorker(finishedUpload.workerID)
 
 	// Indicate in the set of incomplete chunks that this piece was not
 	// completed.
@@ -569,13 +618,15 @@ func (r *Renter) threadedQueueRepairs() {
 // before the file reaches full redundancy.
 func (r *Renter) threadedRepairLoop() {
 	rs := &repairState{
-		activeWorkers:     make(map[types.FileContractID]*worker),
-		availableWorkers:  make(map[types.FileContractID]*worker),
-		gapCounts:         make(map[int]int),
-		incompleteChunks:  make(map[chunkID]*chunkStatus),
-		cachedChunks:      make(map[chunkID][]byte),
-		downloadingChunks: make(map[chunkID]struct{}),
-		resultChan:        make(chan finishedUpload),
+		activeWorkers:      make(map[types.FileContractID]*worker),
+		availableWorkers:   make(map[types.FileContractID]*worker),
+		gapCounts:          make(map[int]int),
+		incompleteChunks:   make(map[chunkID]*chunkStatus),
+		cachedChunks:       make(map[chunkID][]byte),
+		downloadingChunks:  make(map[chunkID]struct{}),
+		outstandingUploads: make(map[types.FileContractID]int),
+		queuedUploads:      make(map[types.FileContractID][]uploadWork),
+		resultChan:         make(chan finishedUpload),
 	}
 	for {
 		if r.tg.Add() != nil {
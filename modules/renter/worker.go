@@ -9,6 +9,7 @@ import (
 
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/renter/contractor"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -21,6 +22,11 @@ type (
 		dataRoot   crypto.Hash
 		pieceIndex uint64
 
+		// offset and length specify the range of the piece that is needed.
+		// If length is zero, the entire piece is downloaded.
+		offset uint64
+		length uint64
+
 		chunkDownload *chunkDownload
 
 		// resultChan is a channel that the worker will use to return the
@@ -77,7 +83,7 @@ type (
 		downloadChan         chan downloadWork // higher priority than all uploads
 		killChan             chan struct{}     // highest priority
 		priorityDownloadChan chan downloadWork // higher priority than downloads (used for user-initiated downloads)
-		uploadChan           chan uploadWork   // lowest priority
+		uploadChan           chan []uploadWork // lowest priority
 
 		// recentUploadFailure documents the most recent time that an upload
 		// has failed.
@@ -107,7 +113,12 @@ func (w *worker) download(dw downloadWork) {
 	}
 	defer d.Close()
 
-	data, err := d.Sector(dw.dataRoot)
+	var data []byte
+	if dw.length == 0 {
+		data, err = d.Sector(dw.dataRoot)
+	} else {
+		data, err = downloadPieceRange(d, dw.dataRoot, dw.offset, dw.length)
+	}
 	go func() {
 		select {
 		case dw.resultChan <- finishedDownload{dw.chunkDownload, data, err, dw.pieceIndex, w.contractID}:
@@ -116,61 +127,90 @@ func (w *worker) download(dw downloadWork) {
 	}()
 }
 
-// upload will perform some upload work.
-func (w *worker) upload(uw uploadWork) {
+// downloadPieceRange downloads length bytes starting at offset within the
+// encrypted piece stored in the sector with the given root. The piece's nonce
+// is downloaded as well, and is returned ahead of the data so that the range
+// can be decrypted.
+func downloadPieceRange(d contractor.Downloader, root crypto.Hash, offset, length uint64) ([]byte, error) {
+	nonce, err := d.Range(root, 0, crypto.TwofishNonceSize)
+	if err != nil {
+		return nil, err
+	}
+	data, err := d.Range(root, crypto.TwofishNonceSize+offset, length)
+	if err != nil {
+		return nil, err
+	}
+	return append(nonce, data...), nil
+}
+
+// upload will perform some upload work. All of the pieces are uploaded
+// together, so that they can share revisions with the host.
+func (w *worker) upload(uws []uploadWork) {
 	e, err := w.renter.hostContractor.Editor(w.contractID, w.renter.tg.StopChan())
 	if err != nil {
 		w.recentUploadFailure = time.Now()
 		w.consecutiveUploadFailures++
-		go func() {
-			select {
-			case uw.resultChan <- finishedUpload{uw.chunkID, crypto.Hash{}, err, uw.pieceIndex, w.contractID}:
-			case <-w.renter.tg.StopChan():
-			}
-		}()
+		for _, uw := range uws {
+			w.sendUploadResult(uw, crypto.Hash{}, err)
+		}
 		return
 	}
 	defer e.Close()
 
-	root, err := e.Upload(uw.data)
+	data := make([][]byte, len(uws))
+	for i, uw := range uws {
+		data[i] = uw.data
+	}
+	roots, err := e.UploadBatch(data)
 	if err != nil {
 		w.recentUploadFailure = time.Now()
 		w.consecutiveUploadFailures++
-		go func() {
-			select {
-			case uw.resultChan <- finishedUpload{uw.chunkID, root, err, uw.pieceIndex, w.contractID}:
-			case <-w.renter.tg.StopChan():
+	} else {
+		// Success - reset the consecutive upload failures count.
+		w.consecutiveUploadFailures = 0
+	}
+
+	// Update the renter metadata for each piece that was uploaded.
+	if len(roots) > 0 {
+		addr := e.Address()
+		endHeight := e.EndHeight()
+		id := w.renter.mu.Lock()
+		for i, root := range roots {
+			uw := uws[i]
+			uw.file.mu.Lock()
+			contract, exists := uw.file.contracts[w.contractID]
+			if !exists {
+				contract = fileContract{
+					ID:          w.contractID,
+					IP:          addr,
+					WindowStart: endHeight,
+				}
 			}
-		}()
-		return
+			contract.Pieces = append(contract.Pieces, pieceData{
+				Chunk:      uw.chunkID.index,
+				Piece:      uw.pieceIndex,
+				MerkleRoot: root,
+			})
+			uw.file.contracts[w.contractID] = contract
+			w.renter.saveFile(uw.file)
+			uw.file.mu.Unlock()
+		}
+		w.renter.mu.Unlock(id)
 	}
 
-	// Success - reset the consecutive upload failures count.
-	w.consecutiveUploadFailures = 0
-
-	// Update the renter metadata.
-	addr := e.Address()
-	endHeight := e.EndHeight()
-	id := w.renter.mu.Lock()
-	uw.file.mu.Lock()
-	contract, exists := uw.file.contracts[w.contractID]
-	if !exists {
-		contract = fileContract{
-			ID:          w.contractID,
-			IP:          addr,
-			WindowStart: endHeight,
+	// The pieces that were not uploaded share the error.
+	for i, uw := range uws {
+		if i < len(roots) {
+			w.sendUploadResult(uw, roots[i], nil)
+		} else {
+			w.sendUploadResult(uw, crypto.Hash{}, err)
 		}
 	}
-	contract.Pieces = append(contract.Pieces, pieceData{
-		Chunk:      uw.chunkID.index,
-		Piece:      uw.pieceIndex,
-		MerkleRoot: root,
-	})
-	uw.file.contracts[w.contractID] = contract
-	w.renter.saveFile(uw.file)
-	uw.file.mu.Unlock()
-	w.renter.mu.Unlock(id)
+}
 
+// sendUploadResult returns the result of uploading a piece to the repair
+// loop.
+func (w *worker) sendUploadResult(uw uploadWork, root crypto.Hash, err error) {
 	go func() {
 		select {
 		case uw.resultChan <- finishedUpload{uw.chunkID, root, err, uw.pieceIndex, w.contractID}:
@@ -258,7 +298,7 @@ func (r *Renter) updateWorkerPool(contracts []modules.RenterContract) {
 				downloadChan:         make(chan downloadWork, 1),
 				killChan:             make(chan struct{}),
 				priorityDownloadChan: make(chan downloadWork, 1),
-				uploadChan:           make(chan uploadWork, 1),
+				uploadChan:           make(chan []uploadWork, 1),
 
 				renter: r,
 			}
@@ -34,6 +34,11 @@ type (
 		SuccessfulReads  uint64 `json:"successfulreads"`
 		SuccessfulWrites uint64 `json:"successfulwrites"`
 
+		// CorruptSectors is the number of sectors in the folder whose data no
+		// longer matches their Merkle root, as found by the background
+		// scrubber. Corrupt sectors are also counted as failed reads.
+		CorruptSectors uint64 `json:"corruptsectors"`
+
 		// Certain operations on a storage folder can take a long time (Add,
 		// Remove, and Resize). The fields below indicate the progress of any
 		// long running operations that might be under way in the storage
@@ -42,6 +47,18 @@ type (
 		ProgressDenominator uint64
 	}
 
+	// SectorCacheMetrics reports the size of the storage manager's cache of
+	// recently read sectors, along with the number of reads that were served
+	// from the cache (hits) and from disk (misses). Reads are not counted
+	// while the cache is disabled.
+	SectorCacheMetrics struct {
+		Capacity uint64 `json:"capacity"` // bytes
+		Size     uint64 `json:"size"`     // bytes
+		Sectors  uint64 `json:"sectors"`
+		Hits     uint64 `json:"hits"`
+		Misses   uint64 `json:"misses"`
+	}
+
 	// A StorageManager is responsible for managing storage folders and
 	// sectors. Sectors are the base unit of storage that gets moved between
 	// renters and hosts, and primarily is stored on the hosts.
@@ -80,6 +97,12 @@ type (
 		// requests to remove data.
 		DeleteSector(sectorRoot crypto.Hash) error
 
+		// MoveStorageFolder will move a storage folder to a new path, for
+		// example on a different disk. The sectors in the storage folder
+		// remain available for reading while the storage folder is moved, and
+		// an interrupted move leaves the storage folder at its old path.
+		MoveStorageFolder(index uint16, newPath string) error
+
 		// ReadSector will read a sector from the storage manager, returning the
 		// bytes that match the input sector root.
 		ReadSector(sectorRoot crypto.Hash) ([]byte, error)
@@ -118,6 +141,14 @@ type (
 		// that data will be lost.
 		ResizeStorageFolder(index uint16, newSize uint64, force bool) error
 
+		// SectorCacheMetrics returns the size and the hit and miss counts of
+		// the cache of recently read sectors.
+		SectorCacheMetrics() SectorCacheMetrics
+
+		// SetSectorCacheSize sets the maximum number of bytes of sector data
+		// that are kept in memory. A size of zero disables the cache.
+		SetSectorCacheSize(size uint64)
+
 		// StorageFolders will return a list of storage folders tracked by the
 		// manager.
 		StorageFolders() []StorageFolderMetadata
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NebulousLabs/Sia/modules/host/contractmanager"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	hostFsckCmd = &cobra.Command{
+		Use:   "fsck",
+		Short: "verify or repair the host's storage folders",
+		Long: `Verify or repair the sector bookkeeping of the host's contract manager, which
+stores the host's sectors in storage folders. These commands operate on the
+contract manager directory directly, and do not require siad to be running. The
+contract manager directory is located at host/contractmanager in the Sia
+directory.`,
+		// Run field not provided; fsck requires a subcommand.
+	}
+
+	hostFsckVerifyCmd = &cobra.Command{
+		Use:   "verify [path]",
+		Short: "verify the contract manager directory",
+		Long: `Verify the contract manager directory at [path], reporting sectors beyond the
+end of their storage folder's files, usage bits without sector metadata,
+orphaned sectors whose metadata has no usage bit, duplicate sector locations
+and virtual sector count mismatches. Uncommitted changes in the write-ahead log
+are not applied; locations where they differ from the sector metadata are
+reported as pending updates, which are expected after an unclean shutdown. The
+directory is not modified.`,
+		Run: wrap(hostfsckverifycmd),
+	}
+
+	hostFsckRepairCmd = &cobra.Command{
+		Use:   "repair [path]",
+		Short: "repair the contract manager directory",
+		Long: `Repair the contract manager directory at [path]. The write-ahead log is
+recovered first, and then inconsistent sector locations are dropped from the
+storage folders, and the metadata of orphaned sectors is cleared. A copy of the
+original settings is kept next to them, with the suffix "_corrupt". siad must
+not be running.
+
+Repair does not correct virtual sector counts. Of a sector stored in several
+locations with different counts, the location with the highest count is kept
+as it is. Repair also does not fix sector data that does not match its root, or
+unavailable storage folders.`,
+		Run: wrap(hostfsckrepaircmd),
+	}
+)
+
+// printFsckReport prints the contents of a contract manager fsck report.
+func printFsckReport(r contractmanager.FsckReport) {
+	fmt.Printf(`Storage folders:      %v
+Physical sectors:     %v
+Virtual sectors:      %v
+Uncommitted changes:  %v
+Pending updates:      %v
+`, r.StorageFolders, r.PhysicalSectors, r.VirtualSectors, r.UncommittedChanges, r.PendingUpdates)
+	if len(r.UnavailableFolders) > 0 {
+		fmt.Printf("Unavailable folders:  %v\n", strings.Join(r.UnavailableFolders, ", "))
+	}
+	if !r.Corrupt() {
+		fmt.Println("The storage folders are consistent.")
+		return
+	}
+	fmt.Printf(`Truncated sectors:    %v
+Empty usage bits:     %v
+Orphaned sectors:     %v
+Duplicate sectors:    %v
+Count mismatches:     %v
+`, r.TruncatedSectors, r.EmptyUsageBits, r.OrphanedSectors, r.DuplicateSectors, r.CountMismatches)
+}
+
+// hostfsckverifycmd is the handler for the command `siac host fsck verify
+// [path]`. It reports the consistency of the contract manager directory.
+func hostfsckverifycmd(path string) {
+	r, err := contractmanager.Fsck(abs(path))
+	if err != nil {
+		die("Could not verify storage folders:", err)
+	}
+	printFsckReport(r)
+}
+
+// hostfsckrepaircmd is the handler for the command `siac host fsck repair
+// [path]`. It repairs the contract manager directory.
+func hostfsckrepaircmd(path string) {
+	r, err := contractmanager.RepairFsck(abs(path))
+	if err != nil {
+		die("Could not repair storage folders:", err)
+	}
+	printFsckReport(r)
+	if r.Corrupt() {
+		fmt.Printf("Storage folders repaired. The original settings were saved in %v, with the suffix \"_corrupt\".\n", abs(path))
+	}
+}
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"encoding/csv"
 	"fmt"
 	"math/big"
+	"net/url"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/NebulousLabs/Sia/api"
 	"github.com/NebulousLabs/Sia/modules"
@@ -45,9 +49,23 @@ Available settings:
      minstorageprice:           currency / TB / Month
      minuploadbandwidthprice:   currency / TB
 
+     maxdownloadspeed:     bytes / second
+     maxuploadspeed:       bytes / second
+     maxrenterconnections: int
+     renterdownloadquota:  bytes
+     renteruploadquota:    bytes
+     renterquotaperiod:    blocks
+
+     sectorcachesize: bytes
+
+Sizes and speeds can be specified with units, e.g. 10MB. A limit of 0 means
+that there is no limit. Renter quotas and the connection limit apply to each
+renter separately, and quotas are reset every renterquotaperiod. A
+sectorcachesize of 0 disables the cache of recently read sectors.
+
 Currency units can be specified, e.g. 10SC; run 'siac help wallet' for details.
 
-Durations (maxduration and windowsize) must be specified in either blocks (b),
+Durations (maxduration, windowsize and renterquotaperiod) must be specified in either blocks (b),
 hours (h), days (d), or weeks (w). A block is approximately 10 minutes, so one
 hour is six blocks, a day is 144 blocks, and a week is 1008 blocks.
 
@@ -72,10 +90,125 @@ Doing so will override the standard connectivity checks.`,
 		Run: hostannouncecmd,
 	}
 
+	hostPricingCmd = &cobra.Command{
+		Use:   "pricing",
+		Short: "View the pricing policy and the recent price changes.",
+		Long: `View the policy used to adjust the host's storage price and collateral
+automatically, and the changes that it made most recently.`,
+		Run: wrap(hostpricingcmd),
+	}
+
+	hostSetPricingCmd = &cobra.Command{
+		Use:   "setpricing [minstorageprice] [maxstorageprice] [mincollateral] [maxcollateral]",
+		Short: "Enable or disable the pricing policy.",
+		Long: `Enable the policy used to adjust the host's storage price and collateral
+automatically. The storage price rises from minstorageprice to maxstorageprice
+as the host's storage fills up, and the collateral falls from maxcollateral to
+mincollateral as the collateral budget is used up. All four values are given
+in currency / TB / Month, e.g.
+	siac host setpricing 100SC 300SC 100SC 200SC
+
+While the policy is enabled, it overrides the storage price and collateral set
+with 'siac host config'. maxstorageprice must not be zero.
+
+Run 'siac host setpricing off' to stop adjusting prices. The current prices are
+kept.`,
+		Run: hostsetpricingcmd,
+	}
+
+	hostRentersCmd = &cobra.Command{
+		Use:   "renters",
+		Short: "View the renter policy.",
+		Long: `View the policy that determines which renters the host forms and renews
+contracts with, and the renters that it lists.`,
+		Run: wrap(hostrenterscmd),
+	}
+
+	hostRentersModeCmd = &cobra.Command{
+		Use:   "mode [open|allowlist|blocklist]",
+		Short: "Set the renter policy mode.",
+		Long: `Set how the host uses its list of renters. In open mode, all renters are
+accepted. In allowlist mode, only the listed renters are accepted. In blocklist
+mode, all renters except the listed renters are accepted. Existing contracts
+are not affected.`,
+		Run: wrap(hostrentersmodecmd),
+	}
+
+	hostRentersAddCmd = &cobra.Command{
+		Use:   "add [pubkey]",
+		Short: "Add a renter to the list.",
+		Long: `Add a renter to the host's list of renters. The renter is identified by the
+public key in the unlock conditions of its contracts, e.g. ed25519:3a3a...,
+which 'siac renter' shows as the renter's identity key.`,
+		Run: wrap(hostrentersaddcmd),
+	}
+
+	hostRentersRemoveCmd = &cobra.Command{
+		Use:   "remove [pubkey]",
+		Short: "Remove a renter from the list.",
+		Long:  "Remove a renter from the host's list of renters.",
+		Run:   wrap(hostrentersremovecmd),
+	}
+
+	hostRevenueCmd = &cobra.Command{
+		Use:   "revenue",
+		Short: "Export the host's revenue ledger as CSV.",
+		Long: `Export the revenue ledger of the host as CSV. The ledger has an entry for
+every storage obligation that has been resolved, recording its contract
+compensation, storage and bandwidth revenue, lost revenue, collateral and
+transaction fees. Amounts are given in hastings.
+
+The export can be limited to the obligations resolved between two days, e.g.
+	siac host revenue --start 2017-06-01 --end 2017-06-30 > june.csv`,
+		Run: wrap(hostrevenuecmd),
+	}
+
+	hostObligationsCmd = &cobra.Command{
+		Use:   "obligations",
+		Short: "List the host's storage obligations.",
+		Long: `List the host's storage obligations, optionally filtered, sorted and
+paginated, e.g.
+	siac host obligations --status unresolved --sort expiration --limit 20`,
+		Run: wrap(hostobligationscmd),
+	}
+
+	hostObligationCmd = &cobra.Command{
+		Use:   "obligation [id]",
+		Short: "View the details of a storage obligation.",
+		Long:  "View the details of a storage obligation, including its payouts and revenue.",
+		Run:   wrap(hostobligationcmd),
+	}
+
+	hostWindDownCmd = &cobra.Command{
+		Use:   "winddown",
+		Short: "View the progress of winding down the host.",
+		Long: `View whether the host is winding down, the block height after which no
+storage obligation is outstanding, and the revenue that is still pending until
+then.`,
+		Run: wrap(hostwinddowncmd),
+	}
+
+	hostWindDownStartCmd = &cobra.Command{
+		Use:   "start",
+		Short: "Start winding down the host.",
+		Long: `Start winding down the host, e.g. before moving it to new hardware. The host
+refuses new contracts and renewals, but keeps serving downloads, revisions and
+storage proofs for its existing contracts. Run 'siac host winddown' to see when
+the host can be shut down.`,
+		Run: wrap(hostwinddownstartcmd),
+	}
+
+	hostWindDownStopCmd = &cobra.Command{
+		Use:   "stop",
+		Short: "Stop winding down the host.",
+		Long:  "Stop winding down the host, so that it accepts new contracts and renewals again.",
+		Run:   wrap(hostwinddownstopcmd),
+	}
+
 	hostFolderCmd = &cobra.Command{
 		Use:   "folder",
-		Short: "Add, remove, or resize a storage folder",
-		Long:  "Add, remove, or resize a storage folder.",
+		Short: "Add, remove, resize, or move a storage folder",
+		Long:  "Add, remove, resize, or move a storage folder.",
 	}
 
 	hostFolderAddCmd = &cobra.Command{
@@ -103,6 +236,16 @@ other storage folders.`,
 		Run: wrap(hostfolderresizecmd),
 	}
 
+	hostFolderMoveCmd = &cobra.Command{
+		Use:   "move [path] [newpath]",
+		Short: "Move a storage folder to a new path",
+		Long: `Move a storage folder to a new path, for example on a different disk. The
+data in the folder is copied to the new path, after which the folder at the old
+path is deleted. The folder at the new path must already exist. The data stays
+available to renters while it is being moved.`,
+		Run: wrap(hostfoldermovecmd),
+	}
+
 	hostSectorCmd = &cobra.Command{
 		Use:   "sector",
 		Short: "Add or delete a sector (add not supported)",
@@ -197,6 +340,15 @@ Host Internal Settings:
 	minstorageprice:           %v / TB / Month
 	minuploadbandwidthprice:   %v / TB
 
+	maxdownloadspeed:     %v
+	maxuploadspeed:       %v
+	maxrenterconnections: %v
+	renterdownloadquota:  %v
+	renteruploadquota:    %v
+	renterquotaperiod:    %v Hours
+
+	sectorcachesize: %v
+
 Host Financials:
 	Contract Count:               %v
 	Transaction Fee Compensation: %v
@@ -219,10 +371,17 @@ RPC Stats:
 	Error Calls:        %v
 	Unrecognized Calls: %v
 	Download Calls:     %v
+	Encrypted Calls:    %v
 	Renew Calls:        %v
 	Revise Calls:       %v
+	Session Calls:      %v
 	Settings Calls:     %v
 	FormContract Calls: %v
+
+Sector Cache:
+	Size:   %v of %v (%v sectors)
+	Hits:   %v
+	Misses: %v
 `,
 			connectabilityString,
 
@@ -240,6 +399,13 @@ RPC Stats:
 			currencyUnits(is.MinStoragePrice.Mul(modules.BlockBytesPerMonthTerabyte)),
 			currencyUnits(is.MinUploadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
 
+			limitUnits(is.MaxDownloadSpeed, "/s"), limitUnits(is.MaxUploadSpeed, "/s"),
+			limitCount(is.MaxRenterConnections),
+			limitUnits(is.RenterDownloadQuota, ""), limitUnits(is.RenterUploadQuota, ""),
+			is.RenterQuotaPeriod/6,
+
+			sectorCacheSize(is.SectorCacheSize),
+
 			fm.ContractCount, currencyUnits(fm.ContractCompensation),
 			currencyUnits(fm.PotentialContractCompensation),
 			currencyUnits(fm.TransactionFeeExpenses),
@@ -257,8 +423,12 @@ RPC Stats:
 			currencyUnits(fm.PotentialUploadBandwidthRevenue),
 
 			nm.ErrorCalls, nm.UnrecognizedCalls, nm.DownloadCalls,
-			nm.RenewCalls, nm.ReviseCalls, nm.SettingsCalls,
-			nm.FormContractCalls)
+			nm.EncryptedCalls, nm.RenewCalls, nm.ReviseCalls, nm.SessionCalls, nm.SettingsCalls,
+			nm.FormContractCalls,
+
+			filesizeUnits(int64(sg.SectorCache.Size)),
+			filesizeUnits(int64(sg.SectorCache.Capacity)),
+			sg.SectorCache.Sectors, sg.SectorCache.Hits, sg.SectorCache.Misses)
 	} else {
 		fmt.Printf(`Host info:
 	Connectability Status: %v
@@ -283,6 +453,17 @@ RPC Stats:
 			currencyUnits(totalRevenue))
 	}
 
+	// warn about storage proofs that are at risk of missing their deadlines.
+	if len(hg.ProofAlerts) > 0 {
+		fmt.Println("\nStorage Proofs at Risk:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "\tID\tProof State\tBlocks Left\tRisked Collateral\tReason")
+		for _, alert := range hg.ProofAlerts {
+			fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\t%v\n", alert.ObligationID, alert.ProofState, alert.BlocksRemaining, currencyUnits(alert.RiskedCollateral), alert.Reason)
+		}
+		w.Flush()
+	}
+
 	fmt.Println("\nStorage Folders:")
 
 	// display storage folder info
@@ -344,15 +525,24 @@ func hostconfigcmd(param, value string) {
 			value = "false"
 		}
 
+	// size (convert to bytes, 0 removes the limit)
+	case "maxdownloadspeed", "maxuploadspeed", "renterdownloadquota", "renteruploadquota", "sectorcachesize":
+		if value != "0" {
+			value, err = parseFilesize(value)
+			if err != nil {
+				die("Could not parse "+param+":", err)
+			}
+		}
+
 	// duration (convert to blocks)
-	case "maxduration", "windowsize":
+	case "maxduration", "windowsize", "renterquotaperiod":
 		value, err = parsePeriod(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
 		}
 
 	// other valid settings
-	case "maxdownloadbatchsize", "maxrevisebatchsize", "netaddress":
+	case "maxdownloadbatchsize", "maxrevisebatchsize", "netaddress", "maxrenterconnections":
 
 	// invalid settings
 	default:
@@ -451,6 +641,15 @@ func hostfolderresizecmd(path, newsize string) {
 	fmt.Printf("Resized folder %v to %v\n", path, newsize)
 }
 
+// hostfoldermovecmd moves a folder in the host to a new path.
+func hostfoldermovecmd(path, newpath string) {
+	err := post("/host/storage/folders/move", fmt.Sprintf("path=%s&newpath=%s", abs(path), abs(newpath)))
+	if err != nil {
+		die("Could not move folder:", err)
+	}
+	fmt.Printf("Moved folder %v to %v\n", path, newpath)
+}
+
 // hostsectordeletecmd deletes a sector from the host.
 func hostsectordeletecmd(root string) {
 	err := post("/host/storage/sectors/delete/"+root, "")
@@ -459,3 +658,364 @@ func hostsectordeletecmd(root string) {
 	}
 	fmt.Println("Deleted sector", root)
 }
+
+// hostpricingcmd displays the pricing policy of the host and the price
+// changes it made most recently.
+func hostpricingcmd() {
+	var pg api.HostPricingGET
+	err := getAPI("/host/pricing", &pg)
+	if err != nil {
+		die("Could not fetch pricing policy:", err)
+	}
+	perTB := func(c types.Currency) string {
+		return currencyUnits(c.Mul(modules.BlockBytesPerMonthTerabyte))
+	}
+
+	fmt.Println("Pricing Policy:")
+	if !pg.Policy.Enabled {
+		fmt.Println("  Enabled:       no")
+	} else {
+		fmt.Println("  Enabled:       yes")
+	}
+	fmt.Println("  Storage Price:", perTB(pg.Policy.MinStoragePrice), "-", perTB(pg.Policy.MaxStoragePrice), "/ TB / Month")
+	fmt.Println("  Collateral:   ", perTB(pg.Policy.MinCollateral), "-", perTB(pg.Policy.MaxCollateral), "/ TB / Month")
+
+	if len(pg.Adjustments) == 0 {
+		fmt.Println("\nNo prices have been adjusted.")
+		return
+	}
+	fmt.Println("\nRecent Adjustments (per TB per Month):")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tHeight\tStorage Used\tStorage Price\tBudget Used\tCollateral")
+	for i := len(pg.Adjustments) - 1; i >= 0; i-- {
+		a := pg.Adjustments[i]
+		fmt.Fprintf(w, "\t%v\t%.0f%%\t%v -> %v\t%.0f%%\t%v -> %v\n", a.BlockHeight,
+			a.StorageUtilization*100, perTB(a.OldStoragePrice), perTB(a.NewStoragePrice),
+			a.CollateralUtilization*100, perTB(a.OldCollateral), perTB(a.NewCollateral))
+	}
+	w.Flush()
+}
+
+// hostsetpricingcmd enables the pricing policy of the host with the given
+// bounds, or disables it.
+func hostsetpricingcmd(cmd *cobra.Command, args []string) {
+	if len(args) == 1 && strings.ToLower(args[0]) == "off" {
+		err := post("/host/pricing", "enabled=false")
+		if err != nil {
+			die("Could not disable pricing policy:", err)
+		}
+		fmt.Println("Pricing policy disabled.")
+		return
+	} else if len(args) != 4 {
+		cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+
+	names := []string{"minstorageprice", "maxstorageprice", "mincollateral", "maxcollateral"}
+	query := "enabled=true"
+	for i, name := range names {
+		hastings, err := parseCurrency(args[i])
+		if err != nil {
+			die("Could not parse "+name+":", err)
+		}
+		h, _ := new(big.Int).SetString(hastings, 10)
+		query += fmt.Sprintf("&%v=%v", name, types.NewCurrency(h).Div(modules.BlockBytesPerMonthTerabyte))
+	}
+	err := post("/host/pricing", query)
+	if err != nil {
+		die("Could not set pricing policy:", err)
+	}
+	fmt.Println("Pricing policy updated.")
+}
+
+// limitUnits formats a limit in bytes, followed by the given suffix. A limit
+// of zero is displayed as unlimited.
+func limitUnits(limit uint64, suffix string) string {
+	if limit == 0 {
+		return "unlimited"
+	}
+	return filesizeUnits(int64(limit)) + suffix
+}
+
+// sectorCacheSize formats the size of the sector cache. A size of zero is
+// displayed as disabled.
+func sectorCacheSize(size uint64) string {
+	if size == 0 {
+		return "disabled"
+	}
+	return filesizeUnits(int64(size))
+}
+
+// limitCount formats a limit on a number of items. A limit of zero is
+// displayed as unlimited.
+func limitCount(limit uint64) string {
+	if limit == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprint(limit)
+}
+
+// hostrenterscmd displays the renter policy of the host.
+func hostrenterscmd() {
+	var hrg api.HostRentersGET
+	err := getAPI("/host/renters", &hrg)
+	if err != nil {
+		die("Could not fetch renter policy:", err)
+	}
+	fmt.Println("Renter Policy:", hrg.Mode)
+	if len(hrg.Renters) == 0 {
+		fmt.Println("No renters are listed.")
+		return
+	}
+	fmt.Println("Listed Renters:")
+	for _, renter := range hrg.Renters {
+		fmt.Println("  " + renter)
+	}
+}
+
+// hostrevenuecmd writes the host's revenue ledger to stdout as CSV.
+func hostrevenuecmd() {
+	query := url.Values{}
+	if hostRevenueStart != "" {
+		start, err := time.ParseInLocation("2006-01-02", hostRevenueStart, time.Local)
+		if err != nil {
+			die("Could not parse start date:", err)
+		}
+		query.Set("start", strconv.FormatInt(start.Unix(), 10))
+	}
+	if hostRevenueEnd != "" {
+		end, err := time.ParseInLocation("2006-01-02", hostRevenueEnd, time.Local)
+		if err != nil {
+			die("Could not parse end date:", err)
+		}
+		// The end date is inclusive.
+		query.Set("end", strconv.FormatInt(end.AddDate(0, 0, 1).Unix(), 10))
+	}
+	var hrg api.HostRevenueGET
+	err := getAPI("/host/revenue?"+query.Encode(), &hrg)
+	if err != nil {
+		die("Could not fetch revenue ledger:", err)
+	}
+
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"Resolved", "Contract ID", "Status", "Negotiation Height", "Expiration Height", "Resolution Height",
+		"Contract Compensation", "Storage Revenue", "Download Revenue", "Upload Revenue", "Lost Revenue",
+		"Locked Collateral", "Lost Collateral", "Transaction Fees"})
+	for _, e := range hrg.Entries {
+		w.Write([]string{
+			e.Timestamp.Format(time.RFC3339),
+			e.ContractID.String(),
+			e.Status,
+			fmt.Sprint(e.NegotiationHeight),
+			fmt.Sprint(e.ExpirationHeight),
+			fmt.Sprint(e.ResolutionHeight),
+			e.ContractCompensation.String(),
+			e.StorageRevenue.String(),
+			e.DownloadBandwidthRevenue.String(),
+			e.UploadBandwidthRevenue.String(),
+			e.LostRevenue.String(),
+			e.LockedCollateral.String(),
+			e.LostCollateral.String(),
+			e.TransactionFees.String(),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		die("Could not write revenue ledger:", err)
+	}
+}
+
+// obligationStatusNames are the names of the storage obligation statuses, in
+// the order of their values.
+var obligationStatusNames = []string{"unresolved", "rejected", "succeeded", "failed"}
+
+// obligationStatus returns the name of a storage obligation status.
+func obligationStatus(status uint64) string {
+	if status >= uint64(len(obligationStatusNames)) {
+		return "unknown"
+	}
+	return obligationStatusNames[status]
+}
+
+// hostobligationscmd lists a page of the host's storage obligations.
+func hostobligationscmd() {
+	vals := url.Values{}
+	if hostObligationsStatus != "" {
+		vals.Set("status", hostObligationsStatus)
+	}
+	if hostObligationsRenter != "" {
+		vals.Set("renter", hostObligationsRenter)
+	}
+	if hostObligationsSort != "" {
+		vals.Set("sortby", hostObligationsSort)
+	}
+	if hostObligationsDesc {
+		vals.Set("order", "desc")
+	}
+	if hostObligationsOffset != 0 {
+		vals.Set("offset", fmt.Sprint(hostObligationsOffset))
+	}
+	if hostObligationsLimit != 0 {
+		vals.Set("limit", fmt.Sprint(hostObligationsLimit))
+	}
+
+	var sog api.StorageObligationsGET
+	err := getAPI("/host/storage/obligations?"+vals.Encode(), &sog)
+	if err != nil {
+		die("Could not fetch storage obligations:", err)
+	}
+	if len(sog.Obligations) == 0 {
+		fmt.Println("No matching storage obligations")
+		return
+	}
+
+	fmt.Printf("Showing %v of %v matching storage obligations:\n", len(sog.Obligations), sog.TotalObligations)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tID\tStatus\tSize\tExpiration\tProof Deadline\tValue")
+	for _, so := range sog.Obligations {
+		fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\t%v\t%v\n", so.ObligationID, obligationStatus(so.ObligationStatus),
+			filesizeUnits(int64(so.DataSize)), so.ExpirationHeight, so.ProofDeadline, currencyUnits(so.Value))
+	}
+	w.Flush()
+}
+
+// hostobligationcmd shows the details of a storage obligation.
+func hostobligationcmd(id string) {
+	var sod modules.StorageObligationDetails
+	err := getAPI("/host/storage/obligations/"+id, &sod)
+	if err != nil {
+		die("Could not fetch storage obligation:", err)
+	}
+	fmt.Printf(`Storage Obligation %v
+Status:              %v
+Renter:              %v
+Size:                %v (%v sectors)
+Revision Number:     %v
+Negotiated:          block %v
+Expiration:          block %v
+Proof Deadline:      block %v
+Origin Confirmed:    %v
+Revision Confirmed:  %v
+Proof Confirmed:     %v
+Proof State:         %v (submitted %v times)
+
+Contract Cost:       %v
+Storage Revenue:     %v
+Download Revenue:    %v
+Upload Revenue:      %v
+Locked Collateral:   %v
+Risked Collateral:   %v
+Transaction Fees:    %v
+`, sod.ObligationID, obligationStatus(sod.ObligationStatus), sod.Renter, filesizeUnits(int64(sod.DataSize)),
+		sod.SectorCount, sod.RevisionNumber, sod.NegotiationHeight, sod.ExpirationHeight, sod.ProofDeadline,
+		yesNo(sod.OriginConfirmed), yesNo(sod.RevisionConfirmed), yesNo(sod.ProofConfirmed), sod.ProofState, sod.ProofSubmissions,
+		currencyUnits(sod.ContractCost), currencyUnits(sod.PotentialStorageRevenue), currencyUnits(sod.PotentialDownloadRevenue),
+		currencyUnits(sod.PotentialUploadRevenue), currencyUnits(sod.LockedCollateral), currencyUnits(sod.RiskedCollateral),
+		currencyUnits(sod.TransactionFeesAdded))
+
+	if len(sod.ValidProofOutputs) == 2 && len(sod.MissedProofOutputs) == 2 {
+		fmt.Printf(`
+Payouts if the storage proof is submitted:
+  Renter: %v
+  Host:   %v
+Payouts if the storage proof is missed:
+  Renter: %v
+  Host:   %v
+`, currencyUnits(sod.ValidProofOutputs[0].Value), currencyUnits(sod.ValidProofOutputs[1].Value),
+			currencyUnits(sod.MissedProofOutputs[0].Value), currencyUnits(sod.MissedProofOutputs[1].Value))
+	}
+
+	fmt.Println()
+	fmt.Println("Submitted Transactions:")
+	for _, txn := range sod.OriginTransactionSet {
+		fmt.Println("  Origin:  ", txn.ID())
+	}
+	for _, txn := range sod.RevisionTransactionSet {
+		fmt.Println("  Revision:", txn.ID())
+	}
+}
+
+// hostwinddowncmd shows the progress of winding down the host.
+func hostwinddowncmd() {
+	var status modules.HostWindDownStatus
+	err := getAPI("/host/winddown", &status)
+	if err != nil {
+		die("Could not fetch wind-down status:", err)
+	}
+	if status.Enabled {
+		fmt.Println("The host has been winding down since block", status.StartHeight)
+	} else {
+		fmt.Println("The host is not winding down.")
+	}
+	if status.OutstandingObligations == 0 {
+		if status.Enabled {
+			fmt.Println("No storage obligations are outstanding. The host can be shut down.")
+		} else {
+			fmt.Println("No storage obligations are outstanding.")
+		}
+		return
+	}
+	var remaining types.BlockHeight
+	if status.CompletionHeight > status.BlockHeight {
+		remaining = status.CompletionHeight - status.BlockHeight
+	}
+	fmt.Printf(`Outstanding Obligations: %v
+Last Proof Deadline:     block %v (current height %v, ~%v blocks remaining)
+
+Pending Revenue:
+  Contract Compensation: %v
+  Storage Revenue:       %v
+  Download Revenue:      %v
+  Upload Revenue:        %v
+Locked Collateral:       %v
+Risked Collateral:       %v
+`, status.OutstandingObligations, status.CompletionHeight, status.BlockHeight, remaining,
+		currencyUnits(status.PendingContractCompensation), currencyUnits(status.PendingStorageRevenue),
+		currencyUnits(status.PendingDownloadBandwidthRevenue), currencyUnits(status.PendingUploadBandwidthRevenue),
+		currencyUnits(status.LockedCollateral), currencyUnits(status.RiskedCollateral))
+}
+
+// hostwinddownstartcmd starts winding down the host.
+func hostwinddownstartcmd() {
+	err := post("/host/winddown", "enabled=true")
+	if err != nil {
+		die("Could not start winding down the host:", err)
+	}
+	fmt.Println("The host is winding down. It will not accept new contracts or renewals.")
+}
+
+// hostwinddownstopcmd stops winding down the host.
+func hostwinddownstopcmd() {
+	err := post("/host/winddown", "enabled=false")
+	if err != nil {
+		die("Could not stop winding down the host:", err)
+	}
+	fmt.Println("The host is no longer winding down.")
+}
+
+// hostrentersmodecmd sets the renter policy mode of the host.
+func hostrentersmodecmd(mode string) {
+	err := post("/host/renters", "mode="+mode)
+	if err != nil {
+		die("Could not set renter policy mode:", err)
+	}
+	fmt.Println("Renter policy mode set to", mode)
+}
+
+// hostrentersaddcmd adds a renter to the host's list of renters.
+func hostrentersaddcmd(pubkey string) {
+	err := post("/host/renters", "add="+pubkey)
+	if err != nil {
+		die("Could not add renter:", err)
+	}
+	fmt.Println("Added renter", pubkey)
+}
+
+// hostrentersremovecmd removes a renter from the host's list of renters.
+func hostrentersremovecmd(pubkey string) {
+	err := post("/host/renters", "remove="+pubkey)
+	if err != nil {
+		die("Could not remove renter:", err)
+	}
+	fmt.Println("Removed renter", pubkey)
+}
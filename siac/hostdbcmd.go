@@ -3,13 +3,17 @@ package main
 import (
 	"fmt"
 	"math/big"
+	"net/url"
 	"os"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/NebulousLabs/Sia/api"
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
 )
 
 const scanHistoryLen = 30
@@ -17,6 +21,12 @@ const scanHistoryLen = 30
 var (
 	hostdbNumHosts int
 	hostdbVerbose  bool
+
+	hostdbListDesc   bool   // sort the host list in descending order
+	hostdbListFilter string // filters applied to the host list
+	hostdbListLimit  int    // max number of hosts to list
+	hostdbListOffset int    // number of matching hosts to skip
+	hostdbListSort   string // field to sort the host list by
 )
 
 var (
@@ -27,6 +37,68 @@ var (
 		Run:   wrap(hostdbcmd),
 	}
 
+	hostdbListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the hosts that match a filter.",
+		Long: `List all known hosts that match a filter, optionally sorted and paginated.
+The filter is a comma-separated list of name=value pairs, for example:
+
+	siac hostdb list --filter maxstorageprice=200SC,minuptime=0.95,acceptingcontracts=yes
+
+Supported filters:
+	minstorageprice, maxstorageprice  storage price per TB per month
+	mincollateral, maxcollateral      collateral per TB per month
+	minremainingstorage               remaining storage, e.g. 1TB
+	minversion                        minimum siad version, e.g. 1.3.0
+	minuptime                         minimum uptime ratio, between 0 and 1
+	minfirstseen, maxfirstseen        block height the host was first seen at
+	acceptingcontracts                yes or no
+	minscore                          minimum score
+
+Hosts can be sorted by storageprice, collateral, remainingstorage, version,
+uptime, firstseen or score.`,
+		Run: wrap(hostdblistcmd),
+	}
+
+	hostdbPruningCmd = &cobra.Command{
+		Use:   "pruning",
+		Short: "View the pruning policy and the recently pruned hosts.",
+		Long: `View the policy used to remove hosts that have been offline for too long from
+the host database, and the hosts that were most recently removed.`,
+		Run: wrap(hostdbpruningcmd),
+	}
+
+	hostdbSetPruningCmd = &cobra.Command{
+		Use:   "setpruning [maxdowntime] [announcementwindow]",
+		Short: "Set the pruning policy.",
+		Long: `Set the policy used to remove hosts that have been offline for too long from
+the host database. A host is removed once it has been offline for longer than
+maxdowntime, unless the renter has a contract with it or it announced itself
+within the announcement window.
+
+Both values are given in either blocks (b), hours (h), days (d), or weeks (w).
+A maxdowntime of 0 disables pruning.`,
+		Run: wrap(hostdbsetpruningcmd),
+	}
+
+	hostdbScoreTestCmd = &cobra.Command{
+		Use:   "scoretest [weights]",
+		Short: "Show how host rankings would change under different weights.",
+		Long: `Rank the active hosts using a different set of scoring weights, and compare the
+result to the ranking under the current weights. The weights in use are not
+changed. [weights] is a comma-separated list of name=value pairs, for example:
+
+	siac hostdb scoretest downloadpriceweight=4,uptimeexponent=2
+
+Weights that are not listed keep their current values. Each adjustment (age,
+burn, collateral, interaction, performance, price, storageremaining, uptime,
+version) has an exponent and a multiplier, e.g. 'uptimeexponent' and
+'uptimemultiplier'. The price categories (contract, download, storage, upload)
+have a weight, e.g. 'downloadpriceweight', and a max price in hastings, e.g.
+'maxdownloadbandwidthprice' or 'maxstorageprice'.`,
+		Run: wrap(hostdbscoretestcmd),
+	}
+
 	hostdbViewCmd = &cobra.Command{
 		Use:   "view [pubkey]",
 		Short: "View the full information for a host.",
@@ -42,7 +114,9 @@ func printScoreBreakdown(info *api.HostdbHostsGET) {
 	fmt.Fprintf(w, "\t\tAge:\t %.3f\n", info.ScoreBreakdown.AgeAdjustment)
 	fmt.Fprintf(w, "\t\tBurn:\t %.3f\n", info.ScoreBreakdown.BurnAdjustment)
 	fmt.Fprintf(w, "\t\tCollateral:\t %.3f\n", info.ScoreBreakdown.CollateralAdjustment)
+	fmt.Fprintf(w, "\t\tDishonesty:\t %.3f\n", info.ScoreBreakdown.DishonestyAdjustment)
 	fmt.Fprintf(w, "\t\tInteraction:\t %.3f\n", info.ScoreBreakdown.InteractionAdjustment)
+	fmt.Fprintf(w, "\t\tPerformance:\t %.3f\n", info.ScoreBreakdown.PerformanceAdjustment)
 	fmt.Fprintf(w, "\t\tPrice:\t %.3f\n", info.ScoreBreakdown.PriceAdjustment*1e6)
 	fmt.Fprintf(w, "\t\tStorage:\t %.3f\n", info.ScoreBreakdown.StorageRemainingAdjustment)
 	fmt.Fprintf(w, "\t\tUptime:\t %.3f\n", info.ScoreBreakdown.UptimeAdjustment)
@@ -50,6 +124,15 @@ func printScoreBreakdown(info *api.HostdbHostsGET) {
 	w.Flush()
 }
 
+// throughputUnits converts a throughput in bytes per second to a string, or
+// returns "unknown" if the throughput has not been measured.
+func throughputUnits(bps float64) string {
+	if bps == 0 {
+		return "unknown"
+	}
+	return filesizeUnits(int64(bps)) + "/s"
+}
+
 func hostdbcmd() {
 	if !hostdbVerbose {
 		info := new(api.HostdbActiveGET)
@@ -300,6 +383,27 @@ func hostdbviewcmd(pubkey string) {
 	fmt.Fprintln(w, "\t\tVersion:\t", info.Entry.Version)
 	w.Flush()
 
+	fmt.Println("\n  Performance:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\t\tConnect Latency:\t", info.Entry.ConnectLatency)
+	fmt.Fprintln(w, "\t\tSettings Latency:\t", info.Entry.SettingsLatency)
+	fmt.Fprintln(w, "\t\tDownload Throughput:\t", throughputUnits(info.Entry.DownloadThroughput))
+	fmt.Fprintln(w, "\t\tUpload Throughput:\t", throughputUnits(info.Entry.UploadThroughput))
+	w.Flush()
+
+	if len(info.Entry.PriceHistory) > 0 {
+		fmt.Println("\n  Price History:")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "\t\tHeight\tContract Price\tStorage Price (TB / Mo)\tDownload Price (1 TB)\tUpload Price (1 TB)")
+		for _, snapshot := range info.Entry.PriceHistory {
+			fmt.Fprintf(w, "\t\t%v\t%v\t%v\t%v\t%v\n", snapshot.BlockHeight, currencyUnits(snapshot.ContractPrice),
+				currencyUnits(snapshot.StoragePrice.Mul(modules.BlockBytesPerMonthTerabyte)),
+				currencyUnits(snapshot.DownloadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
+				currencyUnits(snapshot.UploadBandwidthPrice.Mul(modules.BytesPerTerabyte)))
+		}
+		w.Flush()
+	}
+
 	printScoreBreakdown(info)
 
 	// Compute the total measured uptime and total measured downtime for this
@@ -329,3 +433,188 @@ func hostdbviewcmd(pubkey string) {
 
 	fmt.Println()
 }
+
+// hostdblistcmd is the handler for the command `siac hostdb list`. It lists
+// the hosts matching the filter.
+func hostdblistcmd() {
+	vals := url.Values{}
+	if hostdbListFilter != "" {
+		for _, pair := range strings.Split(hostdbListFilter, ",") {
+			kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+			if len(kv) != 2 || kv[0] == "" {
+				die("Could not parse filter:", pair)
+			}
+			param, value := strings.ToLower(kv[0]), kv[1]
+			switch param {
+			// currency/TB/month (convert to hastings/byte/block)
+			case "minstorageprice", "maxstorageprice", "mincollateral", "maxcollateral":
+				hastings, err := parseCurrency(value)
+				if err != nil {
+					die("Could not parse "+param+":", err)
+				}
+				i, _ := new(big.Int).SetString(hastings, 10)
+				value = types.NewCurrency(i).Div(modules.BlockBytesPerMonthTerabyte).String()
+
+			// filesize (convert to bytes)
+			case "minremainingstorage":
+				var err error
+				value, err = parseFilesize(value)
+				if err != nil {
+					die("Could not parse "+param+":", err)
+				}
+
+			// bool (allow "yes" and "no")
+			case "acceptingcontracts":
+				switch strings.ToLower(value) {
+				case "yes":
+					value = "true"
+				case "no":
+					value = "false"
+				}
+
+			// other valid filters
+			case "minversion", "minuptime", "minfirstseen", "maxfirstseen", "minscore":
+
+			// invalid filters
+			default:
+				die("\"" + param + "\" is not a hostdb filter")
+			}
+			vals.Set(param, value)
+		}
+	}
+	if hostdbListSort != "" {
+		vals.Set("sortby", hostdbListSort)
+	}
+	if hostdbListDesc {
+		vals.Set("order", "desc")
+	}
+	if hostdbListOffset != 0 {
+		vals.Set("offset", fmt.Sprint(hostdbListOffset))
+	}
+	if hostdbListLimit != 0 {
+		vals.Set("limit", fmt.Sprint(hostdbListLimit))
+	}
+
+	var info api.HostdbAllGET
+	err := getAPI("/hostdb/all?"+vals.Encode(), &info)
+	if err != nil {
+		die("Could not fetch host list:", err)
+	}
+	if len(info.Hosts) == 0 {
+		fmt.Println("No matching hosts")
+		return
+	}
+
+	fmt.Printf("Showing %v of %v matching hosts:\n", len(info.Hosts), info.TotalHosts)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tPubkey\tAddress\tPrice (/ TB / Month)\tCollateral (/ TB / Month)\tRemaining Storage\tVersion\tFirst Seen\tAccepting Contracts")
+	for _, host := range info.Hosts {
+		price := host.StoragePrice.Mul(modules.BlockBytesPerMonthTerabyte)
+		collateral := host.Collateral.Mul(modules.BlockBytesPerMonthTerabyte)
+		fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", host.PublicKeyString, host.NetAddress, currencyUnits(price),
+			currencyUnits(collateral), filesizeUnits(int64(host.RemainingStorage)), host.Version, host.FirstSeen, yesNo(host.AcceptingContracts))
+	}
+	w.Flush()
+}
+
+// hostdbscoretestcmd is the handler for the command `siac hostdb scoretest
+// [weights]`. It shows how the ranking of the active hosts would change under
+// the given weights.
+func hostdbscoretestcmd(weights string) {
+	vals := url.Values{}
+	for _, pair := range strings.Split(weights, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			die("Could not parse weight:", pair)
+		}
+		vals.Set(strings.ToLower(kv[0]), kv[1])
+	}
+
+	var st api.HostdbScoreTestGET
+	err := getAPI("/hostdb/scoretest?"+vals.Encode(), &st)
+	if err != nil {
+		die("Could not test weights:", err)
+	}
+	if len(st.Hosts) == 0 {
+		fmt.Println("No known active hosts")
+		return
+	}
+
+	hosts := st.Hosts
+	if hostdbNumHosts > 0 && hostdbNumHosts < len(hosts) {
+		hosts = hosts[:hostdbNumHosts]
+	}
+	var moved int
+	for _, host := range st.Hosts {
+		if host.TestRank != host.CurrentRank {
+			moved++
+		}
+	}
+	fmt.Printf("%v of %v active hosts change rank:\n", moved, len(st.Hosts))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tRank\tCurrent Rank\tChange\tAddress\tPublic Key")
+	for _, host := range hosts {
+		change := "-"
+		if host.TestRank != host.CurrentRank {
+			change = fmt.Sprintf("%+d", host.CurrentRank-host.TestRank)
+		}
+		fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\t%v\n", host.TestRank, host.CurrentRank, change, host.NetAddress, host.PublicKeyString)
+	}
+	w.Flush()
+}
+
+// hostdbpruningcmd displays the pruning policy of the hostdb and the hosts it
+// pruned most recently.
+func hostdbpruningcmd() {
+	var pg api.HostdbPruningGET
+	err := getAPI("/hostdb/pruning", &pg)
+	if err != nil {
+		die("Could not fetch pruning policy:", err)
+	}
+
+	fmt.Println("Pruning Policy:")
+	if pg.Policy.MaxDowntime == 0 {
+		fmt.Println("  Max Downtime:        disabled")
+	} else {
+		fmt.Println("  Max Downtime:       ", pg.Policy.MaxDowntime)
+	}
+	fmt.Println("  Announcement Window:", pg.Policy.AnnouncementWindow, "blocks")
+
+	if len(pg.PrunedHosts) == 0 {
+		fmt.Println("\nNo hosts have been pruned.")
+		return
+	}
+	fmt.Println("\nRecently Pruned Hosts:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tPublic Key\tAddress\tOffline Since\tPruned At")
+	for i := len(pg.PrunedHosts) - 1; i >= 0; i-- {
+		host := pg.PrunedHosts[i]
+		fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\n", host.PublicKey.String(), host.NetAddress,
+			host.OfflineSince.Format("2006-01-02 15:04"), host.Timestamp.Format("2006-01-02 15:04"))
+	}
+	w.Flush()
+}
+
+// hostdbsetpruningcmd sets the pruning policy of the hostdb.
+func hostdbsetpruningcmd(maxDowntime, announcementWindow string) {
+	var downtime time.Duration
+	if maxDowntime != "0" {
+		downtimeBlocks, err := parsePeriod(maxDowntime)
+		if err != nil {
+			die("Could not parse max downtime:", err)
+		}
+		var blocks int
+		fmt.Sscan(downtimeBlocks, &blocks)
+		downtime = time.Duration(blocks) * 10 * time.Minute
+	}
+	windowBlocks, err := parsePeriod(announcementWindow)
+	if err != nil {
+		die("Could not parse announcement window:", err)
+	}
+
+	err = post("/hostdb/pruning", fmt.Sprintf("maxdowntime=%v&announcementwindow=%v", downtime, windowBlocks))
+	if err != nil {
+		die("Could not set pruning policy:", err)
+	}
+	fmt.Println("Pruning policy updated.")
+}
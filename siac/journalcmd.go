@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/NebulousLabs/Sia/modules/renter/contractor"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	renterJournalCmd = &cobra.Command{
+		Use:   "journal",
+		Short: "verify or repair the renter's contract journal",
+		Long: `Verify or repair the contractor journal, which stores the renter's contracts.
+These commands operate on the journal file directly, and do not require siad to
+be running. The journal is located at renter/contractor.journal in the Sia
+directory.`,
+		// Run field not provided; journal requires a subcommand.
+	}
+
+	renterJournalVerifyCmd = &cobra.Command{
+		Use:   "verify [path]",
+		Short: "verify the contractor journal",
+		Long: `Verify the contractor journal at [path], reporting the longest valid prefix of
+the journal and any data following it. siad discards a partially written final
+update set when it loads the journal, but refuses to load a journal with any
+other corruption until it has been repaired. The journal is not modified.`,
+		Run: wrap(renterjournalverifycmd),
+	}
+
+	renterJournalRepairCmd = &cobra.Command{
+		Use:   "repair [path]",
+		Short: "repair the contractor journal",
+		Long: `Repair the contractor journal at [path] by recovering its longest valid prefix
+and discarding everything after it. A copy of the original journal is kept
+next to it, with the suffix "_corrupt". siad must not be running.`,
+		Run: wrap(renterjournalrepaircmd),
+	}
+)
+
+// printJournalReport prints the contents of a contractor journal report.
+func printJournalReport(r contractor.JournalReport) {
+	fmt.Printf(`Journal version:   %v
+Contracts:         %v
+Cached revisions:  %v
+Valid update sets: %v (%v)
+`, r.Version, r.Contracts, r.CachedRevisions, r.ValidUpdateSets, filesizeUnits(r.ValidSize))
+	if !r.Corrupt() {
+		fmt.Println("The journal is intact.")
+		return
+	}
+	fmt.Printf(`Lost update sets:  %v (%v)
+Torn write:        %v
+Error:             %v
+`, r.LostUpdateSets, filesizeUnits(r.LostSize), yesNo(r.TornWrite), r.FirstError)
+}
+
+// renterjournalverifycmd is the handler for the command `siac renter journal
+// verify [path]`. It reports the state of the contractor journal.
+func renterjournalverifycmd(path string) {
+	r, err := contractor.VerifyJournal(abs(path))
+	if err != nil {
+		die("Could not verify journal:", err)
+	}
+	printJournalReport(r)
+}
+
+// renterjournalrepaircmd is the handler for the command `siac renter journal
+// repair [path]`. It truncates the contractor journal to its longest valid
+// prefix.
+func renterjournalrepaircmd(path string) {
+	r, err := contractor.RepairJournal(abs(path))
+	if err != nil {
+		die("Could not repair journal:", err)
+	}
+	printJournalReport(r)
+	if r.Corrupt() {
+		fmt.Printf("Journal repaired. The original journal was saved to %v_corrupt.\n", abs(path))
+	}
+}
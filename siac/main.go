@@ -22,9 +22,20 @@ var (
 	initPassword      bool   // supply a custom password when creating a wallet
 	initForce         bool   // destroy and reencrypt the wallet on init if it already exists
 	hostVerbose       bool   // display additional host info
+	hostRevenueStart  string // first day of the host revenue export
+	hostRevenueEnd    string // last day of the host revenue export
 	renterShowHistory bool   // Show download history in addition to download queue.
 	renterListVerbose bool   // Show additional info about uploaded files.
 
+	hostObligationsStatus string // status of the storage obligations to list
+	hostObligationsRenter string // renter of the storage obligations to list
+	hostObligationsSort   string // field to sort the storage obligations by
+	hostObligationsDesc   bool   // sort the storage obligations in descending order
+	hostObligationsOffset int    // number of storage obligations to skip
+	hostObligationsLimit  int    // max number of storage obligations to list
+
+	renterMaxPriceIncrease float64 // ratio by which contracted hosts may raise their prices
+
 	// Globals.
 	rootCmd *cobra.Command // Root command cobra object, used by bash completion cmd.
 
@@ -249,14 +260,31 @@ func main() {
 	updateCmd.AddCommand(updateCheckCmd)
 
 	root.AddCommand(hostCmd)
-	hostCmd.AddCommand(hostConfigCmd, hostAnnounceCmd, hostFolderCmd, hostSectorCmd)
-	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderRemoveCmd, hostFolderResizeCmd)
+	hostCmd.AddCommand(hostConfigCmd, hostAnnounceCmd, hostFolderCmd, hostSectorCmd, hostPricingCmd, hostSetPricingCmd, hostRentersCmd, hostRevenueCmd, hostObligationsCmd, hostObligationCmd, hostWindDownCmd, hostFsckCmd)
+	hostRentersCmd.AddCommand(hostRentersModeCmd, hostRentersAddCmd, hostRentersRemoveCmd)
+	hostWindDownCmd.AddCommand(hostWindDownStartCmd, hostWindDownStopCmd)
+	hostFsckCmd.AddCommand(hostFsckVerifyCmd, hostFsckRepairCmd)
+	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderRemoveCmd, hostFolderResizeCmd, hostFolderMoveCmd)
 	hostSectorCmd.AddCommand(hostSectorDeleteCmd)
 	hostCmd.Flags().BoolVarP(&hostVerbose, "verbose", "v", false, "Display detailed host info")
+	hostRevenueCmd.Flags().StringVar(&hostRevenueStart, "start", "", "First day to export, as YYYY-MM-DD")
+	hostRevenueCmd.Flags().StringVar(&hostRevenueEnd, "end", "", "Last day to export, as YYYY-MM-DD")
+	hostObligationsCmd.Flags().StringVar(&hostObligationsStatus, "status", "", "Only list obligations with this status: unresolved, rejected, succeeded or failed")
+	hostObligationsCmd.Flags().StringVar(&hostObligationsRenter, "renter", "", "Only list obligations with the renter with this public key")
+	hostObligationsCmd.Flags().StringVarP(&hostObligationsSort, "sort", "s", "", "Sort the obligations by expiration or value")
+	hostObligationsCmd.Flags().BoolVarP(&hostObligationsDesc, "desc", "d", false, "Sort the obligations in descending order")
+	hostObligationsCmd.Flags().IntVar(&hostObligationsOffset, "offset", 0, "Number of matching obligations to skip")
+	hostObligationsCmd.Flags().IntVar(&hostObligationsLimit, "limit", 0, "Maximum number of obligations to list")
 
 	root.AddCommand(hostdbCmd)
-	hostdbCmd.AddCommand(hostdbViewCmd)
+	hostdbCmd.AddCommand(hostdbViewCmd, hostdbListCmd, hostdbScoreTestCmd, hostdbPruningCmd, hostdbSetPruningCmd)
 	hostdbCmd.Flags().IntVarP(&hostdbNumHosts, "numhosts", "n", 0, "Number of hosts to display from the hostdb")
+	hostdbListCmd.Flags().StringVarP(&hostdbListFilter, "filter", "f", "", "Comma-separated list of filters, e.g. maxstorageprice=200SC,minuptime=0.95")
+	hostdbListCmd.Flags().StringVarP(&hostdbListSort, "sort", "s", "", "Field to sort the hosts by")
+	hostdbListCmd.Flags().BoolVarP(&hostdbListDesc, "desc", "d", false, "Sort the hosts in descending order")
+	hostdbListCmd.Flags().IntVar(&hostdbListOffset, "offset", 0, "Number of matching hosts to skip")
+	hostdbListCmd.Flags().IntVar(&hostdbListLimit, "limit", 0, "Maximum number of hosts to list")
+	hostdbScoreTestCmd.Flags().IntVarP(&hostdbNumHosts, "numhosts", "n", 0, "Number of hosts to display")
 	hostdbCmd.Flags().BoolVarP(&hostdbVerbose, "verbose", "v", false, "Display full hostdb information")
 
 	root.AddCommand(minerCmd)
@@ -283,9 +311,12 @@ func main() {
 	renterAllowanceCmd.AddCommand(renterAllowanceCancelCmd)
 
 	renterCmd.Flags().BoolVarP(&renterListVerbose, "verbose", "v", false, "Show additional file info such as redundancy")
+	renterSetAllowanceCmd.Flags().Float64Var(&renterMaxPriceIncrease, "max-price-increase", 0, "Ratio by which a contracted host may raise its prices before it is no longer used (default 3)")
 	renterDownloadsCmd.Flags().BoolVarP(&renterShowHistory, "history", "H", false, "Show download history in addition to the download queue")
 	renterFilesListCmd.Flags().BoolVarP(&renterListVerbose, "verbose", "v", false, "Show additional file info such as redundancy")
 	renterExportCmd.AddCommand(renterExportContractTxnsCmd)
+	renterCmd.AddCommand(renterJournalCmd)
+	renterJournalCmd.AddCommand(renterJournalVerifyCmd, renterJournalRepairCmd)
 
 	root.AddCommand(gatewayCmd)
 	gatewayCmd.AddCommand(gatewayConnectCmd, gatewayDisconnectCmd, gatewayAddressCmd, gatewayListCmd)
@@ -61,6 +61,10 @@ period is given in either blocks (b), hours (h), days (d), or weeks (w). A
 block is approximately 10 minutes, so one hour is six blocks, a day is 144
 blocks, and a week is 1008 blocks.
 
+Hosts that raise their prices by more than --max-price-increase times the
+prices they advertised when a contract was formed are no longer used for
+uploads, downloads or renewals.
+
 Note that setting the allowance will cause siad to immediately begin forming
 contracts! You should only set the allowance once you are fully synced and you
 have a reasonable number (>30) of hosts in your hostdb.`,
@@ -155,9 +159,11 @@ func rentercmd() {
 	Unspent Funds:     %v
 	Total Allocated:   %v
 
+	Identity Key: %v
+
 `, currencyUnits(fm.StorageSpending), currencyUnits(fm.UploadSpending),
 		currencyUnits(fm.DownloadSpending), currencyUnits(unspent),
-		currencyUnits(fm.ContractSpending))
+		currencyUnits(fm.ContractSpending), rg.IdentityKey)
 
 	// also list files
 	renterfileslistcmd()
@@ -248,11 +254,17 @@ func renterallowancecmd() {
 	}
 	allowance := rg.Settings.Allowance
 
+	maxPriceIncrease := "default"
+	if allowance.MaxPriceIncrease != 0 {
+		maxPriceIncrease = fmt.Sprintf("%vx", allowance.MaxPriceIncrease)
+	}
+
 	// convert to SC
 	fmt.Printf(`Allowance:
-	Amount: %v
-	Period: %v blocks
-`, currencyUnits(allowance.Funds), allowance.Period)
+	Amount:             %v
+	Period:             %v blocks
+	Max Price Increase: %v
+`, currencyUnits(allowance.Funds), allowance.Period, maxPriceIncrease)
 }
 
 // renterallowancecancelcmd cancels the current allowance.
@@ -274,7 +286,11 @@ func rentersetallowancecmd(amount, period string) {
 	if err != nil {
 		die("Could not parse period")
 	}
-	err = post("/renter", fmt.Sprintf("funds=%s&period=%s", hastings, blocks))
+	query := fmt.Sprintf("funds=%s&period=%s", hastings, blocks)
+	if renterMaxPriceIncrease != 0 {
+		query += fmt.Sprintf("&maxpriceincrease=%v", renterMaxPriceIncrease)
+	}
+	err = post("/renter", query)
 	if err != nil {
 		die("Could not set allowance:", err)
 	}
@@ -365,6 +381,16 @@ Contract %v
 				currencyUnits(rc.RenterFunds),
 				filesizeUnits(int64(rc.Size)))
 
+			if len(rc.Reconciliations) > 0 {
+				fmt.Println("\n  Reconciliations:")
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "\t\tHeight\tRenter Revision\tHost Revision\tOutcome\tReason")
+				for _, r := range rc.Reconciliations {
+					fmt.Fprintf(w, "\t\t%v\t%v\t%v\t%v\t%v\n", r.Height, r.RenterRevision, r.HostRevision, r.Outcome, r.Reason)
+				}
+				w.Flush()
+			}
+
 			printScoreBreakdown(&hostInfo)
 			return
 		}